@@ -0,0 +1,42 @@
+// Package adminui serves a minimal embedded admin page for environments without the React
+// admin app. It only calls the public JSON API from the browser; there is no server-side logic.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed static
+var assets embed.FS
+
+// Enabled reports whether the embedded admin is switched on (EMBEDDED_ADMIN=true; off by default).
+func Enabled() bool {
+	return strings.EqualFold(os.Getenv("EMBEDDED_ADMIN"), "true")
+}
+
+// cacheHeaders lets browsers cache scripts and styles briefly but always revalidate the page
+// itself, so a redeploy is picked up on the next load.
+func cacheHeaders(c *gin.Context) {
+	path := c.Request.URL.Path
+	if strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".css") {
+		c.Header("Cache-Control", "public, max-age=300")
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+	c.Next()
+}
+
+// Register mounts the admin page under /admin.
+func Register(router *gin.Engine) {
+	static, err := fs.Sub(assets, "static")
+	if err != nil {
+		panic(err) // The embedded directory is fixed at build time
+	}
+	router.Group("/admin", cacheHeaders).StaticFS("/", http.FS(static))
+}
@@ -0,0 +1,53 @@
+package adminui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "false": false, "1": false, "true": true, "TRUE": true} {
+		t.Setenv("EMBEDDED_ADMIN", value)
+		if got := Enabled(); got != want {
+			t.Errorf("EMBEDDED_ADMIN=%q: Enabled() = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		path      string
+		wantCode  int
+		wantCache string
+		wantBody  string
+	}{
+		{"/admin/", http.StatusOK, "no-cache", "<html"},
+		{"/admin/app.js", http.StatusOK, "public, max-age=300", "fetch("},
+		{"/admin/style.css", http.StatusOK, "public, max-age=300", "{"},
+		{"/admin/missing.js", http.StatusNotFound, "", ""},
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	Register(router)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("GET %s: got %d, want %d", tt.path, w.Code, tt.wantCode)
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			continue
+		}
+		if got := w.Header().Get("Cache-Control"); got != tt.wantCache {
+			t.Errorf("GET %s: Cache-Control %q, want %q", tt.path, got, tt.wantCache)
+		}
+		if !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("GET %s: body lacks %q", tt.path, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Minimal admin for the brand API. Everything goes through the JSON endpoints under /api/v1.
+(function () {
+  "use strict";
+
+  var API = "/api/v1";
+  var KEY_STORAGE = "brandAdminApiKey";
+  var current = null; // Name of the selected brand, null when creating a new one
+  var names = [];
+
+  function $(id) { return document.getElementById(id); }
+
+  function setStatus(message, isError) {
+    var status = $("status");
+    status.textContent = message;
+    status.className = isError ? "error" : "";
+  }
+
+  // The API key (writes and admin pages need one) lives in sessionStorage only
+  function apiKey() {
+    return sessionStorage.getItem(KEY_STORAGE) || "";
+  }
+
+  function promptApiKey() {
+    var key = window.prompt("API key", apiKey());
+    if (key !== null) {
+      sessionStorage.setItem(KEY_STORAGE, key.trim());
+    }
+  }
+
+  function request(method, path, body) {
+    var headers = {};
+    if (apiKey()) {
+      headers["Authorization"] = "Bearer " + apiKey();
+    }
+    if (body !== undefined && !(body instanceof FormData)) {
+      headers["Content-Type"] = "application/json";
+      body = JSON.stringify(body);
+    }
+    return fetch(API + path, { method: method, headers: headers, body: body }).then(function (res) {
+      if (res.status === 401 || res.status === 403) {
+        promptApiKey();
+      }
+      return res.json().catch(function () { return {}; }).then(function (data) {
+        if (!res.ok) {
+          throw new Error(data.error || ("HTTP " + res.status));
+        }
+        return data;
+      });
+    });
+  }
+
+  function brandPath(name) {
+    return "/brands/" + encodeURIComponent(name);
+  }
+
+  function renderList() {
+    var filter = $("filter").value.toLowerCase();
+    var list = $("brand-list");
+    list.innerHTML = "";
+    names.filter(function (name) { return name.toLowerCase().indexOf(filter) !== -1; }).forEach(function (name) {
+      var item = document.createElement("li");
+      item.textContent = name;
+      if (name === current) {
+        item.className = "selected";
+      }
+      item.addEventListener("click", function () { selectBrand(name); });
+      list.appendChild(item);
+    });
+  }
+
+  function loadList() {
+    return request("GET", "/brands").then(function (data) {
+      names = data || [];
+      renderList();
+    }).catch(function (err) { setStatus("Could not load brands: " + err.message, true); });
+  }
+
+  function selectBrand(name) {
+    request("GET", brandPath(name)).then(function (brand) {
+      current = brand.Name;
+      $("brand-name").value = brand.Name;
+      $("brand-name").disabled = true; // Renaming isn't supported by the API
+      $("brand-details").value = brand.Details || "";
+      $("delete-button").disabled = false;
+      setStatus("");
+      renderList();
+    }).catch(function (err) { setStatus(err.message, true); });
+  }
+
+  function newBrand() {
+    current = null;
+    $("brand-name").value = "";
+    $("brand-name").disabled = false;
+    $("brand-details").value = "";
+    $("delete-button").disabled = true;
+    setStatus("");
+    renderList();
+  }
+
+  function save(event) {
+    event.preventDefault();
+    var details = $("brand-details").value;
+    var op = current === null
+      ? request("POST", "/brands", { name: $("brand-name").value.trim(), details: details })
+      : request("PUT", brandPath(current), { details: details });
+    op.then(function (brand) {
+      setStatus("Saved");
+      return loadList().then(function () { selectBrand(brand.Name); });
+    }).catch(function (err) { setStatus(err.message, true); });
+  }
+
+  function remove() {
+    if (current === null || !window.confirm("Delete brand '" + current + "'?")) {
+      return;
+    }
+    request("DELETE", brandPath(current)).then(function () {
+      setStatus("Deleted");
+      newBrand();
+      return loadList();
+    }).catch(function (err) { setStatus(err.message, true); });
+  }
+
+  function upload(event) {
+    event.preventDefault();
+    var name = current === null ? $("brand-name").value.trim() : current;
+    if (!name) {
+      setStatus("Enter a brand name first", true);
+      return;
+    }
+    var form = new FormData();
+    form.append("brandName", name);
+    form.append("pdfFile", $("pdf-file").files[0]);
+    setStatus("Uploading…");
+    request("POST", "/brands/upload", form).then(function (brand) {
+      setStatus("Details updated from PDF");
+      return loadList().then(function () { selectBrand(brand.Name); });
+    }).catch(function (err) { setStatus(err.message, true); });
+  }
+
+  $("api-key-button").addEventListener("click", promptApiKey);
+  $("filter").addEventListener("input", renderList);
+  $("new-button").addEventListener("click", newBrand);
+  $("brand-form").addEventListener("submit", save);
+  $("delete-button").addEventListener("click", remove);
+  $("upload-form").addEventListener("submit", upload);
+
+  newBrand();
+  loadList();
+})();
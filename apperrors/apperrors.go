@@ -0,0 +1,67 @@
+// Package apperrors defines the domain errors shared by the services and the HTTP handlers.
+// Services translate MongoDB driver errors into these at the database boundary (see FromDB), so
+// handlers branch on what went wrong rather than on driver details. The driver error stays
+// wrapped for logging.
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Domain errors. Test for them with errors.Is.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrConflictVersion = errors.New("modified concurrently")
+	ErrValidation      = errors.New("validation failed")
+	ErrUnavailable     = errors.New("database unavailable")
+)
+
+// ValidationError is an ErrValidation with a message per offending field.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + e.Fields[name]
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Is makes errors.Is(err, ErrValidation) true for a *ValidationError.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// FromDB translates a MongoDB driver error into the matching domain error, keeping the original
+// wrapped. Errors with no domain meaning (and nil) are returned unchanged.
+func FromDB(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrAlreadyExists), errors.Is(err, ErrConflictVersion),
+		errors.Is(err, ErrValidation), errors.Is(err, ErrUnavailable):
+		return err // Already translated
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %w", ErrAlreadyExists, err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, mongo.ErrClientDisconnected),
+		mongo.IsTimeout(err), mongo.IsNetworkError(err):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
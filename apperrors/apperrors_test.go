@@ -0,0 +1,53 @@
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestFromDB(t *testing.T) {
+	other := errors.New("boom")
+	duplicate := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key"}}}
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"no documents", mongo.ErrNoDocuments, ErrNotFound},
+		{"duplicate key", duplicate, ErrAlreadyExists},
+		{"deadline", context.DeadlineExceeded, ErrUnavailable},
+		{"disconnected", mongo.ErrClientDisconnected, ErrUnavailable},
+		{"already translated", ErrConflictVersion, ErrConflictVersion},
+		{"validation", &ValidationError{Fields: map[string]string{"name": "required"}}, ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromDB(tt.err)
+			if !errors.Is(got, tt.want) {
+				t.Errorf("FromDB(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+			// Write exceptions are not comparable, so errors.Is cannot find them; the driver's own
+			// check still has to see through the wrapping.
+			if !errors.Is(got, tt.err) && !mongo.IsDuplicateKeyError(got) {
+				t.Errorf("FromDB(%v) = %v, lost the original error", tt.err, got)
+			}
+		})
+	}
+
+	if got := FromDB(nil); got != nil {
+		t.Errorf("FromDB(nil) = %v, want nil", got)
+	}
+	if got := FromDB(other); got != other {
+		t.Errorf("FromDB(%v) = %v, want it unchanged", other, got)
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Fields: map[string]string{"name": "required", "email": "invalid"}}
+	if got, want := err.Error(), "validation failed: email: invalid; name: required"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+// Package chaos injects temporary failures so staging can exercise the degraded modes clients
+// have to handle. It is only active with CHAOS_ENDPOINTS=true; otherwise the endpoints aren't
+// registered and every hook is a no-op.
+//
+// Faults are applied by wrappers around the real components (HTTP middleware, a MongoDB command
+// monitor, the extraction entry point), never by checks inside handlers. Each injected fault
+// expires on its own after its duration.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// Fault kinds.
+const (
+	FaultHealth     = "health"     // /health reports DOWN with a 503
+	FaultExtraction = "extraction" // PDF extraction fails as if pdftotext were unavailable
+	FaultDBLatency  = "db_latency" // Every MongoDB command is delayed by LatencyMs
+	FaultErrorRate  = "error_rate" // Percent of API requests get a 503
+)
+
+// MaxDuration is the longest a single injection may last.
+const MaxDuration = 1 * time.Hour
+
+var knownFaults = map[string]bool{FaultHealth: true, FaultExtraction: true, FaultDBLatency: true, FaultErrorRate: true}
+
+// ErrInjected is returned by components failing because of an injected fault.
+var ErrInjected = errors.New("failure injected by chaos testing")
+
+// Injection is one active fault.
+type Injection struct {
+	Fault     string    `json:"fault"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Percent   int       `json:"percent,omitempty"`   // error_rate only
+	LatencyMs int       `json:"latencyMs,omitempty"` // db_latency only
+}
+
+// InjectionRequest asks for a fault to be active for DurationSeconds. Injecting a fault that is
+// already active replaces it.
+type InjectionRequest struct {
+	Fault           string `json:"fault" binding:"required,oneof=health extraction db_latency error_rate"`
+	DurationSeconds int    `json:"durationSeconds" binding:"required,min=1"`
+	Percent         int    `json:"percent" binding:"omitempty,min=1,max=100"`
+	LatencyMs       int    `json:"latencyMs" binding:"omitempty,min=1,max=60000"`
+}
+
+var active = struct {
+	mu     sync.Mutex
+	faults map[string]Injection
+}{faults: make(map[string]Injection)}
+
+// Enabled reports whether chaos endpoints and wrappers are switched on (CHAOS_ENDPOINTS=true).
+func Enabled() bool {
+	return strings.EqualFold(os.Getenv("CHAOS_ENDPOINTS"), "true")
+}
+
+// Inject activates a fault until its duration passes.
+func Inject(req InjectionRequest) (Injection, error) {
+	if !knownFaults[req.Fault] {
+		return Injection{}, fmt.Errorf("unknown fault '%s'", req.Fault)
+	}
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	if duration <= 0 || duration > MaxDuration {
+		return Injection{}, fmt.Errorf("durationSeconds must be between 1 and %d", int(MaxDuration/time.Second))
+	}
+	injection := Injection{Fault: req.Fault, ExpiresAt: time.Now().Add(duration)}
+	switch req.Fault {
+	case FaultErrorRate:
+		if req.Percent <= 0 {
+			return Injection{}, errors.New("percent is required for error_rate")
+		}
+		injection.Percent = req.Percent
+	case FaultDBLatency:
+		if req.LatencyMs <= 0 {
+			return Injection{}, errors.New("latencyMs is required for db_latency")
+		}
+		injection.LatencyMs = req.LatencyMs
+	}
+	active.mu.Lock()
+	defer active.mu.Unlock()
+	active.faults[req.Fault] = injection
+	return injection, nil
+}
+
+// Clear removes every active fault.
+func Clear() {
+	active.mu.Lock()
+	defer active.mu.Unlock()
+	active.faults = make(map[string]Injection)
+}
+
+// Active returns the faults currently in effect, soonest to expire first.
+func Active() []Injection {
+	active.mu.Lock()
+	defer active.mu.Unlock()
+	now := time.Now()
+	injections := make([]Injection, 0, len(active.faults))
+	for name, injection := range active.faults {
+		if !now.Before(injection.ExpiresAt) {
+			delete(active.faults, name) // Expired
+			continue
+		}
+		injections = append(injections, injection)
+	}
+	sort.Slice(injections, func(i, j int) bool { return injections[i].ExpiresAt.Before(injections[j].ExpiresAt) })
+	return injections
+}
+
+// current returns the fault if it is active and not expired.
+func current(fault string) (Injection, bool) {
+	if !Enabled() {
+		return Injection{}, false
+	}
+	active.mu.Lock()
+	defer active.mu.Unlock()
+	injection, ok := active.faults[fault]
+	if ok && !time.Now().Before(injection.ExpiresAt) {
+		delete(active.faults, fault)
+		return Injection{}, false
+	}
+	return injection, ok
+}
+
+// ExtractionFault returns ErrInjected while the extraction fault is active.
+func ExtractionFault() error {
+	if _, ok := current(FaultExtraction); ok {
+		return ErrInjected
+	}
+	return nil
+}
+
+// ErrorRateMiddleware answers the configured percentage of requests under prefix with a 503.
+// Requests to the chaos endpoints themselves are never failed, so an injection can be cleared.
+func ErrorRateMiddleware(prefix, chaosPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if strings.HasPrefix(path, prefix) && !strings.HasPrefix(path, chaosPath) {
+			if injection, ok := current(FaultErrorRate); ok && rand.Intn(100) < injection.Percent {
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service unavailable", "chaos": FaultErrorRate})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// HealthMiddleware makes the health check report DOWN while the health fault is active.
+func HealthMiddleware(c *gin.Context) {
+	if _, ok := current(FaultHealth); ok {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN", "chaos": FaultHealth})
+		return
+	}
+	c.Next()
+}
+
+// CommandMonitor delays every MongoDB command while the db_latency fault is active. The driver
+// calls Started synchronously before sending a command, so the sleep adds to each operation.
+func CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, _ *event.CommandStartedEvent) {
+			injection, ok := current(FaultDBLatency)
+			if !ok {
+				return
+			}
+			select {
+			case <-time.After(time.Duration(injection.LatencyMs) * time.Millisecond):
+			case <-ctx.Done():
+			}
+		},
+	}
+}
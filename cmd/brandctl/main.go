@@ -0,0 +1,283 @@
+// Command brandctl runs common brand administration tasks directly against the
+// database, using the same environment variables and service functions as the
+// HTTP server.
+//
+// Usage:
+//
+//	brandctl [-o table|json] <command> [args]
+//
+// Commands:
+//
+//	list                 List all brand names
+//	get <name>           Show one brand
+//	delete <name>        Delete one brand
+//	import <file.csv>    Create/update brands from a CSV file (name,details)
+//	export [file.csv]    Write all brands as CSV (stdout when no file is given)
+//	reprocess [name]     Recompute derived data (keywords) for one or all brands
+//	indexes              Show the indexes on the brand collection
+//	audit-replay <from> <to>
+//	                     Send the audit entries recorded in [from, to) (RFC 3339)
+//	                     to AUDIT_EXPORT_URL again
+//	normalize-timestamps [zone]
+//	                     Convert string createdAt/updatedAt values to UTC dates
+//	                     (zone: IANA zone for strings without an offset, default UTC)
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/config"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/Gautam3767/Order_form_Details_Backend/services/deletion"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Generous timeout: import/export/reprocess may walk the whole collection
+const commandTimeout = 10 * time.Minute
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: brandctl [-o table|json] <list|get|delete|import|export|reprocess|indexes|normalize-timestamps|audit-replay> [args]")
+	flag.PrintDefaults()
+}
+
+func main() {
+	os.Exit(realMain())
+}
+
+// realMain runs brandctl and returns its exit code, so the deferred disconnect runs before
+// main exits.
+func realMain() int {
+	output := flag.String("o", "table", "Output format: table or json")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown output format '%s'\n", *output)
+		return 2
+	}
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	// Same configuration sources as the server
+	if err := config.LoadEnvFile(); err != nil {
+		log.Printf("Info: No .env file found or error loading it: %v. Relying on system environment variables.", err)
+	}
+	for _, warning := range config.Warnings() {
+		log.Printf("Warning: %s", warning)
+	}
+	database.Connect()
+	defer database.Disconnect()
+
+	coll := database.GetCollection(database.CollectionName())
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if err := run(ctx, coll, *output, args[0], args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// run dispatches a single subcommand.
+func run(ctx context.Context, coll *mongo.Collection, output, command string, args []string) error {
+	switch command {
+	case "list":
+		names, decodeErrors, err := services.ListBrandNames(ctx, coll, bson.M{}, "")
+		if err != nil {
+			return err
+		}
+		if decodeErrors > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d document(s) could not be decoded and were skipped\n", decodeErrors)
+		}
+		if output == "json" {
+			return printJSON(names)
+		}
+		tw := newTable("NAME")
+		for _, name := range names {
+			fmt.Fprintln(tw, name)
+		}
+		return tw.Flush()
+
+	case "get":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: brandctl get <name>")
+		}
+		brand, err := services.GetBrandByName(ctx, coll, args[0])
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return fmt.Errorf("brand '%s' not found", args[0])
+		}
+		if err != nil {
+			return err
+		}
+		if output == "json" {
+			return printJSON(brand)
+		}
+		tw := newTable("ID\tNAME\tDETAILS (BYTES)\tKEYWORDS\tCREATED\tUPDATED")
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n", brand.ID.Hex(), brand.Name, len(brand.Details), len(brand.Keywords),
+			brand.CreatedAt.Format(time.RFC3339), brand.UpdatedAt.Format(time.RFC3339))
+		return tw.Flush()
+
+	case "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: brandctl delete <name>")
+		}
+		manifest, err := deletion.Delete(ctx, coll, args[0], "brandctl")
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return fmt.Errorf("brand '%s' not found", args[0])
+		}
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Brand '%s' deleted", args[0])
+		if !manifest.Complete {
+			message += fmt.Sprintf(" (cleanup incomplete, see deletion manifest %s)", manifest.ID.Hex())
+		}
+		return printResult(output, map[string]interface{}{"deleted": args[0], "deletion": manifest}, message)
+
+	case "import":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: brandctl import <file.csv>")
+		}
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		report, err := services.ImportBrandsCSV(ctx, coll, file, services.ImportBatchSize())
+		if err != nil {
+			return err
+		}
+		if output == "json" {
+			return printJSON(report)
+		}
+		fmt.Printf("Rows: %d  Created: %d  Updated: %d  Unchanged: %d  Failed: %d  (%.1f rows/s)\n",
+			report.TotalRows, report.Created, report.Updated, report.Unchanged, report.Failed, report.RowsPerSecond)
+		if len(report.Errors) > 0 {
+			tw := newTable("ROW\tNAME\tERROR")
+			for _, rowErr := range report.Errors {
+				fmt.Fprintf(tw, "%d\t%s\t%s\n", rowErr.Row, rowErr.Name, rowErr.Error)
+			}
+			return tw.Flush()
+		}
+		return nil
+
+	case "export":
+		var dst io.Writer = os.Stdout
+		if len(args) == 1 {
+			file, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			dst = file
+		}
+		count, err := services.ExportBrandsCSV(ctx, coll, dst)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d brands\n", count)
+		return nil
+
+	case "reprocess":
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+		updated, err := services.ReprocessBrand(ctx, coll, name)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return fmt.Errorf("brand '%s' not found", name)
+		}
+		if err != nil {
+			return err
+		}
+		return printResult(output, map[string]interface{}{"reprocessed": updated}, fmt.Sprintf("Reprocessed %d brand(s)", updated))
+
+	case "normalize-timestamps":
+		zone := ""
+		if len(args) == 1 {
+			zone = args[0]
+		}
+		modified, err := services.NormalizeTimestamps(ctx, coll, zone)
+		if err != nil {
+			return err
+		}
+		return printResult(output, map[string]interface{}{"normalized": modified}, fmt.Sprintf("Normalized %d timestamp value(s)", modified))
+
+	case "indexes":
+		indexes, err := services.ListIndexes(ctx, coll)
+		if err != nil {
+			return err
+		}
+		if output == "json" {
+			return printJSON(indexes)
+		}
+		tw := newTable("NAME\tKEYS\tUNIQUE")
+		for _, idx := range indexes {
+			fmt.Fprintf(tw, "%s\t%s\t%t\n", idx.Name, idx.Keys, idx.Unique)
+		}
+		return tw.Flush()
+
+	case "audit-replay":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: brandctl audit-replay <from> <to> (RFC 3339 times)")
+		}
+		from, err := time.Parse(time.RFC3339, args[0])
+		if err != nil {
+			return fmt.Errorf("invalid from time: %w", err)
+		}
+		to, err := time.Parse(time.RFC3339, args[1])
+		if err != nil {
+			return fmt.Errorf("invalid to time: %w", err)
+		}
+		if !to.After(from) {
+			return fmt.Errorf("to must be after from")
+		}
+		sent, err := services.ReplayAudit(ctx, database.Collection(database.AuditLogCollection), from, to)
+		if err != nil {
+			return fmt.Errorf("replayed %d entries before failing: %w", sent, err)
+		}
+		return printResult(output, map[string]interface{}{"replayed": sent}, fmt.Sprintf("Replayed %d audit entries", sent))
+	}
+
+	return fmt.Errorf("unknown command '%s'", command)
+}
+
+// newTable returns a tabwriter on stdout with the header row already written.
+func newTable(header string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, header)
+	return tw
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printResult prints either the JSON value or the plain message depending on the output format.
+func printResult(output string, v interface{}, message string) error {
+	if output == "json" {
+		return printJSON(v)
+	}
+	fmt.Println(message)
+	return nil
+}
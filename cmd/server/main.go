@@ -0,0 +1,302 @@
+// Command server runs the brand information HTTP API.
+//
+// Run it from the directory holding the .env file (usually the repository root):
+//
+//	go run ./cmd/server
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os" // Import os
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+
+	// --- Use YOUR actual module paths here ---
+	// Make sure these paths match your go.mod file and project structure
+	"github.com/Gautam3767/Order_form_Details_Backend/adminui"
+	"github.com/Gautam3767/Order_form_Details_Backend/chaos"
+	"github.com/Gautam3767/Order_form_Details_Backend/config"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/handlers"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/Gautam3767/Order_form_Details_Backend/services/deletion"
+	"github.com/Gautam3767/Order_form_Details_Backend/uploadpolicy"
+	// -----------------------------------------
+	// Add swagger imports if using swaggo
+	// _ "github.com/Gautam3767/Order_form_Details_Backend/docs" // Adjust if using swagger docs
+	// ginSwagger "github.com/swaggo/gin-swagger"
+	// swaggerFiles "github.com/swaggo/files"
+)
+
+// Optional: Add Swagger annotations if you plan to generate API docs
+// @title Brand Information Service API (MongoDB)
+// @version 1.0
+// @description This service manages brand information for the order form, using MongoDB.
+// @description Every single-brand route is also available under /brands/id/{id} with the brand's ID (the ID field of each brand). Programmatic clients should prefer these: names with dots, percent signs or reserved words can be hard or impossible to address by name.
+// @description Paths are matched exactly and never redirected. A trailing slash is ignored (/brands/ is the same route as /brands, for every method); paths are case-sensitive, so /Brands is a 404.
+// @termsOfService http://swagger.io/terms/
+
+// @contact.name API Support
+// @contact.url http://www.swagger.io/support
+// @contact.email support@swagger.io
+
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @host localhost:8080 // Default host, adjust if needed
+// @BasePath /api/v1
+
+// How long in-flight requests get to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	// Load .env file first.
+	// It's safe to ignore the error if the file is optional (e.g., in production using real env vars)
+	err := config.LoadEnvFile()
+	if err != nil {
+		log.Printf("Info: No .env file found or error loading it: %v. Relying on system environment variables.", err)
+	}
+
+	// Settings that fell back to a default but usually shouldn't
+	for _, warning := range config.Warnings() {
+		log.Printf("Warning: %s", warning)
+	}
+
+	// Connect to Database (MongoDB implementation in database package)
+	database.Connect()
+	database.StartIndexBuild()
+
+	// Feature flags: FEATURES env plus runtime overrides stored in MongoDB
+	featureflags.Init(database.Collection(featureflags.CollectionName))
+
+	// Upload policy: env defaults until a policy document is stored next to the flag overrides
+	uploadpolicy.Init(database.Collection(featureflags.CollectionName))
+
+	// Both keep parsed copies of their env settings; refresh them when the configuration is reloaded
+	config.OnReload(featureflags.ReloadEnv)
+	config.OnReload(uploadpolicy.ReloadEnv)
+
+	// Background jobs
+	services.StartDuplicateScanner(database.GetCollection(database.CollectionName()), services.DuplicateScanInterval())
+
+	services.StartViewCounter(database.Collection(database.BrandViewsCollection), services.ViewFlushInterval())
+	services.StartAlerting()
+	services.StartAuditExport()
+	services.StartSourceRefresh(database.GetCollection(database.CollectionName()), handlers.SupplierPDFLimits)
+	services.StartRetentionPurge()
+	services.StartPortalRevocationRefresh(database.Collection(database.PortalTokenCollection))
+	deletion.StartRecovery(database.GetCollection(database.CollectionName()))
+	database.EnsureCappedCollection(database.AuditLogCollection, services.AuditLogBytes())
+	if services.UploadJournalEnabled() {
+		database.EnsureCappedCollection(database.UploadJournalCollection, services.UploadJournalBytes())
+	}
+
+	// Initialize Gin Router
+	router := gin.Default() // Includes Logger and Recovery middleware
+
+	// --- Path Matching ---
+	// Gin's redirects (301 for GET, 307 otherwise) trip up HTTP clients that don't follow them for
+	// POSTs, so nothing is redirected: API paths are served with or without a trailing slash (see
+	// trimTrailingSlash) and case variants are simply not found.
+	router.RedirectTrailingSlash = false
+	router.RedirectFixedPath = false
+
+	// --- Client IPs ---
+	// Only proxies listed in TRUSTED_PROXIES (e.g. the load balancer's subnet) may set the client IP
+	// via X-Forwarded-For. Gin trusts every proxy by default, which lets any caller spoof their IP,
+	// so with nothing configured forwarded headers are ignored. c.ClientIP() (used by the request
+	// logger and audit log lines) then returns the resolved address.
+	if err := router.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// --- CORS Middleware ---
+	// Registered globally, so it also runs for OPTIONS requests that match no route (Gin's NoRoute chain):
+	// every route, including /brands/upload, gets its preflight answered here
+	router.Use(cors.New(newCORSConfig()))
+
+	// --- Chaos Testing (staging only) ---
+	// Fails a share of API requests while an error_rate fault is injected via /admin/chaos
+	if chaos.Enabled() {
+		router.Use(chaos.ErrorRateMiddleware("/api/", "/api/v1/admin/chaos"))
+		log.Println("Warning: CHAOS_ENDPOINTS is on, faults can be injected via /api/v1/admin/chaos")
+	}
+
+	// --- API Routes ---
+	// Every route under the versioned path is declared in the routing table (see apiRoutes), which
+	// also decides the body limit and middleware each one gets
+	api := router.Group("/api/v1")
+	registerRoutes(api, apiRoutes())
+
+	// --- Swagger Route (Optional) ---
+	// Uncomment if you have set up swaggo (`swag init -g cmd/server/main.go` in your project root)
+	// swaggerURL := ginSwagger.URL("/swagger/doc.json") // Point to generated JSON
+	// router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, swaggerURL))
+	// log.Println("Swagger UI available at /swagger/index.html")
+
+	// --- Health Check Endpoint ---
+	// Basic health check to see if the service is running
+	getWithHead(router, "/health", chaos.HealthMiddleware, func(c *gin.Context) {
+		// Consider adding a DB ping here for a more comprehensive check
+		// Example (requires adapting database package to expose client or ping method):
+		// ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		// defer cancel()
+		// if err := database.PingDB(ctx); err != nil { // Assuming PingDB exists
+		//     c.JSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN", "details": "database unreachable"})
+		//     return
+		// }
+		// pdftotext is a hard dependency of PDF uploads; an open circuit breaker means uploads fail fast
+		extraction := services.ExtractionBreakerStatus()
+		status := "UP"
+		if extraction.State != services.BreakerClosed {
+			status = "DEGRADED"
+		}
+		c.JSON(http.StatusOK, gin.H{"status": status, "dependencies": gin.H{"pdftotext": extraction}})
+	})
+
+	// --- Embed Relay ---
+	// Relay page for order forms embedded in portals that can't send CORS preflights (embedded_mode flag)
+	getWithHead(router, "/embed/relay", handlers.EmbedRelay)
+
+	// --- Embedded Admin UI (Optional) ---
+	// Minimal admin page for environments without the React admin app (EMBEDDED_ADMIN=true)
+	if adminui.Enabled() {
+		adminui.Register(router)
+		log.Println("Embedded admin UI available at /admin/")
+	}
+
+	// --- Reserved Brand Names ---
+	// Brand names equal to a static /brands segment would be shadowed by that route; refuse them
+	handlers.ReserveBrandNames(router.Routes(), "/api/v1/brands")
+
+	// --- Start Server ---
+	// Get port from environment variable or use a default
+	port := os.Getenv("SERVER_PORT")
+	if port == "" {
+		port = "8080" // Default port if not specified
+		log.Printf("Defaulting to port %s", port)
+	}
+
+	// One structured line with the effective configuration, secrets redacted, so deployments are
+	// easy to verify from the logs
+	if summary, err := json.Marshal(config.BuildSummary(services.ExtractorVersion())); err == nil {
+		log.Printf("Startup configuration: %s", summary)
+	}
+
+	log.Printf("Server starting and listening on http://localhost:%s", port)
+	srv := &http.Server{Addr: ":" + port, Handler: trimTrailingSlash("/api/", router)}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to run server: %v", err) // Use Fatalf to exit on server start error
+		}
+	}()
+
+	// --- Graceful Shutdown ---
+	// Finish in-flight requests, drain background workers (flushing buffered view counters), then
+	// disconnect from MongoDB
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	// SIGHUP reloads the tunable settings from .env (same as POST /admin/config/reload)
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			reloadConfig("SIGHUP")
+		}
+	}()
+
+	<-quit
+	log.Println("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Warning: Server did not shut down cleanly: %v", err)
+	}
+	services.ShutdownWorkers(services.WorkerDrainTimeout())
+	database.Disconnect()
+	log.Println("Server stopped")
+}
+
+// reloadConfig runs a configuration reload and records its outcome in the audit log.
+func reloadConfig(trigger string) {
+	result, err := config.Reload()
+	if err != nil {
+		log.Printf("Error reloading configuration (%s): %v", trigger, err)
+		return
+	}
+	if summary, err := json.Marshal(result); err == nil {
+		services.RecordAudit(database.Collection(database.AuditLogCollection), "config.reload", trigger, "", "Configuration reloaded: "+string(summary))
+	}
+}
+
+// canonicalHeaders returns the header names in canonical MIME form (e.g. "content-type" -> "Content-Type"),
+// dropping duplicates
+func canonicalHeaders(names ...string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		canonical := http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if canonical == "" || seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		result = append(result, canonical)
+	}
+	return result
+}
+
+// trimTrailingSlash serves paths under prefix as if they had no trailing slash, so each route
+// answers both variants with the same method instead of redirecting. Other paths are left alone
+// (the admin UI's directory index needs its slash).
+func trimTrailingSlash(prefix string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, prefix) && strings.HasSuffix(r.URL.Path, "/") {
+			r.URL.Path = strings.TrimRight(r.URL.Path, "/")
+			if r.URL.RawPath != "" {
+				r.URL.RawPath = strings.TrimRight(r.URL.RawPath, "/")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newCORSConfig returns the CORS settings for the browser frontends.
+func newCORSConfig() cors.Config {
+	// Configure allowed origins based on your frontend URLs
+	// Include both your main order form app and the admin UI
+	corsConfig := cors.DefaultConfig()
+	// Add your production frontend URLs via CORS_ALLOWED_ORIGINS (comma-separated). Checked per
+	// request so a configuration reload takes effect without a restart.
+	corsConfig.AllowOriginFunc = func(origin string) bool {
+		return slices.Contains(config.CORSOrigins(), origin)
+	}
+	corsConfig.AllowMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	// Header names are canonicalized so the list matches regardless of how the browser cases
+	// Access-Control-Request-Headers (e.g. "content-type" for multipart uploads)
+	corsConfig.AllowHeaders = canonicalHeaders("Origin", "Content-Length", "Content-Type", "Authorization", "Accept", "X-Requested-With", "Cache-Control", "X-Request-ID", "X-Response-Envelope", "X-Strict-Validation") // Added common headers
+	corsConfig.ExposeHeaders = canonicalHeaders("Content-Length", "Content-Disposition", "ETag", "X-Decode-Errors", "X-Request-ID")                                                                                      // Readable by frontend JS
+	corsConfig.AllowCredentials = true                                                                                                                                                                                   // If you need cookies/sessions
+	return corsConfig
+}
+
+// getWithHead registers chain for GET and HEAD requests to path. Gin does not answer HEAD for GET
+// routes on its own; registering both with the same chain lets clients probe endpoints through
+// the same middleware (e.g. the chaos health fault) as real requests. net/http drops the body for
+// HEAD while keeping the same headers. API routes get theirs from registerRoutes, the admin UI
+// from gin's StaticFS.
+func getWithHead(router gin.IRoutes, path string, chain ...gin.HandlerFunc) {
+	router.GET(path, chain...)
+	router.HEAD(path, chain...)
+}
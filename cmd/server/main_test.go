@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestCanonicalHeaders(t *testing.T) {
+	got := canonicalHeaders("content-type", " X-Requested-With ", "Content-Type", "", "cache-control")
+	want := []string{"Content-Type", "X-Requested-With", "Cache-Control"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("canonicalHeaders() = %v, want %v", got, want)
+	}
+}
+
+// Preflights are answered by the global CORS middleware, also for routes with no OPTIONS handler.
+// The browser checks the requested method and headers against the allowed lists sent back.
+func TestCORSPreflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		origin      string
+		method      string
+		headers     []string
+		wantAllowed bool
+	}{
+		{"upload from the order form", "http://localhost:3000", "POST", []string{"content-type"}, true},
+		{"HEAD probe", "http://localhost:5173", "HEAD", nil, true},
+		{"delete with cache-control", "http://localhost:5174", "DELETE", []string{"cache-control", "x-requested-with"}, true},
+		{"unknown origin", "http://evil.example", "POST", []string{"content-type"}, false},
+	}
+	router := gin.New()
+	router.Use(cors.New(newCORSConfig()))
+	router.POST("/api/v1/brands/upload", func(c *gin.Context) { c.Status(http.StatusOK) })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/api/v1/brands/upload", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if len(tt.headers) > 0 {
+				req.Header.Set("Access-Control-Request-Headers", strings.Join(tt.headers, ","))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			allowed := w.Code == http.StatusNoContent && w.Header().Get("Access-Control-Allow-Origin") == tt.origin
+			if allowed != tt.wantAllowed {
+				t.Fatalf("preflight allowed = %v (status %d), want %v", allowed, w.Code, tt.wantAllowed)
+			}
+			if !allowed {
+				return
+			}
+			if methods := w.Header().Get("Access-Control-Allow-Methods"); !slices.Contains(strings.Split(methods, ","), tt.method) {
+				t.Errorf("allowed methods %q lack %s", methods, tt.method)
+			}
+			allowedHeaders := strings.Split(w.Header().Get("Access-Control-Allow-Headers"), ",")
+			for _, header := range tt.headers {
+				if !slices.Contains(allowedHeaders, http.CanonicalHeaderKey(header)) {
+					t.Errorf("allowed headers %v lack %s", allowedHeaders, header)
+				}
+			}
+		})
+	}
+}
+
+func TestTrimTrailingSlash(t *testing.T) {
+	router := gin.New()
+	router.RedirectTrailingSlash = false
+	router.RedirectFixedPath = false
+	router.GET("/api/v1/brands", func(c *gin.Context) { c.String(http.StatusOK, "list") })
+	router.POST("/api/v1/brands", func(c *gin.Context) { c.String(http.StatusCreated, "create") })
+	router.GET("/api/v1/brands/:brandName", func(c *gin.Context) { c.String(http.StatusOK, "brand "+c.Param("brandName")) })
+	router.GET("/admin/", func(c *gin.Context) { c.String(http.StatusOK, "admin") })
+	handler := trimTrailingSlash("/api/", router)
+
+	tests := []struct {
+		method, path string
+		wantStatus   int
+		wantBody     string
+	}{
+		{"GET", "/api/v1/brands", http.StatusOK, "list"},
+		{"GET", "/api/v1/brands/", http.StatusOK, "list"},
+		{"GET", "/api/v1/brands//", http.StatusOK, "list"},
+		{"POST", "/api/v1/brands/", http.StatusCreated, "create"},
+		{"GET", "/api/v1/brands/Acme/", http.StatusOK, "brand Acme"},
+		{"GET", "/api/v1/brands/Acme%20Tools/", http.StatusOK, "brand Acme Tools"},
+		{"GET", "/api/v1/Brands", http.StatusNotFound, ""},
+		{"GET", "/admin/", http.StatusOK, "admin"},
+		{"GET", "/admin", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.wantStatus || (tt.wantBody != "" && w.Body.String() != tt.wantBody) {
+			t.Errorf("%s %s: got %d %q, want %d %q", tt.method, tt.path, w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+// HEAD requests run the whole GET chain, middleware included, not just the final handler.
+func TestGetWithHead(t *testing.T) {
+	router := gin.New()
+	middleware := func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+	}
+	getWithHead(router, "/health", middleware, func(c *gin.Context) { c.Status(http.StatusOK) })
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/health", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s /health: got %d, want the middleware's 503", method, w.Code)
+		}
+	}
+}
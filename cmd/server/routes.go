@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/chaos"
+	"github.com/Gautam3767/Order_form_Details_Backend/handlers"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Body limits applied by the registrar.
+const (
+	defaultMaxJSONBody = 8 << 20  // MAX_JSON_BODY_BYTES
+	noBodyLimit        = 64 << 10 // Routes that read no body; only bounds what a client can make us buffer
+)
+
+// Deadline budgets applied by the registrar (see the deadline package).
+const (
+	defaultRequestDeadline = 10 * time.Second // REQUEST_DEADLINE_SECONDS
+	defaultUploadDeadline  = 30 * time.Second // UPLOAD_DEADLINE_SECONDS; upload, extraction and upsert together
+)
+
+// bodyClass says what kind of request body a route accepts and so which limit applies.
+type bodyClass int
+
+const (
+	bodyNone   bodyClass = iota // No body (GET, HEAD, DELETE)
+	bodyJSON                    // JSON or form payloads, limited to MAX_JSON_BODY_BYTES
+	bodyUpload                  // Files; the handler enforces its own limit (upload policy, LOGO_MAX_BYTES)
+)
+
+// route is one row of the API routing table. Every /api/v1 route is declared here so the limits
+// and middleware a route gets are visible next to it, not spread over group definitions.
+type route struct {
+	method     string
+	path       string // Relative to /api/v1
+	handler    gin.HandlerFunc
+	body       bodyClass
+	doc        string
+	middleware []gin.HandlerFunc // Run before handler, e.g. resolving /brands/id/:id
+}
+
+// apiRoutes returns the routing table. Routes behind a disabled feature (chaos testing, the
+// supplier portal) are left out.
+func apiRoutes() []route {
+	routes := []route{
+		// Brands. Static segments first: each one is also a reserved brand name (see ReserveBrandNames)
+		{"GET", "/brands", handlers.ListBrands, bodyNone, "Get list of brand names", nil},
+		{"POST", "/brands", handlers.CreateBrandManual, bodyJSON, "Create brand via JSON", nil},
+		{"POST", "/brands/upload", handlers.UploadBrandPDF, bodyUpload, "Create/Update brand via PDF upload", nil},
+		{"POST", "/brands/import", handlers.ImportBrands, bodyUpload, "Bulk create/update brands from CSV", nil},
+		{"POST", "/brands/bulk", handlers.CreateBrandsBulk, bodyJSON, "Create up to 1000 brands from a JSON array", nil},
+		{"GET", "/brands/sync", handlers.SyncBrands, bodyNone, "Differential sync for offline clients", nil},
+		{"GET", "/brands/partitions", handlers.GetBrandPartitions, bodyNone, "_id ranges for parallel bulk listing", nil},
+		{"GET", "/brands/search", handlers.SearchBrands, bodyNone, "Full-text search over details", nil},
+		{"GET", "/brands/suggest", handlers.SuggestBrands, bodyNone, "Names starting with ?prefix=, for autocompletion", nil},
+		{"GET", "/brands/:brandName", handlers.GetBrandDetails, bodyNone, "Get details for one brand", nil},
+		{"PUT", "/brands/:brandName", handlers.UpdateBrandManual, bodyJSON, "Update brand details via JSON", nil},
+		{"PATCH", "/brands/:brandName", handlers.PatchBrand, bodyJSON, "Change only the given fields", nil},
+		{"DELETE", "/brands/:brandName", handlers.DeleteBrand, bodyNone, "Delete a brand", nil},
+
+		{"POST", "/brands/:brandName/details/append", handlers.AppendBrandDetails, bodyJSON, "Append a fragment to details", nil},
+		{"POST", "/brands/:brandName/details/prepend", handlers.PrependBrandDetails, bodyJSON, "Prepend a fragment to details", nil},
+		{"GET", "/brands/:brandName/details/sections", handlers.ListDetailsSections, bodyNone, "Section titles and sizes of the details", nil},
+		{"GET", "/brands/:brandName/details/sections/:sectionId", handlers.GetDetailsSection, bodyNone, "One section of the details", nil},
+		{"POST", "/brands/:brandName/pdf", handlers.UploadBrandPDFRaw, bodyUpload, "Create/Update brand from a raw PDF body", nil},
+		{"GET", "/brands/:brandName/views", handlers.GetBrandViews, bodyNone, "Daily view counts", nil},
+		{"POST", "/brands/:brandName/logo", handlers.UploadBrandLogo, bodyUpload, "Replace the logo (PNG/JPEG, resized)", nil},
+		{"GET", "/brands/:brandName/logo", handlers.GetBrandLogo, bodyNone, "One logo variant, ?size=64|256", nil},
+		{"GET", "/brands/:brandName/export", handlers.ExportBrandSheet, bodyNone, "Brand sheet as Markdown or DOCX", nil},
+		{"GET", "/brands/:brandName/children", handlers.GetBrandChildren, bodyNone, "Direct sub-brands", nil},
+		{"GET", "/brands/:brandName/ancestry", handlers.GetBrandAncestry, bodyNone, "Parent brands up to the top level", nil},
+		{"PATCH", "/brands/:brandName/source", handlers.UpdateBrandSource, bodyJSON, "Pause/resume or reschedule the source refresh", nil},
+		{"PUT", "/brands/:brandName/terms", handlers.SetBrandTerms, bodyJSON, "Replace the terms and conditions (bumps their version)", nil},
+		{"GET", "/brands/:brandName/terms/versions", handlers.ListBrandTermsVersions, bodyNone, "Every published version of the terms", nil},
+
+		// Internal contact directory; contacts never appear in the public brand responses
+		{"GET", "/brands/:brandName/contacts", handlers.ListBrandContacts, bodyNone, "List a brand's contacts", nil},
+		{"POST", "/brands/:brandName/contacts", handlers.AddBrandContact, bodyJSON, "Add a contact", nil},
+		{"PUT", "/brands/:brandName/contacts/:contactId", handlers.UpdateBrandContact, bodyJSON, "Replace a contact", nil},
+		{"DELETE", "/brands/:brandName/contacts/:contactId", handlers.RemoveBrandContact, bodyNone, "Remove a contact", nil},
+
+		// Unambiguous aliases: reach brands whose names collide with a static segment (stored
+		// before names were reserved), e.g. a brand called "sync"
+		{"GET", "/brands/by-name/:brandName", handlers.GetBrandDetails, bodyNone, "Get details for one brand", nil},
+		{"PUT", "/brands/by-name/:brandName", handlers.UpdateBrandManual, bodyJSON, "Update brand details via JSON", nil},
+		{"PATCH", "/brands/by-name/:brandName", handlers.PatchBrand, bodyJSON, "Change only the given fields", nil},
+		{"DELETE", "/brands/by-name/:brandName", handlers.DeleteBrand, bodyNone, "Delete a brand", nil},
+
+		// Reverse lookup from extracted keywords to the brands mentioning them
+		{"GET", "/keywords/:term/brands", handlers.ListBrandsByKeyword, bodyNone, "Brands containing a keyword or phrase", nil},
+
+		// Maintenance endpoints for operators
+		{"GET", "/admin/brands", handlers.ListAdminBrands, bodyNone, "Brand names matching ?filter=", nil},
+		{"GET", "/admin/brands/decode-errors", handlers.ListBrandDecodeErrors, bodyNone, "Stored documents that fail to decode", nil},
+		{"GET", "/admin/brands/:brandName/debug", handlers.DebugBrand, bodyNone, "Raw stored state of one brand", nil},
+		{"GET", "/admin/brands/:brandName/diagnostics", handlers.GetBrandDiagnostics, bodyNone, "PDF extraction diagnostics of one brand", nil},
+		{"GET", "/admin/brands/duplicates", handlers.GetBrandDuplicates, bodyNone, "Cached near-duplicate pairs", nil},
+		{"GET", "/admin/brands/source-failures", handlers.ListSourceFailures, bodyNone, "Brands whose supplier PDF keeps failing", nil},
+		{"POST", "/admin/brands/duplicates", handlers.RecomputeBrandDuplicates, bodyJSON, "Recompute near-duplicates now", nil},
+		{"GET", "/admin/brands/views/top", handlers.GetTopViewedBrands, bodyNone, "Most-viewed brands for a period", nil},
+		{"GET", "/admin/features", handlers.ListFeatures, bodyNone, "Effective feature flags", nil},
+		{"PUT", "/admin/features", handlers.UpdateFeatures, bodyJSON, "Change feature flag overrides", nil},
+		{"GET", "/admin/config", handlers.GetConfig, bodyNone, "Effective configuration, secrets redacted", nil},
+		{"POST", "/admin/config/reload", handlers.ReloadConfig, bodyJSON, "Reload tunable settings from .env", nil},
+		{"GET", "/admin/alerts", handlers.GetAlertingStatus, bodyNone, "Alert conditions and whether they are firing", nil},
+		{"GET", "/admin/audit/export", handlers.GetAuditExportStatus, bodyNone, "Audit log export metrics", nil},
+		{"POST", "/admin/audit/replay", handlers.ReplayAuditLog, bodyJSON, "Export a time range of the audit log again", nil},
+		{"GET", "/admin/retention", handlers.GetRetentionStatus, bodyNone, "Retention policies and the last purge run", nil},
+		{"GET", "/admin/journal", handlers.GetUploadJournal, bodyNone, "Recent upload journal entries", nil},
+		{"GET", "/admin/deletions", handlers.ListDeletions, bodyNone, "Recent brand deletions and their cleanup state", nil},
+		{"GET", "/admin/deadlines", handlers.GetDeadlineStats, bodyNone, "Where request deadline budgets ran out", nil},
+		{"GET", "/admin/upload-policy", handlers.GetUploadPolicy, bodyNone, "Effective upload policy", nil},
+		{"PUT", "/admin/upload-policy", handlers.UpdateUploadPolicy, bodyJSON, "Replace the upload policy", nil},
+		{"GET", "/admin/api-keys", handlers.ListAPIKeys, bodyNone, "API keys and their scopes", nil},
+		{"POST", "/admin/api-keys", handlers.CreateAPIKey, bodyJSON, "Create an API key with scopes", nil},
+		{"POST", "/admin/api-keys/:keyId/rotate", handlers.RotateAPIKey, bodyJSON, "Replace a key, the old one expiring after a grace period", nil},
+		{"DELETE", "/admin/api-keys/:keyId", handlers.RevokeAPIKey, bodyNone, "Revoke an API key", nil},
+		{"GET", "/admin/integrations/supplier-feed/mapping", handlers.GetSupplierFeedMapping, bodyNone, "Field names the supplier feed reads", nil},
+		{"PUT", "/admin/integrations/supplier-feed/mapping", handlers.UpdateSupplierFeedMapping, bodyJSON, "Change those field names", nil},
+
+		// Inbound integrations, authenticated by a shared secret per integration
+		{"POST", "/integrations/supplier-feed", handlers.ReceiveSupplierFeed, bodyUpload, "Supplier catalog push", nil},
+
+		// Example payloads for API consoles and client developers
+		{"GET", "/examples", handlers.ListExamples, bodyNone, "Endpoints with example payloads", nil},
+		{"GET", "/examples/:endpointName", handlers.GetExample, bodyNone, "Example requests and responses of one endpoint", nil},
+	}
+
+	// The same single-brand operations keyed by the hex ObjectID; recommended for programmatic
+	// clients since IDs never hit path-matching quirks
+	resolveID := []gin.HandlerFunc{handlers.ResolveBrandID}
+	routes = append(routes, []route{
+		{"GET", "/brands/id/:id", handlers.GetBrandDetails, bodyNone, "Get details for one brand", resolveID},
+		{"PUT", "/brands/id/:id", handlers.UpdateBrandManual, bodyJSON, "Update brand details via JSON", resolveID},
+		{"PATCH", "/brands/id/:id", handlers.PatchBrand, bodyJSON, "Change only the given fields", resolveID},
+		{"DELETE", "/brands/id/:id", handlers.DeleteBrand, bodyNone, "Delete a brand", resolveID},
+		{"POST", "/brands/id/:id/pdf", handlers.UploadBrandPDFRaw, bodyUpload, "Create/Update brand from a raw PDF body", resolveID},
+		{"POST", "/brands/id/:id/details/append", handlers.AppendBrandDetails, bodyJSON, "Append a fragment to details", resolveID},
+		{"POST", "/brands/id/:id/details/prepend", handlers.PrependBrandDetails, bodyJSON, "Prepend a fragment to details", resolveID},
+		{"GET", "/brands/id/:id/details/sections", handlers.ListDetailsSections, bodyNone, "Section titles and sizes of the details", resolveID},
+		{"GET", "/brands/id/:id/details/sections/:sectionId", handlers.GetDetailsSection, bodyNone, "One section of the details", resolveID},
+		{"GET", "/brands/id/:id/views", handlers.GetBrandViews, bodyNone, "Daily view counts", resolveID},
+		{"POST", "/brands/id/:id/logo", handlers.UploadBrandLogo, bodyUpload, "Replace the logo (PNG/JPEG, resized)", resolveID},
+		{"GET", "/brands/id/:id/logo", handlers.GetBrandLogo, bodyNone, "One logo variant, ?size=64|256", resolveID},
+		{"GET", "/brands/id/:id/export", handlers.ExportBrandSheet, bodyNone, "Brand sheet as Markdown or DOCX", resolveID},
+		{"GET", "/brands/id/:id/children", handlers.GetBrandChildren, bodyNone, "Direct sub-brands", resolveID},
+		{"GET", "/brands/id/:id/ancestry", handlers.GetBrandAncestry, bodyNone, "Parent brands up to the top level", resolveID},
+		{"PATCH", "/brands/id/:id/source", handlers.UpdateBrandSource, bodyJSON, "Pause/resume or reschedule the source refresh", resolveID},
+		{"PUT", "/brands/id/:id/terms", handlers.SetBrandTerms, bodyJSON, "Replace the terms and conditions (bumps their version)", resolveID},
+		{"GET", "/brands/id/:id/terms/versions", handlers.ListBrandTermsVersions, bodyNone, "Every published version of the terms", resolveID},
+		{"GET", "/brands/id/:id/contacts", handlers.ListBrandContacts, bodyNone, "List a brand's contacts", resolveID},
+		{"POST", "/brands/id/:id/contacts", handlers.AddBrandContact, bodyJSON, "Add a contact", resolveID},
+		{"PUT", "/brands/id/:id/contacts/:contactId", handlers.UpdateBrandContact, bodyJSON, "Replace a contact", resolveID},
+		{"DELETE", "/brands/id/:id/contacts/:contactId", handlers.RemoveBrandContact, bodyNone, "Remove a contact", resolveID},
+	}...)
+
+	// Read-only supplier portal, authenticated by a portal token bound to one brand. Issuing,
+	// listing and revoking tokens are admin routes and need a key with the admin scope
+	if services.PortalEnabled() {
+		routes = append(routes, []route{
+			{"GET", "/admin/brands/:brandName/portal-tokens", handlers.ListPortalTokens, bodyNone, "Supplier portal tokens of a brand", nil},
+			{"POST", "/admin/brands/:brandName/portal-tokens", handlers.IssuePortalToken, bodyJSON, "Issue a portal token", nil},
+			{"DELETE", "/admin/brands/:brandName/portal-tokens/:tokenId", handlers.RevokePortalToken, bodyNone, "Revoke a portal token", nil},
+			{"GET", "/portal/brand", handlers.GetPortalBrand, bodyNone, "The token's brand, read-only", nil},
+		}...)
+	}
+
+	if chaos.Enabled() {
+		routes = append(routes, []route{
+			{"GET", "/admin/chaos", handlers.GetChaosStatus, bodyNone, "Injected faults", nil},
+			{"POST", "/admin/chaos", handlers.InjectChaos, bodyJSON, "Inject a fault for a while", nil},
+			{"DELETE", "/admin/chaos", handlers.ClearChaos, bodyNone, "Clear all faults", nil},
+		}...)
+	}
+	return routes
+}
+
+// registerRoutes adds every route to group with its middleware stack: the cache policy, the body
+// limit for its class, the API key check for its scope (which may read a form-encoded body in
+// embedded mode), the deadline budget, then the route's own middleware, the CDN purge for brand
+// writes, then the handler. GET routes also answer HEAD with the same stack.
+func registerRoutes(group *gin.RouterGroup, routes []route) {
+	for _, r := range routes {
+		chain := append(cachePolicy(r), limitBody(r.body))
+		if scope := routeScope(r); scope != "" {
+			chain = append(chain, handlers.RequireScope(scope))
+		}
+		chain = append(chain, withDeadline(r.body))
+		chain = append(chain, r.middleware...)
+		if writesBrands(r) {
+			chain = append(chain, handlers.PurgeCDNOnWrite)
+		}
+		chain = append(chain, r.handler)
+		group.Handle(r.method, r.path, chain...)
+		if r.method == http.MethodGet {
+			group.Handle(http.MethodHead, r.path, chain...)
+		}
+	}
+}
+
+// routeScope returns the API key scope a route requires, by its class: everything under /admin/
+// needs admin, other writes brands:write and the internal contact directory brands:read. Public
+// reads need none, nor do the supplier feed and the portal, which authenticate with their own
+// secret or token.
+func routeScope(r route) string {
+	switch {
+	case strings.HasPrefix(r.path, "/admin/"):
+		return services.ScopeAdmin
+	case r.path == "/integrations/supplier-feed", strings.HasPrefix(r.path, "/portal/"):
+		return ""
+	case r.method != http.MethodGet:
+		return services.ScopeBrandsWrite
+	case strings.HasSuffix(r.path, "/contacts"):
+		return services.ScopeBrandsRead
+	}
+	return ""
+}
+
+// writesBrands reports whether a route changes brands and so must purge them from the CDN: the
+// writes under /brands and the supplier feed, which upserts brands by name.
+func writesBrands(r route) bool {
+	if r.method == http.MethodGet {
+		return false
+	}
+	return strings.HasPrefix(r.path, "/brands") || r.path == "/integrations/supplier-feed"
+}
+
+// cachePolicy returns the Cache-Control middleware of a route by its class: public brand reads
+// may be cached by a CDN, admin and portal (token-authenticated) responses never. Other routes
+// get no policy; the logo sets its own, content-hash based one.
+func cachePolicy(r route) []gin.HandlerFunc {
+	switch {
+	case r.method != http.MethodGet:
+		return nil
+	case strings.HasPrefix(r.path, "/admin/"), strings.HasPrefix(r.path, "/portal/"):
+		return []gin.HandlerFunc{handlers.NoStore}
+	case r.path == "/brands":
+		return []gin.HandlerFunc{handlers.CachePublicList}
+	case r.path == "/brands/:brandName", r.path == "/brands/by-name/:brandName", r.path == "/brands/id/:id":
+		return []gin.HandlerFunc{handlers.CachePublicBrand}
+	}
+	return nil
+}
+
+// limitBody caps the request body for a body class. Upload handlers apply their own (runtime
+// configurable) limit, so they are left alone here.
+func limitBody(class bodyClass) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch class {
+		case bodyNone:
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, noBodyLimit)
+		case bodyJSON:
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxJSONBody())
+		}
+		c.Next()
+	}
+}
+
+// withDeadline puts the budget for a body class on the request context. Handlers that work under
+// it (the PDF uploads) check what is left before each stage; the request context also ends when
+// the client goes away.
+func withDeadline(class bodyClass) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget := envSeconds("REQUEST_DEADLINE_SECONDS", defaultRequestDeadline)
+		if class == bodyUpload {
+			budget = envSeconds("UPLOAD_DEADLINE_SECONDS", defaultUploadDeadline)
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), budget)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// envSeconds returns a positive number of seconds from the environment, or def.
+func envSeconds(key string, def time.Duration) time.Duration {
+	if raw := os.Getenv(key); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			return time.Duration(v) * time.Second
+		}
+		log.Printf("Warning: Invalid %s '%s', using default %v", key, raw, def)
+	}
+	return def
+}
+
+// maxJSONBody returns MAX_JSON_BODY_BYTES (default 8 MiB).
+func maxJSONBody() int64 {
+	if raw := os.Getenv("MAX_JSON_BODY_BYTES"); raw != "" {
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil && v > 0 {
+			return v
+		}
+		log.Printf("Warning: Invalid MAX_JSON_BODY_BYTES '%s', using default %d", raw, defaultMaxJSONBody)
+	}
+	return defaultMaxJSONBody
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// testAdminKey is the ADMIN_API_KEY while a stubRouter is in use; stubRequest sends it.
+const testAdminKey = "test-admin-key"
+
+// stubRouter registers the routing table with every handler replaced by one answering status,
+// and without the routes' own middleware (which reaches the database).
+func stubRouter(t *testing.T, routes []route, status int) *gin.Engine {
+	t.Setenv("ADMIN_API_KEY", testAdminKey)
+	stubbed := make([]route, len(routes))
+	for i, r := range routes {
+		r.handler = func(c *gin.Context) { c.JSON(status, gin.H{}) }
+		r.middleware = nil
+		stubbed[i] = r
+	}
+	router := gin.New()
+	registerRoutes(router.Group("/api/v1"), stubbed)
+	return router
+}
+
+// stubRequest is a request to a stubRouter, authenticated with the admin key.
+func stubRequest(method, path string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set("Authorization", "Bearer "+testAdminKey)
+	return req
+}
+
+// examplePath fills a route's parameters with placeholder values.
+func examplePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "x" + segment[1:]
+		}
+	}
+	return "/api/v1" + strings.Join(segments, "/")
+}
+
+// Every route that accepts a body must declare which kind, so it gets a limit, every route that
+// changes data an API key scope (the supplier feed checks its own secret), and every route
+// needs a description. Optional routes are switched on so the whole table is checked.
+func TestRouteTable(t *testing.T) {
+	t.Setenv("PORTAL_SIGNING_SECRET", "test-secret")
+	t.Setenv("CHAOS_ENDPOINTS", "true")
+	seen := make(map[string]bool)
+	for _, r := range apiRoutes() {
+		switch r.method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if r.body == bodyNone {
+				t.Errorf("route %s %s accepts a body but declares no body class", r.method, r.path)
+			}
+		case http.MethodGet, http.MethodDelete:
+			if r.body != bodyNone {
+				t.Errorf("route %s %s declares a body but %s requests have none", r.method, r.path, r.method)
+			}
+		default:
+			t.Errorf("route %s %s has an unexpected method", r.method, r.path)
+		}
+		if r.method != http.MethodGet && routeScope(r) == "" && r.path != "/integrations/supplier-feed" {
+			t.Errorf("route %s %s changes data but requires no API key scope", r.method, r.path)
+		}
+		if r.doc == "" {
+			t.Errorf("route %s %s has no description", r.method, r.path)
+		}
+		if r.handler == nil {
+			t.Errorf("route %s %s has no handler", r.method, r.path)
+		}
+		if key := r.method + " " + r.path; seen[key] {
+			t.Errorf("route %s is declared twice", key)
+		} else {
+			seen[key] = true
+		}
+	}
+}
+
+// TestCacheHeaders checks the caching headers of every route in the table, for GET and the
+// mirrored HEAD: public listings and brands are cacheable and tagged, admin and portal reads are
+// never stored, and writes and other reads carry no policy.
+func TestCacheHeaders(t *testing.T) {
+	t.Setenv("CACHE_LIST_MAX_AGE", "")
+	t.Setenv("CACHE_BRAND_MAX_AGE", "")
+	t.Setenv("CACHE_STALE_WHILE_REVALIDATE", "")
+	routes := apiRoutes()
+	router := stubRouter(t, routes, http.StatusOK)
+
+	for _, r := range routes {
+		wantControl, wantTag := "", ""
+		switch {
+		case r.method != http.MethodGet:
+		case strings.HasPrefix(r.path, "/admin/"), strings.HasPrefix(r.path, "/portal/"):
+			wantControl = "no-store"
+		case r.path == "/brands":
+			wantControl, wantTag = "public, max-age=60, stale-while-revalidate=600", "brands"
+		case r.path == "/brands/:brandName", r.path == "/brands/by-name/:brandName", r.path == "/brands/id/:id":
+			wantControl = "public, max-age=300, stale-while-revalidate=600"
+		}
+		methods := []string{r.method}
+		if r.method == http.MethodGet {
+			methods = append(methods, http.MethodHead)
+		}
+		for _, method := range methods {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, stubRequest(method, examplePath(r.path), nil))
+			header := w.Header()
+			if w.Code != http.StatusOK {
+				t.Errorf("%s %s: status %d, want the stub's 200", method, r.path, w.Code)
+				continue
+			}
+			if got := header.Get("Cache-Control"); got != wantControl {
+				t.Errorf("%s %s: Cache-Control %q, want %q", method, r.path, got, wantControl)
+			}
+			if got := header.Get("Cache-Tag"); got != wantTag {
+				t.Errorf("%s %s: Cache-Tag %q, want %q", method, r.path, got, wantTag)
+			}
+			if public := strings.HasPrefix(wantControl, "public"); public != strings.Contains(header.Get("Vary"), "Accept-Language") {
+				t.Errorf("%s %s: Vary %q on a response that is public: %v", method, r.path, header.Get("Vary"), public)
+			}
+		}
+	}
+}
+
+// Errors from public routes must never be cached or tagged.
+func TestCacheHeadersOnErrors(t *testing.T) {
+	router := stubRouter(t, apiRoutes(), http.StatusNotFound)
+	for _, path := range []string{"/brands", "/brands/:brandName", "/brands/by-name/:brandName", "/brands/id/:id"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, stubRequest(http.MethodGet, examplePath(path), nil))
+		if got := w.Header().Get("Cache-Control"); got != "no-store" || w.Header().Get("Cache-Tag") != "" || w.Header().Get("Vary") != "" {
+			t.Errorf("GET %s 404: Cache-Control %q, Cache-Tag %q, Vary %q; want only no-store", path, got, w.Header().Get("Cache-Tag"), w.Header().Get("Vary"))
+		}
+	}
+}
+
+// Routes with a scope reject requests without an API key, and keys lacking the scope with a 403
+// naming it; the other routes stay open.
+func TestRouteScopes(t *testing.T) {
+	t.Setenv("PORTAL_SIGNING_SECRET", "test-secret")
+	t.Setenv("CHAOS_ENDPOINTS", "true")
+	routes := apiRoutes()
+	router := stubRouter(t, routes, http.StatusOK)
+	for _, r := range routes {
+		if strings.HasPrefix(r.path, "/admin/") && routeScope(r) != services.ScopeAdmin {
+			t.Errorf("%s %s requires %q, want admin", r.method, r.path, routeScope(r))
+		}
+		want := http.StatusOK
+		if routeScope(r) != "" {
+			want = http.StatusUnauthorized
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(r.method, examplePath(r.path), nil))
+		if w.Code != want {
+			t.Errorf("%s %s without a key: status %d, want %d", r.method, r.path, w.Code, want)
+		}
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("read-only key", func(mt *mtest.T) {
+		database.Use(mt.Client)
+		key := bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "prefix", Value: "bk1_readonly"},
+			{Key: "scopes", Value: bson.A{services.ScopeBrandsRead}},
+		}
+		tests := []struct {
+			method, path string
+			wantStatus   int
+			wantScope    string // Named in the 403
+		}{
+			{http.MethodGet, "/brands/:brandName/contacts", http.StatusOK, ""},
+			{http.MethodPost, "/brands", http.StatusForbidden, services.ScopeBrandsWrite},
+			{http.MethodGet, "/admin/features", http.StatusForbidden, services.ScopeAdmin},
+		}
+		for _, tt := range tests {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.api_keys", mtest.FirstBatch, key))
+			req := httptest.NewRequest(tt.method, examplePath(tt.path), nil)
+			req.Header.Set("Authorization", "Bearer bk1_readonly-secret")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			var body struct {
+				Scope string `json:"scope"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != tt.wantStatus || body.Scope != tt.wantScope {
+				t.Errorf("%s %s: status %d naming %q, want %d naming %q", tt.method, tt.path, w.Code, body.Scope, tt.wantStatus, tt.wantScope)
+			}
+		}
+	})
+}
+
+// In embedded mode brands are created through the relay, which sends JSON with the key the host
+// passed it as a bearer token, or by form posts that can't send headers and carry the key in an
+// apiKey field instead. Outside embedded mode the field is not a credential.
+func TestEmbeddedBrandCreation(t *testing.T) {
+	t.Cleanup(func() { featureflags.Init(nil) })
+	var routes []route
+	for _, r := range apiRoutes() {
+		if r.method == http.MethodPost && r.path == "/brands" {
+			r.handler = func(c *gin.Context) {
+				var payload models.CreateBrandPayload
+				if err := c.ShouldBind(&payload); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				c.JSON(http.StatusCreated, gin.H{"name": payload.Name})
+			}
+			routes = append(routes, r)
+		}
+	}
+	t.Setenv("ADMIN_API_KEY", testAdminKey)
+	router := gin.New()
+	registerRoutes(router.Group("/api/v1"), routes)
+
+	form := url.Values{"name": {"Acme"}, "details": {"Terms"}}
+	keyedForm := url.Values{"name": {"Acme"}, "details": {"Terms"}, "apiKey": {testAdminKey}}
+	tests := []struct {
+		name        string
+		embedded    bool
+		contentType string
+		body        string
+		bearer      string
+		wantStatus  int
+	}{
+		{"relayed JSON with key", true, "application/json", `{"name":"Acme","details":"Terms"}`, testAdminKey, http.StatusCreated},
+		{"relayed JSON without key", true, "application/json", `{"name":"Acme","details":"Terms"}`, "", http.StatusUnauthorized},
+		{"form with key field", true, "application/x-www-form-urlencoded", keyedForm.Encode(), "", http.StatusCreated},
+		{"form with wrong key field", true, "application/x-www-form-urlencoded", url.Values{"name": {"Acme"}, "details": {"Terms"}, "apiKey": {"nope"}}.Encode(), "", http.StatusUnauthorized},
+		{"form without key", true, "application/x-www-form-urlencoded", form.Encode(), "", http.StatusUnauthorized},
+		{"form with bearer key", true, "application/x-www-form-urlencoded", form.Encode(), testAdminKey, http.StatusCreated},
+		{"key field outside embedded mode", false, "application/x-www-form-urlencoded", keyedForm.Encode(), "", http.StatusUnauthorized},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			database.Use(mt.Client)
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.api_keys", mtest.FirstBatch)) // Keys other than the admin key are unknown
+			features := ""
+			if tt.embedded {
+				features = featureflags.EmbeddedMode
+			}
+			mt.Setenv("FEATURES", features)
+			featureflags.Init(nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/brands", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				mt.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusCreated && !strings.Contains(w.Body.String(), `"name":"Acme"`) {
+				mt.Errorf("body %s, want the bound payload", w.Body.String())
+			}
+		})
+	}
+}
+
+// The portal token endpoints exist only with the portal enabled, and then only behind admin
+// keys, while the portal itself takes portal tokens instead.
+func TestPortalTokenRoutesNeedAdmin(t *testing.T) {
+	t.Setenv("PORTAL_SIGNING_SECRET", "")
+	for _, r := range apiRoutes() {
+		if strings.Contains(r.path, "portal") {
+			t.Errorf("%s %s is registered with the portal disabled", r.method, r.path)
+		}
+	}
+
+	t.Setenv("PORTAL_SIGNING_SECRET", "test-secret")
+	tokenRoutes := 0
+	for _, r := range apiRoutes() {
+		switch {
+		case strings.Contains(r.path, "/portal-tokens"):
+			tokenRoutes++
+			if routeScope(r) != services.ScopeAdmin {
+				t.Errorf("%s %s requires %q, want admin", r.method, r.path, routeScope(r))
+			}
+		case strings.HasPrefix(r.path, "/portal/") && routeScope(r) != "":
+			t.Errorf("%s %s requires an API key scope %q on top of its portal token", r.method, r.path, routeScope(r))
+		}
+	}
+	if tokenRoutes != 3 {
+		t.Errorf("%d portal token routes, want list, issue and revoke", tokenRoutes)
+	}
+}
+
+// Writes that change brands purge the listings from the CDN, including those outside /brands,
+// and the brand itself when they name one.
+func TestWritesPurgeCDN(t *testing.T) {
+	purged := make(chan []string, 1)
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		purged <- body.Tags
+	}))
+	defer cdn.Close()
+	t.Setenv("CDN_PURGE_URL", cdn.URL)
+
+	router := stubRouter(t, apiRoutes(), http.StatusOK)
+	for _, path := range []string{"/brands/bulk", "/integrations/supplier-feed"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, stubRequest(http.MethodPost, examplePath(path), strings.NewReader("[]")))
+		select {
+		case tags := <-purged:
+			if len(tags) != 1 || tags[0] != "brands" {
+				t.Errorf("POST %s purged %v, want [brands]", path, tags)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("POST %s didn't purge the CDN", path)
+		}
+	}
+
+	// Writes to one brand also purge its responses, tagged with the ID looked up by name (after
+	// the write, or before it for a deletion)
+	id := primitive.NewObjectID()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		mt.Run(method, func(mt *mtest.T) {
+			database.Use(mt.Client)
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch, bson.D{{Key: "_id", Value: id}}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, stubRequest(method, "/api/v1/brands/Acme", strings.NewReader("{}")))
+			want := []string{"brands", "brand-" + id.Hex()}
+			select {
+			case tags := <-purged:
+				if !reflect.DeepEqual(tags, want) {
+					t.Errorf("%s /brands/Acme purged %v, want %v", method, tags, want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Errorf("%s /brands/Acme didn't purge the CDN", method)
+			}
+		})
+	}
+}
+
+// Every static segment under /brands must be a reserved brand name even where no routes are
+// registered (brandctl), must win over the brand routes, and must leave the brand of that name
+// reachable through /brands/by-name/.
+func TestReservedBrandNamesMatchRoutes(t *testing.T) {
+	t.Setenv("PORTAL_SIGNING_SECRET", "test-secret")
+	t.Setenv("CHAOS_ENDPOINTS", "true")
+	reserved := make(map[string]bool)
+	for _, name := range services.ReservedBrandNames() {
+		reserved[name] = true
+	}
+
+	// Each stub answers with its own path, so a request shows which row it reached
+	router := gin.New()
+	api := router.Group("/api/v1")
+	var static []route
+	for _, r := range apiRoutes() {
+		path := r.path
+		api.Handle(r.method, path, func(c *gin.Context) { c.String(http.StatusOK, path) })
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/brands/"), "/")
+		if strings.HasPrefix(path, "/brands/") && !strings.HasPrefix(segment, ":") {
+			if !reserved[segment] {
+				t.Errorf("static segment %q of %s %s is not a reserved brand name", segment, r.method, path)
+			}
+			static = append(static, r)
+		}
+	}
+
+	answer := func(method, path string) string {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/api/v1"+path, nil))
+		return w.Body.String()
+	}
+	for _, r := range static {
+		// Fill the parameters with a value that is itself reserved
+		parts := strings.Split(r.path, "/")
+		for i, part := range parts {
+			if strings.HasPrefix(part, ":") {
+				parts[i] = "sync"
+			}
+		}
+		if got := answer(r.method, strings.Join(parts, "/")); got != r.path {
+			t.Errorf("%s %s reached %q, want the static route", r.method, strings.Join(parts, "/"), got)
+		}
+	}
+	for name := range reserved {
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			if got := answer(method, "/brands/by-name/"+name); got != "/brands/by-name/:brandName" {
+				t.Errorf("%s /brands/by-name/%s reached %q, want the brand", method, name, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/joho/godotenv"
+)
+
+// envFile is the optional dotenv file read at startup and on every reload.
+const envFile = ".env"
+
+// reloadState tracks which settings came from envFile. Variables set in the process environment
+// win over the file (as with godotenv.Load) and can't change while the process runs, so a reload
+// only ever touches settings the file provides.
+var reloadState struct {
+	mu       sync.Mutex
+	fromFile map[string]bool
+	hooks    []func()
+}
+
+// reloadable lists the settings that take effect without a restart, each with the check a new
+// value must pass. They are all read where they are used, so once the environment is updated the
+// next request (or background run) picks them up. Every other known setting is applied once at
+// startup (connections, listeners, worker intervals, caches built on first use).
+var reloadable = map[string]func(string) error{
+	"MAX_DETAILS_BYTES":               positiveInt,
+	"MAX_UPLOAD_BYTES":                positiveInt,
+	"MAX_JSON_BODY_BYTES":             positiveInt,
+	"IMPORT_BATCH_SIZE":               positiveInt,
+	"LOGO_MAX_BYTES":                  positiveInt,
+	"WRITE_CONCERN":                   writeConcern,
+	"CORS_ALLOWED_ORIGINS":            corsOrigins,
+	"FEATURES":                        features,
+	"UPLOAD_ALLOWED_TYPES":            anyValue,
+	"UPLOAD_MAX_PAGES":                nonNegativeInt,
+	"UPLOAD_CONCURRENCY":              positiveInt,
+	"UPLOAD_ALLOW_EMPTY":              boolean,
+	"DUPLICATE_NAME_THRESHOLD":        ratio,
+	"PDF_BREAKER_THRESHOLD":           positiveInt,
+	"PDF_BREAKER_COOLDOWN_SECONDS":    positiveInt,
+	"BRAND_EXPORT_SECTIONS":           anyValue,
+	"ALERT_WEBHOOK_URL":               webhookURL,
+	"ALERT_CONDITIONS":                anyValue,
+	"ALERT_DB_PING_FAILURES":          positiveInt,
+	"ALERT_EXTRACTION_FAILURE_RATIO":  ratio,
+	"ALERT_EXTRACTION_WINDOW_MINUTES": positiveInt,
+	"ALERT_EXTRACTION_MIN_SAMPLES":    positiveInt,
+	"ALERT_QUEUE_DEPTH":               positiveInt,
+	"RETENTION_DRY_RUN":               boolean,
+	"RETENTION_BATCH_SIZE":            positiveInt,
+	"RETENTION_BATCH_PAUSE_MS":        positiveInt,
+	"PORTAL_RATE_LIMIT_PER_MINUTE":    positiveInt,
+	"SYNC_TOMBSTONE_RETENTION_HOURS":  positiveInt,
+	"REQUEST_DEADLINE_SECONDS":        positiveInt,
+	"UPLOAD_DEADLINE_SECONDS":         positiveInt,
+	"AUDIT_EXPORT_URL":                webhookURL,
+	"AUDIT_EXPORT_TOKEN":              anyValue,
+	"AUDIT_EXPORT_BATCH_SIZE":         positiveInt,
+	"AUDIT_EXPORT_QUEUE_SIZE":         positiveInt,
+	"AUDIT_EXPORT_MASK":               anyValue,
+	"AUDIT_EXPORT_MASK_SECRET":        anyValue,
+	"SOURCE_REFRESH_HOURS":            positiveInt,
+	"SOURCE_REFRESH_FAILURES":         positiveInt,
+	"CACHE_LIST_MAX_AGE":              nonNegativeInt,
+	"CACHE_BRAND_MAX_AGE":             nonNegativeInt,
+	"CACHE_STALE_WHILE_REVALIDATE":    nonNegativeInt,
+	"CDN_PURGE_URL":                   webhookURL,
+	"CDN_PURGE_TOKEN":                 anyValue,
+}
+
+// Reloadable reports whether a setting can be changed by Reload without a restart.
+func Reloadable(key string) bool {
+	_, ok := reloadable[key]
+	return ok
+}
+
+// LoadEnvFile loads envFile into the environment without overriding variables that are already
+// set, and remembers which settings it provided so Reload can update them later.
+func LoadEnvFile() error {
+	values, err := godotenv.Read(envFile)
+	if err != nil {
+		return err
+	}
+	reloadState.mu.Lock()
+	defer reloadState.mu.Unlock()
+	reloadState.fromFile = make(map[string]bool, len(values))
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		os.Setenv(key, value)
+		reloadState.fromFile[key] = true
+	}
+	return nil
+}
+
+// OnReload registers fn to run after a reload applied at least one change, for packages that
+// keep parsed copies of their settings (feature flags, the upload policy).
+func OnReload(fn func()) {
+	reloadState.mu.Lock()
+	defer reloadState.mu.Unlock()
+	reloadState.hooks = append(reloadState.hooks, fn)
+}
+
+// ReloadChange is one setting that differed between the environment and envFile. Values are
+// redacted like everywhere else (see Redact).
+type ReloadChange struct {
+	Key    string `json:"key"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+	Reason string `json:"reason,omitempty"` // Why it was rejected
+}
+
+// ReloadResult reports what a reload did.
+type ReloadResult struct {
+	Applied  []ReloadChange `json:"applied"`
+	Rejected []ReloadChange `json:"rejected"`
+}
+
+// Reload re-reads envFile and applies the changed settings that are reloadable and valid.
+// Changes to other settings are rejected with a warning that they need a restart; invalid values
+// are rejected with the reason and leave the current value in place. Settings set in the process
+// environment are never touched. A missing file counts as empty, so settings removed from it
+// return to their defaults.
+func Reload() (ReloadResult, error) {
+	values, err := godotenv.Read(envFile)
+	if errors.Is(err, os.ErrNotExist) {
+		values, err = map[string]string{}, nil
+	}
+	if err != nil {
+		return ReloadResult{}, fmt.Errorf("reading %s: %w", envFile, err)
+	}
+
+	reloadState.mu.Lock()
+	if reloadState.fromFile == nil {
+		reloadState.fromFile = make(map[string]bool)
+	}
+	result := ReloadResult{Applied: []ReloadChange{}, Rejected: []ReloadChange{}}
+	keys := make([]string, 0, len(knownSettings))
+	for key := range knownSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, inFile := values[key]
+		current, set := os.LookupEnv(key)
+		if !reloadState.fromFile[key] && (set || !inFile) {
+			continue // From the process environment, or in neither
+		}
+		if value == current && inFile == set {
+			continue
+		}
+		change := ReloadChange{Key: key, Old: Redact(key, current), New: Redact(key, value)}
+		if check, ok := reloadable[key]; !ok {
+			change.Reason = "requires a restart"
+			log.Printf("Warning: %s changed in %s but requires a restart to take effect", key, envFile)
+		} else if value != "" {
+			if err := check(value); err != nil {
+				change.Reason = err.Error()
+			}
+		}
+		if change.Reason != "" {
+			result.Rejected = append(result.Rejected, change)
+			continue
+		}
+
+		if inFile {
+			os.Setenv(key, value)
+			reloadState.fromFile[key] = true
+		} else {
+			os.Unsetenv(key)
+			delete(reloadState.fromFile, key)
+		}
+		result.Applied = append(result.Applied, change)
+	}
+	hooks := reloadState.hooks
+	reloadState.mu.Unlock()
+
+	if len(result.Applied) > 0 {
+		for _, hook := range hooks {
+			hook()
+		}
+	}
+	return result, nil
+}
+
+func anyValue(string) error { return nil }
+
+func positiveInt(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+		return errors.New("must be a positive integer")
+	}
+	return nil
+}
+
+func nonNegativeInt(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n < 0 {
+		return errors.New("must be a non-negative integer")
+	}
+	return nil
+}
+
+func boolean(value string) error {
+	if !strings.EqualFold(value, "true") && !strings.EqualFold(value, "false") {
+		return errors.New("must be true or false")
+	}
+	return nil
+}
+
+func ratio(value string) error {
+	if v, err := strconv.ParseFloat(value, 64); err != nil || v <= 0 || v > 1 {
+		return errors.New("must be a number greater than 0 and at most 1")
+	}
+	return nil
+}
+
+func writeConcern(value string) error {
+	if value == "default" || value == "majority" || nonNegativeInt(value) == nil {
+		return nil
+	}
+	return errors.New("must be 'majority', 'default' or a node count")
+}
+
+func corsOrigins(value string) error {
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("'%s' is not an origin like https://example.com", origin)
+		}
+	}
+	return nil
+}
+
+func features(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		name := strings.TrimPrefix(strings.TrimSpace(item), "-")
+		if name != "" && !featureflags.Known(name) {
+			return fmt.Errorf("unknown feature flag '%s'", name)
+		}
+	}
+	return nil
+}
+
+func webhookURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an http(s) URL")
+	}
+	return nil
+}
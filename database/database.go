@@ -2,10 +2,15 @@ package database
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
+	"github.com/Gautam3767/Order_form_Details_Backend/chaos"
+
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -15,21 +20,26 @@ var mongoClient *mongo.Client
 var mongoDB *mongo.Database
 var brandCollection *mongo.Collection
 
-// Connect initializes the MongoDB connection
+// Connect initializes the MongoDB connection. It doesn't build the indexes; the server starts
+// that with StartIndexBuild.
 func Connect() {
 	mongoURI := os.Getenv("MONGODB_URI")
-	dbName := os.Getenv("MONGODB_DATABASE")
-	collectionName := os.Getenv("MONGODB_COLLECTION")
+	dbName := DatabaseName()
+	collectionName := CollectionName()
 
-	if mongoURI == "" || dbName == "" || collectionName == "" {
-		log.Fatal("MONGODB_URI, MONGODB_DATABASE, and MONGODB_COLLECTION must be set in the environment variables or .env file")
+	if mongoURI == "" {
+		log.Fatal("MONGODB_URI must be set in the environment variables or .env file")
 	}
 
 	// Use context with timeout for connection attempt
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel() // Release resources associated with context
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	clientOpts := options.Client().ApplyURI(mongoURI)
+	if chaos.Enabled() {
+		clientOpts.SetMonitor(chaos.CommandMonitor()) // Adds injected latency to every command
+	}
+	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
 		log.Fatalf("Failed to create MongoDB client: %v", err)
 	}
@@ -39,30 +49,201 @@ func Connect() {
 		log.Fatalf("Failed to connect to MongoDB (ping failed): %v", err)
 	}
 
-	log.Println("Successfully connected and pinged MongoDB.")
+	log.Printf("Successfully connected and pinged MongoDB (database '%s', collection '%s').", dbName, collectionName)
+
+	Use(client)
+}
 
+// Use points the package at an already connected client (e.g. an mtest mock deployment in
+// tests) without building the indexes.
+func Use(client *mongo.Client) {
 	mongoClient = client
-	mongoDB = client.Database(dbName)
-	brandCollection = mongoDB.Collection(collectionName)
+	mongoDB = client.Database(DatabaseName())
+	brandCollection = mongoDB.Collection(CollectionName())
+}
+
+// Server error codes of createIndexes for an index that exists with other options
+const (
+	errIndexOptionsConflict  = 85
+	errIndexKeySpecsConflict = 86
+)
+
+// ensureIndex creates model in coll. When an index with the same keys or name but other options
+// exists, e.g. because a setting changed, it calls change to bring that index up to date.
+func ensureIndex(coll *mongo.Collection, model mongo.IndexModel, change func(context.Context) error) error {
+	ctx := context.Background()
+	_, err := coll.Indexes().CreateOne(ctx, model)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == errIndexOptionsConflict || cmdErr.Code == errIndexKeySpecsConflict) {
+		log.Printf("Index on '%s' exists with other options, updating it: %v", coll.Name(), err)
+		return change(ctx)
+	}
+	return err
+}
 
-	// --- Optional: Create Indexes ---
-	// Create a unique index on the 'name' field in the background
+// createIndexes creates every index the application relies on and reports whether all of them
+// were. Bump indexSetVersion when adding or changing one here.
+func createIndexes() bool {
+	ok := true
+	// Create a unique index on the 'name' field
 	// It's good practice to ensure brand names are unique at the DB level
-	go func() {
-		indexModel := mongo.IndexModel{
-			Keys:    map[string]interface{}{"name": 1}, // 1 for ascending order
-			Options: options.Index().SetUnique(true).SetBackground(true),
-		}
-		_, err := brandCollection.Indexes().CreateOne(context.Background(), indexModel)
-		if err != nil {
-			// Log the error but don't necessarily crash the app
-			// It might fail if the index already exists or if there are duplicate names before the index is created
-			log.Printf("Warning: Could not create unique index on 'name': %v", err)
-		} else {
-			log.Println("Unique index on 'name' field ensured.")
+	indexModel := mongo.IndexModel{
+		Keys:    map[string]interface{}{"name": 1}, // 1 for ascending order
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	}
+	_, err := brandCollection.Indexes().CreateOne(context.Background(), indexModel)
+	if err != nil {
+		// Log the error but don't necessarily crash the app
+		// It might fail if the index already exists or if there are duplicate names before the index is created
+		log.Printf("Warning: Could not create unique index on 'name': %v", err)
+		ok = false
+	} else {
+		log.Println("Unique index on 'name' field ensured.")
+	}
+
+	// Multikey index backing the "brands mentioning X" keyword lookup
+	keywordIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"keywords.term": 1},
+		Options: options.Index().SetBackground(true),
+	}
+	if _, err := brandCollection.Indexes().CreateOne(context.Background(), keywordIndex); err != nil {
+		log.Printf("Warning: Could not create index on 'keywords.term': %v", err)
+		ok = false
+	} else {
+		log.Println("Index on 'keywords.term' field ensured.")
+	}
+
+	// Text index backing the details search (a collection can only have one)
+	detailsTextIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "details", Value: "text"}},
+		Options: options.Index().SetName("details_text").SetBackground(true),
+	}
+	if _, err := brandCollection.Indexes().CreateOne(context.Background(), detailsTextIndex); err != nil {
+		log.Printf("Warning: Could not create text index on 'details': %v", err)
+		ok = false
+	} else {
+		log.Println("Text index on 'details' field ensured.")
+	}
+
+	// Compound index serving the sync endpoint's (updatedAt, _id) ordering
+	syncIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	}
+	if _, err := brandCollection.Indexes().CreateOne(context.Background(), syncIndex); err != nil {
+		log.Printf("Warning: Could not create index on 'updatedAt,_id': %v", err)
+		ok = false
+	} else {
+		log.Println("Index on 'updatedAt,_id' fields ensured.")
+	}
+
+	// Index with the configured collation so the alphabetical listing stays indexed. The unique
+	// index above keeps its binary comparison: names differing only in case remain distinct.
+	// It replaces the older name-only "name_collated", which can be dropped. A changed
+	// BRAND_COLLATION_LOCALE conflicts with the existing index, which is then rebuilt.
+	err = ensureIndex(brandCollection, collatedNameIndex(CollationLocale()), func(ctx context.Context) error {
+		if _, err := brandCollection.Indexes().DropOne(ctx, collatedNameIndexName); err != nil {
+			return err
 		}
-	}()
+		_, err := brandCollection.Indexes().CreateOne(ctx, collatedNameIndex(CollationLocale()))
+		return err
+	})
+	if err != nil {
+		log.Printf("Warning: Could not create collated index on 'name': %v", err)
+		ok = false
+	} else {
+		log.Printf("Collated index on 'name' (locale %s) ensured.", CollationLocale())
+	}
+
+	// Sub-brand lookups (children, cascading deletes); most brands have no parent
+	parentIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"parentBrand": 1},
+		Options: options.Index().SetSparse(true).SetBackground(true),
+	}
+	if _, err := brandCollection.Indexes().CreateOne(context.Background(), parentIndex); err != nil {
+		log.Printf("Warning: Could not create index on 'parentBrand': %v", err)
+		ok = false
+	} else {
+		log.Println("Index on 'parentBrand' field ensured.")
+	}
+	// Serves the source refresh scheduler's "due" query; only supplier-sourced brands have it
+	sourceIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"source.nextRefreshAt": 1},
+		Options: options.Index().SetSparse(true).SetBackground(true),
+	}
+	if _, err := brandCollection.Indexes().CreateOne(context.Background(), sourceIndex); err != nil {
+		log.Printf("Warning: Could not create index on 'source.nextRefreshAt': %v", err)
+		ok = false
+	} else {
+		log.Println("Index on 'source.nextRefreshAt' field ensured.")
+	}
+
+	// One counter document per brand and day; the unique key makes concurrent $inc upserts safe
+	viewsIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "brand", Value: 1}, {Key: "day", Value: 1}},
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	}
+	if _, err := Collection(BrandViewsCollection).Indexes().CreateOne(context.Background(), viewsIndex); err != nil {
+		log.Printf("Warning: Could not create index on '%s.brand,day': %v", BrandViewsCollection, err)
+		ok = false
+	} else {
+		log.Printf("Index on '%s.brand,day' fields ensured.", BrandViewsCollection)
+	}
+	// Serves the most-viewed ranking, which scans a day range across all brands
+	viewsDayIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"day": 1},
+		Options: options.Index().SetBackground(true),
+	}
+	if _, err := Collection(BrandViewsCollection).Indexes().CreateOne(context.Background(), viewsDayIndex); err != nil {
+		log.Printf("Warning: Could not create index on '%s.day': %v", BrandViewsCollection, err)
+		ok = false
+	}
+
+	// One document per brand and terms version; the unique key keeps concurrent recordings single
+	termsIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "brandId", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	}
+	if _, err := Collection(BrandTermsCollection).Indexes().CreateOne(context.Background(), termsIndex); err != nil {
+		log.Printf("Warning: Could not create unique index on '%s.brandId,version': %v", BrandTermsCollection, err)
+		ok = false
+	} else {
+		log.Printf("Unique index on '%s.brandId,version' fields ensured.", BrandTermsCollection)
+	}
 
+	// Each request with an API key looks it up by the hash of its secret
+	apiKeyIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"hash": 1},
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	}
+	if _, err := Collection(APIKeyCollection).Indexes().CreateOne(context.Background(), apiKeyIndex); err != nil {
+		log.Printf("Warning: Could not create unique index on '%s.hash': %v", APIKeyCollection, err)
+		ok = false
+	} else {
+		log.Printf("Unique index on '%s.hash' ensured.", APIKeyCollection)
+	}
+
+	// TTL index: tombstones of deleted brands are only kept for the sync retention window. A
+	// changed SYNC_TOMBSTONE_RETENTION_HOURS conflicts with the existing index, whose expiry is
+	// then changed in place.
+	tombstones := Collection(TombstoneCollection)
+	tombstoneIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "deletedAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(tombstoneTTLSeconds()).SetBackground(true),
+	}
+	err = ensureIndex(tombstones, tombstoneIndex, func(ctx context.Context) error {
+		return mongoDB.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: TombstoneCollection},
+			{Key: "index", Value: bson.D{{Key: "keyPattern", Value: tombstoneIndex.Keys}, {Key: "expireAfterSeconds", Value: tombstoneTTLSeconds()}}},
+		}).Err()
+	})
+	if err != nil {
+		log.Printf("Warning: Could not create TTL index on '%s.deletedAt': %v", TombstoneCollection, err)
+		ok = false
+	} else {
+		log.Printf("TTL index on '%s.deletedAt' ensured.", TombstoneCollection)
+	}
+	return ok
 }
 
 // GetDB returns the MongoDB database instance
@@ -74,7 +255,7 @@ func GetDB() *mongo.Database {
 // GetCollection returns the specific MongoDB collection for brands
 func GetCollection(name string) *mongo.Collection {
 	// In this simple case, we only have one collection pre-defined
-	if name == os.Getenv("MONGODB_COLLECTION") {
+	if name == CollectionName() {
 		return brandCollection
 	}
 	// If you had multiple collections, you could fetch them dynamically:
@@ -83,6 +264,139 @@ func GetCollection(name string) *mongo.Collection {
 	return brandCollection // Or return nil/error
 }
 
+// Defaults for MONGODB_DATABASE and MONGODB_COLLECTION, so a demo only needs MONGODB_URI
+const (
+	DefaultDatabaseName   = "orderform"
+	DefaultCollectionName = "brands"
+)
+
+// DatabaseName returns MONGODB_DATABASE, or DefaultDatabaseName when it is unset.
+func DatabaseName() string {
+	if name := os.Getenv("MONGODB_DATABASE"); name != "" {
+		return name
+	}
+	return DefaultDatabaseName
+}
+
+// CollectionName returns the brand collection's name: MONGODB_COLLECTION, or DefaultCollectionName when it is unset.
+func CollectionName() string {
+	if name := os.Getenv("MONGODB_COLLECTION"); name != "" {
+		return name
+	}
+	return DefaultCollectionName
+}
+
+// Collection returns any other collection in the configured database (tombstones, ...)
+func Collection(name string) *mongo.Collection {
+	return mongoDB.Collection(name)
+}
+
+// TombstoneCollection holds one record per deleted brand so sync clients can learn about deletions
+const TombstoneCollection = "brand_tombstones"
+
+// BrandViewsCollection holds per-brand daily view counters
+const BrandViewsCollection = "brand_views"
+
+// UploadJournalCollection is the capped collection recording the progress of PDF uploads
+const UploadJournalCollection = "upload_journal"
+
+// PortalTokenCollection holds the metadata of issued supplier portal tokens
+const PortalTokenCollection = "portal_tokens"
+
+// BrandTermsCollection keeps every published version of each brand's terms and conditions
+const BrandTermsCollection = "brand_terms"
+
+// APIKeyCollection holds the API keys, by the hash of their secret
+const APIKeyCollection = "api_keys"
+
+// PartitionSetCollection holds the brand partition boundaries handed to parallel bulk consumers
+const PartitionSetCollection = "brand_partitions"
+
+// DeletionManifestCollection records every brand deletion and how far its cleanup got
+const DeletionManifestCollection = "brand_deletions"
+
+// AuditLogCollection is the capped collection recording administrative actions
+const AuditLogCollection = "audit_log"
+
+// EnsureCappedCollection creates name as a capped collection of sizeBytes if it doesn't exist yet.
+// An existing collection is left as it is; drop it to apply a new size.
+func EnsureCappedCollection(name string, sizeBytes int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := mongoDB.CreateCollection(ctx, name, options.CreateCollection().SetCapped(true).SetSizeInBytes(sizeBytes))
+	var cmdErr mongo.CommandError
+	switch {
+	case err == nil:
+		log.Printf("Capped collection '%s' (%d bytes) created.", name, sizeBytes)
+	case errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists":
+		// Already there from a previous start
+	default:
+		log.Printf("Warning: Could not create capped collection '%s': %v", name, err)
+	}
+}
+
+// Default time deleted-brand tombstones are retained (30 days)
+const defaultTombstoneRetention = 30 * 24 * time.Hour
+
+// TombstoneRetention returns how long tombstones are kept, from SYNC_TOMBSTONE_RETENTION_HOURS.
+// Sync cursors older than this can no longer be honored and trigger a full resync.
+func TombstoneRetention() time.Duration {
+	if raw := os.Getenv("SYNC_TOMBSTONE_RETENTION_HOURS"); raw != "" {
+		if hours, err := strconv.Atoi(raw); err == nil && hours > 0 {
+			return time.Duration(hours) * time.Hour
+		}
+		log.Printf("Warning: Invalid SYNC_TOMBSTONE_RETENTION_HOURS '%s', using default %v", raw, defaultTombstoneRetention)
+	}
+	return defaultTombstoneRetention
+}
+
+// tombstoneTTLSeconds is the expiry of the tombstone TTL index.
+func tombstoneTTLSeconds() int32 {
+	return int32(TombstoneRetention().Seconds())
+}
+
+// defaultCollationLocale is the locale brand names are sorted in when BRAND_COLLATION_LOCALE is unset.
+const defaultCollationLocale = "en"
+
+// CollationLocale returns the locale used to sort brand names (BRAND_COLLATION_LOCALE, default "en").
+func CollationLocale() string {
+	if locale := os.Getenv("BRAND_COLLATION_LOCALE"); locale != "" {
+		return locale
+	}
+	return defaultCollationLocale
+}
+
+// NameCollation is the collation for sorting brand names in locale: strength 2 compares base
+// letters and accents but ignores case, so "Éclair" sorts with the E's instead of after "Z".
+func NameCollation(locale string) *options.Collation {
+	return &options.Collation{Locale: locale, Strength: 2}
+}
+
+// NameSort is the order of alphabetical brand listings. Collated names can tie ("acme" and
+// "Acme" compare equal), so _id breaks the tie to keep the order stable between requests.
+func NameSort() bson.D {
+	return bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}
+}
+
+const collatedNameIndexName = "name_collated_id"
+
+// collatedNameIndex serves NameSort in locale. Queries only use it when they specify the same
+// collation.
+func collatedNameIndex(locale string) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys:    NameSort(),
+		Options: options.Index().SetName(collatedNameIndexName).SetCollation(NameCollation(locale)).SetBackground(true),
+	}
+}
+
+// Ping checks that the primary is reachable.
+func Ping(ctx context.Context) error {
+	if mongoClient == nil {
+		return errors.New("not connected")
+	}
+	return mongoClient.Ping(ctx, readpref.Primary())
+}
+
 // Disconnect closes the MongoDB connection
 // Call this on graceful shutdown if needed
 func Disconnect() {
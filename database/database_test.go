@@ -0,0 +1,59 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNameCollation(t *testing.T) {
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"", defaultCollationLocale},
+		{"de", "de"},
+		{"sv", "sv"},
+	}
+	for _, tt := range tests {
+		t.Setenv("BRAND_COLLATION_LOCALE", tt.env)
+		collation := NameCollation(CollationLocale())
+		// Strength 2: accents count, case doesn't
+		if collation.Locale != tt.want || collation.Strength != 2 {
+			t.Errorf("BRAND_COLLATION_LOCALE=%q: got %s at strength %d, want %s at 2", tt.env, collation.Locale, collation.Strength, tt.want)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		database, collection         string
+		wantDatabase, wantCollection string
+	}{
+		{"", "", DefaultDatabaseName, DefaultCollectionName},
+		{"shop", "", "shop", DefaultCollectionName},
+		{"", "suppliers", DefaultDatabaseName, "suppliers"},
+	}
+	for _, tt := range tests {
+		t.Setenv("MONGODB_DATABASE", tt.database)
+		t.Setenv("MONGODB_COLLECTION", tt.collection)
+		if got := DatabaseName(); got != tt.wantDatabase {
+			t.Errorf("MONGODB_DATABASE=%q: got %q, want %q", tt.database, got, tt.wantDatabase)
+		}
+		if got := CollectionName(); got != tt.wantCollection {
+			t.Errorf("MONGODB_COLLECTION=%q: got %q, want %q", tt.collection, got, tt.wantCollection)
+		}
+	}
+}
+
+// The collated index has the listing's sort keys in the same order, so the sort can use it.
+func TestCollatedNameIndex(t *testing.T) {
+	for _, locale := range []string{"en", "de", "sv"} {
+		index := collatedNameIndex(locale)
+		if !reflect.DeepEqual(index.Keys, NameSort()) {
+			t.Errorf("%s: keys %v, want %v", locale, index.Keys, NameSort())
+		}
+		if got := index.Options.Collation; got == nil || got.Locale != locale || got.Strength != 2 {
+			t.Errorf("%s: collation %+v, want %s at strength 2", locale, got, locale)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LeaseCollection holds one document per held lease: {_id: name, owner, expiresAt}
+const LeaseCollection = "leases"
+
+// SchemaStateCollection records which version of the index set has been built
+const SchemaStateCollection = "schema_state"
+
+// indexSetVersion is the version of the indexes createIndexes builds. Replicas skip building
+// them when the recorded version is at least this one and was built with the same
+// indexSettings, so bump it with every index change.
+const indexSetVersion = 3
+
+// indexSettings are the configured options of the index set. They are recorded with the version,
+// so a start with other settings updates the affected indexes even though the version is current.
+type indexSettings struct {
+	CollationLocale     string `bson:"collationLocale"`     // BRAND_COLLATION_LOCALE, of name_collated_id
+	TombstoneTTLSeconds int32  `bson:"tombstoneTtlSeconds"` // SYNC_TOMBSTONE_RETENTION_HOURS, of the tombstone TTL index
+}
+
+// currentIndexSettings returns the index options the current configuration asks for.
+func currentIndexSettings() indexSettings {
+	return indexSettings{CollationLocale: CollationLocale(), TombstoneTTLSeconds: tombstoneTTLSeconds()}
+}
+
+// Index build coordination.
+const (
+	indexLeaseName       = "indexes"
+	indexLeaseTTL        = 30 * time.Second // Renewed every third of it while the build runs
+	indexWaitPoll        = 2 * time.Second
+	defaultStartupJitter = 2 * time.Second // STARTUP_JITTER_MS
+)
+
+// leaseOwner identifies this process in lease documents.
+var leaseOwner = newLeaseOwner()
+
+func newLeaseOwner() string {
+	host, _ := os.Hostname()
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(buf))
+}
+
+// Lease is a named lock held in MongoDB by one process across all replicas. It expires ttl after
+// its last renewal, so a crashed holder doesn't block the others for long; an expired lease can
+// be taken over. It is renewed in the background until Release.
+type Lease struct {
+	name string
+	ttl  time.Duration
+	stop chan struct{}
+	done chan struct{}
+}
+
+// AcquireLease takes the lease called name for ttl if it is free, expired or already ours. ok is
+// false when another process holds it.
+func AcquireLease(ctx context.Context, name string, ttl time.Duration) (lease *Lease, ok bool, err error) {
+	now := time.Now()
+	filter := bson.M{"_id": name, "$or": bson.A{
+		bson.M{"expiresAt": bson.M{"$lte": now}},
+		bson.M{"owner": leaseOwner},
+	}}
+	update := bson.M{"$set": bson.M{"owner": leaseOwner, "acquiredAt": now, "expiresAt": now.Add(ttl)}}
+	_, err = Collection(LeaseCollection).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// The document exists but matched neither condition: held by someone else
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	lease = &Lease{name: name, ttl: ttl, stop: make(chan struct{}), done: make(chan struct{})}
+	go lease.heartbeat()
+	return lease, true, nil
+}
+
+// heartbeat pushes the expiry back every third of the TTL until Release. It stops early if the
+// lease was taken over, which only happens when renewals failed for a whole TTL.
+func (l *Lease) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			result, err := Collection(LeaseCollection).UpdateOne(ctx,
+				bson.M{"_id": l.name, "owner": leaseOwner},
+				bson.M{"$set": bson.M{"expiresAt": time.Now().Add(l.ttl)}})
+			cancel()
+			switch {
+			case err != nil:
+				log.Printf("Warning: Could not renew lease '%s': %v", l.name, err)
+			case result.MatchedCount == 0:
+				log.Printf("Warning: Lease '%s' expired and was taken over by another instance", l.name)
+				return
+			}
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Release stops renewing the lease and frees it for the other processes right away.
+func (l *Lease) Release() {
+	close(l.stop)
+	<-l.done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := Collection(LeaseCollection).DeleteOne(ctx, bson.M{"_id": l.name, "owner": leaseOwner}); err != nil {
+		log.Printf("Warning: Could not release lease '%s', it expires in %v: %v", l.name, l.ttl, err)
+	}
+}
+
+// StartIndexBuild builds the indexes in the background, by one replica at a time (see
+// ensureIndexes). Only the server calls it: a short-lived tool would hold the index lease past its
+// exit, delaying the servers' build by up to its TTL.
+func StartIndexBuild() {
+	go ensureIndexes()
+}
+
+// ensureIndexes builds the indexes unless the current version is already recorded, with only
+// one replica building at a time: when several start together, the one holding the index lease
+// builds while the others wait and then find the version recorded. If the builder fails it
+// records nothing and releases the lease, and the next waiter tries itself.
+func ensureIndexes() {
+	time.Sleep(startupJitter()) // Replicas deployed together don't all race for the lease at once
+	for {
+		built, err := indexSetBuilt()
+		if err != nil {
+			log.Printf("Warning: Could not read the index version, building indexes anyway: %v", err)
+			createIndexes()
+			return
+		}
+		if built {
+			log.Printf("Indexes already at version %d with the configured settings, nothing to build.", indexSetVersion)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		lease, ok, err := AcquireLease(ctx, indexLeaseName, indexLeaseTTL)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Could not take the index lease, building indexes anyway: %v", err)
+			createIndexes()
+			return
+		}
+		if !ok {
+			time.Sleep(indexWaitPoll) // Another replica is building; check again when it may be done
+			continue
+		}
+		buildIndexSet(lease)
+		return
+	}
+}
+
+// buildIndexSet creates the indexes under lease and records the version if all of them were.
+func buildIndexSet(lease *Lease) {
+	defer lease.Release()
+	if !createIndexes() {
+		log.Printf("Warning: Not all indexes could be created; index version %d is not recorded and the next start retries", indexSetVersion)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := Collection(SchemaStateCollection).UpdateOne(ctx,
+		bson.M{"_id": indexLeaseName},
+		bson.M{"$set": bson.M{"version": indexSetVersion, "settings": currentIndexSettings(), "builtAt": time.Now(), "builtBy": leaseOwner}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		log.Printf("Warning: Could not record index version %d: %v", indexSetVersion, err)
+	}
+}
+
+// indexSetBuilt reports whether the recorded index version is at least indexSetVersion and was
+// built with the current settings.
+func indexSetBuilt() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var state struct {
+		Version  int           `bson:"version"`
+		Settings indexSettings `bson:"settings"`
+	}
+	err := Collection(SchemaStateCollection).FindOne(ctx, bson.M{"_id": indexLeaseName}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return state.Version >= indexSetVersion && state.Settings == currentIndexSettings(), nil
+}
+
+// startupJitter returns a random delay up to STARTUP_JITTER_MS (default 2000; 0 disables it).
+func startupJitter() time.Duration {
+	limit := defaultStartupJitter
+	if raw := os.Getenv("STARTUP_JITTER_MS"); raw != "" {
+		if ms, err := strconv.Atoi(raw); err == nil && ms >= 0 {
+			limit = time.Duration(ms) * time.Millisecond
+		} else {
+			log.Printf("Warning: Invalid STARTUP_JITTER_MS '%s', using default %v", raw, defaultStartupJitter)
+		}
+	}
+	if limit <= 0 {
+		return 0
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(limit)))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(n.Int64())
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestStartupJitter(t *testing.T) {
+	tests := []struct {
+		env string
+		max time.Duration // Exclusive; 0 means no delay
+	}{
+		{"", defaultStartupJitter},
+		{"0", 0},
+		{"50", 50 * time.Millisecond},
+		{"-1", defaultStartupJitter},
+		{"soon", defaultStartupJitter},
+	}
+	for _, tt := range tests {
+		t.Setenv("STARTUP_JITTER_MS", tt.env)
+		for i := 0; i < 20; i++ {
+			got := startupJitter()
+			if got < 0 || (tt.max == 0 && got != 0) || (tt.max > 0 && got >= tt.max) {
+				t.Errorf("STARTUP_JITTER_MS=%q: jitter %v, want in [0, %v)", tt.env, got, tt.max)
+				break
+			}
+		}
+	}
+}
+
+// Replicas on the same host (or restarts with a reused PID) still get distinct owners.
+func TestNewLeaseOwner(t *testing.T) {
+	host, _ := os.Hostname()
+	prefix := host + "-" + strconv.Itoa(os.Getpid()) + "-"
+	a, b := newLeaseOwner(), newLeaseOwner()
+	if !strings.HasPrefix(a, prefix) || !strings.HasPrefix(b, prefix) {
+		t.Errorf("owners %q and %q, want the prefix %q", a, b, prefix)
+	}
+	if a == b {
+		t.Errorf("two owners are both %q", a)
+	}
+}
+
+// The recorded index set only counts as built with the version and the settings it was built
+// with, so changing an index setting rebuilds on the next start.
+func TestIndexSetBuilt(t *testing.T) {
+	t.Setenv("BRAND_COLLATION_LOCALE", "de")
+	t.Setenv("SYNC_TOMBSTONE_RETENTION_HOURS", "48")
+	settings := func(locale string, ttl int32) bson.D {
+		return bson.D{{Key: "collationLocale", Value: locale}, {Key: "tombstoneTtlSeconds", Value: ttl}}
+	}
+	tests := []struct {
+		name  string
+		state bson.D // Recorded document; nil when none is
+		want  bool
+	}{
+		{"current", bson.D{{Key: "version", Value: indexSetVersion}, {Key: "settings", Value: settings("de", 48*3600)}}, true},
+		{"nothing recorded", nil, false},
+		{"older version", bson.D{{Key: "version", Value: indexSetVersion - 1}, {Key: "settings", Value: settings("de", 48*3600)}}, false},
+		{"recorded without settings", bson.D{{Key: "version", Value: indexSetVersion}}, false},
+		{"other locale", bson.D{{Key: "version", Value: indexSetVersion}, {Key: "settings", Value: settings("en", 48*3600)}}, false},
+		{"other retention", bson.D{{Key: "version", Value: indexSetVersion}, {Key: "settings", Value: settings("de", 720*3600)}}, false},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			Use(mt.Client)
+			var docs []bson.D
+			if tt.state != nil {
+				docs = append(docs, append(bson.D{{Key: "_id", Value: indexLeaseName}}, tt.state...))
+			}
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.schema_state", mtest.FirstBatch, docs...))
+			got, err := indexSetBuilt()
+			if err != nil || got != tt.want {
+				t.Errorf("indexSetBuilt() = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// An index that exists with other options is updated through change; other errors are returned.
+func TestEnsureIndexConflict(t *testing.T) {
+	model := mongo.IndexModel{Keys: bson.D{{Key: "deletedAt", Value: 1}}}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tests := []struct {
+		name        string
+		reply       bson.D
+		wantChanged bool
+		wantErr     bool
+	}{
+		{"created", mtest.CreateSuccessResponse(), false, false},
+		{"options conflict", mtest.CreateCommandErrorResponse(mtest.CommandError{Code: errIndexOptionsConflict, Name: "IndexOptionsConflict", Message: "expireAfterSeconds differs"}), true, false},
+		{"key specs conflict", mtest.CreateCommandErrorResponse(mtest.CommandError{Code: errIndexKeySpecsConflict, Name: "IndexKeySpecsConflict", Message: "collation differs"}), true, false},
+		{"other error", mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Name: "Unauthorized", Message: "not allowed"}), false, true},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.reply)
+			changed := false
+			err := ensureIndex(mt.Coll, model, func(context.Context) error {
+				changed = true
+				return nil
+			})
+			if changed != tt.wantChanged || (err != nil) != tt.wantErr {
+				t.Errorf("changed %v, err %v; want changed %v, error %v", changed, err, tt.wantChanged, tt.wantErr)
+			}
+		})
+	}
+}
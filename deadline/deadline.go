@@ -0,0 +1,101 @@
+// Package deadline gives a request one end-to-end time budget instead of a separate timeout per
+// step. The router puts the budget on the request context (one deadline per route class); each
+// stage of the work checks what is left before it starts and fails fast when it can't finish,
+// rather than starting work whose result could never be returned in time.
+//
+// Exhausted budgets are counted per stage (see Stats), so it shows where requests run out of time.
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExhaustedError reports that too little of the request's budget was left to run a stage.
+type ExhaustedError struct {
+	Stage     string
+	Remaining time.Duration
+}
+
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("deadline budget exhausted before %s (%v left)", e.Stage, e.Remaining.Round(time.Millisecond))
+}
+
+// Is makes errors.Is(err, context.DeadlineExceeded) true for an *ExhaustedError.
+func (e *ExhaustedError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// StageStats counts how often a stage found the budget exhausted.
+type StageStats struct {
+	Stage     string    `json:"stage"`
+	Exhausted int64     `json:"exhausted"`
+	LastAt    time.Time `json:"lastAt"`
+}
+
+var stats = struct {
+	mu     sync.Mutex
+	stages map[string]*StageStats
+}{stages: make(map[string]*StageStats)}
+
+// Remaining returns how much of ctx's budget is left, and false when ctx has no deadline.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	end, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(end), true
+}
+
+// Check returns an *ExhaustedError (and records it) when ctx is done or has less than need left
+// before its deadline. A context without a deadline always passes.
+func Check(ctx context.Context, stage string, need time.Duration) error {
+	remaining, ok := Remaining(ctx)
+	if ctx.Err() == nil && (!ok || remaining >= need) {
+		return nil
+	}
+	return Exhausted(stage, remaining)
+}
+
+// Exhausted records that stage ran out of budget with remaining left and returns the error for it,
+// for stages that notice only while running (e.g. a command killed by the deadline).
+func Exhausted(stage string, remaining time.Duration) error {
+	if remaining < 0 {
+		remaining = 0
+	}
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	s, ok := stats.stages[stage]
+	if !ok {
+		s = &StageStats{Stage: stage}
+		stats.stages[stage] = s
+	}
+	s.Exhausted++
+	s.LastAt = time.Now().UTC()
+	return &ExhaustedError{Stage: stage, Remaining: remaining}
+}
+
+// Reserve returns a context for one stage that ends reserve before ctx's deadline, so the stage
+// can't use up the time the stages after it need. Without a deadline on ctx it is just a child.
+func Reserve(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	end, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, end.Add(-reserve))
+}
+
+// Stats returns the exhaustion counts per stage, sorted by stage.
+func Stats() []StageStats {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	result := make([]StageStats, 0, len(stats.stages))
+	for _, s := range stats.stages {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Stage < result[j].Stage })
+	return result
+}
@@ -0,0 +1,106 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// count returns how often stage has been recorded as exhausted.
+func count(stage string) int64 {
+	for _, s := range Stats() {
+		if s.Stage == stage {
+			return s.Exhausted
+		}
+	}
+	return 0
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration // 0: no deadline
+		cancelled bool
+		need      time.Duration
+		wantErr   bool
+	}{
+		{"no deadline", 0, false, time.Hour, false},
+		{"enough left", time.Minute, false, time.Second, false},
+		{"too little left", time.Second, false, time.Minute, true},
+		{"deadline passed", -time.Second, false, 0, true},
+		{"cancelled without a deadline", 0, true, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.timeout != 0 {
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			if tt.cancelled {
+				cancel()
+			}
+			stage := "check " + tt.name
+			err := Check(ctx, stage, tt.need)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() = %v, want error %v", err, tt.wantErr)
+			}
+			wantCount := int64(0)
+			if tt.wantErr {
+				wantCount = 1
+				var exhausted *ExhaustedError
+				if !errors.As(err, &exhausted) || exhausted.Stage != stage {
+					t.Errorf("Check() = %#v, want an *ExhaustedError for %q", err, stage)
+				}
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("errors.Is(%v, context.DeadlineExceeded) = false", err)
+				}
+			}
+			if got := count(stage); got != wantCount {
+				t.Errorf("recorded %d exhaustions, want %d", got, wantCount)
+			}
+		})
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	err := Exhausted("exhausted negative", -time.Second)
+	var exhausted *ExhaustedError
+	if !errors.As(err, &exhausted) || exhausted.Remaining != 0 {
+		t.Fatalf("Exhausted() = %#v, want remaining clamped to 0", err)
+	}
+	Exhausted("exhausted negative", time.Second)
+	if got := count("exhausted negative"); got != 2 {
+		t.Errorf("recorded %d exhaustions, want 2", got)
+	}
+
+	stats := Stats()
+	for i := 1; i < len(stats); i++ {
+		if stats[i-1].Stage >= stats[i].Stage {
+			t.Fatalf("Stats() not sorted by stage: %q before %q", stats[i-1].Stage, stats[i].Stage)
+		}
+	}
+}
+
+func TestReserve(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	parentEnd, _ := parent.Deadline()
+
+	ctx, cancelStage := Reserve(parent, 10*time.Second)
+	defer cancelStage()
+	if end, ok := ctx.Deadline(); !ok || !end.Equal(parentEnd.Add(-10*time.Second)) {
+		t.Errorf("Reserve() deadline = %v, want %v", end, parentEnd.Add(-10*time.Second))
+	}
+
+	ctx, cancelStage = Reserve(context.Background(), 10*time.Second)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Reserve() without a parent deadline added one")
+	}
+	cancelStage()
+	if ctx.Err() == nil {
+		t.Error("Reserve() without a parent deadline returned a context its cancel doesn't end")
+	}
+}
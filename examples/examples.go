@@ -0,0 +1,76 @@
+// Package examples holds curated example requests and responses for the API endpoints, served
+// to frontend and partner developers by GET /examples. Each endpoint is an embedded JSON file
+// named after its handler (e.g. CreateBrandManual.json). The handler tests check them
+// against the binding structs so they can't drift from what the API accepts.
+package examples
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed endpoints/*.json
+var files embed.FS
+
+// Response is one documented outcome of an endpoint. Request, when set, is the body that
+// produces it instead of the endpoint's example request (e.g. one missing a required field).
+// Offsets in an error body refer to the request sent without whitespace.
+type Response struct {
+	Status      int             `json:"status"`
+	Description string          `json:"description"`
+	Code        string          `json:"code,omitempty"` // Error code, as in the body's "code"
+	Request     json.RawMessage `json:"request,omitempty" swaggertype:"object"`
+	Body        json.RawMessage `json:"body" swaggertype:"object"`
+}
+
+// Example documents one endpoint: a valid request and the responses it can get.
+type Example struct {
+	Endpoint  string          `json:"endpoint"` // Handler name, e.g. CreateBrandManual
+	Method    string          `json:"method"`
+	Path      string          `json:"path"` // Relative to /api/v1
+	Request   json.RawMessage `json:"request,omitempty" swaggertype:"object"`
+	Responses []Response      `json:"responses"`
+}
+
+// catalog maps endpoint name -> example.
+var catalog = loadCatalog()
+
+func loadCatalog() map[string]Example {
+	entries, err := files.ReadDir("endpoints")
+	if err != nil {
+		panic(err) // The embedded directory is fixed at build time
+	}
+	result := make(map[string]Example, len(entries))
+	for _, entry := range entries {
+		raw, err := files.ReadFile(path.Join("endpoints", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var example Example
+		if err := json.Unmarshal(raw, &example); err != nil {
+			panic("examples: invalid file " + entry.Name() + ": " + err.Error())
+		}
+		example.Endpoint = strings.TrimSuffix(entry.Name(), ".json")
+		result[example.Endpoint] = example
+	}
+	return result
+}
+
+// Get returns the example of an endpoint.
+func Get(endpoint string) (Example, bool) {
+	example, ok := catalog[endpoint]
+	return example, ok
+}
+
+// All returns every example, sorted by endpoint name.
+func All() []Example {
+	all := make([]Example, 0, len(catalog))
+	for _, example := range catalog {
+		all = append(all, example)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Endpoint < all[j].Endpoint })
+	return all
+}
@@ -0,0 +1,271 @@
+// Package featureflags decides which optional behaviors are enabled in this deployment.
+//
+// Each known flag has a built-in default. The FEATURES environment variable can change it
+// (comma-separated; "name" enables, "-name" disables) and an override document in MongoDB,
+// editable at runtime through the admin API, takes precedence over both. Override state is
+// cached for a short TTL so handlers can call Enabled on every request; an expired cache is
+// reloaded in the background while requests keep using it.
+package featureflags
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/singleflight"
+)
+
+// Known flags. Add new ones here together with their default.
+const (
+	ResponseEnvelope = "response_envelope" // X-Response-Envelope: v1 support
+	CSVImport        = "csv_import"        // POST /brands/import
+	DetailsFragments = "details_fragments" // Append/prepend endpoints
+	DuplicateScan    = "duplicate_scan"    // Scheduled near-duplicate detection
+	StrictJSON       = "strict_json"       // Reject unknown fields in JSON request bodies
+	EmbeddedMode     = "embedded_mode"     // Form-encoded bodies and the iframe relay for portals without CORS preflights
+)
+
+var defaults = map[string]bool{
+	ResponseEnvelope: true,
+	CSVImport:        true,
+	DetailsFragments: true,
+	DuplicateScan:    true,
+	StrictJSON:       false,
+	EmbeddedMode:     false,
+}
+
+// CollectionName is where the override document lives.
+const CollectionName = "settings"
+
+// overrideDocID identifies the override document within the settings collection.
+const overrideDocID = "feature_flags"
+
+// How long override state is cached before it is re-read from MongoDB
+const cacheTTL = 30 * time.Second
+
+// Sources reported for a flag's current value.
+const (
+	SourceDefault  = "default"
+	SourceEnv      = "env"
+	SourceOverride = "override"
+)
+
+// Flag describes the effective state of one flag.
+type Flag struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"` // default, env or override
+}
+
+// snapshot is the state flags are resolved from. It is replaced as a whole, never modified, so
+// readers don't need a lock.
+type snapshot struct {
+	coll      *mongo.Collection
+	env       map[string]bool
+	overrides map[string]bool
+	loadedAt  time.Time // Zero until the override document has been read
+}
+
+type state struct {
+	mu      sync.Mutex // Serializes replacing the snapshot
+	current atomic.Pointer[snapshot]
+	refresh singleflight.Group
+}
+
+var flags = newState()
+
+func newState() *state {
+	s := &state{}
+	s.current.Store(&snapshot{})
+	return s
+}
+
+// update replaces the snapshot with a changed copy of the current one.
+func (s *state) update(change func(next *snapshot)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := *s.current.Load()
+	change(&next)
+	s.current.Store(&next)
+}
+
+// Init reads FEATURES and remembers the collection holding the override document.
+// Before Init (or with a nil collection) only defaults and FEATURES apply.
+func Init(coll *mongo.Collection) {
+	env := parseEnv(os.Getenv("FEATURES"))
+	flags.update(func(next *snapshot) {
+		*next = snapshot{coll: coll, env: env}
+	})
+}
+
+// ReloadEnv re-reads FEATURES, e.g. after a configuration reload. Overrides are unaffected.
+func ReloadEnv() {
+	env := parseEnv(os.Getenv("FEATURES"))
+	flags.update(func(next *snapshot) {
+		next.env = env
+	})
+}
+
+// Known reports whether name is a flag this build knows about.
+func Known(name string) bool {
+	_, known := defaults[name]
+	return known
+}
+
+// parseEnv turns "a,-b" into {a: true, b: false}, ignoring (and logging) unknown names.
+func parseEnv(raw string) map[string]bool {
+	result := make(map[string]bool)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		enabled := !strings.HasPrefix(item, "-")
+		name := strings.TrimPrefix(item, "-")
+		if _, known := defaults[name]; !known {
+			log.Printf("Warning: Ignoring unknown feature flag '%s' in FEATURES", name)
+			continue
+		}
+		result[name] = enabled
+	}
+	return result
+}
+
+// load returns the state to resolve flags from. An expired snapshot is still returned while
+// one caller reloads the override document in the background; only the very first read waits
+// for it. Concurrent callers share a single reload.
+func (s *state) load() *snapshot {
+	current := s.current.Load()
+	if current.coll == nil || time.Since(current.loadedAt) < cacheTTL {
+		return current
+	}
+	if current.loadedAt.IsZero() {
+		s.refresh.Do("overrides", s.reload)
+		return s.current.Load()
+	}
+	s.refresh.DoChan("overrides", s.reload)
+	return current
+}
+
+// reload reads the override document into a new snapshot. Errors keep the previous overrides.
+func (s *state) reload() (interface{}, error) {
+	coll := s.current.Load().coll
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var doc struct {
+		Flags map[string]bool `bson:"flags"`
+	}
+	err := coll.FindOne(ctx, bson.M{"_id": overrideDocID}).Decode(&doc)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Warning: Could not load feature flag overrides, keeping cached state: %v", err)
+	}
+	s.update(func(next *snapshot) {
+		if next.coll != coll || started.Before(next.loadedAt) {
+			return // Init switched collections, or a later read (after SetOverrides) got there first
+		}
+		switch {
+		case err == mongo.ErrNoDocuments:
+			next.overrides = nil
+		case err == nil:
+			next.overrides = doc.Flags
+		}
+		next.loadedAt = started // Also on error, so an outage doesn't turn every request into a DB call
+	})
+	return nil, nil
+}
+
+// resolve returns the effective value and its source.
+func (s *snapshot) resolve(name string) (bool, string) {
+	if v, ok := s.overrides[name]; ok {
+		return v, SourceOverride
+	}
+	if v, ok := s.env[name]; ok {
+		return v, SourceEnv
+	}
+	return defaults[name], SourceDefault
+}
+
+// Enabled reports whether the named flag is on. Unknown flags are always off.
+func Enabled(name string) bool {
+	if _, known := defaults[name]; !known {
+		return false
+	}
+	enabled, _ := flags.load().resolve(name)
+	return enabled
+}
+
+// List returns every known flag with its effective state, sorted by name.
+func List() []Flag {
+	current := flags.load()
+	result := make([]Flag, 0, len(defaults))
+	for name := range defaults {
+		enabled, source := current.resolve(name)
+		result = append(result, Flag{Name: name, Enabled: enabled, Source: source})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// UnknownFlagError is returned by SetOverrides when a name isn't a known flag.
+type UnknownFlagError struct {
+	Names []string
+}
+
+func (e *UnknownFlagError) Error() string {
+	return fmt.Sprintf("unknown feature flag(s): %s", strings.Join(e.Names, ", "))
+}
+
+// SetOverrides stores the given override values (merged into the existing document) and
+// refreshes the cache immediately. A nil value removes that flag's override.
+func SetOverrides(ctx context.Context, changes map[string]*bool) error {
+	var unknown []string
+	for name := range changes {
+		if _, known := defaults[name]; !known {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return &UnknownFlagError{Names: unknown}
+	}
+
+	coll := flags.current.Load().coll
+	if coll == nil {
+		return fmt.Errorf("feature flag overrides are not available (no database)")
+	}
+
+	set := bson.M{"updatedAt": time.Now().UTC()}
+	unset := bson.M{}
+	for name, value := range changes {
+		if value == nil {
+			unset["flags."+name] = ""
+		} else {
+			set["flags."+name] = *value
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	opts := options.Update().SetUpsert(true)
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": overrideDocID}, update, opts); err != nil {
+		return err
+	}
+
+	// Make the change visible on this instance right away; others pick it up within the TTL. A
+	// reload already in flight may have read the document before the write, so don't join it.
+	flags.refresh.Forget("overrides")
+	flags.refresh.Do("overrides", flags.reload)
+	return nil
+}
@@ -0,0 +1,85 @@
+package featureflags
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want map[string]bool
+	}{
+		{"", map[string]bool{}},
+		{"csv_import", map[string]bool{CSVImport: true}},
+		{"-csv_import, duplicate_scan ,", map[string]bool{CSVImport: false, DuplicateScan: true}},
+		{"no_such_flag,-details_fragments", map[string]bool{DetailsFragments: false}},
+	}
+	for _, tt := range tests {
+		if got := parseEnv(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseEnv(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	s := &snapshot{
+		env:       map[string]bool{DetailsFragments: false, CSVImport: false},
+		overrides: map[string]bool{CSVImport: true},
+	}
+	tests := []struct {
+		name        string
+		wantEnabled bool
+		wantSource  string
+	}{
+		{DetailsFragments, false, SourceEnv},
+		{CSVImport, true, SourceOverride},
+		{DuplicateScan, true, SourceDefault},
+	}
+	for _, tt := range tests {
+		if enabled, source := s.resolve(tt.name); enabled != tt.wantEnabled || source != tt.wantSource {
+			t.Errorf("resolve(%s) = %v, %s; want %v, %s", tt.name, enabled, source, tt.wantEnabled, tt.wantSource)
+		}
+	}
+}
+
+// An expired cache is reloaded in the background: callers keep getting the stale overrides
+// instead of waiting for a database that doesn't answer.
+func TestEnabledServesStaleWhileReloading(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+
+	original := flags
+	defer func() { flags = original }()
+	flags = newState()
+	flags.current.Store(&snapshot{
+		coll:      client.Database("featureflags_test").Collection(CollectionName),
+		overrides: map[string]bool{DuplicateScan: false},
+		loadedAt:  time.Now().Add(-2 * cacheTTL),
+	})
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if Enabled(DuplicateScan) {
+				t.Error("stale override not served during the reload")
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Enabled took %v, want it not to wait for the reload", elapsed)
+	}
+}
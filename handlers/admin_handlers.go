@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Admin scans may walk the whole collection, so they get a longer timeout than regular lookups
+const adminScanTimeout = 60 * time.Second
+
+// ListBrandDecodeErrors godoc
+// @Summary List brand documents that cannot be decoded
+// @Description Scan the brand collection and report the IDs of stored documents that don't match the Brand schema so they can be repaired
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Count and list of offending document IDs with the decode error"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands/decode-errors [get]
+func ListBrandDecodeErrors(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), adminScanTimeout)
+	defer cancel()
+
+	failures, err := services.FindUndecodableBrands(ctx, coll)
+	if err != nil {
+		log.Printf("Error scanning brands for decode errors: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan brands"})
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"count": len(failures), "documents": failures}, nil)
+}
+
+// ListAdminBrands godoc
+// @Summary List brand names matching a filter expression
+// @Description Admin-only listing that accepts a filter expression, e.g. len(details) > 500 AND keyword in ["cotton", "linen"] AND updatedAt < "2024-01-01". Supports =, !=, <, <=, >, >=, contains and in on a fixed set of fields, combined with AND, OR, NOT and parentheses (see services.CompileBrandFilter). Expressions are capped in length, nesting and number of comparisons.
+// @Tags admin
+// @Produce json
+// @Param filter query string false "Filter expression; all brands when omitted"
+// @Success 200 {array} string "Matching brand names, sorted alphabetically; meta.parents maps sub-brands to their parent's name"
+// @Failure 400 {object} map[string]interface{} "Malformed or disallowed expression, with the 1-based character position"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands [get]
+func ListAdminBrands(c *gin.Context) {
+	q := queryParams(c)
+	expr := q.String("filter", "")
+	if !validQuery(c, q) {
+		return
+	}
+	filter := bson.M{}
+	if expr != "" {
+		compiled, err := services.CompileBrandFilter(expr)
+		if err != nil {
+			var filterErr *services.FilterError
+			if errors.As(err, &filterErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter: " + filterErr.Message, "position": filterErr.Pos})
+			} else {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter: " + err.Error()})
+			}
+			return
+		}
+		filter = compiled
+	}
+
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), adminScanTimeout)
+	defer cancel()
+
+	brandNames, decodeErrors, err := services.ListBrandNames(ctx, coll, filter, "")
+	if err != nil {
+		log.Printf("Error listing brands for filter '%s': %v", services.LogValue(expr), err)
+		localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		return
+	}
+	// The body stays a bare array of names; parents travel in the envelope meta
+	parents, err := services.BrandParentNames(ctx, coll, filter)
+	if err != nil {
+		log.Printf("Error loading parent brands for filter '%s': %v", services.LogValue(expr), err)
+		localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		return
+	}
+	respondList(c, http.StatusOK, brandNames, len(brandNames), gin.H{"decodeErrors": decodeErrors, "parents": parents})
+}
+
+// knownBrandFields are the top-level document fields models.Brand maps; anything else is reported by the debug endpoint
+var knownBrandFields = bsonFieldNames(reflect.TypeOf(models.Brand{}))
+
+// bsonFieldNames returns the document field names of a struct's bson tags, so the set follows
+// the model as fields are added.
+func bsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name) // The driver's default key
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// DebugBrand godoc
+// @Summary Inspect the raw stored state of a brand
+// @Description Returns the raw MongoDB document as canonical extended JSON (including fields unknown to the Brand model) and, optionally, the query plan for a name lookup
+// @Tags admin
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param explain query bool false "Include the query planner output for the name lookup (adds load)"
+// @Success 200 {object} map[string]interface{} "Raw document and diagnostics"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands/{brandName}/debug [get]
+func DebugBrand(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brandName := c.Param("brandName")
+	filter := bson.D{{Key: "name", Value: brandName}}
+
+	// Decode into bson.Raw rather than models.Brand so nothing is lost or rejected
+	var raw bson.Raw
+	if err := coll.FindOne(ctx, filter).Decode(&raw); err != nil {
+		if !domainError(c, brandName, apperrors.FromDB(err)) {
+			log.Printf("Error loading raw brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return
+	}
+
+	extJSON, err := bson.MarshalExtJSON(raw, true, false) // Canonical form keeps exact BSON types
+	if err != nil {
+		log.Printf("Error converting brand '%s' to extended JSON: %v", services.LogValue(brandName), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render document"})
+		return
+	}
+
+	unknownFields := []string{}
+	if elements, err := raw.Elements(); err == nil {
+		for _, elem := range elements {
+			if !knownBrandFields[elem.Key()] {
+				unknownFields = append(unknownFields, elem.Key())
+			}
+		}
+	}
+
+	// Decode separately so a schema mismatch is reported instead of failing the whole response
+	decodeError := ""
+	var brand models.Brand
+	if err := bson.Unmarshal(raw, &brand); err != nil {
+		decodeError = err.Error()
+	}
+
+	response := gin.H{
+		"document":      json.RawMessage(extJSON),
+		"sizeBytes":     len(raw),
+		"unknownFields": unknownFields,
+		"decodeError":   decodeError,
+	}
+
+	if c.Query("explain") == "true" {
+		explainCmd := bson.D{
+			{Key: "explain", Value: bson.D{
+				{Key: "find", Value: coll.Name()},
+				{Key: "filter", Value: filter},
+			}},
+			{Key: "verbosity", Value: "queryPlanner"},
+		}
+		var plan bson.Raw
+		if err := coll.Database().RunCommand(ctx, explainCmd).Decode(&plan); err != nil {
+			log.Printf("Error explaining lookup for brand '%s': %v", services.LogValue(brandName), err)
+			response["explainError"] = err.Error()
+		} else if winning, err := plan.LookupErr("queryPlanner", "winningPlan"); err == nil {
+			if planJSON, err := bson.MarshalExtJSON(bson.D{{Key: "winningPlan", Value: winning}}, false, false); err == nil {
+				response["explain"] = json.RawMessage(planJSON)
+			}
+		}
+	}
+
+	respond(c, http.StatusOK, response, nil)
+}
+
+// GetBrandDiagnostics godoc
+// @Summary Inspect the diagnostics of a brand
+// @Description Returns the diagnostics of the last PDF extraction (engine, duration, pages, truncation, warnings) and the supplier source with its refresh state, which are never part of the public brand responses
+// @Tags admin
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Success 200 {object} models.BrandDiagnostics "Diagnostics; extraction is null for manual details"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands/{brandName}/diagnostics [get]
+func GetBrandDiagnostics(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	brandName := c.Param("brandName")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, err := services.GetBrandByName(ctx, coll, brandName)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return
+	}
+	respond(c, http.StatusOK, models.BrandDiagnostics{Name: brand.Name, Extraction: brand.Extraction, Source: brand.Source}, nil)
+}
+
+// GetBrandDuplicates godoc
+// @Summary List candidate duplicate brands
+// @Description Returns the cached result of the last near-duplicate scan (similar normalized names or identical details), with when it was computed
+// @Tags admin
+// @Produce json
+// @Success 200 {object} services.DuplicateReport "Candidate pairs with scores and reasons"
+// @Router /admin/brands/duplicates [get]
+func GetBrandDuplicates(c *gin.Context) {
+	report := services.GetDuplicateReport()
+	respondList(c, http.StatusOK, report, len(report.Pairs), gin.H{"computedAt": report.ComputedAt})
+}
+
+// RecomputeBrandDuplicates godoc
+// @Summary Recompute candidate duplicate brands
+// @Description Starts a background near-duplicate scan; poll the GET endpoint for the result
+// @Tags admin
+// @Produce json
+// @Success 202 {object} map[string]interface{} "Scan started (or already running)"
+// @Router /admin/brands/duplicates [post]
+func RecomputeBrandDuplicates(c *gin.Context) {
+	if !featureflags.Enabled(featureflags.DuplicateScan) {
+		featureDisabled(c, featureflags.DuplicateScan)
+		return
+	}
+	coll := database.GetCollection("brands")
+	started := services.TriggerDuplicateScan(coll)
+	respond(c, http.StatusAccepted, gin.H{"started": started, "running": true}, nil)
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import "testing"
+
+// Every field of models.Brand is known to the debug endpoint, so only stray data is reported.
+func TestKnownBrandFields(t *testing.T) {
+	for _, field := range []string{"_id", "name", "details", "keywords", "specs", "logo", "contacts", "createdAt", "updatedAt"} {
+		if !knownBrandFields[field] {
+			t.Errorf("%s is not a known brand field", field)
+		}
+	}
+	for _, field := range []string{"", "Logo", "brandName", "originalPdfPath"} {
+		if knownBrandFields[field] {
+			t.Errorf("%q is reported as a known brand field", field)
+		}
+	}
+}
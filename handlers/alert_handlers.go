@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAlertingStatus godoc
+// @Summary Show alerting status
+// @Description State of the alert evaluator: each built-in condition (db_down, extraction_failures, queue_depth) with its last measured value, threshold and whether it is firing. A firing condition was announced on ALERT_WEBHOOK_URL once and will be again when it clears.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} services.AlertingStatus "Evaluator state"
+// @Router /admin/alerts [get]
+func GetAlertingStatus(c *gin.Context) {
+	respond(c, http.StatusOK, services.CurrentAlertingStatus(), nil)
+}
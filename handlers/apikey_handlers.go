@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Error codes of API key authentication and management
+const (
+	codeAPIKeyMissing      = "API_KEY_MISSING"
+	codeAPIKeyInvalid      = "API_KEY_INVALID"
+	codeAPIKeyScopeMissing = "API_KEY_SCOPE_MISSING"
+	codeAPIKeyVerifyFailed = "API_KEY_VERIFY_FAILED"
+	codeAPIKeyCreateFailed = "API_KEY_CREATE_FAILED"
+	codeAPIKeyListFailed   = "API_KEY_LIST_FAILED"
+	codeAPIKeyInvalidID    = "API_KEY_INVALID_ID"
+	codeAPIKeyNotFound     = "API_KEY_NOT_FOUND"
+	codeAPIKeyRotateFailed = "API_KEY_ROTATE_FAILED"
+	codeAPIKeyRevokeFailed = "API_KEY_REVOKE_FAILED"
+)
+
+// apiKeyContextKey holds the verified *models.APIKey of a request that passed RequireScope.
+const apiKeyContextKey = "apiKey"
+
+// apiKeyFormField carries the key of form-encoded requests in embedded mode, which can't send an
+// Authorization header without a CORS preflight.
+const apiKeyFormField = "apiKey"
+
+// requestActor names who made a request: the API key RequireScope verified (see
+// services.APIKeyActor), or the client IP on routes that take no key.
+func requestActor(c *gin.Context) string {
+	if key, ok := c.Get(apiKeyContextKey); ok {
+		return services.APIKeyActor(key.(*models.APIKey))
+	}
+	return c.ClientIP()
+}
+
+const apiKeyAuthenticateRealm = `Bearer realm="api"`
+
+// apiKeySecret returns the key sent as "Authorization: Bearer <API key>" or, with the
+// embedded_mode flag on, in the apiKey field of an application/x-www-form-urlencoded body.
+func apiKeySecret(c *gin.Context) string {
+	if secret, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(secret)
+	}
+	if c.ContentType() == binding.MIMEPOSTForm && featureflags.Enabled(featureflags.EmbeddedMode) {
+		return strings.TrimSpace(c.PostForm(apiKeyFormField))
+	}
+	return ""
+}
+
+// RequireScope rejects requests without an API key (see apiKeySecret) granting scope: 401 for a
+// missing or invalid key, 403 naming the scope the key lacks. The verified key is left in the
+// context for the handler.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := apiKeySecret(c)
+		if secret == "" {
+			c.Header("WWW-Authenticate", apiKeyAuthenticateRealm)
+			localizedError(c, http.StatusUnauthorized, codeAPIKeyMissing, nil, nil)
+			c.Abort()
+			return
+		}
+		key, bootstrap := services.BootstrapAPIKey(secret)
+		var err error
+		if !bootstrap {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), dbTimeout)
+			defer cancel()
+			key, err = services.VerifyAPIKey(ctx, database.Collection(database.APIKeyCollection), secret)
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKey):
+			c.Header("WWW-Authenticate", apiKeyAuthenticateRealm+`, error="invalid_token"`)
+			localizedError(c, http.StatusUnauthorized, codeAPIKeyInvalid, nil, nil)
+		case err != nil:
+			log.Printf("Error verifying API key: %v", err)
+			localizedError(c, http.StatusInternalServerError, codeAPIKeyVerifyFailed, nil, nil)
+		case !services.APIKeyAllows(key, scope):
+			localizedError(c, http.StatusForbidden, codeAPIKeyScopeMissing, map[string]string{"scope": scope}, gin.H{"scope": scope})
+		default:
+			c.Set(apiKeyContextKey, key)
+			c.Next()
+			return
+		}
+		c.Abort()
+	}
+}
+
+// apiKeyID parses the :keyId path parameter, writing the 400 when it isn't an ObjectID.
+func apiKeyID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("keyId"))
+	if err != nil {
+		localizedError(c, http.StatusBadRequest, codeAPIKeyInvalidID, nil, nil)
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Creates a key with the given scopes (brands:read, brands:write, admin; admin grants all). The secret is returned only in this response; only its hash is stored.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param key body models.APIKeyPayload true "Scopes and an optional label"
+// @Success 201 {object} map[string]interface{} "The secret and the key's metadata"
+// @Failure 400 {object} map[string]string "Invalid scopes"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var payload models.APIKeyPayload
+	if !bindJSON(c, &payload) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	secret, record, err := services.CreateAPIKey(ctx, database.Collection(database.APIKeyCollection), payload.Label, payload.Scopes, requestActor(c))
+	if err != nil {
+		log.Printf("Error creating API key: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeAPIKeyCreateFailed, nil, nil)
+		return
+	}
+	audit(c, "apikey.create", "API key %s (%s) created with scopes %v", record.ID.Hex(), record.Prefix, record.Scopes)
+	respond(c, http.StatusCreated, gin.H{"key": secret, "apiKey": record}, nil)
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description Metadata of every key, newest first, revoked and rotated ones included. Secrets are never shown again.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.APIKey "API keys"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	keys, err := services.ListAPIKeys(ctx, database.Collection(database.APIKeyCollection))
+	if err != nil {
+		log.Printf("Error listing API keys: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeAPIKeyListFailed, nil, nil)
+		return
+	}
+	respondList(c, http.StatusOK, keys, len(keys), nil)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @Description Issues a new secret with the same label and scopes. The old one stays valid for API_KEY_ROTATION_GRACE_HOURS (default 24) so clients can switch over.
+// @Tags admin
+// @Produce json
+// @Param keyId path string true "API key ID"
+// @Success 201 {object} map[string]interface{} "The new secret and key"
+// @Failure 400 {object} map[string]string "Invalid key ID"
+// @Failure 404 {object} map[string]string "No valid key with this ID"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/api-keys/{keyId}/rotate [post]
+func RotateAPIKey(c *gin.Context) {
+	id, ok := apiKeyID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	secret, record, err := services.RotateAPIKey(ctx, database.Collection(database.APIKeyCollection), id, requestActor(c))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			localizedError(c, http.StatusNotFound, codeAPIKeyNotFound, nil, nil)
+		} else {
+			log.Printf("Error rotating API key %s: %v", id.Hex(), err)
+			localizedError(c, http.StatusInternalServerError, codeAPIKeyRotateFailed, nil, nil)
+		}
+		return
+	}
+	audit(c, "apikey.rotate", "API key %s rotated to %s (%s)", id.Hex(), record.ID.Hex(), record.Prefix)
+	respond(c, http.StatusCreated, gin.H{"key": secret, "apiKey": record}, nil)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description The key is rejected from the next request on. Revoking twice is harmless.
+// @Tags admin
+// @Produce json
+// @Param keyId path string true "API key ID"
+// @Success 200 {object} models.APIKey "Revoked key"
+// @Failure 400 {object} map[string]string "Invalid key ID"
+// @Failure 404 {object} map[string]string "Key not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/api-keys/{keyId} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	id, ok := apiKeyID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	record, err := services.RevokeAPIKey(ctx, database.Collection(database.APIKeyCollection), id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			localizedError(c, http.StatusNotFound, codeAPIKeyNotFound, nil, nil)
+		} else {
+			log.Printf("Error revoking API key %s: %v", id.Hex(), err)
+			localizedError(c, http.StatusInternalServerError, codeAPIKeyRevokeFailed, nil, nil)
+		}
+		return
+	}
+	audit(c, "apikey.revoke", "API key %s (%s) revoked", id.Hex(), record.Prefix)
+	respond(c, http.StatusOK, record, nil)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Audit entries name the API key a request was authorized with and keep its IP alongside; routes
+// without a key fall back to the IP.
+func TestAuditRecordsAPIKey(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "bootstrap-secret")
+	t.Setenv("AUDIT_EXPORT_URL", "")
+	keyID := primitive.NewObjectID()
+	recordAction := func(c *gin.Context) {
+		audit(c, "test.action", "Something changed")
+		c.Status(http.StatusNoContent)
+	}
+	router := gin.New()
+	router.POST("/keyed", RequireScope(services.ScopeAdmin), recordAction)
+	router.POST("/open", recordAction)
+
+	tests := []struct {
+		name, path, secret string
+		storedKey          bson.D // Returned by the key lookup, for secrets other than ADMIN_API_KEY
+		wantActor          string
+	}{
+		{"bootstrap key", "/keyed", "bootstrap-secret", nil, "apikey:bootstrap (ADMIN_API_KEY)"},
+		{"stored key", "/keyed", "bk1_stored-secret", bson.D{
+			{Key: "_id", Value: keyID},
+			{Key: "label", Value: "ops"},
+			{Key: "scopes", Value: bson.A{services.ScopeAdmin}},
+		}, "apikey:" + keyID.Hex() + " (ops)"},
+		{"no key", "/open", "", nil, "192.0.2.1"},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			database.Use(mt.Client)
+			if tt.storedKey != nil {
+				mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.api_keys", mtest.FirstBatch, tt.storedKey))
+			}
+			mt.AddMockResponses(mtest.CreateSuccessResponse())
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.secret != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.secret)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusNoContent {
+				mt.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+
+			var inserted bson.Raw
+			for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+				if event.CommandName == "insert" {
+					inserted = event.Command.Lookup("documents").Array().Index(0).Value().Document()
+				}
+			}
+			if inserted == nil {
+				mt.Fatal("no audit entry was stored")
+			}
+			if actor := inserted.Lookup("actor").StringValue(); actor != tt.wantActor {
+				mt.Errorf("actor %q, want %q", actor, tt.wantActor)
+			}
+			if ip := inserted.Lookup("clientIp").StringValue(); ip != "192.0.2.1" {
+				mt.Errorf("clientIp %q, want the request's", ip)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// audit records an administrative action by the client in the audit log (see services.RecordAudit),
+// by the request's API key and with its IP.
+func audit(c *gin.Context, action, format string, args ...interface{}) {
+	services.RecordAudit(database.Collection(database.AuditLogCollection), action, requestActor(c), c.ClientIP(), fmt.Sprintf(format, args...))
+}
+
+// GetAuditExportStatus godoc
+// @Summary Show audit export status
+// @Description Delivery metrics of the audit log export to AUDIT_EXPORT_URL: queued entries and the age of the oldest (lag), delivered entries, batches the collector rejected after every retry, entries dropped from a full queue (still replayable), the masked fields and the last replay.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} services.AuditExportStatus "Export state"
+// @Router /admin/audit/export [get]
+func GetAuditExportStatus(c *gin.Context) {
+	respond(c, http.StatusOK, services.CurrentAuditExportStatus(), nil)
+}
+
+// ReplayAuditLog godoc
+// @Summary Replay audit entries to the SIEM
+// @Description Sends the stored audit entries recorded in [from, to) to AUDIT_EXPORT_URL again, in the background and bypassing the live queue, e.g. after a collector outage. Entries the capped audit log has overwritten are gone. Progress is shown by GET /admin/audit/export.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param range body models.AuditReplayRequest true "Time range"
+// @Success 202 {object} services.AuditReplayStatus "Replay started"
+// @Failure 400 {object} map[string]string "Invalid range"
+// @Failure 409 {object} map[string]string "Export not configured, or a replay is already running"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/audit/replay [post]
+func ReplayAuditLog(c *gin.Context) {
+	var req models.AuditReplayRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	replay, err := services.StartAuditReplay(database.Collection(database.AuditLogCollection), req.From, req.To)
+	switch {
+	case errors.Is(err, services.ErrAuditExportNotConfigured):
+		localizedError(c, http.StatusConflict, codeAuditExportOff, nil, nil)
+		return
+	case errors.Is(err, services.ErrAuditReplayRunning):
+		localizedError(c, http.StatusConflict, codeAuditReplayRunning, nil, nil)
+		return
+	case err != nil:
+		log.Printf("Error starting audit replay: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start the audit replay"})
+		return
+	}
+	audit(c, "audit.replay", "Audit replay of %d entries from %s to %s started", replay.Matched, req.From.Format(time.RFC3339), req.To.Format(time.RFC3339))
+	respond(c, http.StatusAccepted, replay, nil)
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/queryparams"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// excerptRadius is how many bytes of the body are shown on each side of a JSON syntax error.
+const excerptRadius = 20
+
+// strictHeader lets a single request opt into strict binding without the global flag.
+const strictHeader = "X-Strict-Validation"
+
+// strictBinding reports whether unknown fields are rejected for this request: either globally
+// (strict_json feature flag) or per request (X-Strict-Validation: true).
+func strictBinding(c *gin.Context) bool {
+	if strings.EqualFold(strings.TrimSpace(c.GetHeader(strictHeader)), "true") {
+		return true
+	}
+	return featureflags.Enabled(featureflags.StrictJSON)
+}
+
+// unknownFields lists the top-level keys of a JSON object body that don't map to a field of obj
+// (a pointer to a struct). Matching is case-insensitive like encoding/json's, so only keys that
+// would really be dropped are reported. Nested objects are left to DisallowUnknownFields.
+func unknownFields(body []byte, obj interface{}) []string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var raw map[string]json.RawMessage
+	if t.Kind() != reflect.Struct || json.Unmarshal(body, &raw) != nil {
+		return nil
+	}
+
+	var known []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			known = append(known, name)
+		}
+	}
+
+	var unknown []string
+	for key := range raw {
+		matched := false
+		for _, name := range known {
+			if strings.EqualFold(key, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// jsonName is the key encoding/json maps onto field, or "" for a field it skips.
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
+
+var optionalStringType = reflect.TypeOf(models.OptionalString{})
+
+// locateTypeError fills in the field and offset of a type error from a models.OptionalString,
+// which encoding/json passes on without them: the first top-level key of such a field whose value
+// isn't a string or null is the one that failed. The offset is where that value ends, like the
+// decoder's own type errors. Other errors are returned unchanged.
+func locateTypeError(body []byte, obj interface{}, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field != "" {
+		return err
+	}
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if token, tokenErr := decoder.Token(); tokenErr != nil || token != json.Delim('{') {
+		return err
+	}
+	for decoder.More() {
+		token, tokenErr := decoder.Token()
+		key, isKey := token.(string)
+		var value json.RawMessage
+		if tokenErr != nil || !isKey || decoder.Decode(&value) != nil {
+			return err
+		}
+		if value[0] == '"' || string(value) == "null" {
+			continue
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if name := jsonName(field); field.Type == optionalStringType && strings.EqualFold(key, name) {
+				typeErr.Field, typeErr.Offset = name, decoder.InputOffset()
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// bindJSON decodes the request body into obj and runs the binding validation, like
+// c.ShouldBindJSON, but on failure writes a structured error response and returns false:
+//
+//   - syntax errors (including truncated bodies): 400 with offset, line, column and an excerpt
+//   - type mismatches: 422 with the offending field and the expected type
+//   - unknown fields (strict mode only, see strictBinding): 400 listing every unknown field
+//   - validation failures (binding tags): 400 with a localized message per field
+//
+// Messages follow the request's Accept-Language (see localizedError); "code" never changes.
+//
+// Strict mode only rejects fields the payload doesn't define; omitted optional fields are fine.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			// Over the route's body limit (MAX_JSON_BODY_BYTES, applied by the router)
+			localizedError(c, http.StatusRequestEntityTooLarge, codeBodyTooLarge, map[string]string{"max": fmt.Sprint(maxBytesErr.Limit)}, nil)
+			return false
+		}
+		localizedError(c, http.StatusBadRequest, codeBodyUnreadable, nil, nil)
+		return false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body)) // Keep it readable for later middleware/logging
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if strictBinding(c) {
+		// Report all unknown top-level fields at once (the decoder stops at the first) before
+		// a typo'd field turns into a confusing "required" validation error
+		if unknown := unknownFields(body, obj); len(unknown) > 0 {
+			localizedError(c, http.StatusBadRequest, codeUnknownFields, map[string]string{"fields": strings.Join(unknown, ", ")}, gin.H{"unknownFields": unknown})
+			return false
+		}
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(obj); err != nil {
+		writeJSONError(c, body, locateTypeError(body, obj, err))
+		return false
+	}
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		if fields, ok := validationMessages(c, err); ok {
+			localizedError(c, http.StatusBadRequest, codeInvalidInput, nil, gin.H{"fields": fields})
+		} else {
+			localizedError(c, http.StatusBadRequest, codeInvalidInput, nil, gin.H{"detail": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// writeJSONError maps a decoding error onto the structured response described on bindJSON.
+func writeJSONError(c *gin.Context, body []byte, err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		localizedError(c, http.StatusBadRequest, codeEmptyBody, nil, nil)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		writeSyntaxError(c, body, int64(len(body)), "unexpected end of JSON input")
+	case errors.As(err, &syntaxErr):
+		writeSyntaxError(c, body, syntaxErr.Offset, syntaxErr.Error())
+	case errors.As(err, &typeErr):
+		localizedError(c, http.StatusUnprocessableEntity, codeWrongFieldType, map[string]string{
+			"field":    typeErr.Field,
+			"expected": typeErr.Type.String(),
+		}, gin.H{
+			"field":    typeErr.Field,
+			"expected": typeErr.Type.String(),
+			"got":      typeErr.Value,
+			"offset":   typeErr.Offset,
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for this; the field name is quoted in the message
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		localizedError(c, http.StatusBadRequest, codeUnknownFields, map[string]string{"fields": field}, gin.H{"unknownFields": []string{field}})
+	default:
+		localizedError(c, http.StatusBadRequest, codeInvalidInput, nil, gin.H{"detail": err.Error()})
+	}
+}
+
+// writeSyntaxError writes a 400 for a JSON syntax error with a localized message; the raw
+// decoder message stays available as "detail".
+func writeSyntaxError(c *gin.Context, body []byte, offset int64, message string) {
+	detail := jsonSyntaxDetail(body, offset, message)
+	params := map[string]string{"line": fmt.Sprint(detail["line"]), "column": fmt.Sprint(detail["column"])}
+	detail["detail"] = detail["error"]
+	localizedError(c, http.StatusBadRequest, codeInvalidJSON, params, detail)
+}
+
+// jsonSyntaxDetail locates offset in body as a 1-based line/column and cuts an excerpt around it.
+// encoding/json reports the offset after the offending byte, so the column points at that byte.
+func jsonSyntaxDetail(body []byte, offset int64, message string) gin.H {
+	if offset > int64(len(body)) {
+		offset = int64(len(body))
+	}
+	before := body[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - (bytes.LastIndexByte(before, '\n') + 1)
+	if column < 1 {
+		column = 1
+	}
+
+	start, end := int(offset)-excerptRadius, int(offset)+excerptRadius
+	if start < 0 {
+		start = 0
+	}
+	if end > len(body) {
+		end = len(body)
+	}
+	return gin.H{
+		"error":   "Invalid JSON: " + message,
+		"offset":  offset,
+		"line":    line,
+		"column":  column,
+		"excerpt": strings.ToValidUTF8(string(body[start:end]), "?"),
+	}
+}
+
+// queryParams returns a typed reader for the request's query string (see queryparams).
+func queryParams(c *gin.Context) *queryparams.Query {
+	return queryparams.New(c.Request.URL.RawQuery)
+}
+
+// validQuery writes a 400 listing every invalid parameter read from q, in the same
+// {"code", "fields"} shape as body validation failures, and reports whether there were none.
+func validQuery(c *gin.Context, q *queryparams.Query) bool {
+	var queryErr *queryparams.Error
+	if errors.As(q.Err(), &queryErr) {
+		localizedError(c, http.StatusBadRequest, codeInvalidQuery, nil, gin.H{"fields": queryErr.Violations})
+		return false
+	}
+	return true
+}
+
+// bindPayload is bindJSON, except that with the embedded_mode flag on it also accepts
+// application/x-www-form-urlencoded bodies. Such requests are CORS "simple requests", which
+// portals that can't send preflights need. Form fields map through the payload's form tags and
+// are validated with the same binding tags as JSON.
+func bindPayload(c *gin.Context, obj interface{}) bool {
+	if c.ContentType() != binding.MIMEPOSTForm || !featureflags.Enabled(featureflags.EmbeddedMode) {
+		return bindJSON(c, obj)
+	}
+	if err := c.ShouldBindWith(obj, binding.Form); err != nil {
+		if fields, ok := validationMessages(c, err); ok {
+			localizedError(c, http.StatusBadRequest, codeInvalidInput, nil, gin.H{"fields": fields})
+		} else {
+			localizedError(c, http.StatusBadRequest, codeInvalidInput, nil, gin.H{"detail": err.Error()})
+		}
+		return false
+	}
+	return true
+}
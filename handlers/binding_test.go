@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// TestBindJSONErrors checks the status and position detail bindJSON answers malformed bodies with.
+func TestBindJSONErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		strict     string                 // "flag" (strict_json) or "header" (X-Strict-Validation)
+		wantStatus int                    // 0: the body binds
+		wantDetail map[string]interface{} // Expected response fields
+	}{
+		{"valid", `{"name":"Acme","details":"18V drill"}`, "", 0, nil},
+		{"unknown field ignored", `{"name":"Acme","details":"x","colour":"red"}`, "", 0, nil},
+		{"unknown fields with the flag", `{"name":"Acme","details":"x","colour":"red","Size":1}`, "flag", http.StatusBadRequest,
+			map[string]interface{}{"unknownFields": []interface{}{"Size", "colour"}}},
+		{"unknown field with the header", `{"name":"Acme","detials":"x"}`, "header", http.StatusBadRequest,
+			map[string]interface{}{"unknownFields": []interface{}{"detials"}}},
+		{"keys match case-insensitively", `{"Name":"Acme","DETAILS":"x"}`, "header", 0, nil},
+		{"empty body", ``, "", http.StatusBadRequest, nil},
+		{"truncated", `{"name":"Acme",`, "", http.StatusBadRequest,
+			map[string]interface{}{"offset": 15.0, "line": 1.0, "column": 15.0}},
+		{"syntax error on the second line", "{\"name\":\"Acme\",\n \"details\": x}", "", http.StatusBadRequest,
+			map[string]interface{}{"offset": 29.0, "line": 2.0, "column": 13.0}},
+		{"wrong type", `{"name":"Acme","details":42}`, "", http.StatusUnprocessableEntity,
+			map[string]interface{}{"field": "details", "expected": "string", "got": "number"}},
+		{"validation failure", `{"name":"Acme"}`, "", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { featureflags.Init(nil) })
+			if tt.strict == "flag" {
+				t.Setenv("FEATURES", featureflags.StrictJSON)
+			}
+			featureflags.Init(nil)
+
+			c, w := testContext(http.MethodPost, "/brands", tt.body)
+			if tt.strict == "header" {
+				c.Request.Header.Set(strictHeader, "true")
+			}
+			ok := bindJSON(c, &models.CreateBrandPayload{})
+			if ok != (tt.wantStatus == 0) {
+				t.Fatalf("bindJSON() = %v (%s), want status %d", ok, w.Body.String(), tt.wantStatus)
+			}
+			if ok {
+				return
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+			body := decodeResponse(t, w)
+			for key, want := range tt.wantDetail {
+				if !reflect.DeepEqual(body[key], want) {
+					t.Errorf("%s = %v, want %v", key, body[key], want)
+				}
+			}
+		})
+	}
+}
+
+// Form-encoded bodies bind only with embedded_mode on; otherwise they are parsed as JSON.
+func TestBindPayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		embedded    bool
+		contentType string
+		body        string
+		wantStatus  int // 0: the body binds
+		wantName    string
+	}{
+		{"JSON", false, "application/json", `{"name":"Acme","details":"x"}`, 0, "Acme"},
+		{"JSON in embedded mode", true, "application/json", `{"name":"Acme","details":"x"}`, 0, "Acme"},
+		{"form", true, "application/x-www-form-urlencoded", "name=Acme+Tools&details=18V+drill", 0, "Acme Tools"},
+		{"form with charset", true, "application/x-www-form-urlencoded; charset=UTF-8", "name=Acme&details=x", 0, "Acme"},
+		{"form missing a field", true, "application/x-www-form-urlencoded", "name=Acme", http.StatusBadRequest, ""},
+		{"form with a bad format", true, "application/x-www-form-urlencoded", "name=Acme&details=x&detailsFormat=pdf", http.StatusBadRequest, ""},
+		{"form without the flag", false, "application/x-www-form-urlencoded", "name=Acme&details=x", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { featureflags.Init(nil) })
+			if tt.embedded {
+				t.Setenv("FEATURES", featureflags.EmbeddedMode)
+			}
+			featureflags.Init(nil)
+
+			c, w := testContext(http.MethodPost, "/brands", tt.body)
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			var payload models.CreateBrandPayload
+			ok := bindPayload(c, &payload)
+			if ok != (tt.wantStatus == 0) {
+				t.Fatalf("bindPayload() = %v (%s), want status %d", ok, w.Body.String(), tt.wantStatus)
+			}
+			if !ok {
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.wantStatus)
+				}
+				return
+			}
+			if payload.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", payload.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+// TestOptionalFieldsMissingNullEmpty covers the three states of every models.OptionalString
+// field of the PUT and PATCH payloads: a missing key, null and "".
+func TestOptionalFieldsMissingNullEmpty(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		payload   func() interface{}
+		field     func(interface{}) models.OptionalString
+		wantSet   bool
+		wantValid bool
+	}{
+		{"PUT details missing", `{"detailsFormat":"plain"}`, newUpdatePayload, updateDetails, false, false},
+		{"PUT details null", `{"details":null}`, newUpdatePayload, updateDetails, true, false},
+		{"PUT details empty", `{"details":""}`, newUpdatePayload, updateDetails, true, true},
+		{"PATCH details missing", `{"detailsFormat":"plain"}`, newPatchPayload, patchDetails, false, false},
+		{"PATCH details null", `{"details":null}`, newPatchPayload, patchDetails, true, false},
+		{"PATCH details empty", `{"details":""}`, newPatchPayload, patchDetails, true, true},
+		{"PATCH parentBrand missing", `{"details":"x"}`, newPatchPayload, patchParent, false, false},
+		{"PATCH parentBrand null", `{"parentBrand":null}`, newPatchPayload, patchParent, true, false},
+		{"PATCH parentBrand empty", `{"parentBrand":""}`, newPatchPayload, patchParent, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(http.MethodPatch, "/brands/Acme", tt.body)
+			payload := tt.payload()
+			if !bindJSON(c, payload) {
+				t.Fatalf("bindJSON rejected %s: %s", tt.body, w.Body.String())
+			}
+			got := tt.field(payload)
+			if got.Set != tt.wantSet || got.Valid != tt.wantValid || got.Value != "" {
+				t.Errorf("got %+v, want Set=%v Valid=%v Value=\"\"", got, tt.wantSet, tt.wantValid)
+			}
+		})
+	}
+}
+
+func newUpdatePayload() interface{} { return &models.UpdateBrandPayload{} }
+func newPatchPayload() interface{}  { return &models.PatchBrandPayload{} }
+
+func updateDetails(p interface{}) models.OptionalString {
+	return p.(*models.UpdateBrandPayload).Details
+}
+
+func patchDetails(p interface{}) models.OptionalString {
+	return p.(*models.PatchBrandPayload).Details
+}
+
+func patchParent(p interface{}) models.OptionalString {
+	return p.(*models.PatchBrandPayload).ParentBrand
+}
+
+// The handlers reject these before touching the database.
+func TestOptionalFieldsRejectedBeforeWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		handler gin.HandlerFunc
+		want    string
+	}{
+		{"PUT without details", http.MethodPut, `{"detailsFormat":"plain"}`, UpdateBrandManual, `"field":"details","message"`},
+		{"PATCH without any field", http.MethodPatch, `{}`, PatchBrand, "Nothing to change"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(tt.method, "/brands/Acme?writeConcern=default", tt.body)
+			c.Params = gin.Params{{Key: "brandName", Value: "Acme"}}
+			tt.handler(c)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("got %d %s, want 400 containing %s", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionalFieldWrongType(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		payload    func() interface{}
+		wantField  string
+		wantGot    string
+		wantOffset float64 // Where the value ends
+	}{
+		{"array details", `{"details":["18V"]}`, newUpdatePayload, "details", "array", 18},
+		{"number parentBrand", `{"details":"x", "parentBrand":42}`, newPatchPayload, "parentBrand", "number", 32},
+		{"object details", `{"Details":{"a":1}}`, newPatchPayload, "details", "object", 18},
+		{"bool details after a valid field", `{"detailsFormat":"plain","details":true}`, newPatchPayload, "details", "bool", 39},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(http.MethodPatch, "/brands/Acme", tt.body)
+			if bindJSON(c, tt.payload()) {
+				t.Fatalf("bindJSON accepted %s", tt.body)
+			}
+			body := decodeResponse(t, w)
+			if w.Code != http.StatusUnprocessableEntity || body["code"] != codeWrongFieldType {
+				t.Fatalf("got %d %v, want 422 %s", w.Code, body, codeWrongFieldType)
+			}
+			if body["field"] != tt.wantField || body["got"] != tt.wantGot || body["offset"] != tt.wantOffset {
+				t.Errorf("got field=%v got=%v offset=%v, want %s %s %v", body["field"], body["got"], body["offset"], tt.wantField, tt.wantGot, tt.wantOffset)
+			}
+		})
+	}
+}
+
+// FuzzValidQuery checks that validQuery answers any query string whose parameters are invalid
+// with a 400 INVALID_QUERY listing them, and leaves the response alone otherwise.
+func FuzzValidQuery(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"limit=10&includeDetails=true",
+		"limit=18446744073709551616",
+		"limit=%zz",
+		"limit=1&limit=1",
+		"includeDetails=%C0%AF",
+		"cursor=" + strings.Repeat("A", 4096),
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		c, w := testContext(http.MethodGet, "/brands", "")
+		c.Request.URL.RawQuery = raw
+		q := queryParams(c)
+		q.Int("limit", 500, 1, 5000)
+		q.Bool("includeDetails", false)
+		q.String("cursor", "")
+
+		if validQuery(c, q) {
+			if q.Err() != nil || w.Body.Len() != 0 {
+				t.Fatalf("accepted %q with %v, wrote %s", raw, q.Err(), w.Body.String())
+			}
+			return
+		}
+		body := decodeResponse(t, w)
+		fields, _ := body["fields"].([]interface{})
+		if w.Code != http.StatusBadRequest || body["code"] != codeInvalidQuery || len(fields) == 0 {
+			t.Fatalf("%q answered %d %s", raw, w.Code, w.Body.String())
+		}
+	})
+}
@@ -1,17 +1,32 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
+	"math"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
-
-	"github.com/Gautam3767/Order_form_Details_Backend.git/database"
-	"github.com/Gautam3767/Order_form_Details_Backend.git/models"
-	"github.com/Gautam3767/Order_form_Details_Backend.git/services" // Use YOUR module path
-
-	// "github.com/Gautam3767/Order_form_Details_Backend.git/services"
+	"unicode/utf8"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/deadline"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/queryparams"
+	"github.com/Gautam3767/Order_form_Details_Backend/services" // Use YOUR module path
+	"github.com/Gautam3767/Order_form_Details_Backend/services/deletion"
+	"github.com/Gautam3767/Order_form_Details_Backend/uploadpolicy"
+
+	// "github.com/Gautam3767/Order_form_Details_Backend/services"
 	"github.com/gin-gonic/gin"
 
 	// "github.com/yourusername/brand-service/models"   // Adjust import path
@@ -26,12 +41,44 @@ import (
 // Context timeout for database operations
 const dbTimeout = 5 * time.Second
 
+// Stages of a PDF upload within the request's deadline budget, with the least each needs to be
+// worth starting. The upsert's share is held back from extraction, so a slow PDF can't leave
+// nothing for storing its text.
+const (
+	stageJournal = "journal"
+	stageUpsert  = "upsert"
+
+	journalBudget       = 500 * time.Millisecond
+	minExtractionBudget = 2 * time.Second
+	upsertReserve       = 2 * time.Second
+)
+
+// extractText extracts an uploaded PDF's text (a variable so tests can stand in a slow extractor).
+var extractText = services.ExtractTextFromPDF
+
 // ListBrands godoc
 // @Summary List all available brand names
-// @Description Get a list of all brand names stored in the system
+// @Description Get a list of all brand names stored in the system, sorted alphabetically (accent-aware, case-insensitive) unless sort/order say otherwise
 // @Tags brands
 // @Produce json
-// @Success 200 {array} string "List of brand names"
+// @Param q query string false "Only list brands whose name contains this text (case-insensitive)"
+// @Param extractionWarning query bool false "Only list brands whose last PDF extraction produced warnings"
+// @Param sort query string false "Sort field: name (default), createdAt or updatedAt"
+// @Param order query string false "Sort direction: asc (default) or desc"
+// @Param locale query string false "Collation locale for sorting by name (e.g. de, fr); defaults to BRAND_COLLATION_LOCALE"
+// @Param includeDetails query bool false "Return the full brand objects (details, timestamps, ...) instead of names"
+// @Param detailsMaxLen query int false "With includeDetails: cut each brand's details to at most this many bytes"
+// @Param partition query string false "List one partition of GET /brands/partitions in _id order, e.g. 3of8"
+// @Param minId query string false "List brands with _id >= minId in _id order (instead of partition)"
+// @Param maxId query string false "List brands with _id < maxId in _id order (instead of partition)"
+// @Param limit query int false "Page through the brands in _id order, this many per page (default 500, max 5000)"
+// @Param cursor query string false "Opaque nextCursor of the previous page (X-Next-Cursor header or meta.nextCursor) to continue a paged listing"
+// @Param after query string false "Deprecated alias of cursor; also accepts the hex ID of the last brand seen"
+// @Success 200 {array} string "List of brand names, or of models.Brand with includeDetails=true"
+// @Header 200 {integer} X-Decode-Errors "Number of stored documents skipped because they could not be decoded (a trailer with includeDetails=true)"
+// @Header 200 {string} X-Stream-Error "includeDetails=true only, trailer: error code when the streamed list was cut short"
+// @Header 200 {string} X-Next-Cursor "Paged listings only: pass as ?cursor= for the next page; absent on the last page"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters (including an invalid cursor), each listed under fields"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /brands [get]
 func ListBrands(c *gin.Context) {
@@ -39,43 +86,116 @@ func ListBrands(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	// Find documents, projecting only the 'name' field, excluding '_id'
-	opts := options.Find().SetProjection(bson.M{"name": 1, "_id": 0})
-	cursor, err := coll.Find(ctx, bson.M{}, opts) // Empty filter {} means find all
+	q := queryParams(c)
+	filter := bson.M{}
+	if term := strings.TrimSpace(q.String("q", "")); utf8.RuneCountInString(term) > services.MaxNameSearchLength {
+		q.Invalid("q", fmt.Sprintf("must be at most %d characters", services.MaxNameSearchLength))
+	} else if term != "" {
+		filter["name"] = services.NameContains(term)
+	}
+	if q.Bool("extractionWarning", false) {
+		// Only brands whose last PDF extraction reported at least one warning
+		filter["extraction.warnings.0"] = bson.M{"$exists": true}
+	}
 
-	if err != nil {
-		log.Printf("Error finding brands: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve brands"})
-		return
+	// Names are sorted alphabetically by default; ?locale= picks another (whitelisted) collation
+	// locale, ?sort= and ?order= another order
+	locale := q.Enum("locale", "", slices.Sorted(maps.Keys(services.SortLocales))...)
+	order := services.BrandSort{
+		Field:      q.Enum("sort", services.SortByName, services.BrandSortFields...),
+		Descending: q.Enum("order", "asc", "asc", "desc") == "desc",
 	}
-	defer cursor.Close(ctx) // Important to close the cursor
 
-	var results []struct { // Temporary struct to decode only the name
-		Name string `bson:"name"`
+	// Paged listings (the admin table, parallel bulk consumers reading disjoint _id ranges) seek by
+	// _id instead of returning the whole sorted list
+	if wantsBrandRange(q) {
+		for _, name := range []string{"locale", "sort", "order", "includeDetails", "detailsMaxLen"} {
+			if q.Has(name) {
+				q.Invalid(name, "cannot be combined with a paged listing, which is always in _id order")
+			}
+		}
+		listBrandRange(ctx, c, q, coll, filter)
+		return
+	}
+	if locale != "" && order.Field != services.SortByName {
+		q.Invalid("locale", "only applies to sort=name")
+	}
+	if q.Bool("includeDetails", false) {
+		listFullBrands(c, q, coll, filter, locale, order)
+		return
 	}
-	if err = cursor.All(ctx, &results); err != nil {
-		log.Printf("Error decoding brand names: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process brand data"})
+	if q.Has("detailsMaxLen") {
+		q.Invalid("detailsMaxLen", "only applies with includeDetails=true")
+	}
+	if !validQuery(c, q) {
 		return
 	}
 
-	// Extract just the names into a string slice
-	brandNames := make([]string, 0, len(results))
-	for _, res := range results {
-		brandNames = append(brandNames, res.Name)
+	// Shared with brandctl; always returns an empty array instead of null.
+	// Documents that fail to decode are skipped so one bad record can't hide the rest.
+	brandNames, decodeErrors, err := services.ListBrandNamesSorted(ctx, coll, filter, locale, order)
+	if err != nil {
+		log.Printf("Error listing brands: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		return
 	}
 
-	// Return empty array instead of null if no brands found
-	if brandNames == nil {
-		brandNames = []string{}
+	// The body stays a bare array for existing consumers, so skipped documents are reported in a header
+	c.Header("X-Decode-Errors", strconv.Itoa(decodeErrors))
+
+	respondList(c, http.StatusOK, brandNames, len(brandNames), gin.H{"decodeErrors": decodeErrors})
+}
+
+// listFullBrands answers GET /brands?includeDetails=true with the complete brand documents,
+// optionally with their details cut to ?detailsMaxLen= bytes, after reporting any invalid
+// parameter read into q. The plain listing keeps its names-only projection.
+//
+// The documents are streamed as they come off the cursor rather than collected first, since
+// every brand's details can add up to gigabytes. The read runs under the request's deadline
+// budget instead of dbTimeout and stops when the client goes away; X-Decode-Errors is only known
+// at the end, so it is sent as a trailer.
+func listFullBrands(c *gin.Context, q *queryparams.Query, coll *mongo.Collection, filter bson.M, locale string, order services.BrandSort) {
+	maxLen := q.Int("detailsMaxLen", 0, 0, math.MaxInt32)
+	if !validQuery(c, q) {
+		return
+	}
+	// Unlike the names the route's policy is for, this stream can be huge, and a read failing part
+	// way still ends as a 200 (reported only in a trailer), so no cache may keep it
+	c.Header("Cache-Control", "no-store")
+	c.Writer.Header().Del("Cache-Tag")
+
+	ctx := c.Request.Context()
+	stream := newJSONArrayStream(c, "X-Decode-Errors")
+	truncated := 0
+	decodeErrors, err := services.EachBrandSorted(ctx, coll, filter, locale, order, func(brand *models.Brand) error {
+		if brand.DetailsFormat == "" {
+			brand.DetailsFormat = models.DetailsFormatPlain // Stored before formats existed
+		}
+		if q.Has("detailsMaxLen") && len(brand.Details) > maxLen {
+			brand.Details = truncateUTF8(brand.Details, maxLen)
+			truncated++
+		}
+		return stream.add(brand)
+	})
+	if err != nil && !stream.started {
+		log.Printf("Error listing brands: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		return
+	}
+	failure := ""
+	if err != nil {
+		// The client went away, the budget ran out or the cursor failed part way
+		log.Printf("Error streaming brands, response cut after %d: %v", stream.count, err)
+		failure = codeBrandListFailed
 	}
 
-	c.JSON(http.StatusOK, brandNames)
+	c.Writer.Header().Set("X-Decode-Errors", strconv.Itoa(decodeErrors))
+	stream.finish(gin.H{"decodeErrors": decodeErrors, "detailsTruncated": truncated}, failure)
 }
 
 // GetBrandDetails godoc
 // @Summary Get details for a specific brand
-// @Description Get the stored details associated with a given brand name
+// @Description Get the stored details associated with a given brand name. Example payloads: GET /examples/GetBrandDetails
 // @Tags brands
 // @Produce json
 // @Param brandName path string true "Name of the brand"
@@ -89,44 +209,52 @@ func GetBrandDetails(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	var brand models.Brand
 	// Find one document where the 'name' field matches
-	filter := bson.M{"name": brandName}
-	err := coll.FindOne(ctx, filter).Decode(&brand)
-
+	brand, err := services.GetBrandByName(ctx, coll, brandName)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Brand '%s' not found", brandName)})
-		} else {
-			log.Printf("Error finding brand '%s': %v", brandName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error retrieving brand"})
+		if !domainError(c, brandName, err) {
+			log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, brand)
+	// Count the view (buffered, see services.RecordBrandView); HEAD probes aren't views
+	if c.Request.Method == http.MethodGet {
+		services.RecordBrandView(brand.Name)
+	}
+	respondBrand(c, http.StatusOK, brand, nil)
 }
 
 // CreateBrandManual godoc
 // @Summary Create a new brand with details (manual entry)
-// @Description Add a new brand and its details using a JSON payload
+// @Description Add a new brand and its details using a JSON payload (or a form-encoded one when the embedded_mode flag is on, which may carry the brands:write API key in an apiKey field instead of the Authorization header). Example payloads: GET /examples/CreateBrandManual
 // @Tags brands
 // @Accept json
+// @Accept x-www-form-urlencoded
 // @Produce json
 // @Param brand body models.CreateBrandPayload true "Brand data"
+// @Param writeConcern query string false "Write concern: majority, default or a node count (default WRITE_CONCERN)"
 // @Success 201 {object} models.Brand "Brand created successfully"
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 409 {object} map[string]string "Brand already exists (unique name violation)"
+// @Failure 422 {object} map[string]interface{} "Field has the wrong type, or the name is reserved (code BRAND_NAME_RESERVED)"
+// @Failure 413 {object} map[string]interface{} "Details exceed MAX_DETAILS_BYTES (code DETAILS_TOO_LARGE)"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /brands [post]
 func CreateBrandManual(c *gin.Context) {
-	coll := database.GetCollection("brands")
+	coll, writeConcern, ok := withWriteConcern(c, database.GetCollection("brands"))
+	if !ok {
+		return
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
 	var payload models.CreateBrandPayload
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+	if !bindPayload(c, &payload) {
+		return
+	}
+	if rejectReservedBrandName(c, payload.Name) || detailsTooLarge(c, payload.Details) {
 		return
 	}
 
@@ -136,93 +264,222 @@ func CreateBrandManual(c *gin.Context) {
 	filter := bson.M{"name": payload.Name}
 	count, err := coll.CountDocuments(ctx, filter, options.Count().SetLimit(1))
 	if err != nil {
-		log.Printf("Error checking for existing brand '%s': %v", payload.Name, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error checking for existing brand"})
+		log.Printf("Error checking for existing brand '%s': %v", services.LogValue(payload.Name), err)
+		localizedError(c, http.StatusInternalServerError, codeBrandCheckFailed, nil, nil)
 		return
 	}
 	if count > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Brand '%s' already exists", payload.Name)})
+		localizedError(c, http.StatusConflict, codeBrandExists, map[string]string{"name": payload.Name}, nil)
 		return
 	}
 
-	now := time.Now()
+	now := models.Now()
 	newBrand := models.Brand{
-		// ID will be generated by MongoDB
-		Name:      payload.Name,
-		Details:   payload.Details,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:            primitive.NewObjectID(), // Generated here so it's known even if only the write concern fails
+		Name:          payload.Name,
+		Details:       payload.Details,
+		DetailsFormat: services.DetailsFormatOrDetect(payload.DetailsFormat, payload.Details),
+		Keywords:      services.ExtractKeywords(payload.Details),
+		Sections:      services.SplitDetailsSections(payload.Details),
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 
-	result, err := coll.InsertOne(ctx, newBrand)
+	ack := writeAck{Inserted: 1, WriteConcern: writeConcern}
+	_, err = coll.InsertOne(ctx, newBrand)
+	if msg, wcOnly := writeConcernOnly(err); wcOnly {
+		ack.WriteConcernError, err = msg, nil
+	}
 	if err != nil {
-		// Handle potential duplicate key error from the unique index
-		if mongo.IsDuplicateKeyError(err) {
-			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Brand '%s' already exists (database constraint)", payload.Name)})
-		} else {
-			log.Printf("Error inserting brand '%s': %v", newBrand.Name, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create brand"})
+		// A duplicate key from the unique index arrives as apperrors.ErrAlreadyExists
+		if !domainError(c, payload.Name, apperrors.FromDB(err)) {
+			log.Printf("Error inserting brand '%s': %v", services.LogValue(newBrand.Name), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandCreateFailed, nil, nil)
 		}
 		return
 	}
 
-	// Set the ID in the response object
-	newBrand.ID = result.InsertedID.(primitive.ObjectID)
-
-	c.JSON(http.StatusCreated, newBrand)
+	respondBrand(c, http.StatusCreated, &newBrand, gin.H{"ack": ack})
 }
 
 // UpdateBrandManual godoc
 // @Summary Update details for an existing brand (manual entry)
-// @Description Update the details of an existing brand identified by its name
+// @Description Update the details of an existing brand identified by its name. The details key is required; null or an empty string clears the details. Example payloads: GET /examples/UpdateBrandManual
 // @Tags brands
 // @Accept json
 // @Produce json
 // @Param brandName path string true "Name of the brand to update"
 // @Param details body models.UpdateBrandPayload true "New details data"
+// @Param writeConcern query string false "Write concern: majority, default or a node count (default WRITE_CONCERN)"
 // @Success 200 {object} models.Brand "Brand updated successfully"
 // @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 422 {object} map[string]interface{} "Field has the wrong type"
 // @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 413 {object} map[string]interface{} "Details exceed MAX_DETAILS_BYTES (code DETAILS_TOO_LARGE)"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /brands/{brandName} [put]
 func UpdateBrandManual(c *gin.Context) {
-	coll := database.GetCollection("brands")
+	coll, writeConcern, ok := withWriteConcern(c, database.GetCollection("brands"))
+	if !ok {
+		return
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
 	brandName := c.Param("brandName")
 	var payload models.UpdateBrandPayload
 
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+	if !bindJSON(c, &payload) {
+		return
+	}
+	if !payload.Details.Set {
+		requiredFieldError(c, "details") // A full replacement must say what the details become
+		return
+	}
+	details := payload.Details.Value // "" for null: clearing is explicit either way
+	if detailsTooLarge(c, details) {
 		return
 	}
 
 	filter := bson.M{"name": brandName}
 	update := bson.M{
 		"$set": bson.M{
-			"details":   payload.Details,
-			"updatedAt": time.Now(),
+			"details":       details,
+			"detailsFormat": services.DetailsFormatOrDetect(payload.DetailsFormat, details),
+			"keywords":      services.ExtractKeywords(details),
+			"sections":      services.SplitDetailsSections(details),
+			"updatedAt":     models.Now(),
 		},
+		"$unset": bson.M{"extraction": ""}, // Details are no longer the output of a PDF extraction
 	}
 
 	// Option to return the updated document
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
+	ack := writeAck{Matched: 1, Modified: 1, WriteConcern: writeConcern}
 	var updatedBrand models.Brand
 	err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedBrand)
+	if msg, wcOnly := writeConcernOnly(err); wcOnly {
+		// Applied but not acknowledged as requested: return the stored state
+		ack.WriteConcernError = msg
+		var stored *models.Brand
+		if stored, err = services.GetBrandByName(ctx, coll, brandName); err == nil {
+			updatedBrand = *stored
+		}
+	}
 
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Brand '%s' not found for update", brandName)})
+		if !domainError(c, brandName, apperrors.FromDB(err)) {
+			log.Printf("Error updating brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandUpdateFailed, nil, nil)
+		}
+		return
+	}
+
+	respondBrand(c, http.StatusOK, &updatedBrand, gin.H{"ack": ack})
+}
+
+// PatchBrand godoc
+// @Summary Partially update a brand
+// @Description Changes only the fields present in the body. A missing details key leaves the details unchanged, while null or an empty string clears them. Setting detailsFormat alone keeps the details text (and its keywords) as they are; updatedAt changes either way. parentBrand names the brand of the parent company (null or "" detaches it); a parent that would create a cycle or a hierarchy deeper than 16 levels is rejected. Example payloads: GET /examples/PatchBrand
+// @Tags brands
+// @Accept json
+// @Produce json
+// @Param brandName path string true "Name of the brand to update"
+// @Param changes body models.PatchBrandPayload true "Fields to change"
+// @Param writeConcern query string false "Write concern: majority, default or a node count (default WRITE_CONCERN)"
+// @Success 200 {object} models.Brand "Brand updated successfully"
+// @Failure 400 {object} map[string]string "Invalid input (including an unknown or cyclic parentBrand) or nothing to change (code NOTHING_TO_CHANGE)"
+// @Failure 413 {object} map[string]interface{} "Details exceed MAX_DETAILS_BYTES (code DETAILS_TOO_LARGE)"
+// @Failure 422 {object} map[string]interface{} "Field has the wrong type"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName} [patch]
+func PatchBrand(c *gin.Context) {
+	coll, writeConcern, ok := withWriteConcern(c, database.GetCollection("brands"))
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brandName := c.Param("brandName")
+	var payload models.PatchBrandPayload
+	if !bindJSON(c, &payload) {
+		return
+	}
+	if !payload.Details.Set && payload.DetailsFormat == nil && !payload.ParentBrand.Set {
+		localizedError(c, http.StatusBadRequest, codeNothingToChange, map[string]string{"fields": "details, detailsFormat, parentBrand"}, nil)
+		return
+	}
+	if payload.Details.Set && detailsTooLarge(c, payload.Details.Value) {
+		return
+	}
+
+	set := bson.M{"updatedAt": models.Now()}
+	unset := bson.M{}
+	update := bson.M{"$set": set}
+	if payload.ParentBrand.Set {
+		if payload.ParentBrand.Value == "" {
+			unset["parentBrand"] = ""
 		} else {
-			log.Printf("Error updating brand '%s': %v", brandName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update brand"})
+			// The cycle check needs the brand's ID; a concurrent re-parenting can still slip past it,
+			// which the capped traversals tolerate
+			brand, err := services.GetBrandByName(ctx, coll, brandName)
+			var parentID primitive.ObjectID
+			if err == nil {
+				parentID, err = services.ResolveParentBrand(ctx, coll, brand.ID, payload.ParentBrand.Value)
+			}
+			if err != nil {
+				if !domainError(c, brandName, err) {
+					log.Printf("Error resolving parent of brand '%s': %v", services.LogValue(brandName), err)
+					localizedError(c, http.StatusInternalServerError, codeBrandUpdateFailed, nil, nil)
+				}
+				return
+			}
+			set["parentBrand"] = parentID
+		}
+	}
+	if payload.Details.Set {
+		format := ""
+		if payload.DetailsFormat != nil {
+			format = *payload.DetailsFormat
+		}
+		details := payload.Details.Value // "" for null: stored as an empty string, never null
+		set["details"] = details
+		set["detailsFormat"] = services.DetailsFormatOrDetect(format, details)
+		set["keywords"] = services.ExtractKeywords(details)
+		set["sections"] = services.SplitDetailsSections(details)
+		unset["extraction"] = "" // Details are no longer the output of a PDF extraction
+	} else if payload.DetailsFormat != nil {
+		set["detailsFormat"] = *payload.DetailsFormat
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	ack := writeAck{Matched: 1, Modified: 1, WriteConcern: writeConcern}
+	var updatedBrand models.Brand
+	err := coll.FindOneAndUpdate(ctx, bson.M{"name": brandName}, update, opts).Decode(&updatedBrand)
+	if msg, wcOnly := writeConcernOnly(err); wcOnly {
+		// Applied but not acknowledged as requested: return the stored state
+		ack.WriteConcernError = msg
+		var stored *models.Brand
+		if stored, err = services.GetBrandByName(ctx, coll, brandName); err == nil {
+			updatedBrand = *stored
+		}
+	}
+
+	if err != nil {
+		if !domainError(c, brandName, apperrors.FromDB(err)) {
+			log.Printf("Error patching brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandUpdateFailed, nil, nil)
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedBrand)
+	respondBrand(c, http.StatusOK, &updatedBrand, gin.H{"ack": ack})
 }
 
 // UploadBrandPDF godoc
@@ -233,57 +490,176 @@ func UpdateBrandManual(c *gin.Context) {
 // @Produce json
 // @Param brandName formData string true "Name of the brand"
 // @Param pdfFile formData file true "PDF file containing brand details"
+// @Param writeConcern query string false "Write concern: majority, default or a node count (default WRITE_CONCERN)"
 // @Success 200 {object} models.Brand "Brand details updated from PDF"
 // @Success 201 {object} models.Brand "Brand created from PDF"
-// @Failure 400 {object} map[string]string "Bad request (e.g., missing fields, invalid file)"
+// @Failure 400 {object} map[string]string "Bad request (e.g., missing fields, invalid file, incomplete or malformed upload)"
+// @Failure 408 {object} map[string]string "Upload timed out (code UPLOAD_TIMEOUT)"
+// @Failure 413 {object} map[string]string "Upload exceeds the upload policy maxBytes (code UPLOAD_TOO_LARGE)"
+// @Failure 415 {object} map[string]string "File type not allowed by the upload policy"
+// @Failure 422 {object} map[string]string "Reserved brand name, too many pages, or no text while the policy disallows empty PDFs"
 // @Failure 500 {object} map[string]string "Internal server error (e.g., PDF parsing failed, DB error)"
+// @Failure 503 {object} map[string]string "PDF extraction temporarily unavailable (code EXTRACTION_UNAVAILABLE) or too many concurrent uploads (code UPLOAD_BUSY)"
+// @Failure 504 {object} map[string]string "The request's deadline (UPLOAD_DEADLINE_SECONDS) ran out; stage names the step that couldn't run (code DEADLINE_EXCEEDED)"
 // @Router /brands/upload [post]
 func UploadBrandPDF(c *gin.Context) {
-	coll := database.GetCollection("brands")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second) // Longer timeout for upload+parse+db
-	defer cancel()
-
-	// --- 1. Get Form Data (same as before) ---
+	// --- 1. Get Form Data ---
+	// Read the whole multipart body first so aborted/corrupt uploads are reported as client errors
+	if !parseUploadForm(c) {
+		return
+	}
 	brandName := c.PostForm("brandName")
 	if brandName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing 'brandName' form field"})
+		localizedError(c, http.StatusBadRequest, codeMissingFormField, map[string]string{"field": "brandName"}, nil)
+		return
+	}
+	if rejectReservedBrandName(c, brandName) {
 		return
 	}
 	fileHeader, err := c.FormFile("pdfFile")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing 'pdfFile' form field or invalid file upload"})
+		localizedError(c, http.StatusBadRequest, codeMissingFile, map[string]string{"field": "pdfFile"}, nil)
+		return
+	}
+	// The part's declared type must be allowed by the upload policy (no type counts as octet-stream)
+	partType := fileHeader.Header.Get("Content-Type")
+	if partType == "" {
+		partType = "application/octet-stream"
+	}
+	if mediaType, _, err := mime.ParseMediaType(partType); err != nil || !uploadpolicy.Current().AllowsType(mediaType) {
+		localizedError(c, http.StatusUnsupportedMediaType, uploadCodeMediaType, nil, nil)
 		return
 	}
-	// Add validation if desired (file type, size)
 
 	// --- 2. Open and Parse PDF (same as before) ---
 	file, err := fileHeader.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+		localizedError(c, http.StatusInternalServerError, codeUploadOpenFailed, nil, nil)
 		return
 	}
 	defer file.Close()
 
-	extractedText, err := services.ExtractTextFromPDF(file) // Use the chosen parser
+	processPDFUpload(c, brandName, file, fileHeader.Size)
+}
+
+// UploadBrandPDFRaw godoc
+// @Summary Upload a raw PDF to create or update brand details
+// @Description Same as the multipart upload, for clients that send the PDF itself as the request body (e.g. curl --data-binary @file.pdf). The body must start with the PDF signature.
+// @Tags brands
+// @Accept application/pdf
+// @Accept application/octet-stream
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param pdf body string true "PDF file content"
+// @Param writeConcern query string false "Write concern: majority, default or a node count (default WRITE_CONCERN)"
+// @Success 200 {object} models.Brand "Brand details updated from PDF"
+// @Success 201 {object} models.Brand "Brand created from PDF"
+// @Failure 400 {object} map[string]string "Body is not a PDF, or the upload was incomplete"
+// @Failure 408 {object} map[string]string "Upload timed out (code UPLOAD_TIMEOUT)"
+// @Failure 413 {object} map[string]string "Upload exceeds the upload policy maxBytes (code UPLOAD_TOO_LARGE)"
+// @Failure 415 {object} map[string]string "Content-Type not allowed by the upload policy"
+// @Failure 422 {object} map[string]string "Too many pages, or no text while the policy disallows empty PDFs"
+// @Failure 500 {object} map[string]string "Internal server error (e.g., PDF parsing failed, DB error)"
+// @Failure 503 {object} map[string]string "PDF extraction temporarily unavailable (code EXTRACTION_UNAVAILABLE) or too many concurrent uploads (code UPLOAD_BUSY)"
+// @Failure 504 {object} map[string]string "The request's deadline (UPLOAD_DEADLINE_SECONDS) ran out; stage names the step that couldn't run (code DEADLINE_EXCEEDED)"
+// @Router /brands/{brandName}/pdf [post]
+func UploadBrandPDFRaw(c *gin.Context) {
+	// Addressed by ID the brand already exists, so its name can't newly shadow a route
+	if !c.GetBool(resolvedByIDKey) && rejectReservedBrandName(c, c.Param("brandName")) {
+		return
+	}
+	body, ok := readRawPDF(c)
+	if !ok {
+		return
+	}
+	processPDFUpload(c, c.Param("brandName"), bytes.NewReader(body), int64(len(body)))
+}
+
+// processPDFUpload extracts the text of an uploaded PDF and creates or updates the brand with it.
+// Both upload routes end here, so they share journaling, limits and the response shape.
+//
+// Every step runs within the request's deadline budget (set by the router for upload routes), so
+// nothing keeps working after the client has been answered or has given up.
+func processPDFUpload(c *gin.Context, brandName string, file io.ReadSeeker, size int64) {
+	coll, writeConcern, ok := withWriteConcern(c, database.GetCollection("brands"))
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	// Bound concurrent extractions, supplier PDFs included, as set by the upload policy
+	if !uploadpolicy.TryAcquireSlot() {
+		c.Header("Retry-After", "5")
+		localizedError(c, http.StatusServiceUnavailable, uploadCodeBusy, nil, nil)
+		return
+	}
+	defer uploadpolicy.ReleaseSlot()
+
+	// Journal the upload so one that never completes can still be traced afterwards
+	if err := deadline.Check(ctx, stageJournal, journalBudget); err != nil {
+		domainError(c, brandName, err)
+		return
+	}
+	journal := beginUploadJournal(ctx, c, brandName, file, size)
+	defer func() { journal.Finish(c.Writer.Status()) }()
+
+	if err := deadline.Check(ctx, services.StageExtraction, minExtractionBudget+upsertReserve); err != nil {
+		domainError(c, brandName, err)
+		return
+	}
+	extractCtx, cancelExtract := deadline.Reserve(ctx, upsertReserve)
+	extractedText, extraction, err := extractText(extractCtx, file) // Use the chosen parser
+	cancelExtract()
+	if errors.Is(err, services.ErrExtractionUnavailable) {
+		// Circuit breaker open: fail fast instead of waiting for another pdftotext timeout
+		status := services.ExtractionBreakerStatus()
+		if status.RetryAfterSeconds > 0 {
+			c.Header("Retry-After", strconv.Itoa(status.RetryAfterSeconds))
+		}
+		localizedError(c, http.StatusServiceUnavailable, codeExtractionUnavailable, nil, nil)
+		return
+	}
 	if err != nil {
-		log.Printf("Error extracting text from PDF for brand '%s': %v", brandName, err)
+		if domainError(c, brandName, err) {
+			return // Out of budget
+		}
+		log.Printf("Error extracting text from PDF for brand '%s': %v", services.LogValue(brandName), err)
 		// Handle specific parsing errors as before
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse PDF content."})
+		localizedError(c, http.StatusInternalServerError, codePDFParseFailed, nil, nil)
+		return
+	}
+	journal.Mark(models.UploadStageExtracted)
+	policy := uploadpolicy.Current()
+	if policy.MaxPages > 0 && extraction.PageCount > policy.MaxPages {
+		localizedError(c, http.StatusUnprocessableEntity, uploadCodeTooManyPages, map[string]string{"max": strconv.Itoa(policy.MaxPages)}, gin.H{"pages": extraction.PageCount})
 		return
 	}
 	if extractedText == "" {
-		log.Printf("Warning: No text extracted from PDF for brand '%s'.", brandName)
-		// Decide how to proceed - maybe save empty details or return an informative message
+		log.Printf("Warning: No text extracted from PDF for brand '%s'.", services.LogValue(brandName))
+		if !policy.AllowEmpty {
+			localizedError(c, http.StatusUnprocessableEntity, uploadCodeEmpty, nil, nil)
+			return
+		}
+	}
+	// Very large PDFs are cut to the same maximum size enforced for manual edits
+	if limit := maxDetailsBytes(); len(extractedText) > limit {
+		extractedText = truncateUTF8(extractedText, limit)
+		extraction.Truncated = true
+		extraction.Warnings = append(extraction.Warnings, fmt.Sprintf("text truncated to %d bytes", limit))
 	}
 
 	// --- 3. Upsert Brand in DB ---
 	// Upsert = Update if found, Insert if not found
 	filter := bson.M{"name": brandName}
-	now := time.Now()
+	now := models.Now()
 	update := bson.M{
 		"$set": bson.M{
-			"details":   extractedText,
-			"updatedAt": now,
+			"details":       extractedText,
+			"detailsFormat": services.DetectDetailsFormat(extractedText),
+			"keywords":      services.ExtractKeywords(extractedText),
+			"sections":      services.SplitDetailsSections(extractedText),
+			"extraction":    extraction,
+			"updatedAt":     now,
 		},
 		"$setOnInsert": bson.M{ // Fields to set only when inserting (creating)
 			"name":      brandName,
@@ -294,55 +670,121 @@ func UploadBrandPDF(c *gin.Context) {
 		SetUpsert(true).                 // Enable Upsert
 		SetReturnDocument(options.After) // Return the *new* or *updated* document
 
+	if err := deadline.Check(ctx, stageUpsert, upsertReserve/2); err != nil {
+		domainError(c, brandName, err)
+		return
+	}
+	ack := writeAck{WriteConcern: writeConcern}
 	var resultBrand models.Brand
 	err = coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&resultBrand)
+	if msg, wcOnly := writeConcernOnly(err); wcOnly {
+		// Applied but not acknowledged as requested: return the stored state
+		ack.WriteConcernError = msg
+		var stored *models.Brand
+		if stored, err = services.GetBrandByName(ctx, coll, brandName); err == nil {
+			resultBrand = *stored
+		}
+	}
 
+	if err != nil && ctx.Err() != nil {
+		err = deadline.Exhausted(stageUpsert, 0) // The budget ran out mid-write, not the database
+	}
 	if err != nil {
-		// Specific upsert errors might need different handling, but generally:
-		log.Printf("Error upserting brand '%s' from PDF: %v", brandName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error processing PDF upload"})
+		if !domainError(c, brandName, apperrors.FromDB(err)) {
+			log.Printf("Error upserting brand '%s' from PDF: %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeUploadSaveFailed, nil, nil)
+		}
 		return
 	}
 
+	journal.Mark(models.UploadStageUpserted)
+
 	// Determine if it was an insert or update based on timestamps (or check result differently if needed)
 	statusCode := http.StatusOK                             // Assume update
 	if resultBrand.CreatedAt.Equal(resultBrand.UpdatedAt) { // Approximation: if created == updated, it was likely just inserted
 		statusCode = http.StatusCreated
+		ack.Upserted = 1
+	} else {
+		ack.Matched, ack.Modified = 1, 1
 	}
 
 	// --- 4. Return Success Response ---
-	c.JSON(statusCode, resultBrand)
+	respondBrand(c, statusCode, &resultBrand, gin.H{"ack": ack})
 }
 
 // DeleteBrand godoc
 // @Summary Delete a brand
-// @Description Delete a brand by its name
+// @Description Delete a brand by its name, with its sync tombstone and logo files. Cleanup that fails after the brand is removed is retried in the background; meta.deletion reports whether it completed (see GET /admin/deletions). A brand with sub-brands is only deleted with cascade=true, which deletes all of them first (meta.cascaded lists them).
 // @Tags brands
 // @Produce json
 // @Param brandName path string true "Name of the brand to delete"
+// @Param cascade query bool false "Also delete every sub-brand of this brand"
+// @Param writeConcern query string false "Write concern: majority, default or a node count (default WRITE_CONCERN)"
 // @Success 200 {object} map[string]string "Success message"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters, each listed under fields"
 // @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 409 {object} map[string]interface{} "The brand has sub-brands and cascade is not set (code BRAND_HAS_CHILDREN)"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /brands/{brandName} [delete]
 func DeleteBrand(c *gin.Context) {
-	coll := database.GetCollection("brands")
+	q := queryParams(c)
+	cascade := q.Bool("cascade", false)
+	if !validQuery(c, q) {
+		return
+	}
+	coll, writeConcern, ok := withWriteConcern(c, database.GetCollection("brands"))
+	if !ok {
+		return
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
 	brandName := c.Param("brandName")
-	filter := bson.M{"name": brandName}
 
-	result, err := coll.DeleteOne(ctx, filter)
-	if err != nil {
-		log.Printf("Error deleting brand '%s': %v", brandName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete brand"})
+	// The deletion pipeline also records the sync tombstone and removes the logo files (see deletion)
+	var manifest *models.DeletionManifest
+	var cascaded []string
+	var err error
+	if cascade {
+		var manifests []*models.DeletionManifest
+		manifests, err = deletion.DeleteTree(ctx, coll, brandName, requestActor(c))
+		purgeDeletedBrands(c, manifests)
+		for _, m := range manifests {
+			cascaded = append(cascaded, m.BrandName)
+		}
+		if err == nil {
+			manifest, cascaded = manifests[len(manifests)-1], cascaded[:len(cascaded)-1]
+			audit(c, "brand.delete", "Brand '%s' deleted with %d sub-brand(s)", services.LogValue(brandName), len(cascaded))
+		} else if len(cascaded) > 0 {
+			log.Printf("Warning: Cascading deletion of brand '%s' stopped after deleting %d sub-brand(s)", services.LogValue(brandName), len(cascaded))
+		}
+	} else {
+		manifest, err = deletion.Delete(ctx, coll, brandName, requestActor(c))
+	}
+	var hasChildren *deletion.HasChildrenError
+	if errors.As(err, &hasChildren) {
+		localizedError(c, http.StatusConflict, codeBrandHasChildren, map[string]string{"name": brandName}, gin.H{"children": hasChildren.Children})
 		return
 	}
-
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Brand '%s' not found", brandName)})
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error deleting brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandDeleteFailed, nil, nil)
+		}
 		return
 	}
+	if manifest.WriteConcernError != "" {
+		// The delete was applied; only its acknowledgement is missing
+		log.Printf("Warning: Brand '%s' deleted without write concern acknowledgement: %s", services.LogValue(brandName), manifest.WriteConcernError)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Brand '%s' deleted successfully", brandName)})
+	ack := writeAck{WriteConcern: writeConcern, Deleted: 1, WriteConcernError: manifest.WriteConcernError}
+	meta := gin.H{
+		"ack":      ack,
+		"deletion": gin.H{"id": manifest.ID.Hex(), "complete": manifest.Complete},
+	}
+	if cascade {
+		meta["cascaded"] = cascaded
+	}
+	respond(c, http.StatusOK, gin.H{"message": fmt.Sprintf("Brand '%s' deleted successfully", brandName)}, meta)
 }
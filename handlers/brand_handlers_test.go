@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/deadline"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// exhaustedCount returns how often stage has run out of budget so far.
+func exhaustedCount(stage string) int64 {
+	for _, s := range deadline.Stats() {
+		if s.Stage == stage {
+			return s.Exhausted
+		}
+	}
+	return 0
+}
+
+// The brand collection is nil here, so an upsert attempted after extraction would panic; counting
+// the upsert stage also shows the handler never got that far.
+func TestUploadOutOfBudget(t *testing.T) {
+	tests := []struct {
+		name          string
+		budget        time.Duration
+		wantExtractor bool
+	}{
+		{"too little left to start extraction", minExtractionBudget, false},
+		{"slow extraction uses up its share", minExtractionBudget + upsertReserve + 100*time.Millisecond, true},
+	}
+	t.Setenv("UPLOAD_JOURNAL", "false")
+	saved := extractText
+	t.Cleanup(func() { extractText = saved })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(http.MethodPost, "/brands/Acme/pdf?writeConcern=default", "")
+			ctx, cancel := context.WithTimeout(c.Request.Context(), tt.budget)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+			requestEnd, _ := ctx.Deadline()
+
+			called := false
+			extractText = func(ctx context.Context, _ io.Reader) (string, *models.ExtractionInfo, error) {
+				called = true
+				if end, _ := ctx.Deadline(); requestEnd.Sub(end) < upsertReserve {
+					t.Errorf("extraction may run until %v before the deadline, want %v held back for the upsert", requestEnd.Sub(end), upsertReserve)
+				}
+				<-ctx.Done() // Slower than the budget allows
+				return "", nil, deadline.Exhausted(services.StageExtraction, 0)
+			}
+			extractions, upserts := exhaustedCount(services.StageExtraction), exhaustedCount(stageUpsert)
+
+			processPDFUpload(c, "Acme", bytes.NewReader([]byte("%PDF-1.4")), 8)
+
+			if called != tt.wantExtractor {
+				t.Errorf("extractor called = %v, want %v", called, tt.wantExtractor)
+			}
+			if w.Code != http.StatusGatewayTimeout {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, http.StatusGatewayTimeout, w.Body.String())
+			}
+			body := decodeResponse(t, w)
+			if body["code"] != codeDeadlineExceeded || body["stage"] != services.StageExtraction {
+				t.Errorf("code %v at stage %v, want %s at %s", body["code"], body["stage"], codeDeadlineExceeded, services.StageExtraction)
+			}
+			if got := exhaustedCount(services.StageExtraction) - extractions; got != 1 {
+				t.Errorf("extraction exhausted %d times, want 1", got)
+			}
+			if got := exhaustedCount(stageUpsert) - upserts; got != 0 {
+				t.Errorf("upsert exhausted %d times, want 0: it must not be attempted", got)
+			}
+		})
+	}
+}
+
+func TestUploadBreakerOpen(t *testing.T) {
+	t.Setenv("UPLOAD_JOURNAL", "false")
+	saved := extractText
+	t.Cleanup(func() { extractText = saved })
+	extractText = func(context.Context, io.Reader) (string, *models.ExtractionInfo, error) {
+		return "", nil, services.ErrExtractionUnavailable
+	}
+
+	c, w := testContext(http.MethodPost, "/brands/Acme/pdf?writeConcern=default", "")
+	processPDFUpload(c, "Acme", bytes.NewReader([]byte("%PDF-1.4")), 8)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d (%s)", w.Code, http.StatusServiceUnavailable, w.Body.String())
+	}
+	if body := decodeResponse(t, w); body["code"] != codeExtractionUnavailable {
+		t.Errorf("code = %v, want %s", body["code"], codeExtractionUnavailable)
+	}
+}
+
+// Locales outside services.SortLocales are rejected before the database is queried.
+func TestListBrandsRejectsLocale(t *testing.T) {
+	for _, locale := range []string{"xx", "EN", "de-DE", "en_US", "und"} {
+		c, w := testContext(http.MethodGet, "/brands?locale="+locale, "")
+		ListBrands(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("locale %q: got %d %s, want 400", locale, w.Code, w.Body.String())
+		}
+	}
+}
+
+// The full listing is streamed and never cached, while the names listing keeps the public policy.
+func TestListBrandsCachePolicy(t *testing.T) {
+	t.Setenv("CACHE_LIST_MAX_AGE", "")
+	t.Setenv("CACHE_STALE_WHILE_REVALIDATE", "")
+	router := gin.New()
+	router.GET("/brands", CachePublicList, ListBrands)
+	brand := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Acme"}, {Key: "details", Value: "Drills"}}
+
+	tests := []struct {
+		target      string
+		wantControl string
+		wantTag     string
+	}{
+		{"/brands", "public, max-age=60, stale-while-revalidate=600", services.CacheTagBrandList},
+		{"/brands?includeDetails=true", "no-store", ""},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.target, func(mt *mtest.T) {
+			database.Use(mt.Client)
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch, brand))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.wantControl {
+				t.Errorf("Cache-Control %q, want %q", got, tt.wantControl)
+			}
+			if got := w.Header().Get("Cache-Tag"); got != tt.wantTag {
+				t.Errorf("Cache-Tag %q, want %q", got, tt.wantTag)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// resolvedByIDKey marks requests whose brand was addressed by ID (see ResolveBrandID).
+const resolvedByIDKey = "brandResolvedByID"
+
+// ResolveBrandID is the middleware behind the /brands/id/:id routes. It looks up the brand with
+// the hex ObjectID in :id and sets :brandName to its name, so the regular by-name handlers serve
+// these routes unchanged. IDs avoid every path-matching problem names can have (dots, percent
+// signs, reserved words), so programmatic clients should prefer them.
+func ResolveBrandID(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid brand ID"})
+		return
+	}
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var brand models.Brand
+	opts := options.FindOne().SetProjection(bson.M{"name": 1})
+	if err := coll.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&brand); err != nil {
+		if !domainError(c, id.Hex(), apperrors.FromDB(err)) {
+			log.Printf("Error resolving brand ID %s: %v", id.Hex(), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		c.Abort()
+		return
+	}
+	c.Params = append(c.Params, gin.Param{Key: "brandName", Value: brand.Name})
+	c.Set(resolvedByIDKey, true)
+	c.Next()
+}
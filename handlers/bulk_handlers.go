@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/i18n"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxBulkCreate is the most brands one bulk creation request may hold.
+const maxBulkCreate = 1000
+
+// CreateBrandsBulk godoc
+// @Summary Create many brands at once
+// @Description Takes a JSON array of up to 1000 brands, each like the body of POST /brands, and inserts the valid ones in one unordered write. Every item gets a result in request order: created (with its id), duplicate (the name exists, or appears earlier in the array), invalid (with the code and message POST /brands would answer) or failed. The status is 201 when every item was created and 207 otherwise.
+// @Tags brands
+// @Accept json
+// @Produce json
+// @Param brands body []models.CreateBrandPayload true "Brands to create"
+// @Success 201 {object} models.BulkCreateSummary "All brands created"
+// @Success 207 {object} models.BulkCreateSummary "Some or no brands created; see results"
+// @Failure 400 {object} map[string]interface{} "Invalid JSON or an empty array"
+// @Failure 422 {object} map[string]interface{} "The body is not a JSON array"
+// @Failure 413 {object} map[string]interface{} "More than 1000 brands"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/bulk [post]
+func CreateBrandsBulk(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	var items []json.RawMessage
+	if !bindJSON(c, &items) {
+		return
+	}
+	if len(items) == 0 {
+		localizedError(c, http.StatusBadRequest, codeEmptyBody, nil, nil)
+		return
+	}
+	if len(items) > maxBulkCreate {
+		params := map[string]string{"max": strconv.Itoa(maxBulkCreate), "count": strconv.Itoa(len(items))}
+		localizedError(c, http.StatusRequestEntityTooLarge, codeBulkTooLarge, params, gin.H{"max": maxBulkCreate})
+		return
+	}
+
+	summary := models.BulkCreateSummary{Results: make([]models.BulkCreateResult, len(items))}
+	var brands []models.Brand
+	var positions []int // Index in items of each brand
+	firstSeen := make(map[string]int, len(items))
+	now := models.Now()
+	for i, raw := range items {
+		result := &summary.Results[i]
+		result.Index = i
+		var payload models.CreateBrandPayload
+		if code, message := bulkItemError(c, raw, &payload); code != "" {
+			result.Name, result.Status, result.Code, result.Error = payload.Name, models.BulkInvalid, code, message
+			continue
+		}
+		result.Name = payload.Name
+		if first, ok := firstSeen[payload.Name]; ok {
+			result.Status, result.Error = models.BulkDuplicate, "Same name as item "+strconv.Itoa(first)
+			continue
+		}
+		firstSeen[payload.Name] = i
+
+		brands = append(brands, models.Brand{
+			ID:            primitive.NewObjectID(),
+			Name:          payload.Name,
+			Details:       payload.Details,
+			DetailsFormat: services.DetailsFormatOrDetect(payload.DetailsFormat, payload.Details),
+			Keywords:      services.ExtractKeywords(payload.Details),
+			Sections:      services.SplitDetailsSections(payload.Details),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		positions = append(positions, i)
+	}
+
+	if len(brands) > 0 {
+		failed, err := services.InsertBrands(ctx, coll, brands)
+		if err != nil {
+			// Nothing is known about which brands made it; a retry reports those as duplicates
+			if !domainError(c, "", err) {
+				log.Printf("Error inserting %d brands in bulk: %v", len(brands), err)
+				localizedError(c, http.StatusInternalServerError, codeBrandCreateFailed, nil, nil)
+			}
+			return
+		}
+		for j, brand := range brands {
+			result := &summary.Results[positions[j]]
+			switch err := failed[j]; {
+			case err == nil:
+				result.Status, result.ID = models.BulkCreated, brand.ID.Hex()
+			case errors.Is(err, apperrors.ErrAlreadyExists):
+				result.Status, result.Code = models.BulkDuplicate, codeBrandExists
+				result.Error = i18n.Message(requestLocale(c), codeBrandExists, map[string]string{"name": brand.Name})
+			default:
+				log.Printf("Error inserting brand '%s' in bulk: %v", services.LogValue(brand.Name), err)
+				result.Status, result.Code = models.BulkFailed, codeBrandCreateFailed
+				result.Error = i18n.Message(requestLocale(c), codeBrandCreateFailed, nil)
+			}
+		}
+	}
+
+	for _, result := range summary.Results {
+		switch result.Status {
+		case models.BulkCreated:
+			summary.Created++
+		case models.BulkDuplicate:
+			summary.Duplicates++
+		case models.BulkInvalid:
+			summary.Invalid++
+		case models.BulkFailed:
+			summary.Failed++
+		}
+	}
+	log.Printf("Bulk create: %d brand(s) created, %d duplicate, %d invalid, %d failed", summary.Created, summary.Duplicates, summary.Invalid, summary.Failed)
+
+	status := http.StatusCreated
+	if summary.Created < len(items) {
+		status = http.StatusMultiStatus
+	}
+	respond(c, status, summary, nil)
+}
+
+// bulkItemError decodes and checks one item of a bulk creation like POST /brands would and
+// returns the error code and localized message it would answer with, or "" when it is valid.
+func bulkItemError(c *gin.Context, raw json.RawMessage, payload *models.CreateBrandPayload) (code, message string) {
+	locale := requestLocale(c)
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if strictBinding(c) {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(payload); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			params := map[string]string{"field": typeErr.Field, "expected": typeErr.Type.String()}
+			return codeWrongFieldType, i18n.Message(locale, codeWrongFieldType, params)
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+			return codeUnknownFields, i18n.Message(locale, codeUnknownFields, map[string]string{"fields": field})
+		default:
+			return codeInvalidInput, "Invalid input: " + err.Error()
+		}
+	}
+	if err := binding.Validator.ValidateStruct(payload); err != nil {
+		fields, ok := validationMessages(c, err)
+		if !ok {
+			return codeInvalidInput, "Invalid input: " + err.Error()
+		}
+		messages := make([]string, len(fields))
+		for i, field := range fields {
+			messages[i] = field["message"].(string)
+		}
+		return codeInvalidInput, strings.Join(messages, "; ")
+	}
+	if limit := maxDetailsBytes(); len(payload.Details) > limit {
+		params := map[string]string{"size": strconv.Itoa(len(payload.Details)), "max": strconv.Itoa(limit)}
+		return codeDetailsTooLarge, i18n.Message(locale, codeDetailsTooLarge, params)
+	}
+	if services.IsReservedBrandName(payload.Name) {
+		return codeBrandNameReserved, i18n.Message(locale, codeBrandNameReserved, map[string]string{"name": payload.Name})
+	}
+	return "", ""
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+func TestBulkItemError(t *testing.T) {
+	t.Setenv("MAX_DETAILS_BYTES", "16")
+	tests := []struct {
+		name     string
+		item     string
+		strict   bool
+		wantCode string
+	}{
+		{"valid", `{"name":"Acme","details":"Cotton shirts"}`, false, ""},
+		{"details at the limit", `{"name":"Acme","details":"` + strings.Repeat("x", 16) + `"}`, false, ""},
+		{"details too large", `{"name":"Acme","details":"` + strings.Repeat("x", 17) + `"}`, false, codeDetailsTooLarge},
+		{"wrong type", `{"name":"Acme","details":5}`, false, codeWrongFieldType},
+		{"unknown field ignored", `{"name":"Acme","details":"x","colour":"red"}`, false, ""},
+		{"unknown field strict", `{"name":"Acme","details":"x","colour":"red"}`, true, codeUnknownFields},
+		{"missing details", `{"name":"Acme"}`, false, codeInvalidInput},
+		{"bad format", `{"name":"Acme","details":"x","detailsFormat":"html"}`, false, codeInvalidInput},
+		{"reserved name", `{"name":"bulk","details":"x"}`, false, codeBrandNameReserved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := testContext(http.MethodPost, "/brands/bulk", "")
+			if tt.strict {
+				c.Request.Header.Set(strictHeader, "true")
+			}
+			var payload models.CreateBrandPayload
+			code, message := bulkItemError(c, json.RawMessage(tt.item), &payload)
+			if code != tt.wantCode {
+				t.Errorf("code = %q (%s), want %q", code, message, tt.wantCode)
+			}
+			if (message == "") != (tt.wantCode == "") {
+				t.Errorf("message = %q for code %q", message, code)
+			}
+		})
+	}
+}
+
+// Requests without a single valid item are answered without touching the database.
+func TestCreateBrandsBulkRejects(t *testing.T) {
+	t.Setenv("MAX_DETAILS_BYTES", "16")
+	tooMany := "[" + strings.TrimSuffix(strings.Repeat(`{"name":"a","details":"b"},`, maxBulkCreate+1), ",") + "]"
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"empty array", `[]`, http.StatusBadRequest, codeEmptyBody},
+		{"too many", tooMany, http.StatusRequestEntityTooLarge, codeBulkTooLarge},
+		{"not an array", `{"name":"Acme"}`, http.StatusUnprocessableEntity, codeWrongFieldType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(http.MethodPost, "/brands/bulk", tt.body)
+			CreateBrandsBulk(c)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+			if body := decodeResponse(t, w); body["code"] != tt.wantCode {
+				t.Errorf("code = %v, want %s", body["code"], tt.wantCode)
+			}
+		})
+	}
+
+	c, w := testContext(http.MethodPost, "/brands/bulk", `[{"name":"sync","details":"x"},{"name":"Acme","details":"`+strings.Repeat("x", 17)+`"},{"details":"x"}]`)
+	CreateBrandsBulk(c)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d (%s), want 207", w.Code, w.Body.String())
+	}
+	var summary models.BulkCreateSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	wantCodes := []string{codeBrandNameReserved, codeDetailsTooLarge, codeInvalidInput}
+	if summary.Invalid != len(wantCodes) || summary.Created != 0 || len(summary.Results) != len(wantCodes) {
+		t.Fatalf("summary = %+v, want %d invalid items", summary, len(wantCodes))
+	}
+	for i, result := range summary.Results {
+		if result.Index != i || result.Status != models.BulkInvalid || result.Code != wantCodes[i] {
+			t.Errorf("result %d = %+v, want invalid with %s", i, result, wantCodes[i])
+		}
+	}
+}
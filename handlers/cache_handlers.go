@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Default CDN cache lifetimes in seconds, overridable via the CACHE_* settings.
+const (
+	defaultListMaxAge           = 60
+	defaultBrandMaxAge          = 300
+	defaultStaleWhileRevalidate = 600
+)
+
+// cacheVary lists the request headers public responses differ by: the representation and
+// encoding, the language of messages and the opt-in response envelope.
+var cacheVary = strings.Join([]string{"Accept", "Accept-Encoding", "Accept-Language", envelopeHeader}, ", ")
+
+// cacheSeconds reads a CACHE_* lifetime from the environment.
+func cacheSeconds(key string, def int) int {
+	if raw := os.Getenv(key); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n >= 0 {
+			return n
+		}
+		log.Printf("Warning: Invalid %s '%s', using default %d", key, services.LogValue(raw), def)
+	}
+	return def
+}
+
+// cachingWriter sets Cache-Control and Vary when the status is written: the route's policy
+// for 200 and 304, no-store for anything else so a CDN never keeps an error. A Cache-Control the
+// handler set itself (e.g. the logo's immutable one) is left alone.
+type cachingWriter struct {
+	gin.ResponseWriter
+	cacheControl string
+	prepared     bool
+}
+
+func (w *cachingWriter) prepare(status int) {
+	if w.prepared {
+		return
+	}
+	w.prepared = true
+	header := w.Header()
+	if status != http.StatusOK && status != http.StatusNotModified {
+		header.Set("Cache-Control", "no-store")
+		header.Del("Cache-Tag")
+		return
+	}
+	if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", w.cacheControl)
+	}
+	header.Set("Vary", cacheVary)
+}
+
+func (w *cachingWriter) WriteHeader(status int) {
+	w.prepare(status)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cachingWriter) Write(data []byte) (int, error) {
+	w.prepare(w.Status())
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cachingWriter) WriteString(s string) (int, error) {
+	w.prepare(w.Status())
+	return w.ResponseWriter.WriteString(s)
+}
+
+// withCacheControl installs a cachingWriter with the given policy for the rest of the chain.
+func withCacheControl(c *gin.Context, cacheControl string) {
+	c.Writer = &cachingWriter{ResponseWriter: c.Writer, cacheControl: cacheControl}
+	c.Next()
+}
+
+// CachePublicList is the cache policy of brand listings: a short max-age (CACHE_LIST_MAX_AGE,
+// default 60s) and stale-while-revalidate (CACHE_STALE_WHILE_REVALIDATE, default 600s), since
+// any brand change alters them. They are tagged "brands" for purging.
+func CachePublicList(c *gin.Context) {
+	c.Header("Cache-Tag", services.CacheTagBrandList)
+	withCacheControl(c, fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d",
+		cacheSeconds("CACHE_LIST_MAX_AGE", defaultListMaxAge),
+		cacheSeconds("CACHE_STALE_WHILE_REVALIDATE", defaultStaleWhileRevalidate)))
+}
+
+// CachePublicBrand is the cache policy of a single brand (CACHE_BRAND_MAX_AGE, default 300s).
+// respondBrand tags the response with the brand's ID, which writes purge.
+func CachePublicBrand(c *gin.Context) {
+	withCacheControl(c, fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d",
+		cacheSeconds("CACHE_BRAND_MAX_AGE", defaultBrandMaxAge),
+		cacheSeconds("CACHE_STALE_WHILE_REVALIDATE", defaultStaleWhileRevalidate)))
+}
+
+// NoStore keeps responses out of every cache: admin data and anything read with a credential.
+func NoStore(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Next()
+}
+
+// deletedBrandsKey holds the hex IDs of the brands a handler deleted, for PurgeCDNOnWrite.
+const deletedBrandsKey = "deletedBrandIDs"
+
+// purgeDeletedBrands has PurgeCDNOnWrite purge the brands of deletion manifests besides the one
+// the request names: a cascading deletion's sub-brands.
+func purgeDeletedBrands(c *gin.Context, manifests []*models.DeletionManifest) {
+	ids := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		ids = append(ids, m.BrandID.Hex())
+	}
+	c.Set(deletedBrandsKey, ids)
+}
+
+// PurgeCDNOnWrite purges the CDN after a successful write to a brand: the listings, and every
+// response about the brand when it can be identified (by :id, :brandName or the upload's
+// brandName form field) or was deleted along with it (see purgeDeletedBrands). Without
+// CDN_PURGE_URL it does nothing. The brand's ID is looked up after the write, except for
+// deletions, where it's gone by then. The brands a cascading deletion removed before it failed
+// are purged as well.
+func PurgeCDNOnWrite(c *gin.Context) {
+	if !services.CDNPurgeConfigured() {
+		c.Next()
+		return
+	}
+	var brandID string
+	if c.Request.Method == http.MethodDelete {
+		brandID = purgeBrandID(c)
+	}
+	c.Next()
+	deleted := c.GetStringSlice(deletedBrandsKey)
+	if status := c.Writer.Status(); (status < 200 || status >= 300) && len(deleted) == 0 {
+		return
+	}
+	if brandID == "" {
+		brandID = purgeBrandID(c)
+	}
+	tags := []string{services.CacheTagBrandList}
+	if brandID != "" {
+		tags = append(tags, services.BrandCacheTag(brandID))
+	}
+	for _, id := range deleted {
+		if id != brandID {
+			tags = append(tags, services.BrandCacheTag(id))
+		}
+	}
+	services.PurgeCDN(tags...)
+}
+
+// purgeBrandID returns the hex ID of the brand the request is about, or "" when it names none
+// or the lookup fails.
+func purgeBrandID(c *gin.Context) string {
+	if id, err := primitive.ObjectIDFromHex(c.Param("id")); err == nil {
+		return id.Hex()
+	}
+	name := c.Param("brandName")
+	if name == "" {
+		name = c.PostForm("brandName") // PDF uploads name the brand in the form
+	}
+	if name == "" {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+	var brand models.Brand
+	opts := options.FindOne().SetProjection(bson.M{"_id": 1})
+	if err := database.GetCollection("brands").FindOne(ctx, bson.M{"name": name}, opts).Decode(&brand); err != nil {
+		return ""
+	}
+	return brand.ID.Hex()
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A cascading deletion purges every brand it removed, also when it stopped partway.
+func TestPurgeCDNOnWriteDeletedBrands(t *testing.T) {
+	purged := make(chan []string, 1)
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		purged <- body.Tags
+	}))
+	defer cdn.Close()
+	t.Setenv("CDN_PURGE_URL", cdn.URL)
+
+	root, child, grandchild := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	manifest := func(id primitive.ObjectID) *models.DeletionManifest {
+		return &models.DeletionManifest{BrandID: id}
+	}
+	tests := []struct {
+		name      string
+		status    int
+		manifests []*models.DeletionManifest
+		want      []string // nil when nothing is purged
+	}{
+		{"whole tree", http.StatusOK, []*models.DeletionManifest{manifest(grandchild), manifest(child), manifest(root)},
+			[]string{"brands", "brand-" + root.Hex(), "brand-" + grandchild.Hex(), "brand-" + child.Hex()}},
+		{"stopped after a sub-brand", http.StatusInternalServerError, []*models.DeletionManifest{manifest(grandchild)},
+			[]string{"brands", "brand-" + root.Hex(), "brand-" + grandchild.Hex()}},
+		{"nothing deleted", http.StatusInternalServerError, nil, nil},
+		{"single brand", http.StatusOK, nil, []string{"brands", "brand-" + root.Hex()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.DELETE("/brands/:id", PurgeCDNOnWrite, func(c *gin.Context) {
+				if tt.manifests != nil {
+					purgeDeletedBrands(c, tt.manifests)
+				}
+				c.Status(tt.status)
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/brands/"+root.Hex(), nil))
+			select {
+			case tags := <-purged:
+				if !reflect.DeepEqual(tags, tt.want) {
+					t.Errorf("purged %v, want %v", tags, tt.want)
+				}
+			case <-time.After(time.Second):
+				if tt.want != nil {
+					t.Errorf("didn't purge the CDN, want %v", tt.want)
+				}
+			}
+		})
+	}
+}
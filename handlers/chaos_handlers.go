@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/chaos"
+	"github.com/gin-gonic/gin"
+)
+
+// GetChaosStatus godoc
+// @Summary List injected faults
+// @Description Faults currently injected for chaos testing and when each expires. Only available with CHAOS_ENDPOINTS=true.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} chaos.Injection "Active faults"
+// @Router /admin/chaos [get]
+func GetChaosStatus(c *gin.Context) {
+	injections := chaos.Active()
+	respondList(c, http.StatusOK, injections, len(injections), nil)
+}
+
+// InjectChaos godoc
+// @Summary Inject a fault
+// @Description Makes a dependency fail for durationSeconds (at most an hour): health (the health check reports DOWN), extraction (PDF extraction unavailable), db_latency (latencyMs added to every MongoDB command) or error_rate (percent of API requests answered with 503). Injecting an active fault replaces it. Only available with CHAOS_ENDPOINTS=true.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param injection body chaos.InjectionRequest true "Fault to inject"
+// @Success 201 {object} chaos.Injection "Injected fault"
+// @Failure 400 {object} map[string]string "Invalid injection"
+// @Router /admin/chaos [post]
+func InjectChaos(c *gin.Context) {
+	var req chaos.InjectionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	injection, err := chaos.Inject(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, "chaos.inject", "Chaos fault '%s' injected until %s", injection.Fault, injection.ExpiresAt.Format("15:04:05"))
+	respond(c, http.StatusCreated, injection, nil)
+}
+
+// ClearChaos godoc
+// @Summary Clear injected faults
+// @Description Removes every injected fault immediately. Only available with CHAOS_ENDPOINTS=true.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]string "Faults cleared"
+// @Router /admin/chaos [delete]
+func ClearChaos(c *gin.Context) {
+	chaos.Clear()
+	audit(c, "chaos.clear", "Chaos faults cleared")
+	respond(c, http.StatusOK, gin.H{"message": "All injected faults cleared"}, nil)
+}
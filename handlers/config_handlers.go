@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/config"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetConfig godoc
+// @Summary Show effective configuration
+// @Description Returns the same configuration summary that is logged at startup: port, database, CORS origins, limits, feature flags, extractor and build version. Credentials in URIs and secret settings are redacted.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} config.Summary "Redacted configuration"
+// @Router /admin/config [get]
+func GetConfig(c *gin.Context) {
+	respond(c, http.StatusOK, config.BuildSummary(services.ExtractorVersion()), nil)
+}
+
+// ReloadConfig godoc
+// @Summary Reload tunable settings
+// @Description Re-reads the .env file and applies changed settings that can take effect without a restart (limits, CORS origins, feature flags, alert thresholds, ...), after validating them. Changes to other settings (MONGODB_URI, SERVER_PORT, ...) and invalid values are rejected and listed with the reason. Variables set in the process environment take precedence over the file and are never changed. Sending the process SIGHUP does the same.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} config.ReloadResult "Applied and rejected changes, values redacted"
+// @Failure 500 {object} map[string]string "The .env file could not be read"
+// @Router /admin/config/reload [post]
+func ReloadConfig(c *gin.Context) {
+	result, err := config.Reload()
+	if err != nil {
+		log.Printf("Error reloading configuration: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload configuration"})
+		return
+	}
+	if summary, err := json.Marshal(result); err == nil {
+		audit(c, "config.reload", "Configuration reloaded: %s", summary)
+	}
+	respond(c, http.StatusOK, result, nil)
+}
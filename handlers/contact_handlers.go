@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// contactError writes the response for an error from the contact services.
+func contactError(c *gin.Context, brandName string, err error) {
+	switch {
+	case errors.Is(err, services.ErrContactNotFound):
+		localizedError(c, http.StatusNotFound, codeContactNotFound, nil, nil)
+	case domainError(c, brandName, err):
+	default:
+		log.Printf("Error updating contacts for brand '%s': %v", services.LogValue(brandName), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error updating contacts"})
+	}
+}
+
+// contactIDParam parses :contactId, writing a 400 when it isn't a valid ID.
+func contactIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("contactId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// ListBrandContacts godoc
+// @Summary List a brand's contacts
+// @Description Internal contact directory for a brand (sales, logistics, escalation). Not included in public brand responses.
+// @Tags contacts
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Success 200 {array} models.Contact "Contacts"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Router /brands/{brandName}/contacts [get]
+func ListBrandContacts(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brandName := c.Param("brandName")
+	contacts, err := services.ListBrandContacts(ctx, coll, brandName)
+	if err != nil {
+		contactError(c, brandName, err)
+		return
+	}
+	respondList(c, http.StatusOK, contacts, len(contacts), nil)
+}
+
+// AddBrandContact godoc
+// @Summary Add a contact to a brand
+// @Description Adds a contact with a generated ID. Marking it preferred un-prefers the brand's previous preferred contact atomically. Example payloads: GET /examples/AddBrandContact
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param contact body models.ContactPayload true "Contact"
+// @Success 201 {object} models.Contact "Contact created"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Router /brands/{brandName}/contacts [post]
+func AddBrandContact(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brandName := c.Param("brandName")
+	var payload models.ContactPayload
+	if !bindJSON(c, &payload) {
+		return
+	}
+	contact, contacts, err := services.AddBrandContact(ctx, coll, brandName, payload)
+	if err != nil {
+		contactError(c, brandName, err)
+		return
+	}
+	respond(c, http.StatusCreated, contact, gin.H{"contactCount": len(contacts)})
+}
+
+// UpdateBrandContact godoc
+// @Summary Replace a brand contact
+// @Description Replaces the contact's fields, keeping its ID. Marking it preferred un-prefers the others atomically.
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param contactId path string true "Contact ID"
+// @Param contact body models.ContactPayload true "Contact"
+// @Success 200 {object} models.Contact "Contact updated"
+// @Failure 400 {object} map[string]string "Invalid input or contact ID"
+// @Failure 404 {object} map[string]string "Brand or contact not found"
+// @Router /brands/{brandName}/contacts/{contactId} [put]
+func UpdateBrandContact(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brandName := c.Param("brandName")
+	contactID, ok := contactIDParam(c)
+	if !ok {
+		return
+	}
+	var payload models.ContactPayload
+	if !bindJSON(c, &payload) {
+		return
+	}
+	contact, contacts, err := services.UpdateBrandContact(ctx, coll, brandName, contactID, payload)
+	if err != nil {
+		contactError(c, brandName, err)
+		return
+	}
+	respond(c, http.StatusOK, contact, gin.H{"contactCount": len(contacts)})
+}
+
+// RemoveBrandContact godoc
+// @Summary Remove a brand contact
+// @Tags contacts
+// @Param brandName path string true "Name of the brand"
+// @Param contactId path string true "Contact ID"
+// @Produce json
+// @Success 200 {object} map[string]string "Contact removed"
+// @Failure 400 {object} map[string]string "Invalid contact ID"
+// @Failure 404 {object} map[string]string "Brand or contact not found"
+// @Router /brands/{brandName}/contacts/{contactId} [delete]
+func RemoveBrandContact(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brandName := c.Param("brandName")
+	contactID, ok := contactIDParam(c)
+	if !ok {
+		return
+	}
+	if _, err := services.RemoveBrandContact(ctx, coll, brandName, contactID); err != nil {
+		contactError(c, brandName, err)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"message": "Contact removed successfully"}, nil)
+}
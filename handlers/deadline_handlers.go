@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/deadline"
+	"github.com/gin-gonic/gin"
+)
+
+// GetDeadlineStats godoc
+// @Summary Where request deadlines ran out
+// @Description Counts, per stage (journal, extraction, upsert), how often a request's deadline budget was too low to start or finish that stage, and when it last happened. Counts are kept in memory since the last restart. Budgets are set per route class by REQUEST_DEADLINE_SECONDS and UPLOAD_DEADLINE_SECONDS.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} deadline.StageStats "Exhaustions per stage"
+// @Router /admin/deadlines [get]
+func GetDeadlineStats(c *gin.Context) {
+	respond(c, http.StatusOK, deadline.Stats(), nil)
+}
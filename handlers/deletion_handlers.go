@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services/deletion"
+	"github.com/gin-gonic/gin"
+)
+
+// Page size limits for the deletion manifests
+const (
+	defaultDeletionLimit = 50
+	maxDeletionLimit     = 500
+)
+
+// ListDeletions godoc
+// @Summary Recent brand deletions
+// @Description Newest first. Each manifest lists what the deletion removed and the state of every step (document, tombstone, logo files, caches). Incomplete deletions are retried every DELETION_RETRY_SECONDS.
+// @Tags admin
+// @Produce json
+// @Param incomplete query bool false "Only deletions whose cleanup hasn't completed"
+// @Param limit query int false "Number of manifests (default 50, max 500)"
+// @Success 200 {array} models.DeletionManifest "Deletion manifests"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters, each listed under fields"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/deletions [get]
+func ListDeletions(c *gin.Context) {
+	q := queryParams(c)
+	incomplete := q.Bool("incomplete", false)
+	limit := q.Int("limit", defaultDeletionLimit, 1, maxDeletionLimit)
+	if !validQuery(c, q) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	manifests, err := deletion.List(ctx, database.GetCollection("brands"), incomplete, limit)
+	if err != nil {
+		log.Printf("Error listing brand deletions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list deletions"})
+		return
+	}
+	respondList(c, http.StatusOK, manifests, len(manifests), nil)
+}
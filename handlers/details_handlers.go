@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fragments are joined to existing details with a blank line between them
+const detailsSeparator = "\n\n"
+
+// How many times an append/prepend is retried when the brand changed concurrently
+const fragmentMaxAttempts = 3
+
+// maxDetailsBytes returns the configured maximum details size in bytes; CSV imports share it.
+var maxDetailsBytes = services.MaxDetailsBytes
+
+// detailsTooLarge answers 413 when details exceed MAX_DETAILS_BYTES and reports whether it did.
+// Manual writes are refused rather than cut, unlike extracted text.
+func detailsTooLarge(c *gin.Context, details string) bool {
+	limit := maxDetailsBytes()
+	if len(details) <= limit {
+		return false
+	}
+	localizedError(c, http.StatusRequestEntityTooLarge, codeDetailsTooLarge,
+		map[string]string{"size": strconv.Itoa(len(details)), "max": strconv.Itoa(limit)}, gin.H{"max": limit})
+	return true
+}
+
+// truncateUTF8 cuts s to at most maxBytes without splitting a multi-byte character.
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// formatFragment renders the fragment with its optional section heading.
+func formatFragment(payload models.DetailsFragmentPayload) string {
+	if section := strings.TrimSpace(payload.Section); section != "" {
+		return "[" + section + "]\n" + payload.Text
+	}
+	return payload.Text
+}
+
+// joinDetails combines existing details and a fragment, only adding the separator when both are non-empty.
+func joinDetails(first, second string) string {
+	if first == "" {
+		return second
+	}
+	if second == "" {
+		return first
+	}
+	return first + detailsSeparator + second
+}
+
+// AppendBrandDetails godoc
+// @Summary Append text to a brand's details
+// @Description Adds a text fragment (optionally under a section label) to the end of the existing details. Example payloads: GET /examples/AppendBrandDetails
+// @Tags brands
+// @Accept json
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param fragment body models.DetailsFragmentPayload true "Text to append"
+// @Param dryRun query bool false "Only report the resulting length without saving"
+// @Param writeConcern query string false "Write concern: majority, default or a node count (default WRITE_CONCERN)"
+// @Success 200 {object} models.Brand "Updated brand (or length report for dry runs)"
+// @Failure 400 {object} map[string]interface{} "Invalid input, or invalid query parameters listed under fields"
+// @Failure 422 {object} map[string]interface{} "Field has the wrong type"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 409 {object} map[string]string "Brand was modified concurrently"
+// @Failure 413 {object} map[string]string "Resulting details would exceed the maximum size (code DETAILS_RESULT_TOO_LARGE)"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/details/append [post]
+func AppendBrandDetails(c *gin.Context) {
+	applyDetailsFragment(c, false)
+}
+
+// PrependBrandDetails godoc
+// @Summary Prepend text to a brand's details
+// @Description Adds a text fragment (optionally under a section label) to the start of the existing details
+// @Tags brands
+// @Accept json
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param fragment body models.DetailsFragmentPayload true "Text to prepend"
+// @Param dryRun query bool false "Only report the resulting length without saving"
+// @Param writeConcern query string false "Write concern: majority, default or a node count (default WRITE_CONCERN)"
+// @Success 200 {object} models.Brand "Updated brand (or length report for dry runs)"
+// @Failure 400 {object} map[string]interface{} "Invalid input, or invalid query parameters listed under fields"
+// @Failure 422 {object} map[string]interface{} "Field has the wrong type"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 409 {object} map[string]string "Brand was modified concurrently"
+// @Failure 413 {object} map[string]string "Resulting details would exceed the maximum size (code DETAILS_RESULT_TOO_LARGE)"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/details/prepend [post]
+func PrependBrandDetails(c *gin.Context) {
+	applyDetailsFragment(c, true)
+}
+
+// applyDetailsFragment implements append/prepend. The new details are computed in Go (so keywords
+// can be recomputed) and written with a compare-and-swap on updatedAt, which makes the change atomic
+// with respect to concurrent writers; a lost race is retried a few times before giving up with 409.
+func applyDetailsFragment(c *gin.Context, prepend bool) {
+	if !featureflags.Enabled(featureflags.DetailsFragments) {
+		featureDisabled(c, featureflags.DetailsFragments)
+		return
+	}
+	q := queryParams(c)
+	dryRun := q.Bool("dryRun", false)
+	if !validQuery(c, q) {
+		return
+	}
+	coll, writeConcern, ok := withWriteConcern(c, database.GetCollection("brands"))
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brandName := c.Param("brandName")
+	var payload models.DetailsFragmentPayload
+	if !bindJSON(c, &payload) {
+		return
+	}
+	fragment := formatFragment(payload)
+	limit := maxDetailsBytes()
+
+	for attempt := 1; attempt <= fragmentMaxAttempts; attempt++ {
+		var current models.Brand
+		err := coll.FindOne(ctx, bson.M{"name": brandName}).Decode(&current)
+		if err != nil {
+			if !domainError(c, brandName, apperrors.FromDB(err)) {
+				log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+				localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+			}
+			return
+		}
+
+		newDetails := joinDetails(current.Details, fragment)
+		if prepend {
+			newDetails = joinDetails(fragment, current.Details)
+		}
+
+		if dryRun {
+			respond(c, http.StatusOK, gin.H{
+				"dryRun":        true,
+				"currentLength": len(current.Details),
+				"resultLength":  len(newDetails),
+				"maxLength":     limit,
+				"exceedsLimit":  len(newDetails) > limit,
+			}, nil)
+			return
+		}
+		if len(newDetails) > limit {
+			localizedError(c, http.StatusRequestEntityTooLarge, codeDetailsResultTooLarge,
+				map[string]string{"size": strconv.Itoa(len(newDetails)), "max": strconv.Itoa(limit)}, gin.H{"max": limit})
+			return
+		}
+
+		// Only apply if nobody else updated the brand since we read it
+		filter := bson.M{"_id": current.ID, "updatedAt": current.UpdatedAt}
+		update := bson.M{
+			"$set": bson.M{
+				"details":   newDetails,
+				"keywords":  services.ExtractKeywords(newDetails),
+				"sections":  services.SplitDetailsSections(newDetails),
+				"updatedAt": models.Now(),
+			},
+			"$unset": bson.M{"extraction": ""}, // Details are no longer the output of a PDF extraction
+		}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+		ack := writeAck{Matched: 1, Modified: 1, WriteConcern: writeConcern}
+		var updatedBrand models.Brand
+		err = coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedBrand)
+		if msg, wcOnly := writeConcernOnly(err); wcOnly {
+			// Applied but not acknowledged as requested: return the stored state
+			ack.WriteConcernError = msg
+			if err = coll.FindOne(ctx, bson.M{"_id": current.ID}).Decode(&updatedBrand); err != nil {
+				// Don't retry: the fragment is already applied
+				log.Printf("Error re-reading brand '%s' after update: %v", services.LogValue(brandName), err)
+				localizedError(c, http.StatusInternalServerError, codeDetailsRereadFailed, nil, nil)
+				return
+			}
+		}
+		if err == nil {
+			respondBrand(c, http.StatusOK, &updatedBrand, gin.H{"ack": ack})
+			return
+		}
+		if err = apperrors.FromDB(err); !errors.Is(err, apperrors.ErrNotFound) {
+			if !domainError(c, brandName, err) {
+				log.Printf("Error updating details for brand '%s': %v", services.LogValue(brandName), err)
+				localizedError(c, http.StatusInternalServerError, codeDetailsUpdateFailed, nil, nil)
+			}
+			return
+		}
+		// Lost the race (or the brand was deleted); re-read and try again
+	}
+
+	localizedError(c, http.StatusConflict, codeBrandModified, map[string]string{"name": brandName}, nil)
+}
+
+// loadDetailsSections loads a brand and its details sections, writing the error response and
+// returning false when that fails.
+func loadDetailsSections(c *gin.Context, brandName string) (*models.Brand, []models.DetailsSection, bool) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, err := services.GetBrandByName(ctx, coll, brandName)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return nil, nil, false
+	}
+	return brand, services.DetailsSectionsOf(brand), true
+}
+
+// ListDetailsSections godoc
+// @Summary List the sections of a brand's details
+// @Description Long details are split into sections at heading-like lines (markdown headings, numbered, capitalized or colon-terminated lines) whenever they change, so clients can show a table of contents and load one section at a time. Section IDs are derived from the section's text: a section keeps its ID across re-uploads as long as its text is unchanged. Offsets and lengths are in bytes of the details, which stay authoritative.
+// @Tags brands
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Success 200 {array} models.DetailsSection "Sections in order; empty when the details are"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/details/sections [get]
+func ListDetailsSections(c *gin.Context) {
+	brandName := c.Param("brandName")
+	_, sections, ok := loadDetailsSections(c, brandName)
+	if !ok {
+		return
+	}
+	if sections == nil {
+		sections = []models.DetailsSection{}
+	}
+	respondList(c, http.StatusOK, sections, len(sections), nil)
+}
+
+// GetDetailsSection godoc
+// @Summary Get one section of a brand's details
+// @Description Returns the text of one section listed by GET /brands/{brandName}/details/sections, heading included.
+// @Tags brands
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param sectionId path string true "Section ID"
+// @Success 200 {object} models.DetailsSectionText "The section"
+// @Failure 404 {object} map[string]string "Brand or section not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/details/sections/{sectionId} [get]
+func GetDetailsSection(c *gin.Context) {
+	brandName := c.Param("brandName")
+	sectionID := c.Param("sectionId")
+	brand, sections, ok := loadDetailsSections(c, brandName)
+	if !ok {
+		return
+	}
+	for _, section := range sections {
+		if section.ID == sectionID {
+			respond(c, http.StatusOK, models.DetailsSectionText{
+				ID:    section.ID,
+				Title: section.Title,
+				Text:  brand.Details[section.Offset : section.Offset+section.Length],
+			}, nil)
+			return
+		}
+	}
+	localizedError(c, http.StatusNotFound, codeSectionNotFound, map[string]string{"name": brandName, "id": sectionID}, nil)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		s        string
+		maxBytes int
+		want     string
+	}{
+		{"", 0, ""},
+		{"", 5, ""},
+		{"abc", 0, ""},
+		{"abc", 2, "ab"},
+		{"abc", 3, "abc"},
+		{"abc", 10, "abc"},
+		{"aé", 2, "a"}, // é is 2 bytes
+		{"aé", 3, "aé"},
+		{"€uro", 1, ""}, // € is 3 bytes
+		{"€uro", 2, ""},
+		{"€uro", 3, "€"},
+		{"a😀b", 4, "a"}, // 😀 is 4 bytes
+		{"a😀b", 5, "a😀"},
+		{"a\xffb", 2, "a\xff"}, // Invalid bytes are kept as they are
+	}
+	for _, tt := range tests {
+		if got := truncateUTF8(tt.s, tt.maxBytes); got != tt.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.s, tt.maxBytes, got, tt.want)
+		}
+	}
+}
+
+// Cutting valid text anywhere keeps it valid and as long as possible.
+func TestTruncateUTF8KeepsValidText(t *testing.T) {
+	s := strings.Repeat("Größe 42 € 😀 ", 4)
+	for maxBytes := 0; maxBytes <= len(s); maxBytes++ {
+		got := truncateUTF8(s, maxBytes)
+		switch {
+		case !utf8.ValidString(got):
+			t.Fatalf("truncateUTF8 at %d bytes returns invalid UTF-8 %q", maxBytes, got)
+		case len(got) > maxBytes || !strings.HasPrefix(s, got):
+			t.Fatalf("truncateUTF8 at %d bytes = %q", maxBytes, got)
+		case maxBytes-len(got) >= utf8.UTFMax:
+			t.Fatalf("truncateUTF8 at %d bytes cut %d bytes more than needed", maxBytes, maxBytes-len(got))
+		}
+	}
+}
+
+// Manual writes over MAX_DETAILS_BYTES are refused before touching the database.
+func TestDetailsTooLarge(t *testing.T) {
+	t.Setenv("MAX_DETAILS_BYTES", "10")
+	tests := []struct {
+		name      string
+		method    string
+		handler   gin.HandlerFunc
+		body      string
+		language  string // Accept-Language
+		wantError string
+	}{
+		{"POST", http.MethodPost, CreateBrandManual, `{"name":"Acme","details":"eleven bytes"}`, "",
+			"Details are 12 bytes, exceeding the maximum of 10"},
+		{"PUT", http.MethodPut, UpdateBrandManual, `{"details":"eleven bytes"}`, "",
+			"Details are 12 bytes, exceeding the maximum of 10"},
+		{"PATCH", http.MethodPatch, PatchBrand, `{"details":"eleven bytes"}`, "",
+			"Details are 12 bytes, exceeding the maximum of 10"},
+		{"PUT in German", http.MethodPut, UpdateBrandManual, `{"details":"eleven bytes"}`, "de-AT,en;q=0.5",
+			"Die Details sind 12 Bytes groß und überschreiten das Maximum von 10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(tt.method, "/brands/Acme?writeConcern=default", tt.body)
+			c.Params = gin.Params{{Key: "brandName", Value: "Acme"}}
+			c.Request.Header.Set("Accept-Language", tt.language)
+			tt.handler(c)
+			body := decodeResponse(t, w)
+			if w.Code != http.StatusRequestEntityTooLarge || body["max"] != 10.0 || body["code"] != codeDetailsTooLarge {
+				t.Errorf("got %d %s, want 413 %s", w.Code, w.Body.String(), codeDetailsTooLarge)
+			}
+			if body["error"] != tt.wantError {
+				t.Errorf("error = %q, want %q", body["error"], tt.wantError)
+			}
+		})
+	}
+}
+
+// Appending or prepending changes the details, so like PUT and PATCH they drop the diagnostics of
+// the PDF extraction the old details came from.
+func TestDetailsFragmentUnsetsExtraction(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		want    string
+	}{
+		{"append", AppendBrandDetails, "Terms\n\nMore"},
+		{"prepend", PrependBrandDetails, "More\n\nTerms"},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			database.Use(mt.Client)
+			brand := bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "name", Value: "Acme"},
+				{Key: "details", Value: "Terms"},
+				{Key: "updatedAt", Value: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+				{Key: "extraction", Value: bson.D{{Key: "engine", Value: "pdftotext"}}},
+			}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch, brand),
+				mtest.CreateSuccessResponse(bson.E{Key: "value", Value: brand}),
+			)
+			c, w := testContext(http.MethodPost, "/brands/Acme/details/"+tt.name, `{"text":"More"}`)
+			c.Params = gin.Params{{Key: "brandName", Value: "Acme"}}
+			tt.handler(c)
+			if w.Code != http.StatusOK {
+				mt.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+
+			mt.GetStartedEvent() // The find
+			update := mt.GetStartedEvent().Command.Lookup("update").Document()
+			if details := update.Lookup("$set", "details").StringValue(); details != tt.want {
+				mt.Errorf("details %q, want %q", details, tt.want)
+			}
+			if _, err := update.LookupErr("$unset", "extraction"); err != nil {
+				mt.Errorf("update %s keeps the extraction diagnostics", update)
+			}
+		})
+	}
+}
+
+// dryRun takes the same boolean spellings as every other flag; anything else is refused before
+// the brand is read, let alone written.
+func TestDetailsFragmentDryRunFlag(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantWrite  bool
+	}{
+		{"dryRun=yes", http.StatusBadRequest, false},
+		{"dryRun=1", http.StatusOK, false},
+		{"dryRun=true", http.StatusOK, false},
+		{"dryRun=false", http.StatusOK, true},
+		{"", http.StatusOK, true},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.query, func(mt *mtest.T) {
+			database.Use(mt.Client)
+			brand := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Acme"}, {Key: "details", Value: "Terms"}}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch, brand),
+				mtest.CreateSuccessResponse(bson.E{Key: "value", Value: brand}),
+			)
+			c, w := testContext(http.MethodPost, "/brands/Acme/details/append?"+tt.query, `{"text":"More"}`)
+			c.Params = gin.Params{{Key: "brandName", Value: "Acme"}}
+			AppendBrandDetails(c)
+			if w.Code != tt.wantStatus {
+				mt.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest {
+				if body := decodeResponse(mt.T, w); body["code"] != codeInvalidQuery {
+					mt.Errorf("code %v, want %s", body["code"], codeInvalidQuery)
+				}
+			}
+
+			var commands []string
+			for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+				commands = append(commands, event.CommandName)
+			}
+			wrote := len(commands) > 0 && commands[len(commands)-1] == "findAndModify"
+			if wrote != tt.wantWrite || (tt.wantStatus == http.StatusBadRequest && len(commands) > 0) {
+				mt.Errorf("sent %v, want a write: %v", commands, tt.wantWrite)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/config"
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/gin-gonic/gin"
+)
+
+// relayPage is loaded in a hidden iframe by portals that can't make cross-origin API calls. It
+// accepts {id, method, path, body, key} messages from the allowed origins, performs the request
+// from the API's own origin (so no CORS is involved) with key as the bearer API key, and posts
+// {id, status, body} back. The host passes a key scoped to what the portal may do, e.g. brands:write.
+const relayPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Order form relay</title></head>
+<body><script>
+(function () {
+  var allowed = __ORIGINS__;
+  window.addEventListener("message", function (event) {
+    if (allowed.indexOf(event.origin) < 0) return;
+    var msg = event.data || {};
+    var method = String(msg.method || "POST").toUpperCase();
+    if (method !== "POST" || typeof msg.path !== "string" || msg.path.indexOf("/api/v1/") !== 0) {
+      event.source.postMessage({ id: msg.id, status: 400, body: { error: "Only POST requests to /api/v1/ can be relayed" } }, event.origin);
+      return;
+    }
+    var headers = { "Content-Type": "application/json" };
+    if (typeof msg.key === "string" && msg.key !== "") headers.Authorization = "Bearer " + msg.key;
+    fetch(msg.path, { method: method, headers: headers, body: JSON.stringify(msg.body || {}) })
+      .then(function (res) {
+        return res.text().then(function (text) {
+          var body = text;
+          try { body = JSON.parse(text); } catch (e) {}
+          event.source.postMessage({ id: msg.id, status: res.status, body: body }, event.origin);
+        });
+      })
+      .catch(function (err) {
+        event.source.postMessage({ id: msg.id, status: 0, body: { error: String(err) } }, event.origin);
+      });
+  });
+  if (window.parent !== window) {
+    window.parent.postMessage({ relay: "ready" }, "*");
+  }
+})();
+</script></body></html>`
+
+// EmbedRelay godoc
+// @Summary Iframe relay for embedded order forms
+// @Description HTML page that forwards postMessage submissions from the CORS-allowed origins to the API, authenticated with the API key passed in each message. Only available with the embedded_mode flag.
+// @Tags embed
+// @Produce html
+// @Success 200 {string} string "Relay page"
+// @Failure 404 {object} map[string]string "Embedded mode is disabled"
+// @Router /embed/relay [get]
+func EmbedRelay(c *gin.Context) {
+	if !featureflags.Enabled(featureflags.EmbeddedMode) {
+		featureDisabled(c, featureflags.EmbeddedMode)
+		return
+	}
+	origins := config.CORSOrigins()
+	encoded, err := json.Marshal(origins) // Escapes <, > and & so it can't close the script tag
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render relay page"})
+		return
+	}
+	// Only the portals we'd answer CORS requests for may frame the relay
+	ancestors := strings.Join(origins, " ")
+	if ancestors == "" {
+		ancestors = "'none'"
+	}
+	c.Header("Content-Security-Policy", "frame-ancestors "+ancestors)
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(strings.Replace(relayPage, "__ORIGINS__", string(encoded), 1)))
+}
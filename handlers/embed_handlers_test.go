@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+)
+
+func TestEmbedRelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		embedded   bool
+		origins    string
+		wantStatus int
+		wantCSP    string
+		wantList   string // The allowed origins as embedded in the page
+	}{
+		{"disabled", false, "", http.StatusNotFound, "", ""},
+		{"one origin", true, "https://portal.example", http.StatusOK,
+			"frame-ancestors https://portal.example", `["https://portal.example"]`},
+		{"several origins", true, "https://a.example, https://b.example", http.StatusOK,
+			"frame-ancestors https://a.example https://b.example", `["https://a.example","https://b.example"]`},
+		{"no usable origin", true, " , ", http.StatusOK, "frame-ancestors 'none'", "null"},
+		{"markup is escaped", true, "https://x.example</script>", http.StatusOK,
+			"frame-ancestors https://x.example</script>", `["https://x.example\u003c/script\u003e"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { featureflags.Init(nil) })
+			if tt.embedded {
+				t.Setenv("FEATURES", featureflags.EmbeddedMode)
+			}
+			featureflags.Init(nil)
+			t.Setenv("CORS_ALLOWED_ORIGINS", tt.origins)
+
+			c, w := testContext(http.MethodGet, "/embed/relay", "")
+			EmbedRelay(c)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if csp := w.Header().Get("Content-Security-Policy"); csp != tt.wantCSP {
+				t.Errorf("Content-Security-Policy = %q, want %q", csp, tt.wantCSP)
+			}
+			if !strings.Contains(w.Body.String(), "var allowed = "+tt.wantList+";") {
+				t.Errorf("page does not allow %s", tt.wantList)
+			}
+			if !strings.Contains(w.Body.String(), `headers.Authorization = "Bearer " + msg.key`) {
+				t.Error("page does not send the message's API key")
+			}
+		})
+	}
+}
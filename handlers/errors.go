@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/deadline"
+	"github.com/Gautam3767/Order_form_Details_Backend/i18n"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Error codes with a translated message in the i18n catalogs. The code is returned unchanged in
+// every locale so clients can branch on it; only "error" is localized.
+const (
+	codeBrandNotFound         = "BRAND_NOT_FOUND"
+	codeBrandExists           = "BRAND_ALREADY_EXISTS"
+	codeBrandModified         = "BRAND_MODIFIED_CONCURRENTLY"
+	codeBrandListFailed       = "BRAND_LIST_FAILED"
+	codeBrandReadFailed       = "BRAND_READ_FAILED"
+	codeBrandCheckFailed      = "BRAND_CHECK_FAILED"
+	codeBrandCreateFailed     = "BRAND_CREATE_FAILED"
+	codeBrandUpdateFailed     = "BRAND_UPDATE_FAILED"
+	codeBrandDeleteFailed     = "BRAND_DELETE_FAILED"
+	codeBrandDecodeFailed     = "BRAND_DECODE_FAILED"
+	codeUnsupportedLocale     = "UNSUPPORTED_LOCALE"
+	codeInvalidKeywordTerm    = "INVALID_KEYWORD_TERM"
+	codeDetailsTooLarge       = "DETAILS_TOO_LARGE"
+	codeDetailsResultTooLarge = "DETAILS_RESULT_TOO_LARGE"
+	codeDetailsRereadFailed   = "DETAILS_REREAD_FAILED"
+	codeDetailsUpdateFailed   = "DETAILS_UPDATE_FAILED"
+	codeContactNotFound       = "CONTACT_NOT_FOUND"
+	codeFeatureDisabled       = "FEATURE_DISABLED"
+	codeInvalidInput          = "INVALID_INPUT"
+	codeNothingToChange       = "NOTHING_TO_CHANGE"
+	codeEmptyBody             = "EMPTY_BODY"
+	codeBodyTooLarge          = "BODY_TOO_LARGE"
+	codeBodyUnreadable        = "BODY_UNREADABLE"
+	codeInvalidJSON           = "INVALID_JSON"
+	codeWrongFieldType        = "WRONG_FIELD_TYPE"
+	codeUnknownFields         = "UNKNOWN_FIELDS"
+	codeExtractionUnavailable = "EXTRACTION_UNAVAILABLE"
+	codePDFParseFailed        = "PDF_PARSE_FAILED"
+	codeMissingFormField      = "MISSING_FORM_FIELD"
+	codeMissingFile           = "MISSING_FILE"
+	codeUploadOpenFailed      = "UPLOAD_OPEN_FAILED"
+	codeUploadSaveFailed      = "UPLOAD_SAVE_FAILED"
+	codeUploadFailed          = "UPLOAD_FAILED"
+	codeImportNotCSV          = "IMPORT_NOT_CSV"
+	codeImportInvalid         = "IMPORT_INVALID_FILE"
+	codeImportAborted         = "IMPORT_ABORTED"
+	codeDatabaseUnavailable   = "DATABASE_UNAVAILABLE"
+	codeInvalidQuery          = "INVALID_QUERY"
+	codeDeadlineExceeded      = "DEADLINE_EXCEEDED"
+	codeBrandHasChildren      = "BRAND_HAS_CHILDREN"
+	codeAuditExportOff        = "AUDIT_EXPORT_NOT_CONFIGURED"
+	codeAuditReplayRunning    = "AUDIT_REPLAY_RUNNING"
+	codeExampleNotFound       = "EXAMPLE_NOT_FOUND"
+	codeBulkTooLarge          = "BULK_TOO_LARGE"
+	codeSectionNotFound       = "SECTION_NOT_FOUND"
+)
+
+// requestLocale returns the catalog locale negotiated from the request's Accept-Language header.
+func requestLocale(c *gin.Context) string {
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// localizedError writes {"error": <message in the request's language>, "code": code} plus any
+// extra fields. params fill the message's {placeholders}.
+func localizedError(c *gin.Context, status int, code string, params map[string]string, extra gin.H) {
+	body := gin.H{}
+	for key, value := range extra {
+		body[key] = value
+	}
+	body["error"] = i18n.Message(requestLocale(c), code, params)
+	body["code"] = code
+	c.JSON(status, body)
+}
+
+// brandNotFound writes the standard 404 for a missing brand.
+func brandNotFound(c *gin.Context, brandName string) {
+	localizedError(c, http.StatusNotFound, codeBrandNotFound, map[string]string{"name": brandName}, nil)
+}
+
+// domainError writes the response for a domain error (see apperrors) from an operation on
+// brandName and reports whether it did. Anything else is left to the caller, which logs it with
+// its own context and answers 500.
+func domainError(c *gin.Context, brandName string, err error) bool {
+	var validation *apperrors.ValidationError
+	var exhausted *deadline.ExhaustedError
+	switch {
+	case errors.As(err, &exhausted):
+		// Before ErrUnavailable, which FromDB also makes of an expired context
+		log.Printf("Warning: Request for brand '%s' ran out of time: %v", services.LogValue(brandName), err)
+		localizedError(c, http.StatusGatewayTimeout, codeDeadlineExceeded, map[string]string{"stage": exhausted.Stage}, gin.H{"stage": exhausted.Stage})
+	case errors.Is(err, apperrors.ErrNotFound):
+		brandNotFound(c, brandName)
+	case errors.Is(err, apperrors.ErrAlreadyExists):
+		localizedError(c, http.StatusConflict, codeBrandExists, map[string]string{"name": brandName}, nil)
+	case errors.Is(err, apperrors.ErrConflictVersion):
+		localizedError(c, http.StatusConflict, codeBrandModified, map[string]string{"name": brandName}, nil)
+	case errors.As(err, &validation):
+		fields := make([]gin.H, 0, len(validation.Fields))
+		for field, message := range validation.Fields {
+			fields = append(fields, gin.H{"field": field, "message": message})
+		}
+		localizedError(c, http.StatusBadRequest, codeInvalidInput, nil, gin.H{"fields": fields})
+	case errors.Is(err, apperrors.ErrValidation):
+		localizedError(c, http.StatusBadRequest, codeInvalidInput, nil, nil)
+	case errors.Is(err, apperrors.ErrUnavailable):
+		log.Printf("Error: Database unavailable for brand '%s': %v", services.LogValue(brandName), err)
+		localizedError(c, http.StatusServiceUnavailable, codeDatabaseUnavailable, nil, nil)
+	default:
+		return false
+	}
+	return true
+}
+
+// validationMessages turns binding validation errors into one localized entry per field, e.g.
+// {"field": "email", "rule": "email", "message": "..."}. ok is false for other errors.
+func validationMessages(c *gin.Context, err error) ([]gin.H, bool) {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil, false
+	}
+	locale := requestLocale(c)
+	fields := make([]gin.H, 0, len(errs))
+	for _, fe := range errs {
+		field := lowerFirst(fe.Field())
+		code := "VALIDATION_" + strings.ToUpper(fe.Tag())
+		switch fe.Tag() {
+		case "required", "email", "oneof":
+		default:
+			code = "VALIDATION_INVALID"
+		}
+		fields = append(fields, gin.H{
+			"field":   field,
+			"rule":    fe.Tag(),
+			"message": i18n.Message(locale, code, map[string]string{"field": field, "param": fe.Param()}),
+		})
+	}
+	return fields, true
+}
+
+// requiredFieldError writes the same 400 a failed "required" binding tag produces, for payload
+// fields whose presence can't be expressed as a tag (see models.OptionalString).
+func requiredFieldError(c *gin.Context, field string) {
+	localizedError(c, http.StatusBadRequest, codeInvalidInput, nil, gin.H{"fields": []gin.H{{
+		"field":   field,
+		"rule":    "required",
+		"message": i18n.Message(requestLocale(c), "VALIDATION_REQUIRED", map[string]string{"field": field}),
+	}}})
+}
+
+// lowerFirst maps a Go field name to the JSON name our payloads use ("Name" -> "name").
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
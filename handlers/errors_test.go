@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+)
+
+func TestDomainError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"not found", fmt.Errorf("%w: lookup", apperrors.ErrNotFound), http.StatusNotFound, codeBrandNotFound},
+		{"already exists", apperrors.ErrAlreadyExists, http.StatusConflict, codeBrandExists},
+		{"version conflict", apperrors.ErrConflictVersion, http.StatusConflict, codeBrandModified},
+		{"validation fields", &apperrors.ValidationError{Fields: map[string]string{"name": "required"}}, http.StatusBadRequest, codeInvalidInput},
+		{"validation", apperrors.ErrValidation, http.StatusBadRequest, codeInvalidInput},
+		{"unavailable", apperrors.ErrUnavailable, http.StatusServiceUnavailable, codeDatabaseUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(http.MethodGet, "/brands/Acme", "")
+			if !domainError(c, "Acme", tt.err) {
+				t.Fatalf("domainError(%v) = false, want true", tt.err)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if body := decodeResponse(t, w); body["code"] != tt.wantCode {
+				t.Errorf("code = %v, want %s", body["code"], tt.wantCode)
+			}
+		})
+	}
+
+	c, w := testContext(http.MethodGet, "/brands/Acme", "")
+	if domainError(c, "Acme", errors.New("boom")) {
+		t.Errorf("domainError handled a non-domain error")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("domainError wrote %q for a non-domain error", w.Body.String())
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/examples"
+	"github.com/gin-gonic/gin"
+)
+
+// ListExamples godoc
+// @Summary List the endpoints with examples
+// @Description Endpoints with curated example requests and responses, for API consoles and client developers. GET /examples/{endpointName} returns one.
+// @Tags examples
+// @Produce json
+// @Success 200 {array} examples.Example "Every example"
+// @Router /examples [get]
+func ListExamples(c *gin.Context) {
+	all := examples.All()
+	respondList(c, http.StatusOK, all, len(all), nil)
+}
+
+// GetExample godoc
+// @Summary Get example payloads for one endpoint
+// @Description A valid example request for the endpoint (named after its handler, e.g. CreateBrandManual) and an example response for success and for each error code it documents. Error examples that depend on the body carry the request that produces them. The handler tests check the examples against the request payloads.
+// @Tags examples
+// @Produce json
+// @Param endpointName path string true "Endpoint (handler) name, e.g. CreateBrandManual"
+// @Success 200 {object} examples.Example "Example requests and responses"
+// @Failure 404 {object} map[string]string "No examples for that endpoint"
+// @Router /examples/{endpointName} [get]
+func GetExample(c *gin.Context) {
+	name := c.Param("endpointName")
+	example, ok := examples.Get(name)
+	if !ok {
+		localizedError(c, http.StatusNotFound, codeExampleNotFound, map[string]string{"name": name}, nil)
+		return
+	}
+	respond(c, http.StatusOK, example, nil)
+}
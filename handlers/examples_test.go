@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/examples"
+	"github.com/Gautam3767/Order_form_Details_Backend/i18n"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+// exampleBodies maps the endpoints with an example request to the payload their handler binds.
+var exampleBodies = map[string]func() interface{}{
+	"CreateBrandManual":  func() interface{} { return &models.CreateBrandPayload{} },
+	"UpdateBrandManual":  func() interface{} { return &models.UpdateBrandPayload{} },
+	"PatchBrand":         func() interface{} { return &models.PatchBrandPayload{} },
+	"AppendBrandDetails": func() interface{} { return &models.DetailsFragmentPayload{} },
+	"AddBrandContact":    func() interface{} { return &models.ContactPayload{} },
+	"UpdateBrandSource":  func() interface{} { return &models.SourceRefreshPayload{} },
+}
+
+// bindingCodes are the error codes bindJSON itself produces; their documented bodies must match
+// its response exactly.
+var bindingCodes = map[string]bool{
+	codeWrongFieldType: true,
+	codeUnknownFields:  true,
+	codeInvalidInput:   true,
+}
+
+// bindExample runs request through bindJSON in strict mode, like a client that sends
+// X-Strict-Validation: true, and returns whether it was accepted and the error response if not.
+// The request is sent compacted, which is what documented offsets refer to.
+func bindExample(request json.RawMessage, obj interface{}) (bool, string) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, request); err != nil {
+		return false, err.Error()
+	}
+	c, w := testContext(http.MethodPost, "/", compact.String())
+	c.Request.Header.Set(strictHeader, "true")
+	return bindJSON(c, obj), w.Body.String()
+}
+
+// TestExamples checks the embedded examples (see the examples package) against the payloads the
+// handlers bind: every example request must be accepted, and every documented binding error
+// must be exactly what bindJSON answers for its request. Error codes must exist in the i18n
+// catalogs.
+func TestExamples(t *testing.T) {
+	for _, example := range examples.All() {
+		t.Run(example.Endpoint, func(t *testing.T) {
+			newBody, hasBody := exampleBodies[example.Endpoint]
+			if example.Request != nil && !hasBody {
+				t.Fatal("has a request but no payload is registered for it")
+			}
+			if hasBody {
+				if ok, body := bindExample(example.Request, newBody()); !ok {
+					t.Errorf("request is rejected: %s", body)
+				}
+			}
+			for _, response := range example.Responses {
+				t.Run(fmt.Sprintf("%d %s", response.Status, response.Code), func(t *testing.T) {
+					checkExampleResponse(t, response, newBody)
+				})
+			}
+		})
+	}
+}
+
+// checkExampleResponse checks one documented response; newBody is nil for endpoints without one.
+func checkExampleResponse(t *testing.T, response examples.Response, newBody func() interface{}) {
+	var documented map[string]interface{}
+	if err := json.Unmarshal(response.Body, &documented); err != nil && response.Code != "" {
+		t.Fatalf("error body is not an object: %v", err)
+	}
+	if code, _ := documented["code"].(string); code != response.Code {
+		t.Fatalf("body has code %q", code)
+	}
+	if response.Code != "" && !i18n.Has(response.Code) {
+		t.Fatal("code has no message in the i18n catalogs")
+	}
+	if response.Request == nil {
+		return
+	}
+	if newBody == nil {
+		t.Fatal("has a request but the endpoint takes no body")
+	}
+
+	ok, body := bindExample(response.Request, newBody())
+	if !bindingCodes[response.Code] {
+		// The request is valid; the error comes from the brand's state (e.g. it already exists)
+		if !ok {
+			t.Errorf("request is rejected: %s", body)
+		}
+		return
+	}
+	var actual map[string]interface{}
+	if ok || json.Unmarshal([]byte(body), &actual) != nil {
+		t.Fatalf("request is accepted or answered with %q", body)
+	}
+	if !reflect.DeepEqual(actual, documented) {
+		got, _ := json.Marshal(actual)
+		t.Errorf("bindJSON answers %s", got)
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Content types of the brand sheet export formats.
+const (
+	markdownContentType = "text/markdown; charset=utf-8"
+	docxContentType     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// ExportBrandSheet godoc
+// @Summary Export a brand sheet
+// @Description Download a formatted brand sheet as Markdown or Word. The sections and their order come from BRAND_EXPORT_SECTIONS (metadata, specs, details, keywords; default metadata,specs,details); sections the brand has no data for are left out.
+// @Tags brands
+// @Produce text/markdown
+// @Produce application/vnd.openxmlformats-officedocument.wordprocessingml.document
+// @Param brandName path string true "Name of the brand"
+// @Param format query string false "md (default) or docx"
+// @Success 200 {file} binary "Brand sheet as an attachment"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters, each listed under fields"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/export [get]
+func ExportBrandSheet(c *gin.Context) {
+	q := queryParams(c)
+	format := q.Enum("format", "md", "md", "docx")
+	if !validQuery(c, q) {
+		return
+	}
+
+	coll := database.GetCollection("brands")
+	brandName := c.Param("brandName")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, err := services.GetBrandByName(ctx, coll, brandName)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return
+	}
+
+	sheet := services.BuildBrandSheet(brand, services.ExportSections())
+	var body []byte
+	contentType := markdownContentType
+	if format == "docx" {
+		contentType = docxContentType
+		if body, err = services.RenderSheetDOCX(sheet); err != nil {
+			log.Printf("Error rendering DOCX for brand '%s': %v", services.LogValue(brandName), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export brand"})
+			return
+		}
+	} else {
+		body = services.RenderSheetMarkdown(sheet)
+	}
+
+	// FormatMediaType switches to the RFC 2231 filename* form for names that need it
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": brand.Name + "." + format})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	c.Header("Content-Disposition", disposition)
+	c.Data(http.StatusOK, contentType, body)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/gin-gonic/gin"
+)
+
+// featureDisabled writes the standard response for endpoints switched off by a feature flag.
+func featureDisabled(c *gin.Context, flag string) {
+	localizedError(c, http.StatusNotFound, codeFeatureDisabled, nil, gin.H{"feature": flag})
+}
+
+// ListFeatures godoc
+// @Summary List feature flags
+// @Description Shows every known feature flag with its effective state and where that state comes from (default, env, override)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} featureflags.Flag "Feature flags"
+// @Router /admin/features [get]
+func ListFeatures(c *gin.Context) {
+	list := featureflags.List()
+	respondList(c, http.StatusOK, list, len(list), nil)
+}
+
+// UpdateFeaturesPayload sets (true/false) or clears (null) runtime overrides by flag name
+type UpdateFeaturesPayload struct {
+	Flags map[string]*bool `json:"flags" binding:"required"`
+}
+
+// UpdateFeatures godoc
+// @Summary Change feature flag overrides
+// @Description Sets or clears (null) runtime overrides for known feature flags; unknown names are rejected
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param flags body UpdateFeaturesPayload true "Overrides to apply"
+// @Success 200 {array} featureflags.Flag "Feature flags after the change"
+// @Failure 400 {object} map[string]string "Invalid input or unknown flag"
+// @Failure 422 {object} map[string]interface{} "Field has the wrong type"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/features [put]
+func UpdateFeatures(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var payload UpdateFeaturesPayload
+	if !bindJSON(c, &payload) {
+		return
+	}
+
+	if err := featureflags.SetOverrides(ctx, payload.Flags); err != nil {
+		if unknown, ok := err.(*featureflags.UnknownFlagError); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": unknown.Error(), "unknown": unknown.Names})
+			return
+		}
+		log.Printf("Error updating feature flags: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update feature flags"})
+		return
+	}
+
+	// Audit trail: who changed what
+	for name, value := range payload.Flags {
+		state := "cleared"
+		if value != nil && *value {
+			state = "enabled"
+		} else if value != nil {
+			state = "disabled"
+		}
+		audit(c, "feature.override", "feature flag '%s' override %s", name, state)
+	}
+
+	list := featureflags.List()
+	respondList(c, http.StatusOK, list, len(list), nil)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetBrandChildren godoc
+// @Summary List the sub-brands of a brand
+// @Description Returns the brands whose parentBrand is this brand, sorted by name. Only direct children are listed; follow each one for deeper levels.
+// @Tags brands
+// @Produce json
+// @Param brandName path string true "Name of the parent brand"
+// @Success 200 {array} services.BrandRef "Direct sub-brands"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/children [get]
+func GetBrandChildren(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	brandName := c.Param("brandName")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, err := services.GetBrandByName(ctx, coll, brandName)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return
+	}
+	children, err := services.BrandChildren(ctx, coll, brand.ID)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error listing sub-brands of '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		}
+		return
+	}
+	respondList(c, http.StatusOK, children, len(children), nil)
+}
+
+// GetBrandAncestry godoc
+// @Summary List the parent brands of a brand
+// @Description Returns the chain of parent brands, nearest first, ending with the top-level brand. The walk stops after 16 levels or at a loop in stored data; meta.complete is false then.
+// @Tags brands
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Success 200 {array} services.BrandRef "Parent brands, nearest first; empty for a top-level brand"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/ancestry [get]
+func GetBrandAncestry(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	brandName := c.Param("brandName")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, err := services.GetBrandByName(ctx, coll, brandName)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return
+	}
+	ancestors, complete, err := services.BrandAncestry(ctx, coll, brand)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error walking parents of '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return
+	}
+	if !complete {
+		log.Printf("Warning: Parent chain of brand '%s' is cyclic or deeper than %d levels", services.LogValue(brandName), services.MaxBrandDepth)
+	}
+	respondList(c, http.StatusOK, ancestors, len(ancestors), gin.H{"complete": complete})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Imports can span many batches, so they get a much longer budget than single-document operations
+const importTimeout = 5 * time.Minute
+
+// ImportBrands godoc
+// @Summary Bulk import brands from a CSV file
+// @Description Upload a CSV file with 'name' and 'details' columns. Each row creates or updates the brand with that name.
+// @Tags brands
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file with a header row containing 'name' and 'details'"
+// @Success 200 {object} services.ImportReport "Import summary including per-row errors"
+// @Failure 400 {object} map[string]string "Missing or unreadable file"
+// @Failure 413 {object} map[string]string "Upload exceeds the upload policy maxBytes"
+// @Failure 415 {object} map[string]string "Unsupported file type"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/import [post]
+func ImportBrands(c *gin.Context) {
+	if !featureflags.Enabled(featureflags.CSVImport) {
+		featureDisabled(c, featureflags.CSVImport)
+		return
+	}
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	if !parseUploadForm(c) {
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		localizedError(c, http.StatusBadRequest, codeMissingFile, map[string]string{"field": "file"}, nil)
+		return
+	}
+
+	// Only CSV is supported for now; spreadsheets must be exported to CSV first
+	if ext := strings.ToLower(filepath.Ext(fileHeader.Filename)); ext != ".csv" {
+		localizedError(c, http.StatusUnsupportedMediaType, codeImportNotCSV, nil, nil)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		localizedError(c, http.StatusInternalServerError, codeUploadOpenFailed, nil, nil)
+		return
+	}
+	defer file.Close()
+
+	// The file is streamed row by row into batched bulk writes
+	report, err := services.ImportBrandsCSV(ctx, coll, file, services.ImportBatchSize())
+	if err != nil {
+		log.Printf("Error importing brands from '%s': %v", services.LogValue(fileHeader.Filename), err)
+		if report == nil {
+			// Nothing was written: the file itself was unusable (bad header, empty, ...)
+			localizedError(c, http.StatusBadRequest, codeImportInvalid, nil, gin.H{"detail": err.Error()})
+			return
+		}
+		// Some batches may already have been written; return what we know alongside the error
+		localizedError(c, http.StatusInternalServerError, codeImportAborted, nil, gin.H{"report": report})
+		return
+	}
+
+	respond(c, http.StatusOK, report, nil)
+}
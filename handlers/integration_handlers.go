@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// integrationSecretHeader carries the shared secret of an inbound integration.
+const integrationSecretHeader = "X-Integration-Secret"
+
+// Error codes of the supplier feed integration
+const (
+	codeFeedNotConfigured     = "SUPPLIER_FEED_NOT_CONFIGURED"
+	codeFeedSecretInvalid     = "INTEGRATION_SECRET_INVALID"
+	codeFeedInvalid           = "SUPPLIER_FEED_INVALID"
+	codeFeedAborted           = "SUPPLIER_FEED_ABORTED"
+	codeFeedMappingLoadFailed = "SUPPLIER_FEED_MAPPING_LOAD_FAILED"
+	codeFeedMappingSaveFailed = "SUPPLIER_FEED_MAPPING_SAVE_FAILED"
+)
+
+// Applying a feed writes many brands at once, so it gets the import timeout rather than dbTimeout
+const supplierFeedTimeout = 2 * time.Minute
+
+// supplierFeedAuthorized checks the shared secret (SUPPLIER_FEED_SECRET). Without a configured
+// secret the integration is off. It writes the error response and returns false on failure.
+func supplierFeedAuthorized(c *gin.Context) bool {
+	secret := os.Getenv("SUPPLIER_FEED_SECRET")
+	if secret == "" {
+		localizedError(c, http.StatusNotFound, codeFeedNotConfigured, nil, nil)
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader(integrationSecretHeader)), []byte(secret)) != 1 {
+		log.Printf("Rejected supplier feed from %s: invalid %s", c.ClientIP(), integrationSecretHeader)
+		localizedError(c, http.StatusUnauthorized, codeFeedSecretInvalid, nil, nil)
+		return false
+	}
+	return true
+}
+
+// ReceiveSupplierFeed godoc
+// @Summary Receive a supplier catalog push
+// @Description Upserts the brands in a supplier's JSON feed (an array of entries). Field names come from the supplier feed mapping. The description becomes the details, specs are merged into the brand's specs, and a PDF URL is fetched in the background to replace the details once extracted. Returns a per-entry report like the CSV import.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Param X-Integration-Secret header string true "Shared secret of the integration"
+// @Param feed body []map[string]interface{} true "Feed entries"
+// @Success 200 {object} services.ImportReport "Per-entry results"
+// @Failure 400 {object} map[string]string "Body is not a JSON array of objects"
+// @Failure 401 {object} map[string]string "Invalid integration secret"
+// @Failure 404 {object} map[string]string "Integration not configured"
+// @Failure 413 {object} map[string]string "Feed exceeds the upload policy maxBytes"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /integrations/supplier-feed [post]
+func ReceiveSupplierFeed(c *gin.Context) {
+	if !supplierFeedAuthorized(c) {
+		return
+	}
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), supplierFeedTimeout)
+	defer cancel()
+
+	var entries []map[string]interface{}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBytes())
+	if err := json.NewDecoder(c.Request.Body).Decode(&entries); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			localizedError(c, http.StatusRequestEntityTooLarge, uploadCodeTooLarge, nil, nil)
+			return
+		}
+		localizedError(c, http.StatusBadRequest, codeFeedInvalid, nil, gin.H{"detail": err.Error()})
+		return
+	}
+
+	mapping, err := services.LoadSupplierFeedMapping(ctx, database.Collection(featureflags.CollectionName))
+	if err != nil {
+		log.Printf("Error loading supplier feed mapping: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeFeedMappingLoadFailed, nil, nil)
+		return
+	}
+
+	report, pdfs, err := services.ApplySupplierFeed(ctx, coll, entries, mapping)
+	if err != nil {
+		log.Printf("Error applying supplier feed: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeFeedAborted, nil, gin.H{"report": report})
+		return
+	}
+
+	queued := 0
+	for _, pdf := range pdfs {
+		if services.QueueSupplierPDF(coll, pdf, maxUploadBytes(), maxDetailsBytes()) {
+			queued++
+		}
+	}
+	respond(c, http.StatusOK, report, gin.H{"pdfsQueued": queued, "pdfsSkipped": len(pdfs) - queued})
+}
+
+// GetSupplierFeedMapping godoc
+// @Summary Show the supplier feed mapping
+// @Description Which entry fields the supplier feed reads for name, description, specs and PDF URL (dotted paths reach into nested objects)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} services.SupplierFeedMapping "Effective mapping"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/integrations/supplier-feed/mapping [get]
+func GetSupplierFeedMapping(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	mapping, err := services.LoadSupplierFeedMapping(ctx, database.Collection(featureflags.CollectionName))
+	if err != nil {
+		log.Printf("Error loading supplier feed mapping: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeFeedMappingLoadFailed, nil, nil)
+		return
+	}
+	respond(c, http.StatusOK, mapping, nil)
+}
+
+// UpdateSupplierFeedMapping godoc
+// @Summary Replace the supplier feed mapping
+// @Description Stores new field names for the supplier feed; empty fields fall back to the defaults
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param mapping body services.SupplierFeedMapping true "Field names"
+// @Success 200 {object} services.SupplierFeedMapping "Mapping after the change"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/integrations/supplier-feed/mapping [put]
+func UpdateSupplierFeedMapping(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var payload services.SupplierFeedMapping
+	if !bindJSON(c, &payload) {
+		return
+	}
+	mapping, err := services.SaveSupplierFeedMapping(ctx, database.Collection(featureflags.CollectionName), payload)
+	if err != nil {
+		log.Printf("Error saving supplier feed mapping: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeFeedMappingSaveFailed, nil, nil)
+		return
+	}
+	audit(c, "supplierfeed.mapping", "supplier feed mapping set to %+v", mapping)
+	respond(c, http.StatusOK, mapping, nil)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Limits for the journal endpoint.
+const (
+	defaultJournalLimit = 50
+	maxJournalLimit     = 500
+)
+
+// beginUploadJournal hashes the uploaded file and records that the upload was received. The
+// file is rewound afterwards. Returns nil (a no-op journal) when journaling is off or fails.
+func beginUploadJournal(ctx context.Context, c *gin.Context, brandName string, file io.ReadSeeker, size int64) *services.UploadJournal {
+	if !services.UploadJournalEnabled() {
+		return nil
+	}
+	hasher := sha256.New()
+	_, err := io.Copy(hasher, file)
+	if _, seekErr := file.Seek(0, io.SeekStart); err != nil || seekErr != nil {
+		log.Printf("Warning: Could not hash upload for brand '%s', not journaled: %v", services.LogValue(brandName), err)
+		return nil
+	}
+	coll := database.Collection(database.UploadJournalCollection)
+	return services.BeginUploadJournal(ctx, coll, requestID(c), brandName, hex.EncodeToString(hasher.Sum(nil)), size)
+}
+
+// GetUploadJournal godoc
+// @Summary Recent upload journal entries
+// @Description Newest first. Each entry shows how far an upload got (received, extracted, upserted, responded) and the status sent; an entry stuck before "responded" was interrupted.
+// @Tags admin
+// @Produce json
+// @Param brand query string false "Only entries for this brand"
+// @Param limit query int false "Number of entries per page (default 50, max 500)"
+// @Param page query int false "Page from 1 (default 1; 0 is the first page too). Pages may skip at most 10000 entries"
+// @Success 200 {array} models.UploadJournalEntry "Journal entries"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters, each listed under fields"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/journal [get]
+func GetUploadJournal(c *gin.Context) {
+	q := queryParams(c)
+	page := q.Page(defaultJournalLimit, maxJournalLimit)
+	brand := q.String("brand", "")
+	if !validQuery(c, q) {
+		return
+	}
+	coll := database.Collection(database.UploadJournalCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	entries, err := services.RecentUploadJournal(ctx, coll, brand, page.Skip, page.Limit)
+	if err != nil {
+		log.Printf("Error reading upload journal: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload journal"})
+		return
+	}
+	respondList(c, http.StatusOK, entries, len(entries), gin.H{"enabled": services.UploadJournalEnabled(), "page": page.Number, "limit": page.Limit})
+}
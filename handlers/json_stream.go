@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// streamFlushEvery is how many elements a streamed list writes between flushes, so the client
+// sees progress and buffered output stays small.
+const streamFlushEvery = 64
+
+// streamErrorTrailer carries the error code when a streamed list was cut short. By then the 200
+// status is sent, so the trailer (HTTP/1.1 chunked or HTTP/2) is the only place a bare array can
+// report it; enveloped responses also get an "error" member.
+const streamErrorTrailer = "X-Stream-Error"
+
+// jsonArrayStream writes a list response one element at a time, in the shapes respondList
+// produces (a bare array, or {"data": [...], "meta": {...}} with the envelope), so large lists
+// are never held in memory. Nothing is written until the first element or finish, so a handler
+// can still send a plain error response when the query fails up front. ?pretty is not applied.
+type jsonArrayStream struct {
+	c        *gin.Context
+	trailers []string
+	started  bool
+	count    int
+	enc      *json.Encoder
+}
+
+// newJSONArrayStream prepares a stream. trailers names the headers the handler sets only after
+// the body (before calling finish).
+func newJSONArrayStream(c *gin.Context, trailers ...string) *jsonArrayStream {
+	return &jsonArrayStream{c: c, trailers: append(trailers, streamErrorTrailer), enc: json.NewEncoder(c.Writer)}
+}
+
+func (s *jsonArrayStream) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	requestID(s.c)
+	s.c.Header("Trailer", strings.Join(s.trailers, ", "))
+	s.c.Header("Content-Type", "application/json; charset=utf-8")
+	s.c.Status(http.StatusOK)
+	opening := "["
+	if wantsEnvelope(s.c) {
+		opening = `{"data":[`
+	}
+	_, err := io.WriteString(s.c.Writer, opening)
+	return err
+}
+
+// add writes one element. It returns the write error once the client has gone away.
+func (s *jsonArrayStream) add(v interface{}) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	if s.count > 0 {
+		if _, err := io.WriteString(s.c.Writer, ","); err != nil {
+			return err
+		}
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.count++
+	if s.count%streamFlushEvery == 0 {
+		s.c.Writer.Flush()
+	}
+	return nil
+}
+
+// finish closes the array, adding meta (with count and requestId) when enveloped. A non-empty
+// failure (an error code) means the list is incomplete: it is sent in the error trailer and,
+// enveloped, as an "error" member. The output is valid JSON either way.
+func (s *jsonArrayStream) finish(meta gin.H, failure string) {
+	if startErr := s.start(); startErr != nil {
+		return
+	}
+	if !wantsEnvelope(s.c) {
+		io.WriteString(s.c.Writer, "]")
+	} else {
+		if meta == nil {
+			meta = gin.H{}
+		}
+		meta["count"] = s.count
+		meta["requestId"] = requestID(s.c)
+		io.WriteString(s.c.Writer, `],"meta":`)
+		s.enc.Encode(meta)
+		if failure != "" {
+			io.WriteString(s.c.Writer, `,"error":`)
+			s.enc.Encode(gin.H{"code": failure, "error": i18n.Message(requestLocale(s.c), failure, nil)})
+		}
+		io.WriteString(s.c.Writer, "}")
+	}
+	if failure != "" {
+		s.c.Writer.Header().Set(streamErrorTrailer, failure)
+	}
+	s.c.Writer.Flush()
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/gin-gonic/gin"
+)
+
+// Streamed lists are valid JSON in both shapes, also when cut short, and report the failure in
+// the trailer.
+func TestJSONArrayStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		envelope bool
+		elements int
+		failure  string
+	}{
+		{"empty", false, 0, ""},
+		{"plain", false, 3, ""},
+		{"plain cut short", false, 2, codeBrandListFailed},
+		{"envelope", true, 3, ""},
+		{"envelope cut short", true, 2, codeBrandListFailed},
+		{"envelope empty failure", true, 0, codeBrandListFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { featureflags.Init(nil) })
+			t.Setenv("FEATURES", featureflags.ResponseEnvelope)
+			featureflags.Init(nil)
+
+			c, w := testContext(http.MethodGet, "/brands?includeDetails=true", "")
+			if tt.envelope {
+				c.Request.Header.Set(envelopeHeader, envelopeV1)
+			}
+			stream := newJSONArrayStream(c, "X-Decode-Errors")
+			for i := 0; i < tt.elements; i++ {
+				if err := stream.add(gin.H{"name": "Brand " + strconv.Itoa(i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c.Writer.Header().Set("X-Decode-Errors", "0")
+			stream.finish(gin.H{"decodeErrors": 0}, tt.failure)
+
+			var items []map[string]interface{}
+			if tt.envelope {
+				var body struct {
+					Data  []map[string]interface{} `json:"data"`
+					Meta  map[string]interface{}   `json:"meta"`
+					Error map[string]interface{}   `json:"error"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("invalid JSON: %v (%s)", err, w.Body.String())
+				}
+				items = body.Data
+				if body.Meta["count"] != float64(tt.elements) || body.Meta["requestId"] == nil {
+					t.Errorf("meta = %v, want count %d and a request ID", body.Meta, tt.elements)
+				}
+				if got, _ := body.Error["code"].(string); got != tt.failure {
+					t.Errorf("error member code = %q, want %q", got, tt.failure)
+				}
+			} else if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+				t.Fatalf("invalid JSON: %v (%s)", err, w.Body.String())
+			}
+			if len(items) != tt.elements {
+				t.Errorf("%d elements, want %d", len(items), tt.elements)
+			}
+
+			result := w.Result()
+			if result.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", result.StatusCode)
+			}
+			if got := result.Trailer.Get(streamErrorTrailer); got != tt.failure {
+				t.Errorf("%s trailer = %q, want %q", streamErrorTrailer, got, tt.failure)
+			}
+			if got := result.Trailer.Get("X-Decode-Errors"); got != "0" {
+				t.Errorf("X-Decode-Errors trailer = %q, want 0", got)
+			}
+		})
+	}
+}
+
+// discardWriter is a flushable response writer that keeps nothing, so the benchmark measures
+// the stream rather than a growing recorder.
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+func (w *discardWriter) Flush()                      {}
+
+// BenchmarkJSONArrayStream streams lists of growing size. Allocated bytes per element (B/elem)
+// stay flat across the sizes and the retained heap doesn't grow with them: nothing but the
+// current element is held.
+func BenchmarkJSONArrayStream(b *testing.B) {
+	details := string(make([]byte, 4096))
+	for _, size := range []int{100, 1000, 10000} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			for i := 0; i < b.N; i++ {
+				c, _ := gin.CreateTestContext(&discardWriter{header: http.Header{}})
+				c.Request, _ = http.NewRequest(http.MethodGet, "/brands?includeDetails=true", nil)
+				stream := newJSONArrayStream(c)
+				for j := 0; j < size; j++ {
+					if err := stream.add(gin.H{"name": "Brand", "details": details}); err != nil {
+						b.Fatal(err)
+					}
+				}
+				stream.finish(nil, "")
+			}
+			b.StopTimer()
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			b.ReportMetric(float64(after.TotalAlloc-before.TotalAlloc)/float64(b.N*size), "B/elem")
+			b.ReportMetric(float64(int64(after.HeapInuse)-int64(before.HeapInuse)), "heap-growth-B")
+		})
+	}
+}
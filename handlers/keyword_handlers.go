@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListBrandsByKeyword godoc
+// @Summary List brands mentioning a keyword
+// @Description Get all brands whose extracted keywords contain the term, with how often it occurs in their details. The term is tokenized like stored details, so punctuation and case don't matter. A phrase matches brands whose details contain its words consecutively and in order, counted by its occurrences; stopwords, numbers and short words are ignored both in the phrase and between its words, and only brands having every word of the phrase among their stored keywords are considered.
+// @Tags keywords
+// @Produce json
+// @Param term path string true "Keyword or phrase (case-insensitive)"
+// @Success 200 {array} models.KeywordBrandMatch "Brands containing the term, most mentions first"
+// @Failure 400 {object} map[string]string "Invalid term"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /keywords/{term}/brands [get]
+func ListBrandsByKeyword(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	term := c.Param("term")
+	terms := services.KeywordTerms(term)
+	if len(terms) == 0 {
+		localizedError(c, http.StatusBadRequest, codeInvalidKeywordTerm, nil, nil)
+		return
+	}
+
+	// Stored keywords narrow the candidates; a phrase is then looked up in the details themselves
+	filter := bson.M{"keywords.term": bson.M{"$all": terms}}
+	projection := bson.M{"_id": 0, "name": 1, "keywords": 1}
+	if len(terms) > 1 {
+		projection["details"] = 1
+	}
+	opts := options.Find().SetProjection(projection)
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Error finding brands for keyword '%s': %v", services.LogValue(term), err)
+		localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.Brand
+	if err = cursor.All(ctx, &results); err != nil {
+		log.Printf("Error decoding brands for keyword '%s': %v", services.LogValue(term), err)
+		localizedError(c, http.StatusInternalServerError, codeBrandDecodeFailed, nil, nil)
+		return
+	}
+
+	matches := make([]models.KeywordBrandMatch, 0, len(results))
+	for _, brand := range results {
+		if count := phraseCount(brand, terms); count > 0 {
+			matches = append(matches, models.KeywordBrandMatch{Name: brand.Name, Count: count})
+		}
+	}
+
+	// Most mentions first, then alphabetical for a stable order
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Count != matches[j].Count {
+			return matches[i].Count > matches[j].Count
+		}
+		return matches[i].Name < matches[j].Name
+	})
+
+	respondList(c, http.StatusOK, matches, len(matches), nil)
+}
+
+// phraseCount is how often a brand mentions the phrase made of terms: a single word's stored
+// keyword count, or the phrase's occurrences in the details.
+func phraseCount(brand models.Brand, terms []string) int {
+	if len(terms) > 1 {
+		return services.CountPhrase(brand.Details, terms)
+	}
+	for _, keyword := range brand.Keywords {
+		if keyword.Term == terms[0] {
+			return keyword.Count
+		}
+	}
+	return 0
+}
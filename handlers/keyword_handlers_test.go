@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+func TestPhraseCount(t *testing.T) {
+	brand := models.Brand{
+		Details:  "Organic cotton shirts, cotton caps and organic wool. Organic cotton bags.",
+		Keywords: []models.Keyword{{Term: "cotton", Count: 3}, {Term: "organic", Count: 3}, {Term: "shirts", Count: 1}},
+	}
+	tests := []struct {
+		terms []string
+		want  int
+	}{
+		{[]string{"cotton"}, 3},
+		{[]string{"linen"}, 0},
+		{[]string{"organic", "cotton"}, 2},
+		{[]string{"cotton", "organic"}, 0},
+		{[]string{"cotton", "shirts"}, 1},
+		{[]string{"organic", "wool"}, 1},
+	}
+	for _, tt := range tests {
+		if got := phraseCount(brand, tt.terms); got != tt.want {
+			t.Errorf("phraseCount(%q) = %d, want %d", tt.terms, got, tt.want)
+		}
+	}
+}
+
+// A term that can never have been stored is rejected before any database access.
+func TestListBrandsByKeywordRejectsUnindexableTerms(t *testing.T) {
+	for _, term := range []string{"of", "the and", "42", "--"} {
+		c, w := testContext(http.MethodGet, "/", "")
+		c.AddParam("term", term)
+		ListBrandsByKeyword(c)
+		if w.Code != http.StatusBadRequest || decodeResponse(t, w)["code"] != codeInvalidKeywordTerm {
+			t.Errorf("term %q: got %d %s, want 400 %s", term, w.Code, w.Body.String(), codeInvalidKeywordTerm)
+		}
+	}
+}
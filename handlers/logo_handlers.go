@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes for logo uploads and downloads.
+const (
+	codeLogoUnsupportedType = "LOGO_UNSUPPORTED_TYPE"
+	codeLogoInvalid         = "LOGO_INVALID"
+	codeLogoNotFound        = "LOGO_NOT_FOUND"
+	codeLogoSizeUnavailable = "LOGO_SIZE_UNAVAILABLE"
+)
+
+// Cache lifetimes for logo responses. A request carrying the variant's current hash as ?v= can
+// be cached for good: re-uploading changes the hash, and so the URL.
+const (
+	logoCacheControl          = "public, max-age=86400"
+	logoImmutableCacheControl = "public, max-age=31536000, immutable"
+)
+
+// UploadBrandLogo godoc
+// @Summary Upload a brand logo
+// @Description Stores a PNG or JPEG logo (multipart field 'logo', at most LOGO_MAX_BYTES) as resized variants fitting 64px and 256px boxes. Re-uploading replaces every variant at once. The type is detected from the content, not the declared Content-Type.
+// @Tags brands
+// @Accept multipart/form-data
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param logo formData file true "PNG or JPEG image"
+// @Success 200 {object} models.BrandLogo "Stored logo variants"
+// @Failure 400 {object} map[string]string "Missing 'logo' field or incomplete upload"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 413 {object} map[string]string "Logo exceeds LOGO_MAX_BYTES (code UPLOAD_TOO_LARGE)"
+// @Failure 415 {object} map[string]string "Not a PNG or JPEG image (code LOGO_UNSUPPORTED_TYPE)"
+// @Failure 422 {object} map[string]string "Image is corrupt or too large to decode (code LOGO_INVALID)"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/logo [post]
+func UploadBrandLogo(c *gin.Context) {
+	if !parseUploadFormLimit(c, services.LogoMaxBytes()) {
+		return
+	}
+	fileHeader, err := c.FormFile("logo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing 'logo' form field or invalid file upload"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+		return
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		log.Printf("Error reading uploaded logo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+		return
+	}
+
+	contentType := services.SniffLogoType(data)
+	if contentType != services.LogoTypePNG && contentType != services.LogoTypeJPEG {
+		localizedError(c, http.StatusUnsupportedMediaType, codeLogoUnsupportedType, nil, gin.H{"detected": contentType})
+		return
+	}
+	brandName := c.Param("brandName")
+	rendered, err := services.RenderLogoVariants(data, contentType)
+	if errors.Is(err, services.ErrInvalidLogo) {
+		log.Printf("Rejected logo for brand '%s' from %s: %v", services.LogValue(brandName), c.ClientIP(), err)
+		localizedError(c, http.StatusUnprocessableEntity, codeLogoInvalid, nil, nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error rendering logo for brand '%s': %v", services.LogValue(brandName), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process logo"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+	logo, found, err := services.StoreBrandLogo(ctx, database.GetCollection("brands"), brandName, contentType, rendered)
+	if err != nil {
+		log.Printf("Error storing logo for brand '%s': %v", services.LogValue(brandName), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store logo"})
+		return
+	}
+	if !found {
+		brandNotFound(c, brandName)
+		return
+	}
+	respond(c, http.StatusOK, logo, nil)
+}
+
+// GetBrandLogo godoc
+// @Summary Get a brand logo
+// @Description Streams one variant of the brand's logo, with an ETag derived from its content hash. Pass the variant's sha256 as ?v= to get a response that can be cached indefinitely.
+// @Tags brands
+// @Produce image/png
+// @Produce image/jpeg
+// @Param brandName path string true "Name of the brand"
+// @Param size query int false "Variant size in pixels: 64 or 256 (default 256)"
+// @Param v query string false "Cache buster: the variant's sha256"
+// @Success 200 {file} binary "Logo image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} map[string]string "No variant of that size"
+// @Failure 404 {object} map[string]string "Brand not found or has no logo"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/logo [get]
+func GetBrandLogo(c *gin.Context) {
+	size := services.LogoSizes[len(services.LogoSizes)-1]
+	if raw := c.Query("size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			localizedError(c, http.StatusBadRequest, codeLogoSizeUnavailable, map[string]string{"sizes": logoSizeList()}, nil)
+			return
+		}
+		size = n
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+	coll := database.GetCollection("brands")
+
+	brandName := c.Param("brandName")
+	logo, found, err := services.FindBrandLogo(ctx, coll, brandName)
+	if err != nil {
+		log.Printf("Error finding logo for brand '%s': %v", services.LogValue(brandName), err)
+		localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		return
+	}
+	if !found {
+		brandNotFound(c, brandName)
+		return
+	}
+	if logo == nil {
+		localizedError(c, http.StatusNotFound, codeLogoNotFound, map[string]string{"name": brandName}, nil)
+		return
+	}
+	variant := logo.Variant(size)
+	if variant == nil {
+		localizedError(c, http.StatusBadRequest, codeLogoSizeUnavailable, map[string]string{"sizes": logoSizeList()}, nil)
+		return
+	}
+
+	etag := `"` + variant.SHA256 + `"`
+	c.Header("ETag", etag)
+	if c.Query("v") == variant.SHA256 {
+		c.Header("Cache-Control", logoImmutableCacheControl)
+	} else {
+		c.Header("Cache-Control", logoCacheControl)
+	}
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	stream, err := services.OpenLogoFile(ctx, coll.Database(), variant.FileID)
+	if err != nil {
+		log.Printf("Error opening %dpx logo of brand '%s': %v", size, services.LogValue(brandName), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read logo"})
+		return
+	}
+	defer stream.Close()
+	c.DataFromReader(http.StatusOK, variant.Bytes, logo.ContentType, stream, nil)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag (or is "*").
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// logoSizeList renders services.LogoSizes for error messages, e.g. "64, 256".
+func logoSizeList() string {
+	sizes := make([]string, len(services.LogoSizes))
+	for i, size := range services.LogoSizes {
+		sizes[i] = strconv.Itoa(size)
+	}
+	return strings.Join(sizes, ", ")
+}
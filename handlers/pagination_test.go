@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestHostilePagingParameters sends the paging values that used to reach MongoDB (negative or
+// zero sizes, overflowing numbers, repeated parameters) and expects a 400 INVALID_QUERY naming
+// the parameter before any database access.
+func TestHostilePagingParameters(t *testing.T) {
+	endpoints := []struct {
+		name    string
+		handler gin.HandlerFunc
+		base    string // Otherwise valid query
+		params  []string
+	}{
+		{"brand list", ListBrands, "", []string{"limit"}},
+		{"sync", SyncBrands, "", []string{"limit"}},
+		{"journal", GetUploadJournal, "", []string{"limit", "page"}},
+		{"search", SearchBrands, "q=boots&", []string{"limit", "offset"}},
+		{"suggest", SuggestBrands, "prefix=ac&", []string{"limit"}},
+	}
+	hostile := []string{
+		"-1",
+		"999999999",
+		"9223372036854775807",
+		"9223372036854775808",
+		"-9223372036854775809",
+		"99999999999999999999999999",
+		"1e3",
+		"0x10",
+		"10abc",
+		"+-5",
+		"%20",
+		"１０", // Full-width digits
+		"%zz",
+	}
+	for _, endpoint := range endpoints {
+		for _, param := range endpoint.params {
+			repeated := "1&" + param + "=2"
+			for _, value := range append(hostile, repeated) {
+				query := endpoint.base + param + "=" + value
+				t.Run(endpoint.name+"?"+query, func(t *testing.T) {
+					c, w := testContext(http.MethodGet, "/?"+query, "")
+					endpoint.handler(c)
+					body := decodeResponse(t, w)
+					if w.Code != http.StatusBadRequest || body["code"] != codeInvalidQuery {
+						t.Fatalf("got %d %s, want 400 %s", w.Code, w.Body.String(), codeInvalidQuery)
+					}
+					if !namesField(body, param) && !namesField(body, "query") {
+						t.Errorf("violations %v don't name %s", body["fields"], param)
+					}
+				})
+			}
+		}
+	}
+}
+
+// A zero limit is out of range everywhere, while page=0 is the first page like page=1 and
+// offset=0 skips nothing.
+func TestZeroPagingParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		query   string
+		valid   string // Zero parameter that must be accepted
+	}{
+		{"journal", GetUploadJournal, "limit=0&page=0", "page"},
+		{"search", SearchBrands, "q=boots&limit=0&offset=0", "offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(http.MethodGet, "/?"+tt.query, "")
+			tt.handler(c)
+			body := decodeResponse(t, w)
+			if w.Code != http.StatusBadRequest || !namesField(body, "limit") || namesField(body, tt.valid) {
+				t.Errorf("got %d %s, want 400 for limit only", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// namesField reports whether an INVALID_QUERY body lists a violation of field.
+func namesField(body map[string]interface{}, field string) bool {
+	fields, _ := body["fields"].([]interface{})
+	for _, entry := range fields {
+		if v, ok := entry.(map[string]interface{}); ok && v["field"] == field {
+			return true
+		}
+	}
+	return false
+}
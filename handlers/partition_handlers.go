@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/queryparams"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Error codes of the partitioned listing
+const (
+	codePartitionComputeFailed = "PARTITION_COMPUTE_FAILED"
+	codePartitionLoadFailed    = "PARTITION_LOAD_FAILED"
+)
+
+// Page size limits when listing one partition
+const (
+	defaultPartitionPageLimit = 500
+	maxPartitionPageLimit     = 5000
+)
+
+// GetBrandPartitions godoc
+// @Summary Split the brand collection into ranges for parallel consumers
+// @Description Returns count disjoint, open-ended _id ranges that together cover every brand, for workers listing /brands?partition=KofN (or ?minId=&maxId=) in parallel. The set for a count is computed once and kept until refreshed, so all workers of a sync see the same ranges; any set stays overlap-free as brands are written, it only gets less balanced. Brands created during a sync may be missed by it.
+// @Tags brands
+// @Produce json
+// @Param count query int false "Number of partitions (default 8, max 64)"
+// @Param refresh query bool false "Recompute the ranges from the current collection (don't use while a sync with this count is running)"
+// @Success 200 {object} models.BrandPartitionSet "The partition set"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters, each listed under fields"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/partitions [get]
+func GetBrandPartitions(c *gin.Context) {
+	q := queryParams(c)
+	count := q.Int("count", 8, 1, services.MaxBrandPartitions)
+	refresh := q.Bool("refresh", false)
+	if !validQuery(c, q) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	set, err := services.BrandPartitions(ctx, database.GetCollection("brands"), database.Collection(database.PartitionSetCollection), count, refresh)
+	if err != nil {
+		if !domainError(c, "", err) {
+			log.Printf("Error computing brand partitions: %v", err)
+			localizedError(c, http.StatusInternalServerError, codePartitionComputeFailed, nil, nil)
+		}
+		return
+	}
+	respond(c, http.StatusOK, set, nil)
+}
+
+// wantsBrandRange reports whether a brand listing asks for pages in _id order (a partition, or
+// plain ?limit=/?cursor= paging) instead of the whole alphabetical list.
+func wantsBrandRange(q *queryparams.Query) bool {
+	return q.Has("partition") || q.Has("minId") || q.Has("maxId") || q.Has("after") || q.Has("cursor") || q.Has("limit")
+}
+
+// listBrandRange answers GET /brands page by page in _id order, optionally within a partition
+// (?partition=KofN or ?minId=/maxId=), after reporting any invalid parameter read into q. Seeking
+// by _id keeps pages stable while brands are added or removed. The body stays the bare array of
+// names; the cursor for the next page is in the X-Next-Cursor header and the envelope meta.
+func listBrandRange(ctx context.Context, c *gin.Context, q *queryparams.Query, coll *mongo.Collection, filter bson.M) {
+	limit := q.Int("limit", defaultPartitionPageLimit, 1, maxPartitionPageLimit)
+	after := brandCursorParam(q, "cursor")
+	if q.Has("after") {
+		if q.Has("cursor") {
+			q.Invalid("after", "cannot be combined with cursor")
+		}
+		after = brandCursorParam(q, "after")
+	}
+	partition := models.BrandPartition{
+		MinID: objectIDParam(q, "minId", "must be a brand ID"),
+		MaxID: objectIDParam(q, "maxId", "must be a brand ID"),
+	}
+	var k, n int
+	q.Value("partition", fmt.Sprintf("must look like 3of8, with at most %d partitions", services.MaxBrandPartitions), func(raw string) (err error) {
+		k, n, err = parsePartition(raw)
+		return err
+	})
+	if n > 0 && (partition.MinID != nil || partition.MaxID != nil) {
+		q.Invalid("partition", "cannot be combined with minId or maxId")
+	}
+	if !validQuery(c, q) {
+		return
+	}
+
+	if n > 0 {
+		set, err := services.BrandPartitions(ctx, coll, database.Collection(database.PartitionSetCollection), n, false)
+		if err != nil {
+			if !domainError(c, "", err) {
+				log.Printf("Error loading brand partitions: %v", err)
+				localizedError(c, http.StatusInternalServerError, codePartitionLoadFailed, nil, nil)
+			}
+			return
+		}
+		partition = set.Partition(k)
+	}
+	page, err := services.ListBrandNamesInRange(ctx, coll, filter, partition, after, limit)
+	if err != nil {
+		if !domainError(c, "", err) {
+			log.Printf("Error listing brand partition: %v", err)
+			localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		}
+		return
+	}
+
+	c.Header("X-Decode-Errors", strconv.Itoa(page.DecodeErrors))
+	if page.HasMore {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	respondList(c, http.StatusOK, page.Names, len(page.Names), gin.H{
+		"decodeErrors": page.DecodeErrors,
+		"hasMore":      page.HasMore,
+		"nextCursor":   page.NextCursor,
+		"partition":    partition,
+	})
+}
+
+// objectIDParam reads an optional hex object ID from q.
+func objectIDParam(q *queryparams.Query, name, message string) *primitive.ObjectID {
+	var id *primitive.ObjectID
+	q.Value(name, message, func(raw string) error {
+		parsed, err := primitive.ObjectIDFromHex(raw)
+		if err == nil {
+			id = &parsed
+		}
+		return err
+	})
+	return id
+}
+
+// brandCursorParam reads an optional listing cursor from q: the nextCursor of a previous page, or
+// (what ?after= took before cursors were opaque) the hex ID of the last brand seen.
+func brandCursorParam(q *queryparams.Query, name string) *primitive.ObjectID {
+	var id *primitive.ObjectID
+	q.Value(name, "must be the nextCursor of the previous page", func(raw string) error {
+		parsed, err := services.DecodeBrandCursor(raw)
+		if err != nil {
+			parsed, err = primitive.ObjectIDFromHex(raw)
+		}
+		if err == nil {
+			id = &parsed
+		}
+		return err
+	})
+	return id
+}
+
+// parsePartition parses "KofN" with 1 <= K <= N <= services.MaxBrandPartitions.
+func parsePartition(raw string) (int, int, error) {
+	left, right, ok := strings.Cut(raw, "of")
+	if !ok {
+		return 0, 0, errors.New("missing 'of'")
+	}
+	k, errK := strconv.Atoi(left)
+	n, errN := strconv.Atoi(right)
+	if errK != nil || errN != nil || k < 1 || k > n || n > services.MaxBrandPartitions {
+		return 0, 0, errors.New("partition out of range")
+	}
+	return k, n, nil
+}
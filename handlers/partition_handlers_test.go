@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParsePartition(t *testing.T) {
+	tests := []struct {
+		raw     string
+		k, n    int
+		wantErr bool
+	}{
+		{"3of8", 3, 8, false},
+		{"1of1", 1, 1, false},
+		{"64of64", 64, 64, false},
+		{"0of8", 0, 0, true},
+		{"9of8", 0, 0, true},
+		{"1of65", 0, 0, true},
+		{"3/8", 0, 0, true},
+		{"of8", 0, 0, true},
+		{"3of", 0, 0, true},
+		{"-1of8", 0, 0, true},
+	}
+	for _, tt := range tests {
+		k, n, err := parsePartition(tt.raw)
+		if k != tt.k || n != tt.n || (err != nil) != tt.wantErr {
+			t.Errorf("parsePartition(%q) = %d, %d, %v, want %d, %d, error %v", tt.raw, k, n, err, tt.k, tt.n, tt.wantErr)
+		}
+	}
+}
+
+// Invalid range parameters are all reported before the database is touched.
+func TestListBrandRangeRejectsParams(t *testing.T) {
+	const minID = "64a000000000000000000001"
+	tests := []struct {
+		target     string
+		wantFields []string
+	}{
+		{"/brands?minId=nope", []string{"minId"}},
+		{"/brands?partition=3of8&minId=" + minID, []string{"partition"}},
+		{"/brands?partition=9of8&limit=0&after=x", []string{"limit", "after", "partition"}},
+	}
+	for _, tt := range tests {
+		c, w := testContext(http.MethodGet, tt.target, "")
+		listBrandRange(c.Request.Context(), c, queryParams(c), nil, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status = %d, want %d", tt.target, w.Code, http.StatusBadRequest)
+			continue
+		}
+		body := decodeResponse(t, w)
+		if body["code"] != codeInvalidQuery {
+			t.Errorf("GET %s: code = %v, want %s", tt.target, body["code"], codeInvalidQuery)
+		}
+		fields, _ := body["fields"].([]interface{})
+		got := make(map[string]bool)
+		for _, f := range fields {
+			if entry, ok := f.(map[string]interface{}); ok {
+				got[entry["field"].(string)] = true
+			}
+		}
+		for _, field := range tt.wantFields {
+			if !got[field] {
+				t.Errorf("GET %s: no violation for %q in %v", tt.target, field, fields)
+			}
+		}
+		if len(got) != len(tt.wantFields) {
+			t.Errorf("GET %s: violations for %v, want %v", tt.target, got, tt.wantFields)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Error codes of the supplier portal
+const (
+	codePortalTokenIssueFailed  = "PORTAL_TOKEN_ISSUE_FAILED"
+	codePortalTokenListFailed   = "PORTAL_TOKEN_LIST_FAILED"
+	codePortalTokenInvalidID    = "PORTAL_TOKEN_INVALID_ID"
+	codePortalTokenNotFound     = "PORTAL_TOKEN_NOT_FOUND"
+	codePortalTokenRevokeFailed = "PORTAL_TOKEN_REVOKE_FAILED"
+	codePortalTokenMissing      = "PORTAL_TOKEN_MISSING"
+	codePortalTokenInvalid      = "PORTAL_TOKEN_INVALID"
+	codePortalTokenVerifyFailed = "PORTAL_TOKEN_VERIFY_FAILED"
+	codePortalRateLimited       = "PORTAL_RATE_LIMITED"
+	codePortalBrandGone         = "PORTAL_BRAND_GONE"
+)
+
+// portalBrand loads the brand named in the path for the portal token admin endpoints, writing
+// the error response when it can't.
+func portalBrand(ctx context.Context, c *gin.Context) (*models.Brand, bool) {
+	brandName := c.Param("brandName")
+	brand, err := services.GetBrandByName(ctx, database.GetCollection("brands"), brandName)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return nil, false
+	}
+	return brand, true
+}
+
+// IssuePortalToken godoc
+// @Summary Issue a supplier portal token
+// @Description Creates a long-lived token that lets a supplier read their own brand via GET /portal/brand. The token is returned only in this response; it stays valid until revoked. Only available with PORTAL_SIGNING_SECRET set.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param token body models.PortalTokenPayload false "Optional label"
+// @Success 201 {object} map[string]interface{} "The token and its metadata"
+// @Failure 401 {object} map[string]string "Missing or invalid API key"
+// @Failure 403 {object} map[string]string "API key lacks the admin scope"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands/{brandName}/portal-tokens [post]
+func IssuePortalToken(c *gin.Context) {
+	var payload models.PortalTokenPayload
+	if c.Request.ContentLength != 0 && !bindJSON(c, &payload) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, ok := portalBrand(ctx, c)
+	if !ok {
+		return
+	}
+	token, record, err := services.IssuePortalToken(ctx, database.Collection(database.PortalTokenCollection), brand, payload.Label, requestActor(c))
+	if err != nil {
+		log.Printf("Error issuing portal token for brand '%s': %v", services.LogValue(brand.Name), err)
+		localizedError(c, http.StatusInternalServerError, codePortalTokenIssueFailed, nil, nil)
+		return
+	}
+	audit(c, "portaltoken.issue", "Portal token %s issued for brand '%s'", record.ID.Hex(), services.LogValue(brand.Name))
+	respond(c, http.StatusCreated, gin.H{"token": token, "portalToken": record}, nil)
+}
+
+// ListPortalTokens godoc
+// @Summary List a brand's supplier portal tokens
+// @Description Metadata of every token issued for the brand, newest first, revoked ones included. The tokens themselves are never shown again. Only available with PORTAL_SIGNING_SECRET set.
+// @Tags admin
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Success 200 {array} models.PortalToken "Issued tokens"
+// @Failure 401 {object} map[string]string "Missing or invalid API key"
+// @Failure 403 {object} map[string]string "API key lacks the admin scope"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands/{brandName}/portal-tokens [get]
+func ListPortalTokens(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, ok := portalBrand(ctx, c)
+	if !ok {
+		return
+	}
+	tokens, err := services.ListPortalTokens(ctx, database.Collection(database.PortalTokenCollection), brand.ID)
+	if err != nil {
+		log.Printf("Error listing portal tokens for brand '%s': %v", services.LogValue(brand.Name), err)
+		localizedError(c, http.StatusInternalServerError, codePortalTokenListFailed, nil, nil)
+		return
+	}
+	respondList(c, http.StatusOK, tokens, len(tokens), nil)
+}
+
+// RevokePortalToken godoc
+// @Summary Revoke a supplier portal token
+// @Description Revokes one of the brand's tokens. This instance rejects it immediately; other instances within PORTAL_REVOKE_REFRESH_SECONDS. Revoking twice is harmless. Only available with PORTAL_SIGNING_SECRET set.
+// @Tags admin
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param tokenId path string true "Token ID"
+// @Success 200 {object} models.PortalToken "Revoked token"
+// @Failure 400 {object} map[string]string "Invalid token ID"
+// @Failure 401 {object} map[string]string "Missing or invalid API key"
+// @Failure 403 {object} map[string]string "API key lacks the admin scope"
+// @Failure 404 {object} map[string]string "Brand or token not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands/{brandName}/portal-tokens/{tokenId} [delete]
+func RevokePortalToken(c *gin.Context) {
+	tokenID, err := primitive.ObjectIDFromHex(c.Param("tokenId"))
+	if err != nil {
+		localizedError(c, http.StatusBadRequest, codePortalTokenInvalidID, nil, nil)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, ok := portalBrand(ctx, c)
+	if !ok {
+		return
+	}
+	record, err := services.RevokePortalToken(ctx, database.Collection(database.PortalTokenCollection), brand.ID, tokenID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			localizedError(c, http.StatusNotFound, codePortalTokenNotFound, nil, nil)
+		} else {
+			log.Printf("Error revoking portal token %s: %v", tokenID.Hex(), err)
+			localizedError(c, http.StatusInternalServerError, codePortalTokenRevokeFailed, nil, nil)
+		}
+		return
+	}
+	audit(c, "portaltoken.revoke", "Portal token %s of brand '%s' revoked", tokenID.Hex(), services.LogValue(brand.Name))
+	respond(c, http.StatusOK, record, nil)
+}
+
+// GetPortalBrand godoc
+// @Summary Read your own brand (supplier portal)
+// @Description Returns the current details, specs, logo and timestamps of the brand the bearer token was issued for. Read-only; internal data such as contacts and extraction diagnostics is never included. Requests are limited per token (PORTAL_RATE_LIMIT_PER_MINUTE). Only available with PORTAL_SIGNING_SECRET set.
+// @Tags portal
+// @Produce json
+// @Param Authorization header string true "Bearer <portal token>"
+// @Success 200 {object} models.PortalBrand "The brand"
+// @Failure 401 {object} map[string]string "Missing, invalid or revoked token"
+// @Failure 404 {object} map[string]string "The brand no longer exists"
+// @Failure 429 {object} map[string]string "Rate limit exceeded for this token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /portal/brand [get]
+func GetPortalBrand(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="portal"`)
+		localizedError(c, http.StatusUnauthorized, codePortalTokenMissing, nil, nil)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tokens := database.Collection(database.PortalTokenCollection)
+	claims, err := services.VerifyPortalToken(ctx, tokens, strings.TrimSpace(token))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPortalToken) || errors.Is(err, services.ErrPortalTokenRevoked) {
+			c.Header("WWW-Authenticate", `Bearer realm="portal", error="invalid_token"`)
+			localizedError(c, http.StatusUnauthorized, codePortalTokenInvalid, nil, nil)
+		} else {
+			log.Printf("Error verifying portal token: %v", err)
+			localizedError(c, http.StatusInternalServerError, codePortalTokenVerifyFailed, nil, nil)
+		}
+		return
+	}
+	if allowed, retryAfter := services.AllowPortalRequest(claims.TokenID); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		localizedError(c, http.StatusTooManyRequests, codePortalRateLimited, nil, nil)
+		return
+	}
+
+	var brand models.Brand
+	err = database.GetCollection("brands").FindOne(ctx, bson.M{"_id": claims.BrandID}).Decode(&brand)
+	if err = apperrors.FromDB(err); err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			localizedError(c, http.StatusNotFound, codePortalBrandGone, nil, nil)
+		} else if !domainError(c, claims.BrandID.Hex(), err) {
+			log.Printf("Error loading brand %s for the portal: %v", claims.BrandID.Hex(), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return
+	}
+
+	format := brand.DetailsFormat
+	if format == "" {
+		format = models.DetailsFormatPlain // Stored before formats existed
+	}
+	respond(c, http.StatusOK, models.PortalBrand{
+		Name:          brand.Name,
+		Details:       brand.Details,
+		DetailsFormat: format,
+		Specs:         brand.Specs,
+		Logo:          brand.Logo,
+		CreatedAt:     brand.CreatedAt,
+		UpdatedAt:     brand.UpdatedAt,
+	}, nil)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// codeBrandNameReserved is returned when a new brand would be named like a static route segment.
+const codeBrandNameReserved = "BRAND_NAME_RESERVED"
+
+// ReserveBrandNames reserves (see services.ReserveBrandNames) the first segment after prefix of
+// every route where that segment isn't a parameter. Call it after all brand routes are registered.
+func ReserveBrandNames(routes gin.RoutesInfo, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	for _, route := range routes {
+		rest, ok := strings.CutPrefix(route.Path, prefix)
+		if !ok {
+			continue
+		}
+		segment, _, _ := strings.Cut(rest, "/")
+		if segment != "" && !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			services.ReserveBrandNames(segment)
+		}
+	}
+}
+
+// rejectReservedBrandName writes a 422 listing the reserved words and returns true when name is
+// one of them.
+func rejectReservedBrandName(c *gin.Context, name string) bool {
+	if !services.IsReservedBrandName(name) {
+		return false
+	}
+	localizedError(c, http.StatusUnprocessableEntity, codeBrandNameReserved, map[string]string{"name": name}, gin.H{"reserved": services.ReservedBrandNames()})
+	return true
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Static segments under the prefix are reserved; parameters, deeper segments and other prefixes
+// are not. Routes registered static-first still reach brands by the /by-name/ alias.
+func TestReserveBrandNames(t *testing.T) {
+	router := gin.New()
+	answer := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) { c.String(http.StatusOK, name+" "+c.Param("brandName")) }
+	}
+	brands := router.Group("/api/v1/brands")
+	brands.GET("/stats", answer("stats"))
+	brands.GET("/:brandName", answer("brand"))
+	brands.GET("/:brandName/views", answer("views"))
+	brands.GET("/by-name/:brandName", answer("brand"))
+	router.GET("/api/v1/keywords/top", answer("keywords"))
+	ReserveBrandNames(router.Routes(), "/api/v1/brands/")
+
+	tests := []struct {
+		name     string
+		reserved bool
+	}{
+		{"stats", true},
+		{"by-name", true},
+		{"Stats", false},
+		{":brandName", false},
+		{"views", false},
+		{"keywords", false},
+		{"top", false},
+	}
+	for _, tt := range tests {
+		if got := services.IsReservedBrandName(tt.name); got != tt.reserved {
+			t.Errorf("IsReservedBrandName(%q) = %v, want %v", tt.name, got, tt.reserved)
+		}
+	}
+
+	for path, want := range map[string]string{
+		"/api/v1/brands/stats":         "stats ",
+		"/api/v1/brands/by-name/stats": "brand stats",
+		"/api/v1/brands/Stats":         "brand Stats",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Body.String() != want {
+			t.Errorf("GET %s answered %q, want %q", path, w.Body.String(), want)
+		}
+	}
+}
+
+func TestRejectReservedBrandName(t *testing.T) {
+	for name, wantRejected := range map[string]bool{"upload": true, "sync": true, "Upload": false, "Acme": false} {
+		c, w := testContext(http.MethodPost, "/brands", "")
+		if got := rejectReservedBrandName(c, name); got != wantRejected {
+			t.Fatalf("rejectReservedBrandName(%q) = %v, want %v", name, got, wantRejected)
+		}
+		if !wantRejected {
+			continue
+		}
+		body := decodeResponse(t, w)
+		if w.Code != http.StatusUnprocessableEntity || body["code"] != codeBrandNameReserved {
+			t.Errorf("%q: got %d %s, want 422 %s", name, w.Code, w.Body.String(), codeBrandNameReserved)
+		}
+		if reserved, _ := body["reserved"].([]interface{}); len(reserved) != len(services.ReservedBrandNames()) {
+			t.Errorf("%q: reserved = %v, want %v", name, body["reserved"], services.ReservedBrandNames())
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Clients opt into the {"data": ..., "meta": ...} response shape with this header;
+// without it responses stay bare arrays/objects as the order form expects.
+const (
+	envelopeHeader  = "X-Response-Envelope"
+	envelopeV1      = "v1"
+	requestIDHeader = "X-Request-ID"
+)
+
+// wantsEnvelope reports whether the caller asked for enveloped responses.
+func wantsEnvelope(c *gin.Context) bool {
+	if !featureflags.Enabled(featureflags.ResponseEnvelope) {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(c.GetHeader(envelopeHeader)), envelopeV1)
+}
+
+// requestID returns the caller-supplied X-Request-ID or generates one, echoing it in the response.
+func requestID(c *gin.Context) string {
+	if id, ok := c.Get(requestIDHeader); ok {
+		return id.(string)
+	}
+	id := c.GetHeader(requestIDHeader)
+	if id == "" || len(id) > 128 {
+		buf := make([]byte, 8)
+		_, _ = rand.Read(buf)
+		id = hex.EncodeToString(buf)
+	}
+	c.Set(requestIDHeader, id)
+	c.Header(requestIDHeader, id)
+	return id
+}
+
+// writeJSON writes body as JSON, indented when ?pretty=true is set (useful when debugging with curl).
+func writeJSON(c *gin.Context, status int, body interface{}) {
+	if c.Query("pretty") == "true" {
+		c.IndentedJSON(status, body)
+		return
+	}
+	c.JSON(status, body)
+}
+
+// respond is the single place success responses are written. Handlers pass the payload and
+// whatever metadata they have; the envelope (if requested) and pretty-printing are applied here.
+func respond(c *gin.Context, status int, data interface{}, meta gin.H) {
+	reqID := requestID(c)
+	if !wantsEnvelope(c) {
+		writeJSON(c, status, data)
+		return
+	}
+	if meta == nil {
+		meta = gin.H{}
+	}
+	meta["requestId"] = reqID
+	writeJSON(c, status, gin.H{"data": data, "meta": meta})
+}
+
+// respondList writes a collection response; count is always part of the envelope meta.
+func respondList(c *gin.Context, status int, items interface{}, count int, meta gin.H) {
+	if meta == nil {
+		meta = gin.H{}
+	}
+	meta["count"] = count
+	respond(c, status, items, meta)
+}
+
+// respondBrand writes a single brand, adding its ETag (header and meta) and version to any
+// other metadata (e.g. the write ack of a mutation).
+func respondBrand(c *gin.Context, status int, brand *models.Brand, meta gin.H) {
+	if brand.DetailsFormat == "" {
+		brand.DetailsFormat = models.DetailsFormatPlain // Stored before formats existed
+	}
+	version := brand.UpdatedAt.UnixMilli()
+	etag := fmt.Sprintf(`W/"%s-%d"`, brand.ID.Hex(), version)
+	c.Header("ETag", etag)
+	c.Header("Cache-Tag", services.BrandCacheTag(brand.ID.Hex()))
+	if meta == nil {
+		meta = gin.H{}
+	}
+	meta["etag"] = etag
+	meta["version"] = version
+	respond(c, status, brand, meta)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Every endpoint answers with a bare array or object by default and with {"data", "meta"} when
+// X-Response-Envelope: v1 is sent; either way the request ID is echoed.
+func TestResponseShapes(t *testing.T) {
+	const details = "INTRO\nHello.\n"
+	now := primitive.NewDateTimeFromTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	brandDoc := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "name", Value: "Acme"},
+		{Key: "details", Value: details},
+		{Key: "createdAt", Value: now},
+		{Key: "updatedAt", Value: now},
+	}
+	cursor := func(docs ...bson.D) bson.D {
+		return mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch, docs...)
+	}
+	name := func(n string) bson.D {
+		return bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: n}}
+	}
+	sectionID := services.SplitDetailsSections(details)[0].ID
+
+	tests := []struct {
+		name      string
+		handler   gin.HandlerFunc
+		target    string
+		params    gin.Params
+		responses []bson.D // Mock database replies, in order
+		list      bool     // data is an array rather than an object
+		meta      []string // meta keys besides requestId
+	}{
+		{"ListBrands", ListBrands, "/brands", nil, []bson.D{cursor(name("Acme"), name("Zeta"))}, true, []string{"count", "decodeErrors"}},
+		{"ListBrands paged", ListBrands, "/brands?limit=1", nil, []bson.D{cursor(name("Acme"), name("Zeta"))}, true, []string{"count", "decodeErrors", "hasMore", "nextCursor"}},
+		{"SuggestBrands", SuggestBrands, "/brands/suggest?prefix=ac", nil, []bson.D{cursor(name("Acme"))}, true, []string{"count"}},
+		{"GetBrandDetails", GetBrandDetails, "/brands/Acme", gin.Params{{Key: "brandName", Value: "Acme"}}, []bson.D{cursor(brandDoc)}, false, []string{"etag", "version"}},
+		{"ListDetailsSections", ListDetailsSections, "/brands/Acme/details/sections", gin.Params{{Key: "brandName", Value: "Acme"}}, []bson.D{cursor(brandDoc)}, true, []string{"count"}},
+		{"GetDetailsSection", GetDetailsSection, "/brands/Acme/details/sections/x", gin.Params{{Key: "brandName", Value: "Acme"}, {Key: "sectionId", Value: sectionID}}, []bson.D{cursor(brandDoc)}, false, nil},
+		{"GetBrandDiagnostics", GetBrandDiagnostics, "/admin/brands/Acme/diagnostics", gin.Params{{Key: "brandName", Value: "Acme"}}, []bson.D{cursor(brandDoc)}, false, nil},
+		{"ListSourceFailures", ListSourceFailures, "/admin/brands/source-failures", nil, []bson.D{cursor()}, true, []string{"count", "threshold"}},
+		{"GetBrandDuplicates", GetBrandDuplicates, "/admin/brands/duplicates", nil, nil, false, []string{"count", "computedAt"}},
+		{"ListFeatures", ListFeatures, "/admin/features", nil, nil, true, []string{"count"}},
+		{"ListExamples", ListExamples, "/examples", nil, nil, true, []string{"count"}},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		for _, enveloped := range []bool{false, true} {
+			shape := "bare"
+			if enveloped {
+				shape = "enveloped"
+			}
+			mt.Run(tt.name+" "+shape, func(mt *mtest.T) {
+				database.Use(mt.Client)
+				mt.AddMockResponses(tt.responses...)
+				c, w := testContext(http.MethodGet, tt.target, "")
+				c.Params = tt.params
+				c.Request.Header.Set(requestIDHeader, "req-1")
+				if enveloped {
+					c.Request.Header.Set(envelopeHeader, envelopeV1)
+				}
+				tt.handler(c)
+
+				if w.Code != http.StatusOK {
+					mt.Fatalf("status %d: %s", w.Code, w.Body.String())
+				}
+				if got := w.Header().Get(requestIDHeader); got != "req-1" {
+					mt.Errorf("X-Request-ID %q, want req-1", got)
+				}
+				var body interface{}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					mt.Fatalf("invalid JSON: %v (%s)", err, w.Body.String())
+				}
+				if !enveloped {
+					checkDataShape(mt, body, tt.list)
+					if object, ok := body.(map[string]interface{}); ok && object["meta"] != nil {
+						mt.Errorf("bare response has meta: %s", w.Body.String())
+					}
+					return
+				}
+
+				envelope, ok := body.(map[string]interface{})
+				if !ok || len(envelope) != 2 || envelope["data"] == nil && !tt.list {
+					mt.Fatalf("want {data, meta}, got %s", w.Body.String())
+				}
+				checkDataShape(mt, envelope["data"], tt.list)
+				meta, _ := envelope["meta"].(map[string]interface{})
+				if meta["requestId"] != "req-1" {
+					mt.Errorf("meta.requestId %v, want req-1", meta["requestId"])
+				}
+				for _, key := range tt.meta {
+					if _, ok := meta[key]; !ok {
+						mt.Errorf("meta has no %s: %v", key, meta)
+					}
+				}
+				if etag, ok := meta["etag"]; ok && etag != w.Header().Get("ETag") {
+					mt.Errorf("meta.etag %v, ETag header %q", etag, w.Header().Get("ETag"))
+				}
+			})
+		}
+	}
+}
+
+// checkDataShape fails unless data is a JSON array (list) or object.
+func checkDataShape(mt *mtest.T, data interface{}, list bool) {
+	mt.Helper()
+	switch data.(type) {
+	case []interface{}:
+		if !list {
+			mt.Errorf("got an array, want an object")
+		}
+	case map[string]interface{}:
+		if list {
+			mt.Errorf("got an object, want an array")
+		}
+	default:
+		mt.Errorf("got %T, want an array or object", data)
+	}
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetRetentionStatus godoc
+// @Summary Show data retention status
+// @Description Configured retention per collection (RETENTION_*_DAYS), whether purges run in dry-run mode, and the counts of the last purge run. Purging is disabled when no retention is configured.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} services.RetentionStatus "Retention policies and last run"
+// @Router /admin/retention [get]
+func GetRetentionStatus(c *gin.Context) {
+	respond(c, http.StatusOK, services.CurrentRetentionStatus(), nil)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SearchBrands godoc
+// @Summary Search brand details
+// @Description Full-text search over the brands' details (MongoDB text index, English stemming): words match any of them, "quoted phrases" must appear as such, -word excludes brands. Returns the most relevant brands first with their score and a snippet around the first match. Page with offset and limit; meta.total counts all matches.
+// @Tags brands
+// @Produce json
+// @Param q query string true "Search text, e.g. \"minimum order 500 units\""
+// @Param limit query int false "Hits per page (default 20, max 100)"
+// @Param offset query int false "Hits to skip (default 0, max 10000)"
+// @Success 200 {array} services.BrandSearchHit "Matching brands, most relevant first"
+// @Failure 400 {object} map[string]interface{} "Missing or invalid query parameters, each listed under fields"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/search [get]
+func SearchBrands(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	q := queryParams(c)
+	query := strings.TrimSpace(q.String("q", ""))
+	switch {
+	case query == "":
+		q.Invalid("q", "is required")
+	case utf8.RuneCountInString(query) > services.MaxSearchQueryLength:
+		q.Invalid("q", fmt.Sprintf("must be at most %d characters", services.MaxSearchQueryLength))
+	}
+	limit := q.Int("limit", 20, 1, 100)
+	offset := q.Int("offset", 0, 0, services.MaxSearchOffset)
+	if !validQuery(c, q) {
+		return
+	}
+
+	hits, total, err := services.SearchBrandDetails(ctx, coll, query, offset, limit)
+	if err != nil {
+		log.Printf("Error searching brand details for '%s': %v", services.LogValue(query), err)
+		localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		return
+	}
+	respondList(c, http.StatusOK, hits, len(hits), gin.H{"total": total, "offset": offset, "limit": limit})
+}
+
+// SuggestBrands godoc
+// @Summary Suggest brand names
+// @Description Autocompletion for the order form: brand names starting with prefix (case-insensitive), in alphabetical order, at most limit of them.
+// @Tags brands
+// @Produce json
+// @Param prefix query string true "Beginning of the name, at least 1 character"
+// @Param limit query int false "Maximum number of names (default 10, max 50)"
+// @Success 200 {array} string "Matching brand names"
+// @Failure 400 {object} map[string]interface{} "Missing or invalid query parameters, each listed under fields"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/suggest [get]
+func SuggestBrands(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	q := queryParams(c)
+	prefix := q.String("prefix", "")
+	switch length := utf8.RuneCountInString(prefix); {
+	case length == 0:
+		q.Invalid("prefix", "must be at least 1 character")
+	case length > services.MaxNameSearchLength:
+		q.Invalid("prefix", fmt.Sprintf("must be at most %d characters", services.MaxNameSearchLength))
+	}
+	limit := q.Int("limit", 10, 1, 50)
+	if !validQuery(c, q) {
+		return
+	}
+
+	names, err := services.SuggestBrandNames(ctx, coll, prefix, limit)
+	if err != nil {
+		log.Printf("Error suggesting brands for '%s': %v", services.LogValue(prefix), err)
+		localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		return
+	}
+	respondList(c, http.StatusOK, names, len(names), nil)
+}
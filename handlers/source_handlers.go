@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SupplierPDFLimits returns the download and details size limits for fetching supplier PDFs,
+// for background jobs started outside a request (see services.StartSourceRefresh).
+func SupplierPDFLimits() (int64, int) {
+	return maxUploadBytes(), maxDetailsBytes()
+}
+
+// UpdateBrandSource godoc
+// @Summary Pause, resume or reschedule a brand's source refresh
+// @Description Brands whose details come from a supplier feed PDF are re-fetched every refreshHours (default SOURCE_REFRESH_HOURS); details and updatedAt only change when the file did. paused stops that until resumed (the source is then due right away); refreshHours 0 returns to the default. Example payloads: GET /examples/UpdateBrandSource
+// @Tags brands
+// @Accept json
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param refresh body models.SourceRefreshPayload true "Fields to change"
+// @Success 200 {object} models.BrandSource "Source after the change"
+// @Failure 400 {object} map[string]interface{} "Invalid input, or the brand has no source URL"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/source [patch]
+func UpdateBrandSource(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	brandName := c.Param("brandName")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var payload models.SourceRefreshPayload
+	if !bindJSON(c, &payload) {
+		return
+	}
+	source, err := services.UpdateSourceRefresh(ctx, coll, brandName, payload)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error updating the source refresh of brand '%s': %v", services.LogValue(brandName), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update source refresh"})
+		}
+		return
+	}
+	audit(c, "brand.source", "Source refresh of brand '%s' set to paused=%t, refreshHours=%d", services.LogValue(brandName), source.Paused, source.RefreshHours)
+	respond(c, http.StatusOK, source, nil)
+}
+
+// ListSourceFailures godoc
+// @Summary List brands whose source keeps failing
+// @Description Brands whose supplier PDF failed to fetch or extract at least SOURCE_REFRESH_FAILURES times in a row, most failures first, with the last error. Their details are as of the last successful fetch.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.SourceFailure "Failing sources"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands/source-failures [get]
+func ListSourceFailures(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	failures, err := services.ListSourceFailures(ctx, coll)
+	if err != nil {
+		log.Printf("Error listing failing brand sources: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeBrandListFailed, nil, nil)
+		return
+	}
+	respondList(c, http.StatusOK, failures, len(failures), gin.H{"threshold": services.SourceRefreshFailureThreshold()})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Page size limits for one sync round
+const (
+	defaultSyncLimit = 100
+	maxSyncLimit     = 1000
+)
+
+// SyncBrands godoc
+// @Summary Differential sync of brands for offline clients
+// @Description Returns brands created/updated and tombstones for brands deleted since the given cursor, ordered deterministically. Keep calling with nextCursor while hasMore is true. When full is true the client must replace its local data (first sync, or cursor older than the tombstone retention).
+// @Tags brands
+// @Produce json
+// @Param since query string false "Opaque cursor from a previous sync (omit for a full sync)"
+// @Param limit query int false "Maximum changes per page (default 100, max 1000)"
+// @Success 200 {object} services.SyncResult "Changes and the cursor to continue from"
+// @Failure 400 {object} map[string]interface{} "Invalid 'since' cursor, or invalid query parameters listed under fields"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/sync [get]
+func SyncBrands(c *gin.Context) {
+	q := queryParams(c)
+	limit := q.Int("limit", defaultSyncLimit, 1, maxSyncLimit)
+	since := q.String("since", "")
+	if !validQuery(c, q) {
+		return
+	}
+	coll := database.GetCollection("brands")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := services.SyncBrands(ctx, coll, since, limit)
+	if err != nil {
+		if err == services.ErrInvalidSyncCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'since' cursor"})
+			return
+		}
+		log.Printf("Error computing brand sync: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute changes"})
+		return
+	}
+
+	respondList(c, http.StatusOK, result, len(result.Changes), gin.H{"hasMore": result.HasMore, "nextCursor": result.NextCursor})
+}
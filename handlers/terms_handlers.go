@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// codeTermsVersionListFailed is returned when the terms history can't be read
+const codeTermsVersionListFailed = "TERMS_VERSION_LIST_FAILED"
+
+// SetBrandTerms godoc
+// @Summary Set a brand's terms and conditions
+// @Description Replaces the terms customers have to accept before ordering from the brand: text, a link to an attachment, or both. They are part of the brand in every brand response. The version goes up by one when the text or attachment changes; sending the current terms again changes nothing. Every version stays listed by GET /brands/{brandName}/terms/versions.
+// @Tags brands
+// @Accept json
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param terms body models.BrandTermsPayload true "New terms"
+// @Success 200 {object} models.BrandTerms "Terms with their version"
+// @Failure 400 {object} map[string]interface{} "Neither text nor attachmentUrl, or an invalid URL"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/terms [put]
+func SetBrandTerms(c *gin.Context) {
+	coll := database.GetCollection("brands")
+	brandName := c.Param("brandName")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var payload models.BrandTermsPayload
+	if !bindJSON(c, &payload) {
+		return
+	}
+	terms, err := services.SetBrandTerms(ctx, coll, database.Collection(database.BrandTermsCollection), brandName, payload)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error setting the terms of brand '%s': %v", services.LogValue(brandName), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set brand terms"})
+		}
+		return
+	}
+	audit(c, "brand.terms", "Terms of brand '%s' set, now version %d", services.LogValue(brandName), terms.Version)
+	respond(c, http.StatusOK, terms, nil)
+}
+
+// ListBrandTermsVersions godoc
+// @Summary List every version of a brand's terms
+// @Description Returns each published version of the brand's terms and conditions, newest first, with the text and attachment it had, so the terms an order was accepted under can be shown later
+// @Tags brands
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Success 200 {array} models.BrandTermsVersion "Versions, newest first"
+// @Failure 404 {object} map[string]string "Brand not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/terms/versions [get]
+func ListBrandTermsVersions(c *gin.Context) {
+	brandName := c.Param("brandName")
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brand, err := services.GetBrandByName(ctx, database.GetCollection("brands"), brandName)
+	if err != nil {
+		if !domainError(c, brandName, err) {
+			log.Printf("Error finding brand '%s': %v", services.LogValue(brandName), err)
+			localizedError(c, http.StatusInternalServerError, codeBrandReadFailed, nil, nil)
+		}
+		return
+	}
+	versions, err := services.ListTermsVersions(ctx, database.Collection(database.BrandTermsCollection), brand.ID)
+	if err != nil {
+		log.Printf("Error listing the terms versions of brand '%s': %v", services.LogValue(brandName), err)
+		localizedError(c, http.StatusInternalServerError, codeTermsVersionListFailed, nil, nil)
+		return
+	}
+	respondList(c, http.StatusOK, versions, len(versions), nil)
+}
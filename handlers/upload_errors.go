@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/uploadpolicy"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartMemory is how much of a multipart form is kept in memory before spilling to temp
+// files (Gin's default for c.FormFile).
+const multipartMemory = 32 << 20
+
+// Error codes for uploads that failed because of the client, so they can be told apart from
+// server failures in logs and by the frontend.
+const (
+	uploadCodeTooLarge     = "UPLOAD_TOO_LARGE"
+	uploadCodeIncomplete   = "UPLOAD_INCOMPLETE" // Body ended early, e.g. the browser aborted
+	uploadCodeTimeout      = "UPLOAD_TIMEOUT"
+	uploadCodeMalformed    = "UPLOAD_MALFORMED"
+	uploadCodeMediaType    = "UPLOAD_UNSUPPORTED_MEDIA_TYPE"
+	uploadCodeNotPDF       = "UPLOAD_NOT_PDF"
+	uploadCodeTooManyPages = "UPLOAD_TOO_MANY_PAGES"
+	uploadCodeEmpty        = "UPLOAD_NO_TEXT"
+	uploadCodeBusy         = "UPLOAD_BUSY"
+)
+
+// pdfMagic is the signature every PDF file starts with.
+var pdfMagic = []byte("%PDF-")
+
+// maxUploadBytes returns the request body limit for uploads (upload policy maxBytes).
+func maxUploadBytes() int64 {
+	return uploadpolicy.Current().MaxBytes
+}
+
+// classifyUploadError maps an error from reading a multipart body to a client status and code.
+// ok is false for errors that aren't the client's fault.
+func classifyUploadError(err error) (status int, code string, ok bool) {
+	var maxBytesErr *http.MaxBytesError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge, uploadCodeTooLarge, true
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return http.StatusBadRequest, uploadCodeIncomplete, true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusRequestTimeout, uploadCodeTimeout, true
+	case errors.Is(err, multipart.ErrMessageTooLarge):
+		return http.StatusRequestEntityTooLarge, uploadCodeTooLarge, true
+	case errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary),
+		strings.HasPrefix(err.Error(), "multipart: "):
+		return http.StatusBadRequest, uploadCodeMalformed, true
+	}
+	return 0, "", false
+}
+
+// parseUploadForm reads the multipart body up front (limited to maxUploadBytes) so read failures
+// are classified once, instead of surfacing later as a missing field or a 500. It writes the
+// response and returns false on failure; afterwards c.PostForm and c.FormFile use the parsed form.
+func parseUploadForm(c *gin.Context) bool {
+	return parseUploadFormLimit(c, maxUploadBytes())
+}
+
+// parseUploadFormLimit is parseUploadForm with an explicit body limit.
+func parseUploadFormLimit(c *gin.Context, limit int64) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	err := c.Request.ParseMultipartForm(multipartMemory)
+	if err == nil {
+		return true
+	}
+	if status, code, ok := classifyUploadError(err); ok {
+		// Client problem (aborted or broken upload): no need to page anyone
+		log.Printf("Client upload error (%s) from %s: %v", code, c.ClientIP(), err)
+		localizedError(c, status, code, nil, gin.H{"detail": err.Error()})
+		return false
+	}
+	log.Printf("Error reading multipart upload: %v", err)
+	localizedError(c, http.StatusInternalServerError, codeUploadFailed, nil, nil)
+	return false
+}
+
+// readRawPDF reads a raw PDF request body (one of the policy's allowed types, by default
+// application/pdf or application/octet-stream, and starting with the PDF signature) within maxUploadBytes. It writes the response and returns false on failure.
+func readRawPDF(c *gin.Context) ([]byte, bool) {
+	mediaType, _, err := mime.ParseMediaType(c.ContentType())
+	if err != nil || !uploadpolicy.Current().AllowsType(mediaType) {
+		localizedError(c, http.StatusUnsupportedMediaType, uploadCodeMediaType, nil, nil)
+		return nil, false
+	}
+	limit := maxUploadBytes()
+	if c.Request.ContentLength > limit {
+		// Refuse up front instead of reading a body we'll reject anyway
+		localizedError(c, http.StatusRequestEntityTooLarge, uploadCodeTooLarge, nil, nil)
+		return nil, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+	if err != nil {
+		if status, code, ok := classifyUploadError(err); ok {
+			log.Printf("Client upload error (%s) from %s: %v", code, c.ClientIP(), err)
+			localizedError(c, status, code, nil, gin.H{"detail": err.Error()})
+			return nil, false
+		}
+		log.Printf("Error reading raw PDF upload: %v", err)
+		localizedError(c, http.StatusInternalServerError, codeUploadFailed, nil, nil)
+		return nil, false
+	}
+	if !bytes.HasPrefix(body, pdfMagic) {
+		localizedError(c, http.StatusBadRequest, uploadCodeNotPDF, nil, nil)
+		return nil, false
+	}
+	return body, true
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/uploadpolicy"
+)
+
+// timeoutError is a net.Error reporting a timeout, like a read deadline expiring mid-upload.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+const testBoundary = "XyZ"
+
+// multipartBody returns a form with one file part, cut off after cut bytes when cut > 0.
+func multipartBody(cut int) string {
+	body := "--" + testBoundary + "\r\n" +
+		"Content-Disposition: form-data; name=\"pdf\"; filename=\"a.pdf\"\r\n" +
+		"Content-Type: application/pdf\r\n\r\n" +
+		strings.Repeat("%PDF-1.4 ", 20) + "\r\n" +
+		"--" + testBoundary + "--\r\n"
+	if cut > 0 {
+		return body[:cut]
+	}
+	return body
+}
+
+func TestParseUploadForm(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		maxBytes    string
+		wantStatus  int // 0: the form is parsed
+		wantCode    string
+	}{
+		{"complete", "multipart/form-data; boundary=" + testBoundary, multipartBody(0), "", 0, ""},
+		{"aborted mid-file", "multipart/form-data; boundary=" + testBoundary, multipartBody(150), "", http.StatusBadRequest, uploadCodeIncomplete},
+		{"over MAX_UPLOAD_BYTES", "multipart/form-data; boundary=" + testBoundary, multipartBody(0), "100", http.StatusRequestEntityTooLarge, uploadCodeTooLarge},
+		{"not multipart", "application/json", `{}`, "", http.StatusBadRequest, uploadCodeMalformed},
+		{"missing boundary", "multipart/form-data", multipartBody(0), "", http.StatusBadRequest, uploadCodeMalformed},
+		{"wrong boundary", "multipart/form-data; boundary=other", multipartBody(0), "", http.StatusBadRequest, uploadCodeIncomplete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { uploadpolicy.Init(nil) })
+			t.Setenv("MAX_UPLOAD_BYTES", tt.maxBytes)
+			uploadpolicy.Init(nil)
+			c, w := testContext(http.MethodPost, "/brands/upload", tt.body)
+			c.Request.Header.Set("Content-Type", tt.contentType)
+
+			ok := parseUploadForm(c)
+			if ok != (tt.wantStatus == 0) {
+				t.Fatalf("parseUploadForm() = %v (%d %s), want status %d", ok, w.Code, w.Body.String(), tt.wantStatus)
+			}
+			if ok {
+				return
+			}
+			body := decodeResponse(t, w)
+			if w.Code != tt.wantStatus || body["code"] != tt.wantCode {
+				t.Errorf("got %d %v, want %d %s", w.Code, body["code"], tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestClassifyUploadError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantClient bool
+	}{
+		{"read timeout", timeoutError{}, http.StatusRequestTimeout, uploadCodeTimeout, true},
+		{"body limit", &http.MaxBytesError{Limit: 10}, http.StatusRequestEntityTooLarge, uploadCodeTooLarge, true},
+		{"server failure", errors.New("open /tmp/multipart-123: no space left on device"), 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code, client := classifyUploadError(tt.err)
+			if status != tt.wantStatus || code != tt.wantCode || client != tt.wantClient {
+				t.Errorf("got %d %q %v, want %d %q %v", status, code, client, tt.wantStatus, tt.wantCode, tt.wantClient)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/uploadpolicy"
+	"github.com/gin-gonic/gin"
+)
+
+// GetUploadPolicy godoc
+// @Summary Show the upload policy
+// @Description The rules uploads are accepted under, and whether they come from the stored policy document or the environment defaults
+// @Tags admin
+// @Produce json
+// @Success 200 {object} uploadpolicy.Policy "Effective upload policy"
+// @Router /admin/upload-policy [get]
+func GetUploadPolicy(c *gin.Context) {
+	respond(c, http.StatusOK, uploadpolicy.Current(), nil)
+}
+
+// UpdateUploadPolicy godoc
+// @Summary Replace the upload policy
+// @Description Stores a complete upload policy. Other instances apply it within 30 seconds.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param policy body uploadpolicy.Policy true "New policy (source and updatedAt are ignored)"
+// @Success 200 {object} uploadpolicy.Policy "Upload policy after the change"
+// @Failure 400 {object} map[string]interface{} "Invalid policy"
+// @Failure 422 {object} map[string]interface{} "Field has the wrong type"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/upload-policy [put]
+func UpdateUploadPolicy(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var payload uploadpolicy.Policy
+	if !bindJSON(c, &payload) {
+		return
+	}
+
+	previous, err := uploadpolicy.Set(ctx, payload)
+	if err != nil {
+		var invalid *uploadpolicy.ValidationError
+		if errors.As(err, &invalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalid.Error(), "problems": invalid.Problems})
+			return
+		}
+		log.Printf("Error updating upload policy: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update upload policy"})
+		return
+	}
+
+	// Audit trail: who changed what
+	current := uploadpolicy.Current()
+	for _, change := range previous.Changes(current) {
+		audit(c, "uploadpolicy.update", "upload policy %s", change)
+	}
+	respond(c, http.StatusOK, current, nil)
+}
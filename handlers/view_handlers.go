@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/queryparams"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Limits for the views endpoints.
+const (
+	defaultViewPeriodDays = 30
+	maxViewPeriodDays     = 366
+	defaultTopViewed      = 10
+	maxTopViewed          = 100
+)
+
+// viewPeriod reads ?from= and ?to= (YYYY-MM-DD, UTC, inclusive) from q, defaulting to the last
+// 30 days. Invalid or too long ranges are recorded in q.
+func viewPeriod(q *queryparams.Query) (time.Time, time.Time) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := q.Date("to", today)
+	from := q.Date("from", to.AddDate(0, 0, -(defaultViewPeriodDays-1)))
+	if from.After(to) {
+		q.Invalid("from", "must not be after 'to'")
+	} else if to.Sub(from) >= maxViewPeriodDays*24*time.Hour {
+		q.Invalid("from", "period too long, at most "+strconv.Itoa(maxViewPeriodDays)+" days")
+	}
+	return from, to
+}
+
+// GetBrandViews godoc
+// @Summary Daily views of a brand
+// @Description Number of detail views per UTC day, including days without views. Counts are buffered and written every few seconds, so the current day may lag slightly.
+// @Tags brands
+// @Produce json
+// @Param brandName path string true "Name of the brand"
+// @Param from query string false "First day (YYYY-MM-DD), default 29 days before 'to'"
+// @Param to query string false "Last day (YYYY-MM-DD), default today"
+// @Success 200 {array} models.BrandViewDay "Daily view counts"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters, each listed under fields"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /brands/{brandName}/views [get]
+func GetBrandViews(c *gin.Context) {
+	q := queryParams(c)
+	from, to := viewPeriod(q)
+	if !validQuery(c, q) {
+		return
+	}
+	coll := database.Collection(database.BrandViewsCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	brandName := c.Param("brandName")
+	series, err := services.BrandViewSeries(ctx, coll, brandName, from, to)
+	if err != nil {
+		log.Printf("Error loading views for brand '%s': %v", services.LogValue(brandName), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve brand views"})
+		return
+	}
+	var total int64
+	for _, day := range series {
+		total += day.Count
+	}
+	respondList(c, http.StatusOK, series, len(series), gin.H{"total": total, "tracking": services.ViewTrackingEnabled()})
+}
+
+// GetTopViewedBrands godoc
+// @Summary Most-viewed brands
+// @Description Brands ranked by detail views over a period
+// @Tags admin
+// @Produce json
+// @Param from query string false "First day (YYYY-MM-DD), default 29 days before 'to'"
+// @Param to query string false "Last day (YYYY-MM-DD), default today"
+// @Param limit query int false "Number of brands (default 10, max 100)"
+// @Success 200 {array} models.BrandViewTotal "Brands with their view totals"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters, each listed under fields"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/brands/views/top [get]
+func GetTopViewedBrands(c *gin.Context) {
+	q := queryParams(c)
+	from, to := viewPeriod(q)
+	limit := q.Int("limit", defaultTopViewed, 1, maxTopViewed)
+	if !validQuery(c, q) {
+		return
+	}
+	coll := database.Collection(database.BrandViewsCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	top, err := services.TopViewedBrands(ctx, coll, from, to, limit)
+	if err != nil {
+		log.Printf("Error ranking brand views: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve brand views"})
+		return
+	}
+	respondList(c, http.StatusOK, top, len(top), gin.H{"from": from.Format(services.ViewDayLayout), "to": to.Format(services.ViewDayLayout)})
+}
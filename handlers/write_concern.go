@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// writeConcernDefault means "whatever the client/server is configured with".
+const writeConcernDefault = "default"
+
+// writeAck reports what a mutating request did; it is returned as meta.ack.
+type writeAck struct {
+	Matched           int64  `json:"matched"`
+	Modified          int64  `json:"modified"`
+	Upserted          int64  `json:"upserted"`
+	Inserted          int64  `json:"inserted"`
+	Deleted           int64  `json:"deleted"`
+	WriteConcern      string `json:"writeConcern"`
+	WriteConcernError string `json:"writeConcernError,omitempty"` // Applied, but not acknowledged as requested
+}
+
+// parseWriteConcern accepts "default", "majority" or a non-negative node count.
+func parseWriteConcern(value string) (*writeconcern.WriteConcern, bool) {
+	switch value {
+	case "", writeConcernDefault:
+		return nil, true
+	case "majority":
+		return writeconcern.Majority(), true
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+		return &writeconcern.WriteConcern{W: n}, true
+	}
+	return nil, false
+}
+
+// defaultWriteConcern returns WRITE_CONCERN (falling back to the connection's own default).
+func defaultWriteConcern() string {
+	value := os.Getenv("WRITE_CONCERN")
+	if _, ok := parseWriteConcern(value); !ok {
+		log.Printf("Warning: Invalid WRITE_CONCERN '%s', using the connection default", value)
+		return writeConcernDefault
+	}
+	if value == "" {
+		return writeConcernDefault
+	}
+	return value
+}
+
+// withWriteConcern returns coll configured with the request's ?writeConcern= (or the configured
+// default) and the label to report in the ack. It writes a 400 and returns false for invalid values.
+func withWriteConcern(c *gin.Context, coll *mongo.Collection) (*mongo.Collection, string, bool) {
+	label := c.Query("writeConcern")
+	if label == "" {
+		label = defaultWriteConcern()
+	}
+	wc, ok := parseWriteConcern(label)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid writeConcern, expected 'majority', 'default' or a node count"})
+		return nil, "", false
+	}
+	if wc == nil {
+		return coll, writeConcernDefault, true
+	}
+	clone, err := coll.Clone(options.Collection().SetWriteConcern(wc))
+	if err != nil {
+		log.Printf("Error applying write concern '%s': %v", label, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply write concern"})
+		return nil, "", false
+	}
+	return clone, label, true
+}
+
+// writeConcernOnly reports whether err is only a write concern failure: the write itself was
+// applied but could not be acknowledged as requested (e.g. w > 1 on a standalone server, or a
+// replica set without a reachable majority). Callers treat the write as done and surface the
+// message in the ack instead of failing the request.
+func writeConcernOnly(err error) (string, bool) {
+	var we mongo.WriteException
+	if errors.As(err, &we) && we.WriteConcernError != nil && len(we.WriteErrors) == 0 {
+		return we.WriteConcernError.Message, true
+	}
+	return "", false
+}
@@ -0,0 +1,105 @@
+// Package i18n translates the machine-readable error codes returned by the API into
+// user-facing messages. Catalogs are embedded JSON files, one per locale, mapping codes to
+// messages with {placeholders}. English is the fallback for unknown locales and missing entries.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fallback is the locale used when nothing better matches; every code must exist in it.
+const Fallback = "en"
+
+//go:embed locales/*.json
+var files embed.FS
+
+// catalogs maps locale -> code -> message.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err) // The embedded directory is fixed at build time
+	}
+	result := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		raw, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(raw, &catalog); err != nil {
+			panic("i18n: invalid catalog " + entry.Name() + ": " + err.Error())
+		}
+		result[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+	if _, ok := result[Fallback]; !ok {
+		panic("i18n: missing fallback catalog " + Fallback)
+	}
+	return result
+}
+
+// Locales returns the available locales, sorted.
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Negotiate picks the best available locale for an Accept-Language header
+// (e.g. "fr-CH, fr;q=0.9, en;q=0.8"), falling back to English.
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := Fallback, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		// Only the primary language matters for our catalogs ("fr-CH" -> "fr")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := catalogs[lang]; ok && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Has reports whether code has a message in the fallback catalog.
+func Has(code string) bool {
+	_, ok := catalogs[Fallback][code]
+	return ok
+}
+
+// Message returns the message for code in locale with {name} placeholders replaced from params.
+// Missing translations fall back to English, and unknown codes to the code itself.
+func Message(locale, code string, params map[string]string) string {
+	message, ok := catalogs[locale][code]
+	if !ok {
+		if message, ok = catalogs[Fallback][code]; !ok {
+			log.Printf("Warning: No message for error code '%s'", code)
+			return code
+		}
+	}
+	if len(params) == 0 {
+		return message
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
@@ -0,0 +1,81 @@
+package i18n
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"de-AT", "de"},
+		{"FR-ch", "fr"},
+		{"xx, yy;q=0.5", "en"},
+		{"fr-CH, fr;q=0.9, en;q=0.8", "fr"},
+		{"en;q=0.5, de;q=0.9", "de"},
+		{"de;q=0.1, xx", "de"},
+		{"fr;q=bogus, de;q=0.2", "de"},
+		{"de;q=0, fr;q=0", "en"},
+		{" de-AT ; q=0.7 , fr ; q=0.6", "de"},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		locale, code string
+		params       map[string]string
+		want         string
+	}{
+		{"en", "BRAND_NOT_FOUND", map[string]string{"name": "Acme"}, "Brand 'Acme' not found"},
+		{"de", "BRAND_NOT_FOUND", map[string]string{"name": "Acme"}, "Marke „Acme“ nicht gefunden"},
+		{"xx", "INVALID_INPUT", nil, "Invalid input"},
+		{"en", "NO_SUCH_CODE", nil, "NO_SUCH_CODE"},
+		{"en", "INVALID_JSON", map[string]string{"line": "2", "column": "13"}, "Invalid JSON at line 2, column 13"},
+	}
+	for _, tt := range tests {
+		if got := Message(tt.locale, tt.code, tt.params); got != tt.want {
+			t.Errorf("Message(%q, %q) = %q, want %q", tt.locale, tt.code, got, tt.want)
+		}
+	}
+}
+
+var placeholder = regexp.MustCompile(`\{\w+\}`)
+
+// Every catalog translates every English code, using the same placeholders.
+func TestCatalogsComplete(t *testing.T) {
+	for _, locale := range Locales() {
+		for code, english := range catalogs[Fallback] {
+			message, ok := catalogs[locale][code]
+			if !ok {
+				t.Errorf("%s: missing %s", locale, code)
+				continue
+			}
+			want, got := placeholders(english), placeholders(message)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s has placeholders %v, want %v", locale, code, got, want)
+			}
+		}
+		for code := range catalogs[locale] {
+			if _, ok := catalogs[Fallback][code]; !ok {
+				t.Errorf("%s: %s is not in the %s catalog", locale, code, Fallback)
+			}
+		}
+	}
+}
+
+func placeholders(message string) []string {
+	found := placeholder.FindAllString(message, -1)
+	sort.Strings(found)
+	return found
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey is a stored API key. Only the SHA-256 of the secret is kept; the secret itself is shown
+// once, when the key is created or rotated.
+type APIKey struct {
+	ID        primitive.ObjectID  `bson:"_id" json:"id"`
+	Label     string              `bson:"label,omitempty" json:"label,omitempty"`
+	Prefix    string              `bson:"prefix" json:"prefix"` // Start of the secret, to tell keys apart
+	Hash      string              `bson:"hash" json:"-"`
+	Scopes    []string            `bson:"scopes" json:"scopes"`
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+	CreatedBy string              `bson:"createdBy,omitempty" json:"createdBy,omitempty"` // Who created it (see APIKeyActor in services)
+	ExpiresAt *time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"` // End of the grace period after a rotation
+	RotatedTo *primitive.ObjectID `bson:"rotatedTo,omitempty" json:"rotatedTo,omitempty"` // The key that replaced this one
+	RevokedAt *time.Time          `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+// APIKeyPayload creates an API key
+type APIKeyPayload struct {
+	Label  string   `json:"label" binding:"max=200"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=brands:read brands:write admin"`
+}
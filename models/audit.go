@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditSchemaVersion is the layout version of audit entries. It is stored and exported with
+// every entry so SIEM parsers can tell layouts apart; bump it when a field is renamed, removed
+// or changes meaning.
+const AuditSchemaVersion = 2
+
+// AuditEntry records one administrative action (a deletion, a configuration or feature flag
+// change, a portal token issued, ...). Entries live in a capped collection and are exported as
+// one JSON line each.
+type AuditEntry struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchemaVersion int                `bson:"schemaVersion" json:"schemaVersion"`
+	At            time.Time          `bson:"at" json:"at"`
+	Action        string             `bson:"action" json:"action"`                         // e.g. "brand.delete"
+	Actor         string             `bson:"actor" json:"actor"`                           // API key (see services.APIKeyActor), client IP on routes without one, or what triggered it (e.g. SIGHUP)
+	ClientIP      string             `bson:"clientIp,omitempty" json:"clientIp,omitempty"` // Client IP of the request, since version 2
+	Message       string             `bson:"message" json:"message"`                       // Human-readable description, as logged
+}
+
+// AuditReplayRequest selects the audit entries to export again: those recorded at or after From
+// and before To.
+type AuditReplayRequest struct {
+	From time.Time `json:"from" binding:"required"`
+	To   time.Time `json:"to" binding:"required,gtfield=From"`
+}
@@ -8,22 +8,64 @@ import (
 
 // Brand represents the data structure for a brand in the MongoDB collection
 type Brand struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`            // MongoDB primary key
-	Name      string             `bson:"name" validate:"required"` // Index this field in MongoDB for lookups
-	Details   string             `bson:"details"`
-	CreatedAt time.Time          `bson:"createdAt"`
-	UpdatedAt time.Time          `bson:"updatedAt"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty"`            // MongoDB primary key
+	Name          string              `bson:"name" validate:"required"` // Index this field in MongoDB for lookups
+	Details       string              `bson:"details"`
+	DetailsFormat string              `bson:"detailsFormat,omitempty"`       // plain, markdown or tsv; empty on brands stored before formats existed
+	Keywords      []Keyword           `bson:"keywords,omitempty"`            // Top terms extracted from Details, recomputed on every change
+	Sections      []DetailsSection    `bson:"sections,omitempty" json:"-"`   // Section index of Details, recomputed on every change; served by the sections endpoints
+	Specs         primitive.M         `bson:"specs,omitempty"`               // Supplier-provided specification fields, merged key by key by the supplier feed
+	Extraction    *ExtractionInfo     `bson:"extraction,omitempty" json:"-"` // Diagnostics from the last PDF extraction; admin only, see GET /admin/brands/:brandName/diagnostics
+	Logo          *BrandLogo          `bson:"logo,omitempty"`                // Resized logo variants; set via the logo endpoint
+	Contacts      []Contact           `bson:"contacts,omitempty" json:"-"`   // Internal only: managed via the contacts endpoints, never in public responses
+	ParentBrand   *primitive.ObjectID `bson:"parentBrand,omitempty"`         // ID of the parent company's brand; set via PATCH parentBrand
+	Source        *BrandSource        `bson:"source,omitempty" json:"-"`     // Supplier URL the details are refreshed from; admin only, see GET /admin/brands/:brandName/diagnostics
+	Terms         *BrandTerms         `bson:"terms,omitempty"`               // Terms customers accept before ordering; set via the terms endpoint
+	CreatedAt     time.Time           `bson:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt"`
 	// Optional: Store filename if you keep the original PDF
 	// OriginalPDFPath string `bson:"originalPdfPath,omitempty"`
 }
 
 // CreateBrandPayload remains the same as it's for HTTP request binding
 type CreateBrandPayload struct {
-	Name    string `json:"name" binding:"required"`
-	Details string `json:"details" binding:"required"`
+	Name          string `json:"name" form:"name" binding:"required"`
+	Details       string `json:"details" form:"details" binding:"required"`
+	DetailsFormat string `json:"detailsFormat" form:"detailsFormat" binding:"omitempty,oneof=plain markdown tsv"` // Detected from the text when omitted
 }
 
-// UpdateBrandPayload remains the same
+// UpdateBrandPayload replaces the details. The details key is required; null or "" clears them.
 type UpdateBrandPayload struct {
-	Details string `json:"details" binding:"required"`
+	Details       OptionalString `json:"details" swaggertype:"string"`
+	DetailsFormat string         `json:"detailsFormat" binding:"omitempty,oneof=plain markdown tsv"` // Detected from the text when omitted
+}
+
+// PatchBrandPayload changes only the fields that are present. A missing details key leaves the
+// details unchanged; null or "" clears them. parentBrand names the parent company's brand; null
+// or "" makes the brand top-level again.
+type PatchBrandPayload struct {
+	Details       OptionalString `json:"details" swaggertype:"string"`
+	DetailsFormat *string        `json:"detailsFormat" binding:"omitempty,oneof=plain markdown tsv"`
+	ParentBrand   OptionalString `json:"parentBrand" swaggertype:"string"`
+}
+
+// Formats a brand's details can be rendered in
+const (
+	DetailsFormatPlain    = "plain"
+	DetailsFormatMarkdown = "markdown"
+	DetailsFormatTSV      = "tsv"
+)
+
+// DetailsFragmentPayload is used to append/prepend a piece of text to existing details
+type DetailsFragmentPayload struct {
+	Text    string `json:"text" binding:"required"`
+	Section string `json:"section"` // Optional label rendered as a heading line above the text
+}
+
+// BrandTombstone records a deleted brand so offline clients can remove it during sync
+type BrandTombstone struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	BrandID   primitive.ObjectID `bson:"brandId" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	DeletedAt time.Time          `bson:"deletedAt" json:"deletedAt"`
 }
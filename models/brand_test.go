@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Public brand responses (cached by the CDN) never carry the internal fields.
+func TestBrandJSONHidesInternalFields(t *testing.T) {
+	brand := Brand{
+		Name:       "Acme",
+		Extraction: &ExtractionInfo{Engine: "pdftotext", Warnings: []string{"broken xref"}},
+		Contacts:   []Contact{{Name: "Jo"}},
+		Sections:   []DetailsSection{{ID: "abc"}},
+		Source:     &BrandSource{URL: "https://supplier.example/catalog.pdf", LastError: "HTTP 403"},
+	}
+	raw, err := json.Marshal(brand)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"Extraction", "Contacts", "Sections", "Source"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("brand JSON contains %s: %s", key, raw)
+		}
+	}
+}
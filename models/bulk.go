@@ -0,0 +1,29 @@
+package models
+
+// Outcomes of one item of a bulk brand creation
+const (
+	BulkCreated   = "created"
+	BulkDuplicate = "duplicate" // The name exists already, or earlier in the same request
+	BulkInvalid   = "invalid"
+	BulkFailed    = "failed" // Valid, but the database rejected the insert for another reason
+)
+
+// BulkCreateResult is the outcome for the item at Index of a bulk creation request.
+type BulkCreateResult struct {
+	Index  int    `json:"index"`
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`    // Hex ObjectID of the created brand
+	Code   string `json:"code,omitempty"`  // Error code for invalid and failed items, as in single-item responses
+	Error  string `json:"error,omitempty"` // Localized like the "error" of single-item responses
+}
+
+// BulkCreateSummary counts the outcomes of a bulk creation and lists one result per item, in
+// request order.
+type BulkCreateSummary struct {
+	Created    int                `json:"created"`
+	Duplicates int                `json:"duplicates"`
+	Invalid    int                `json:"invalid"`
+	Failed     int                `json:"failed"`
+	Results    []BulkCreateResult `json:"results"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Contact roles tracked per brand.
+const (
+	ContactRoleSales      = "sales"
+	ContactRoleLogistics  = "logistics"
+	ContactRoleEscalation = "escalation"
+	ContactRoleOther      = "other"
+)
+
+// Contact is a person at the brand ops can reach. Contacts are internal: they are stored on the
+// brand document but never serialized in brand responses (see Brand.Contacts).
+type Contact struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Role      string             `bson:"role" json:"role"`
+	Email     string             `bson:"email,omitempty" json:"email,omitempty"`
+	Phone     string             `bson:"phone,omitempty" json:"phone,omitempty"`
+	Preferred bool               `bson:"preferred" json:"preferred"` // At most one per brand
+}
+
+// ContactPayload creates or replaces a contact
+type ContactPayload struct {
+	Name      string `json:"name" binding:"required"`
+	Role      string `json:"role" binding:"required,oneof=sales logistics escalation other"`
+	Email     string `json:"email" binding:"omitempty,email"`
+	Phone     string `json:"phone"`
+	Preferred bool   `json:"preferred"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Steps of a brand deletion, in the order they run (see the deletion package).
+const (
+	DeletionStepDocument  = "document"  // Brand document removed
+	DeletionStepTombstone = "tombstone" // Sync tombstone recorded
+	DeletionStepLogoFiles = "logoFiles" // GridFS logo variants removed
+	DeletionStepCaches    = "caches"    // In-memory caches (duplicate report) updated
+)
+
+// Status of a deletion step.
+const (
+	DeletionPending = "pending"
+	DeletionDone    = "done"
+	DeletionFailed  = "failed"
+)
+
+// DeletionManifest records one brand deletion: what was removed and how far it got. It is written
+// before anything is deleted, so a deletion cut short can be found and finished (see
+// deletion.Resume) instead of leaving a half-deleted brand behind.
+type DeletionManifest struct {
+	ID                primitive.ObjectID   `bson:"_id" json:"id"`
+	BrandID           primitive.ObjectID   `bson:"brandId" json:"brandId"`
+	BrandName         string               `bson:"brandName" json:"brandName"`
+	Contacts          int                  `bson:"contacts" json:"contacts"`
+	LogoFiles         []primitive.ObjectID `bson:"logoFiles,omitempty" json:"logoFiles,omitempty"`
+	Transactional     bool                 `bson:"transactional" json:"transactional"` // Document and tombstone were removed/recorded atomically
+	Steps             []DeletionStep       `bson:"steps" json:"steps"`
+	Complete          bool                 `bson:"complete" json:"complete"`
+	StartedAt         time.Time            `bson:"startedAt" json:"startedAt"`
+	UpdatedAt         time.Time            `bson:"updatedAt" json:"updatedAt"`
+	RequestedBy       string               `bson:"requestedBy,omitempty" json:"requestedBy,omitempty"`             // API key or client IP (see services.APIKeyActor), or "brandctl"
+	WriteConcernError string               `bson:"writeConcernError,omitempty" json:"writeConcernError,omitempty"` // Removed, but not acknowledged as requested
+}
+
+// DeletionStep is the state of one step of a deletion.
+type DeletionStep struct {
+	Name     string `bson:"name" json:"name"`
+	Status   string `bson:"status" json:"status"`
+	Error    string `bson:"error,omitempty" json:"error,omitempty"`
+	Attempts int    `bson:"attempts" json:"attempts"`
+}
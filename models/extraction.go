@@ -0,0 +1,19 @@
+package models
+
+// ExtractionInfo records how a brand's details were extracted from an uploaded PDF,
+// so poor-quality extractions can be diagnosed without digging through logs
+type ExtractionInfo struct {
+	Engine     string   `bson:"engine" json:"engine"`         // Tool that produced the text, e.g. "pdftotext"
+	DurationMs int64    `bson:"durationMs" json:"durationMs"` // Wall-clock time spent extracting
+	PageCount  int      `bson:"pageCount" json:"pageCount"`   // Pages processed
+	Truncated  bool     `bson:"truncated" json:"truncated"`   // Text was cut to the maximum details size
+	Warnings   []string `bson:"warnings" json:"warnings"`     // Non-fatal problems noticed during extraction
+}
+
+// BrandDiagnostics is the admin-only view of a brand's internal state, kept out of the public
+// (and CDN-cached) brand responses
+type BrandDiagnostics struct {
+	Name       string          `json:"name"`
+	Extraction *ExtractionInfo `json:"extraction"`       // Null for manual details
+	Source     *BrandSource    `json:"source,omitempty"` // Supplier feed URL and refresh state, if the brand has one
+}
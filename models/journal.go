@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stages an upload passes through, in order, as recorded in the upload journal.
+const (
+	UploadStageReceived  = "received"
+	UploadStageExtracted = "extracted"
+	UploadStageUpserted  = "upserted"
+	UploadStageResponded = "responded"
+)
+
+// UploadJournalEntry records the progress of one PDF upload. The journal lives in a capped
+// collection, where updates may not grow a document, so every field is written on insert and
+// stage timestamps stay at the zero time until the stage is reached.
+type UploadJournalEntry struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RequestID   string             `bson:"requestId" json:"requestId"`
+	Brand       string             `bson:"brand" json:"brand"`
+	FileSHA256  string             `bson:"fileSha256" json:"fileSha256"`
+	Size        int64              `bson:"size" json:"size"`
+	ReceivedAt  time.Time          `bson:"receivedAt" json:"receivedAt"`
+	ExtractedAt time.Time          `bson:"extractedAt" json:"extractedAt"`
+	UpsertedAt  time.Time          `bson:"upsertedAt" json:"upsertedAt"`
+	RespondedAt time.Time          `bson:"respondedAt" json:"respondedAt"`
+	Status      int32              `bson:"status" json:"status"` // HTTP status sent, 0 until responded
+	Stage       string             `bson:"-" json:"stage"`       // Last stage reached, derived from the timestamps
+}
+
+// LastStage returns the last stage the upload reached.
+func (e *UploadJournalEntry) LastStage() string {
+	switch {
+	case !e.RespondedAt.IsZero():
+		return UploadStageResponded
+	case !e.UpsertedAt.IsZero():
+		return UploadStageUpserted
+	case !e.ExtractedAt.IsZero():
+		return UploadStageExtracted
+	}
+	return UploadStageReceived
+}
@@ -0,0 +1,13 @@
+package models
+
+// Keyword is a single extracted term from a brand's details along with how often it occurs
+type Keyword struct {
+	Term  string `bson:"term" json:"term"`
+	Count int    `bson:"count" json:"count"`
+}
+
+// KeywordBrandMatch is returned by the keyword reverse lookup: which brand mentions a term and how often
+type KeywordBrandMatch struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
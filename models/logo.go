@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BrandLogo describes the stored variants of a brand's logo. The image data lives in GridFS;
+// the brand document only references it, so re-uploading swaps all variants in one update.
+type BrandLogo struct {
+	ContentType string        `bson:"contentType" json:"contentType"` // image/png or image/jpeg, the same for every variant
+	Variants    []LogoVariant `bson:"variants" json:"variants"`       // Ordered by size, smallest first
+	UpdatedAt   time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
+
+// LogoVariant is one resized copy of a logo, fitting in a Size x Size box.
+type LogoVariant struct {
+	Size   int                `bson:"size" json:"size"`
+	Width  int                `bson:"width" json:"width"`
+	Height int                `bson:"height" json:"height"`
+	Bytes  int64              `bson:"bytes" json:"bytes"`
+	SHA256 string             `bson:"sha256" json:"sha256"` // Hex digest of the encoded image, used as its ETag
+	FileID primitive.ObjectID `bson:"fileId" json:"-"`
+}
+
+// Variant returns the variant of the given size, or nil.
+func (l *BrandLogo) Variant(size int) *LogoVariant {
+	if l == nil {
+		return nil
+	}
+	for i := range l.Variants {
+		if l.Variants[i].Size == size {
+			return &l.Variants[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// OptionalString is a payload field that tells apart a missing key, an explicit null and a
+// value, which a plain string (or *string) can't: encoding/json leaves both missing and null
+// as the zero value.
+type OptionalString struct {
+	Set   bool   // The key was present in the body
+	Valid bool   // The value was not null
+	Value string // "" unless Valid
+}
+
+// UnmarshalJSON is only called when the key is present, including for null.
+//
+// A value that isn't a string fails with a *json.UnmarshalTypeError without Field and Offset:
+// encoding/json doesn't add them to an Unmarshaler's error, so the caller has to (see bindJSON).
+func (o *OptionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Valid, o.Value = false, ""
+		return nil
+	}
+	if len(data) == 0 || data[0] != '"' {
+		return &json.UnmarshalTypeError{Value: jsonKind(data), Type: reflect.TypeOf("")}
+	}
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Valid = true
+	return nil
+}
+
+// jsonKind names the type of a JSON value the way encoding/json's type errors do.
+func jsonKind(data []byte) string {
+	switch {
+	case len(data) == 0:
+		return "value"
+	case data[0] == '{':
+		return "object"
+	case data[0] == '[':
+		return "array"
+	case data[0] == 't' || data[0] == 'f':
+		return "bool"
+	default:
+		return "number"
+	}
+}
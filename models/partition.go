@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BrandPartitionSet splits the brands collection into Count disjoint _id ranges so bulk consumers
+// can read it in parallel. Boundaries[i] ends partition i+1 (exclusive) and starts partition i+2;
+// the first and last partitions are open-ended, so any set of boundaries covers every document
+// exactly once, however the collection changes after it was computed.
+type BrandPartitionSet struct {
+	Count      int                  `bson:"_id" json:"count"`
+	Boundaries []primitive.ObjectID `bson:"boundaries" json:"-"`
+	Partitions []BrandPartition     `bson:"-" json:"partitions"`
+	ComputedAt time.Time            `bson:"computedAt" json:"computedAt"`
+}
+
+// BrandPartition is one _id range of a partition set: MinID inclusive, MaxID exclusive. A missing
+// bound is open.
+type BrandPartition struct {
+	Index int                 `json:"index"` // 1-based, as in ?partition=3of8
+	MinID *primitive.ObjectID `json:"minId,omitempty"`
+	MaxID *primitive.ObjectID `json:"maxId,omitempty"`
+}
+
+// Partition returns partition index (1-based) of the set.
+func (s *BrandPartitionSet) Partition(index int) BrandPartition {
+	p := BrandPartition{Index: index}
+	if index > 1 {
+		p.MinID = &s.Boundaries[index-2]
+	}
+	if index < s.Count {
+		p.MaxID = &s.Boundaries[index-1]
+	}
+	return p
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Every ID falls into exactly one partition of a set, below, between and above the boundaries.
+func TestBrandPartitionSetPartition(t *testing.T) {
+	ids := make([]primitive.ObjectID, 7)
+	for i := range ids {
+		ids[i][11] = byte(10 * (i + 1))
+	}
+	set := BrandPartitionSet{Count: 4, Boundaries: []primitive.ObjectID{ids[1], ids[3], ids[5]}}
+	if first := set.Partition(1); first.MinID != nil || *first.MaxID != ids[1] {
+		t.Errorf("Partition(1) = %+v, want open start up to %s", first, ids[1].Hex())
+	}
+	if last := set.Partition(4); last.MaxID != nil || *last.MinID != ids[5] {
+		t.Errorf("Partition(4) = %+v, want from %s to an open end", last, ids[5].Hex())
+	}
+
+	contains := func(p BrandPartition, id primitive.ObjectID) bool {
+		return (p.MinID == nil || id.Hex() >= p.MinID.Hex()) && (p.MaxID == nil || id.Hex() < p.MaxID.Hex())
+	}
+	probes := append([]primitive.ObjectID{primitive.NilObjectID}, ids...)
+	for _, id := range probes {
+		var in []int
+		for k := 1; k <= set.Count; k++ {
+			if p := set.Partition(k); contains(p, id) {
+				in = append(in, p.Index)
+			}
+		}
+		if len(in) != 1 {
+			t.Errorf("ID %s is in partitions %v, want exactly one", id.Hex(), in)
+		}
+	}
+
+	single := BrandPartitionSet{Count: 1}
+	if p := single.Partition(1); p.MinID != nil || p.MaxID != nil {
+		t.Errorf("Partition(1) of a single set = %+v, want both ends open", p)
+	}
+}
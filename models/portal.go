@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PortalToken is an issued supplier portal token. Only its metadata is stored; the token itself
+// is a signed claim (see services.IssuePortalToken) and is shown once when issued.
+type PortalToken struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	BrandID   primitive.ObjectID `bson:"brandId" json:"brandId"` // Bound by ID so a rename doesn't break the link
+	Label     string             `bson:"label,omitempty" json:"label,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	CreatedBy string             `bson:"createdBy,omitempty" json:"createdBy,omitempty"` // Who issued it (see services.APIKeyActor)
+	RevokedAt *time.Time         `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+// PortalTokenPayload issues a portal token
+type PortalTokenPayload struct {
+	Label string `json:"label" binding:"max=200"` // Free text to tell tokens apart, e.g. the supplier contact
+}
+
+// PortalBrand is the read-only view of a brand a supplier sees through the portal. It is built
+// field by field so internal data (contacts, extraction diagnostics) can never leak into it.
+type PortalBrand struct {
+	Name          string      `json:"name"`
+	Details       string      `json:"details"`
+	DetailsFormat string      `json:"detailsFormat"`
+	Specs         primitive.M `json:"specs,omitempty"`
+	Logo          *BrandLogo  `json:"logo,omitempty"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// DetailsSection locates one section of a brand's details, so long details can be read a
+// section at a time. The details stay authoritative: sections are byte ranges of them,
+// recomputed whenever they change.
+type DetailsSection struct {
+	ID     string `bson:"id" json:"id"`         // Derived from the section's text, so unchanged sections keep their ID
+	Title  string `bson:"title" json:"title"`   // Empty for text before the first heading
+	Offset int    `bson:"offset" json:"offset"` // Byte offset in details
+	Length int    `bson:"length" json:"length"` // In bytes
+}
+
+// DetailsSectionText is one section with its text.
+type DetailsSectionText struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
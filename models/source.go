@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// BrandSource records where a brand's details are fetched from (the PDF URL of a supplier feed
+// entry) and the state of their scheduled refresh.
+type BrandSource struct {
+	URL                 string     `bson:"url" json:"url"`
+	ContentSHA256       string     `bson:"contentSha256,omitempty" json:"contentSha256,omitempty"` // Of the file the details were last extracted from
+	RefreshHours        int        `bson:"refreshHours,omitempty" json:"refreshHours,omitempty"`   // 0: SOURCE_REFRESH_HOURS
+	Paused              bool       `bson:"paused" json:"paused"`                                   // Auto-refresh stopped by an admin
+	NextRefreshAt       *time.Time `bson:"nextRefreshAt,omitempty" json:"nextRefreshAt,omitempty"`
+	LastFetchedAt       *time.Time `bson:"lastFetchedAt,omitempty" json:"lastFetchedAt,omitempty"`
+	LastChangedAt       *time.Time `bson:"lastChangedAt,omitempty" json:"lastChangedAt,omitempty"` // Last fetch that found a different file
+	ConsecutiveFailures int        `bson:"consecutiveFailures" json:"consecutiveFailures"`
+	LastError           string     `bson:"lastError,omitempty" json:"lastError,omitempty"`
+}
+
+// SourceRefreshPayload changes the refresh of one brand's source; absent fields stay as they are.
+type SourceRefreshPayload struct {
+	Paused       *bool `json:"paused"`
+	RefreshHours *int  `json:"refreshHours" binding:"omitempty,min=0,max=8760"` // 0 returns to the default
+}
+
+// SourceFailure is a brand whose source failed to fetch several times in a row.
+type SourceFailure struct {
+	Name   string      `json:"name"`
+	Source BrandSource `json:"source"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BrandTerms are the brand-specific terms and conditions customers accept before ordering. The
+// version starts at 1 and goes up with every change of text or attachment, so an acceptance can
+// name the exact terms it was given for.
+type BrandTerms struct {
+	Text          string    `bson:"text" json:"text,omitempty"`
+	AttachmentURL string    `bson:"attachmentUrl" json:"attachmentUrl,omitempty"` // E.g. a PDF hosted by the brand
+	Version       int       `bson:"version" json:"version"`
+	UpdatedAt     time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// BrandTermsVersion is one version of a brand's terms as published. The versions are kept in
+// their own collection, so the text an order was accepted under stays available after the brand's
+// terms change (or the brand is deleted).
+type BrandTermsVersion struct {
+	BrandID       primitive.ObjectID `bson:"brandId" json:"brandId"`
+	Version       int                `bson:"version" json:"version"`
+	Text          string             `bson:"text" json:"text,omitempty"`
+	AttachmentURL string             `bson:"attachmentUrl" json:"attachmentUrl,omitempty"`
+	PublishedAt   time.Time          `bson:"publishedAt" json:"publishedAt"` // When the brand's terms became this version
+}
+
+// BrandTermsPayload replaces a brand's terms; at least one of text and attachmentUrl is needed.
+type BrandTermsPayload struct {
+	Text          string `json:"text" binding:"required_without=AttachmentURL"`
+	AttachmentURL string `json:"attachmentUrl" binding:"omitempty,url"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Now returns the current time for storing in createdAt/updatedAt-style fields: in UTC and
+// truncated to milliseconds, the precision of a BSON date. Timestamps returned straight from a
+// write then match what a later read returns (and serialize as RFC3339 with a "Z" offset).
+func Now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// A timestamp from Now reads back from BSON unchanged and serializes with a "Z" offset.
+func TestNowRoundTrips(t *testing.T) {
+	now := Now()
+	if now.Location() != time.UTC || now.Nanosecond()%int(time.Millisecond) != 0 {
+		t.Fatalf("Now() = %v, want UTC at millisecond precision", now)
+	}
+
+	raw, err := bson.Marshal(bson.M{"updatedAt": now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored struct {
+		UpdatedAt time.Time `bson:"updatedAt"`
+	}
+	if err := bson.Unmarshal(raw, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !stored.UpdatedAt.Equal(now) {
+		t.Errorf("read back %v, wrote %v", stored.UpdatedAt, now)
+	}
+
+	encoded, err := json.Marshal(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(encoded), `Z"`) {
+		t.Errorf("JSON %s has no Z offset", encoded)
+	}
+}
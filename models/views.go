@@ -0,0 +1,13 @@
+package models
+
+// BrandViewDay is the number of detail views of a brand on one UTC day (YYYY-MM-DD).
+type BrandViewDay struct {
+	Day   string `bson:"day" json:"day"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+// BrandViewTotal is a brand's total views over a period, used for the most-viewed ranking.
+type BrandViewTotal struct {
+	Name  string `bson:"_id" json:"name"`
+	Count int64  `bson:"count" json:"count"`
+}
@@ -0,0 +1,229 @@
+// Package queryparams parses typed query parameters with defaults and constraints.
+//
+// A Query collects every violation instead of stopping at the first, so a handler reads all its
+// parameters and then answers one 400 listing everything that was wrong. A parameter given more
+// than once, or not valid UTF-8, is a violation too rather than silently using the first value.
+// Empty values count as absent and get the default.
+package queryparams
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DateLayout is the format of date-only parameters (UTC days).
+const DateLayout = "2006-01-02"
+
+// MaxSkip bounds how many documents a page may skip, so a huge page number can't overflow the
+// skip or have MongoDB walk most of a collection just to discard it.
+const MaxSkip = 10000
+
+// Violation is one invalid parameter. Field is the parameter name, or "query" when the query
+// string itself can't be decoded.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error lists every violation of a query, in the order the parameters were read.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Field + ": " + v.Message
+	}
+	return "invalid query parameters: " + strings.Join(parts, "; ")
+}
+
+// Query reads parameters from one query string. Its methods return the default for absent or
+// invalid parameters; check Err once all of them are read.
+type Query struct {
+	values     url.Values
+	violations []Violation
+}
+
+// New parses rawQuery (as in URL.RawQuery). Badly escaped pairs are dropped and reported.
+func New(rawQuery string) *Query {
+	values, err := url.ParseQuery(rawQuery)
+	q := &Query{values: values}
+	if err != nil {
+		q.Invalid("query", "is not properly URL-encoded")
+	}
+	return q
+}
+
+// Invalid records a violation, e.g. for a constraint spanning several parameters.
+func (q *Query) Invalid(name, message string) {
+	q.violations = append(q.violations, Violation{Field: name, Message: message})
+}
+
+// Err returns an *Error with every violation recorded so far, or nil.
+func (q *Query) Err() error {
+	if len(q.violations) == 0 {
+		return nil
+	}
+	return &Error{Violations: q.violations}
+}
+
+// Has reports whether name is given with a non-empty value.
+func (q *Query) Has(name string) bool {
+	for _, value := range q.values[name] {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// raw returns the single value of name, reporting false when it's absent, empty or invalid.
+func (q *Query) raw(name string) (string, bool) {
+	values := q.values[name]
+	switch {
+	case len(values) == 0:
+		return "", false
+	case len(values) > 1:
+		q.Invalid(name, "must be given at most once")
+		return "", false
+	case !utf8.ValidString(values[0]):
+		q.Invalid(name, "must be valid UTF-8")
+		return "", false
+	}
+	return values[0], values[0] != ""
+}
+
+// String returns the value of name, or def.
+func (q *Query) String(name, def string) string {
+	if raw, ok := q.raw(name); ok {
+		return raw
+	}
+	return def
+}
+
+// Int returns name as an integer in [min, max], or def.
+func (q *Query) Int(name string, def, min, max int) int {
+	raw, ok := q.raw(name)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min || n > max {
+		q.Invalid(name, fmt.Sprintf("must be an integer between %d and %d", min, max))
+		return def
+	}
+	return n
+}
+
+// Page is one page of a listing paged with page and limit.
+type Page struct {
+	Number int // From 1
+	Limit  int
+	Skip   int // Documents before the page, (Number-1)*Limit
+}
+
+// Page reads the page and limit parameters: limit in [1, maxLimit] (default defLimit) and page
+// from 1 (default 1), where page=0 is accepted as the first page too. A page starting past MaxSkip
+// is a violation, so Skip is always in [0, MaxSkip].
+func (q *Query) Page(defLimit, maxLimit int) Page {
+	limit := q.Int("limit", defLimit, 1, maxLimit)
+	number := q.Int("page", 1, 0, MaxSkip/limit+1)
+	if number == 0 {
+		number = 1
+	}
+	return Page{Number: number, Limit: limit, Skip: (number - 1) * limit}
+}
+
+// Enum returns name if it is one of allowed, or def.
+func (q *Query) Enum(name, def string, allowed ...string) string {
+	raw, ok := q.raw(name)
+	if !ok {
+		return def
+	}
+	if slices.Contains(allowed, raw) {
+		return raw
+	}
+	q.Invalid(name, "must be one of: "+strings.Join(allowed, ", "))
+	return def
+}
+
+// Bool returns name as a boolean (true/false, 1/0), or def.
+func (q *Query) Bool(name string, def bool) bool {
+	raw, ok := q.raw(name)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		q.Invalid(name, "must be true or false")
+		return def
+	}
+	return b
+}
+
+// Time returns name as an RFC 3339 time, or def.
+func (q *Query) Time(name string, def time.Time) time.Time {
+	raw, ok := q.raw(name)
+	if !ok {
+		return def
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		q.Invalid(name, "must be an RFC 3339 time, e.g. 2024-05-01T12:00:00Z")
+		return def
+	}
+	return t
+}
+
+// Date returns name as a UTC day in DateLayout (YYYY-MM-DD), or def.
+func (q *Query) Date(name string, def time.Time) time.Time {
+	raw, ok := q.raw(name)
+	if !ok {
+		return def
+	}
+	t, err := time.Parse(DateLayout, raw)
+	if err != nil {
+		q.Invalid(name, "must be a date in YYYY-MM-DD format")
+		return def
+	}
+	return t
+}
+
+// List returns the comma-separated items of name, trimmed and without empty ones, or def. With
+// allowed set every item must be one of them; maxItems > 0 caps the number of items.
+func (q *Query) List(name string, def []string, maxItems int, allowed ...string) []string {
+	raw, ok := q.raw(name)
+	if !ok {
+		return def
+	}
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		if len(allowed) > 0 && !slices.Contains(allowed, item) {
+			q.Invalid(name, fmt.Sprintf("has unknown item %q, expected: %s", item, strings.Join(allowed, ", ")))
+			return def
+		}
+		items = append(items, item)
+	}
+	if maxItems > 0 && len(items) > maxItems {
+		q.Invalid(name, fmt.Sprintf("must have at most %d items", maxItems))
+		return def
+	}
+	return items
+}
+
+// Value passes the value of name to parse, recording message as the violation if it fails. Use
+// it for types this package doesn't know, e.g. object IDs.
+func (q *Query) Value(name, message string, parse func(raw string) error) {
+	if raw, ok := q.raw(name); ok && parse(raw) != nil {
+		q.Invalid(name, message)
+	}
+}
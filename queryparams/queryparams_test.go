@@ -0,0 +1,232 @@
+package queryparams
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{"", 50, false},
+		{"limit=", 50, false},
+		{"limit=1", 1, false},
+		{"limit=500", 500, false},
+		{"limit=0", 50, true},
+		{"limit=501", 50, true},
+		{"limit=abc", 50, true},
+		{"limit=1.5", 50, true},
+		{"limit=99999999999999999999999", 50, true},
+		{"limit=1&limit=2", 50, true},
+	}
+	for _, tt := range tests {
+		q := New(tt.query)
+		if got := q.Int("limit", 50, 1, 500); got != tt.want || (q.Err() != nil) != tt.wantErr {
+			t.Errorf("Int(%q) = %d, err %v; want %d, error %v", tt.query, got, q.Err(), tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		query    string
+		want     Page
+		wantErrs []string
+	}{
+		{"", Page{1, 20, 0}, nil},
+		{"page=0", Page{1, 20, 0}, nil},
+		{"page=1", Page{1, 20, 0}, nil},
+		{"page=3&limit=50", Page{3, 50, 100}, nil},
+		{"page=101&limit=100", Page{101, 100, MaxSkip}, nil},
+		{"page=102&limit=100", Page{1, 100, 0}, []string{"page"}},
+		{"page=501", Page{501, 20, MaxSkip}, nil},
+		{"page=502", Page{1, 20, 0}, []string{"page"}},
+		{"page=-1&limit=0", Page{1, 20, 0}, []string{"limit", "page"}},
+		{"page=9223372036854775807&limit=100", Page{1, 100, 0}, []string{"page"}},
+		{"page=9223372036854775808", Page{1, 20, 0}, []string{"page"}},
+		{"limit=999999999", Page{1, 20, 0}, []string{"limit"}},
+	}
+	for _, tt := range tests {
+		q := New(tt.query)
+		got := q.Page(20, 100)
+		if got != tt.want {
+			t.Errorf("Page(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+		if fields := violationFields(q.Err()); !reflect.DeepEqual(fields, tt.wantErrs) {
+			t.Errorf("Page(%q): violations on %v, want %v", tt.query, fields, tt.wantErrs)
+		}
+	}
+}
+
+func TestEnumBoolString(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantSort string
+		wantDesc bool
+		wantName string
+		wantErrs []string
+	}{
+		{"", "name", false, "", nil},
+		{"sort=updatedAt&desc=1&name=Acme", "updatedAt", true, "Acme", nil},
+		{"sort=Name&desc=yes", "name", false, "", []string{"sort", "desc"}},
+		{"name=a&name=b", "name", false, "", []string{"name"}},
+		{"name=%FF", "name", false, "", []string{"name"}},
+	}
+	for _, tt := range tests {
+		q := New(tt.query)
+		sort := q.Enum("sort", "name", "name", "updatedAt")
+		desc := q.Bool("desc", false)
+		name := q.String("name", "")
+		if sort != tt.wantSort || desc != tt.wantDesc || name != tt.wantName {
+			t.Errorf("%q: got %s %v %q, want %s %v %q", tt.query, sort, desc, name, tt.wantSort, tt.wantDesc, tt.wantName)
+		}
+		if got := violationFields(q.Err()); !reflect.DeepEqual(got, tt.wantErrs) {
+			t.Errorf("%q: violations on %v, want %v", tt.query, got, tt.wantErrs)
+		}
+	}
+}
+
+func TestTimeAndDate(t *testing.T) {
+	def := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query    string
+		wantTime time.Time
+		wantDate time.Time
+		wantErrs []string
+	}{
+		{"", def, def, nil},
+		{"since=2024-05-01T12:00:00Z&day=2024-05-01", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"since=2024-05-01&day=2024-05-01T12:00:00Z", def, def, []string{"since", "day"}},
+		{"since=2024-13-01T00:00:00Z&day=2024-02-30", def, def, []string{"since", "day"}},
+	}
+	for _, tt := range tests {
+		q := New(tt.query)
+		since := q.Time("since", def)
+		day := q.Date("day", def)
+		if !since.Equal(tt.wantTime) || !day.Equal(tt.wantDate) {
+			t.Errorf("%q: got %v %v, want %v %v", tt.query, since, day, tt.wantTime, tt.wantDate)
+		}
+		if got := violationFields(q.Err()); !reflect.DeepEqual(got, tt.wantErrs) {
+			t.Errorf("%q: violations on %v, want %v", tt.query, got, tt.wantErrs)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	def := []string{"name"}
+	tests := []struct {
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{"", def, false},
+		{"fields=name,details", []string{"name", "details"}, false},
+		{"fields= name , ,details,", []string{"name", "details"}, false},
+		{"fields=,", nil, false},
+		{"fields=name,owner", def, true},
+		{"fields=name,name,name,name", def, true},
+	}
+	for _, tt := range tests {
+		q := New(tt.query)
+		got := q.List("fields", def, 3, "name", "details", "updatedAt")
+		if !reflect.DeepEqual(got, tt.want) || (q.Err() != nil) != tt.wantErr {
+			t.Errorf("List(%q) = %v, err %v; want %v, error %v", tt.query, got, q.Err(), tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	parseErr := errors.New("bad")
+	q := New("id=ok&other=bad")
+	q.Value("id", "must be an ID", func(raw string) error { return nil })
+	q.Value("other", "must be an ID", func(raw string) error { return parseErr })
+	q.Value("missing", "must be an ID", func(raw string) error { return parseErr })
+	if got := violationFields(q.Err()); !reflect.DeepEqual(got, []string{"other"}) {
+		t.Errorf("violations on %v, want [other]", got)
+	}
+}
+
+// Every violation is reported, in the order the parameters were read.
+func TestViolationsAggregate(t *testing.T) {
+	q := New("limit=0&sort=bogus&since=yesterday&%zz")
+	q.Int("limit", 10, 1, 100)
+	q.Enum("sort", "name", "name")
+	q.Time("since", time.Time{})
+	q.Invalid("cursor", "cannot be combined with after")
+
+	var queryErr *Error
+	if !errors.As(q.Err(), &queryErr) {
+		t.Fatalf("Err() = %v, want an *Error", q.Err())
+	}
+	want := []string{"query", "limit", "sort", "since", "cursor"}
+	if got := violationFields(queryErr); !reflect.DeepEqual(got, want) {
+		t.Errorf("violations on %v, want %v", got, want)
+	}
+	if !strings.Contains(queryErr.Error(), "limit: must be an integer between 1 and 100") {
+		t.Errorf("Error() = %q, want it to name the bounds", queryErr.Error())
+	}
+}
+
+func violationFields(err error) []string {
+	var queryErr *Error
+	if !errors.As(err, &queryErr) {
+		return nil
+	}
+	var fields []string
+	for _, v := range queryErr.Violations {
+		fields = append(fields, v.Field)
+	}
+	return fields
+}
+
+// FuzzQuery feeds hostile query strings (overflowing numbers, broken escapes, invalid UTF-8,
+// repeated parameters) through every reader: nothing may panic, invalid values must fall back to
+// the default and be reported.
+func FuzzQuery(f *testing.F) {
+	seeds := []string{
+		"",
+		"limit=10&sort=name&desc=true&since=2024-05-01T12:00:00Z&day=2024-05-01&fields=a,b",
+		"limit=99999999999999999999999999999999",
+		"limit=-9223372036854775809",
+		"limit=%",
+		"limit=%zz&sort=%E2%82",
+		"sort=%FF%FE",
+		"limit=1&limit=2&limit=3",
+		"fields=" + strings.Repeat(",", 1000),
+		"since=9999-99-99T99:99:99Z",
+		"&&&===&;",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		q := New(raw)
+		limit := q.Int("limit", 10, 1, 100)
+		sort := q.Enum("sort", "name", "name", "updatedAt")
+		q.Bool("desc", false)
+		q.Time("since", time.Time{})
+		q.Date("day", time.Time{})
+		fields := q.List("fields", nil, 5, "a", "b")
+		q.String("q", "")
+
+		if limit < 1 || limit > 100 {
+			t.Errorf("limit %d outside its bounds", limit)
+		}
+		if sort != "name" && sort != "updatedAt" {
+			t.Errorf("sort %q not an allowed value", sort)
+		}
+		if len(fields) > 5 {
+			t.Errorf("%d fields, want at most 5", len(fields))
+		}
+		var queryErr *Error
+		if err := q.Err(); err != nil && (!errors.As(err, &queryErr) || len(queryErr.Violations) == 0) {
+			t.Errorf("Err() = %v, want an *Error with violations", err)
+		}
+	})
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+)
+
+// Built-in alert conditions.
+const (
+	AlertDBDown             = "db_down"
+	AlertExtractionFailures = "extraction_failures"
+	AlertQueueDepth         = "queue_depth"
+)
+
+// Alerting defaults, overridable via the ALERT_* settings.
+const (
+	defaultAlertInterval          = 15 * time.Second
+	defaultAlertDBPingFailures    = 4 // With the default interval: down for a minute
+	defaultAlertFailureRatio      = 0.5
+	defaultAlertExtractionWindow  = 10 * time.Minute
+	defaultAlertExtractionSamples = 5
+	defaultAlertQueueDepth        = 48
+	alertPingTimeout              = 5 * time.Second
+	alertWebhookTimeout           = 10 * time.Second
+)
+
+// AlertConditionStatus is the evaluator's view of one condition, as shown by the admin API.
+type AlertConditionStatus struct {
+	Name      string     `json:"name"`
+	Firing    bool       `json:"firing"`
+	Since     *time.Time `json:"since,omitempty"` // When the condition started firing
+	Value     string     `json:"value"`           // Last measured value, e.g. "3 consecutive ping failures"
+	Threshold string     `json:"threshold"`
+}
+
+// AlertingStatus is the state of the alert evaluator.
+type AlertingStatus struct {
+	WebhookConfigured bool                   `json:"webhookConfigured"` // Without a webhook conditions are evaluated but nothing is sent
+	IntervalSeconds   int                    `json:"intervalSeconds"`
+	LastEvaluatedAt   *time.Time             `json:"lastEvaluatedAt,omitempty"`
+	LastWebhookError  string                 `json:"lastWebhookError,omitempty"`
+	Conditions        []AlertConditionStatus `json:"conditions"`
+}
+
+// alerting holds the evaluator state. It is in memory only: after a restart a condition that is
+// still true alerts again.
+var alerting = struct {
+	mu            sync.Mutex
+	status        AlertingStatus
+	pingFailures  int
+	webhookClient *http.Client
+}{webhookClient: &http.Client{Timeout: alertWebhookTimeout}}
+
+// extractionOutcomes records when extractions ran and whether they failed, for the failure ratio.
+var extractionOutcomes = struct {
+	mu      sync.Mutex
+	entries []extractionOutcome
+}{}
+
+type extractionOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// recordExtractionOutcome adds one extraction result, dropping those older than the window.
+func recordExtractionOutcome(failed bool) {
+	now := time.Now()
+	extractionOutcomes.mu.Lock()
+	defer extractionOutcomes.mu.Unlock()
+	extractionOutcomes.entries = append(pruneOutcomes(extractionOutcomes.entries, now), extractionOutcome{at: now, failed: failed})
+}
+
+func pruneOutcomes(entries []extractionOutcome, now time.Time) []extractionOutcome {
+	cutoff := now.Add(-alertExtractionWindow())
+	i := 0
+	for i < len(entries) && entries[i].at.Before(cutoff) {
+		i++
+	}
+	return entries[i:]
+}
+
+// extractionFailures returns the failed and total extractions within the window.
+func extractionFailures() (failed, total int) {
+	extractionOutcomes.mu.Lock()
+	defer extractionOutcomes.mu.Unlock()
+	extractionOutcomes.entries = pruneOutcomes(extractionOutcomes.entries, time.Now())
+	for _, outcome := range extractionOutcomes.entries {
+		if outcome.failed {
+			failed++
+		}
+	}
+	return failed, len(extractionOutcomes.entries)
+}
+
+// alertConditionsEnabled returns the conditions listed in ALERT_CONDITIONS (default all).
+func alertConditionsEnabled() map[string]bool {
+	raw := os.Getenv("ALERT_CONDITIONS")
+	if strings.TrimSpace(raw) == "" {
+		return map[string]bool{AlertDBDown: true, AlertExtractionFailures: true, AlertQueueDepth: true}
+	}
+	enabled := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		enabled[strings.TrimSpace(name)] = true
+	}
+	return enabled
+}
+
+// AlertInterval returns how often conditions are evaluated (ALERT_INTERVAL_SECONDS, default 15).
+func AlertInterval() time.Duration {
+	return time.Duration(envPositiveInt("ALERT_INTERVAL_SECONDS", int(defaultAlertInterval/time.Second))) * time.Second
+}
+
+func alertExtractionWindow() time.Duration {
+	return time.Duration(envPositiveInt("ALERT_EXTRACTION_WINDOW_MINUTES", int(defaultAlertExtractionWindow/time.Minute))) * time.Minute
+}
+
+// alertFailureRatio returns ALERT_EXTRACTION_FAILURE_RATIO (0 < ratio <= 1, default 0.5).
+func alertFailureRatio() float64 {
+	if raw := os.Getenv("ALERT_EXTRACTION_FAILURE_RATIO"); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil && v > 0 && v <= 1 {
+			return v
+		}
+		log.Printf("Warning: Invalid ALERT_EXTRACTION_FAILURE_RATIO '%s', using default %.2f", LogValue(raw), defaultAlertFailureRatio)
+	}
+	return defaultAlertFailureRatio
+}
+
+// StartAlerting evaluates the built-in conditions every AlertInterval, posting to ALERT_WEBHOOK_URL
+// (Slack-compatible) when a condition starts firing and again when it clears.
+func StartAlerting() {
+	goWorker("alerting", func(ctx context.Context) {
+		ticker := time.NewTicker(AlertInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				evaluateAlerts()
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// evaluateAlerts measures every condition and notifies about the ones that changed state.
+func evaluateAlerts() {
+	ctx, cancel := context.WithTimeout(context.Background(), alertPingTimeout)
+	pingErr := database.Ping(ctx)
+	cancel()
+
+	failed, total := extractionFailures()
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(failed) / float64(total)
+	}
+	depth := SupplierPDFQueueDepth()
+
+	alerting.mu.Lock()
+	if pingErr != nil {
+		alerting.pingFailures++
+	} else {
+		alerting.pingFailures = 0
+	}
+	maxPingFailures := envPositiveInt("ALERT_DB_PING_FAILURES", defaultAlertDBPingFailures)
+	minSamples := envPositiveInt("ALERT_EXTRACTION_MIN_SAMPLES", defaultAlertExtractionSamples)
+	maxRatio := alertFailureRatio()
+	maxDepth := envPositiveInt("ALERT_QUEUE_DEPTH", defaultAlertQueueDepth)
+
+	measured := []struct {
+		status AlertConditionStatus
+		firing bool
+	}{
+		{AlertConditionStatus{Name: AlertDBDown, Value: fmt.Sprintf("%d consecutive ping failures", alerting.pingFailures), Threshold: strconv.Itoa(maxPingFailures)},
+			alerting.pingFailures >= maxPingFailures},
+		{AlertConditionStatus{Name: AlertExtractionFailures, Value: fmt.Sprintf("%d of %d failed", failed, total), Threshold: fmt.Sprintf("%.2f over %v, at least %d extractions", maxRatio, alertExtractionWindow(), minSamples)},
+			total >= minSamples && ratio >= maxRatio},
+		{AlertConditionStatus{Name: AlertQueueDepth, Value: fmt.Sprintf("%d queued supplier PDFs", depth), Threshold: strconv.Itoa(maxDepth)},
+			depth >= maxDepth},
+	}
+
+	enabled := alertConditionsEnabled()
+	now := time.Now()
+	previous := make(map[string]AlertConditionStatus, len(alerting.status.Conditions))
+	for _, cond := range alerting.status.Conditions {
+		previous[cond.Name] = cond
+	}
+	var messages []string
+	conditions := make([]AlertConditionStatus, 0, len(measured))
+	for _, m := range measured {
+		if !enabled[m.status.Name] {
+			continue
+		}
+		cond, was := m.status, previous[m.status.Name]
+		cond.Firing = m.firing
+		switch {
+		case m.firing && !was.Firing:
+			since := now
+			cond.Since = &since
+			messages = append(messages, fmt.Sprintf(":rotating_light: Alert %s: %s (threshold %s)", cond.Name, cond.Value, cond.Threshold))
+		case m.firing:
+			cond.Since = was.Since // Still firing: already notified
+		case was.Firing:
+			messages = append(messages, fmt.Sprintf(":white_check_mark: Resolved %s after %v: %s", cond.Name, now.Sub(*was.Since).Round(time.Second), cond.Value))
+		}
+		conditions = append(conditions, cond)
+	}
+	alerting.status.Conditions = conditions
+	alerting.status.LastEvaluatedAt = &now
+	alerting.mu.Unlock()
+
+	for _, message := range messages {
+		log.Printf("Alerting: %s", message)
+		err := postAlert(message)
+		alerting.mu.Lock()
+		alerting.status.LastWebhookError = ""
+		if err != nil {
+			alerting.status.LastWebhookError = err.Error()
+		}
+		alerting.mu.Unlock()
+		if err != nil {
+			log.Printf("Error posting alert to webhook: %v", err)
+		}
+	}
+}
+
+// postAlert sends a Slack-compatible {"text": ...} message. Without a webhook it does nothing.
+func postAlert(text string) error {
+	webhook := os.Getenv("ALERT_WEBHOOK_URL")
+	if webhook == "" {
+		return nil
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	resp, err := alerting.webhookClient.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // The URL itself is a credential; keep it out of logs and the status
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// CurrentAlertingStatus returns the evaluator's state for the admin API.
+func CurrentAlertingStatus() AlertingStatus {
+	alerting.mu.Lock()
+	defer alerting.mu.Unlock()
+	status := alerting.status
+	status.WebhookConfigured = os.Getenv("ALERT_WEBHOOK_URL") != ""
+	status.IntervalSeconds = int(AlertInterval() / time.Second)
+	status.Conditions = append([]AlertConditionStatus{}, alerting.status.Conditions...)
+	return status
+}
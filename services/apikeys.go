@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// API key scopes. A key with ScopeAdmin may do everything.
+const (
+	ScopeBrandsRead  = "brands:read"
+	ScopeBrandsWrite = "brands:write"
+	ScopeAdmin       = "admin"
+)
+
+const (
+	apiKeyPrefix               = "bk1_"
+	apiKeyPrefixShown          = 8  // Characters of the secret stored (and listed) in the clear
+	defaultAPIKeyRotationGrace = 24 // Hours the old secret stays valid after a rotation
+)
+
+// ErrInvalidAPIKey is returned for unknown, revoked and expired keys alike.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyAllows reports whether key grants scope.
+func APIKeyAllows(key *models.APIKey, scope string) bool {
+	return slices.Contains(key.Scopes, scope) || slices.Contains(key.Scopes, ScopeAdmin)
+}
+
+// CreateAPIKey stores a new key with scopes and returns its secret, which can't be shown again.
+func CreateAPIKey(ctx context.Context, keys *mongo.Collection, label string, scopes []string, createdBy string) (string, *models.APIKey, error) {
+	secret, err := newAPIKeySecret()
+	if err != nil {
+		return "", nil, err
+	}
+	record := &models.APIKey{
+		ID:        primitive.NewObjectID(),
+		Label:     label,
+		Prefix:    secret[:len(apiKeyPrefix)+apiKeyPrefixShown],
+		Hash:      hashAPIKey(secret),
+		Scopes:    scopes,
+		CreatedAt: models.Now(),
+		CreatedBy: createdBy,
+	}
+	if _, err := keys.InsertOne(ctx, record); err != nil {
+		return "", nil, apperrors.FromDB(err)
+	}
+	return secret, record, nil
+}
+
+// ListAPIKeys returns every key, newest first, revoked and expired ones included.
+func ListAPIKeys(ctx context.Context, keys *mongo.Collection) ([]models.APIKey, error) {
+	cursor, err := keys.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	list := []models.APIKey{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	return list, nil
+}
+
+// RotateAPIKey replaces a valid key with a new one of the same label and scopes. The old secret
+// keeps working for API_KEY_ROTATION_GRACE_HOURS (default 24) so clients can switch over.
+func RotateAPIKey(ctx context.Context, keys *mongo.Collection, id primitive.ObjectID, createdBy string) (string, *models.APIKey, error) {
+	var old models.APIKey
+	if err := keys.FindOne(ctx, validAPIKeyFilter(bson.M{"_id": id})).Decode(&old); err != nil {
+		return "", nil, apperrors.FromDB(err)
+	}
+	secret, record, err := CreateAPIKey(ctx, keys, old.Label, old.Scopes, createdBy)
+	if err != nil {
+		return "", nil, err
+	}
+	grace := time.Duration(envPositiveInt("API_KEY_ROTATION_GRACE_HOURS", defaultAPIKeyRotationGrace)) * time.Hour
+	update := bson.M{"$set": bson.M{"expiresAt": models.Now().Add(grace), "rotatedTo": record.ID}}
+	if _, err := keys.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return "", nil, apperrors.FromDB(err)
+	}
+	return secret, record, nil
+}
+
+// RevokeAPIKey makes a key invalid right away. Revoking it again keeps the first revocation time.
+func RevokeAPIKey(ctx context.Context, keys *mongo.Collection, id primitive.ObjectID) (*models.APIKey, error) {
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"revokedAt": bson.M{"$ifNull": bson.A{"$revokedAt", models.Now()}},
+	}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var record models.APIKey
+	if err := keys.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&record); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	return &record, nil
+}
+
+// APIKeyActor names a key in the audit trail and wherever a record says who made it:
+// "apikey:<id> (<label>)", with "bootstrap" as the ID of ADMIN_API_KEY.
+func APIKeyActor(key *models.APIKey) string {
+	id := "bootstrap"
+	if !key.ID.IsZero() {
+		id = key.ID.Hex()
+	}
+	if key.Label == "" {
+		return "apikey:" + id
+	}
+	return fmt.Sprintf("apikey:%s (%s)", id, key.Label)
+}
+
+// BootstrapAPIKey returns the key ADMIN_API_KEY stands for when secret is that key. It has the
+// admin scope and isn't stored, so operators can create the first stored keys with it.
+func BootstrapAPIKey(secret string) (*models.APIKey, bool) {
+	bootstrap := os.Getenv("ADMIN_API_KEY")
+	if bootstrap == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(bootstrap)) != 1 {
+		return nil, false
+	}
+	return &models.APIKey{Label: "ADMIN_API_KEY", Scopes: []string{ScopeAdmin}}, true
+}
+
+// VerifyAPIKey returns the stored key a secret belongs to, unless it is unknown, revoked or past
+// its rotation grace period.
+func VerifyAPIKey(ctx context.Context, keys *mongo.Collection, secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	var record models.APIKey
+	err := apperrors.FromDB(keys.FindOne(ctx, validAPIKeyFilter(bson.M{"hash": hashAPIKey(secret)})).Decode(&record))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// validAPIKeyFilter narrows filter to keys that are neither revoked nor expired.
+func validAPIKeyFilter(filter bson.M) bson.M {
+	filter["revokedAt"] = nil
+	filter["$or"] = bson.A{bson.M{"expiresAt": nil}, bson.M{"expiresAt": bson.M{"$gt": models.Now()}}}
+	return filter
+}
+
+// newAPIKeySecret returns "bk1_" and 32 random bytes, base64url.
+func newAPIKeySecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashAPIKey is the stored form of a secret. The secrets are random, so a plain SHA-256 suffices.
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Only the hash and a short prefix of a new key's secret are stored, and the secret verifies
+// against the stored hash.
+func TestCreateAPIKeyStoresHash(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("create", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		secret, record, err := CreateAPIKey(context.Background(), mt.Coll, "reports", []string{ScopeBrandsRead}, "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		inserted := mt.GetStartedEvent().Command.Lookup("documents").Array().Index(0).Value().Document()
+		if strings.Contains(inserted.String(), secret) {
+			t.Errorf("stored document %s contains the secret", inserted)
+		}
+		if hash := inserted.Lookup("hash").StringValue(); hash != hashAPIKey(secret) {
+			t.Errorf("stored hash %q, want the secret's", hash)
+		}
+		if !strings.HasPrefix(secret, record.Prefix) || len(record.Prefix) != len(apiKeyPrefix)+apiKeyPrefixShown {
+			t.Errorf("prefix %q doesn't start secret %q", record.Prefix, secret)
+		}
+	})
+	mt.Run("verify", func(mt *mtest.T) {
+		if _, err := VerifyAPIKey(context.Background(), mt.Coll, "not-a-key"); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("foreign secret: %v, want ErrInvalidAPIKey", err)
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.api_keys", mtest.FirstBatch))
+		if _, err := VerifyAPIKey(context.Background(), mt.Coll, apiKeyPrefix+"unknown"); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("unknown key: %v, want ErrInvalidAPIKey", err)
+		}
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		if _, err := filter.LookupErr("revokedAt"); err != nil {
+			t.Errorf("filter %s doesn't exclude revoked keys", filter)
+		}
+	})
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "bootstrap")
+	if _, ok := BootstrapAPIKey("bootstrapx"); ok {
+		t.Error("a different secret matched ADMIN_API_KEY")
+	}
+	admin, ok := BootstrapAPIKey("bootstrap")
+	if !ok || !APIKeyAllows(admin, ScopeBrandsWrite) {
+		t.Errorf("ADMIN_API_KEY = %+v, %v; want a key allowing everything", admin, ok)
+	}
+	readOnly := &models.APIKey{Scopes: []string{ScopeBrandsRead}}
+	if !APIKeyAllows(readOnly, ScopeBrandsRead) || APIKeyAllows(readOnly, ScopeBrandsWrite) || APIKeyAllows(readOnly, ScopeAdmin) {
+		t.Errorf("key with %v allows the wrong scopes", readOnly.Scopes)
+	}
+}
+
+func TestAPIKeyActor(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		key  models.APIKey
+		want string
+	}{
+		{models.APIKey{ID: id, Label: "reports"}, "apikey:" + id.Hex() + " (reports)"},
+		{models.APIKey{ID: id}, "apikey:" + id.Hex()},
+		{models.APIKey{Label: "ADMIN_API_KEY"}, "apikey:bootstrap (ADMIN_API_KEY)"},
+	}
+	for _, tt := range tests {
+		if got := APIKeyActor(&tt.key); got != tt.want {
+			t.Errorf("APIKeyActor(%+v) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
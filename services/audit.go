@@ -0,0 +1,438 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Audit log defaults, overridable via the AUDIT_* settings.
+const (
+	defaultAuditLogMB        = 64
+	defaultAuditExportQueue  = 10000
+	defaultAuditExportBatch  = 100
+	auditExportFlushInterval = 5 * time.Second
+	auditExportTimeout       = 10 * time.Second
+	auditExportAttempts      = 4
+	auditWriteTimeout        = 5 * time.Second
+	auditCountTimeout        = 10 * time.Second
+)
+
+// auditExportBackoff is the wait after a failed delivery attempt, doubled after every further one
+// (a variable so tests can retry quickly).
+var auditExportBackoff = time.Second
+
+// Fields AUDIT_EXPORT_MASK can mask in exported entries.
+const (
+	AuditMaskIP    = "ip"    // The client IP, and the actor when it is one
+	AuditMaskEmail = "email" // Email addresses anywhere in the message
+)
+
+// ErrAuditExportNotConfigured is returned by StartAuditReplay without AUDIT_EXPORT_URL.
+var ErrAuditExportNotConfigured = errors.New("audit export is not configured (AUDIT_EXPORT_URL)")
+
+// ErrAuditReplayRunning is returned by StartAuditReplay while another replay is in progress.
+var ErrAuditReplayRunning = errors.New("an audit replay is already running")
+
+var auditEmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// AuditReplayStatus describes the last replay started from the admin API.
+type AuditReplayStatus struct {
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	Matched    int64      `json:"matched"` // Entries in the range when the replay started
+	Sent       int        `json:"sent"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// AuditExportStatus is the state of the audit export, as shown by the admin API. The counters
+// are since startup.
+type AuditExportStatus struct {
+	Configured      bool               `json:"configured"` // Without AUDIT_EXPORT_URL entries are only stored
+	Queued          int                `json:"queued"`
+	QueueCapacity   int                `json:"queueCapacity"`
+	LagSeconds      float64            `json:"lagSeconds"` // Age of the oldest entry not yet delivered
+	Delivered       int64              `json:"delivered"`
+	FailedBatches   int64              `json:"failedBatches"` // Batches still rejected after every retry; they stay queued
+	Dropped         int64              `json:"dropped"`       // Evicted from a full queue; still in the audit log for replay
+	LastDeliveredAt *time.Time         `json:"lastDeliveredAt,omitempty"`
+	LastError       string             `json:"lastError,omitempty"`
+	Masked          []string           `json:"masked"`
+	Replay          *AuditReplayStatus `json:"replay,omitempty"`
+}
+
+// auditExport holds the entries waiting for delivery. The queue is bounded so an unreachable
+// collector can't grow memory: when it is full the oldest entry is dropped (and counted). Every
+// entry is in the audit log collection as well, so dropped ones can be replayed later.
+var auditExport = struct {
+	mu        sync.Mutex
+	queue     []models.AuditEntry
+	wake      chan struct{}
+	status    AuditExportStatus
+	replaying bool
+	client    *http.Client
+}{wake: make(chan struct{}, 1), client: &http.Client{Timeout: auditExportTimeout}}
+
+// AuditLogBytes returns the size of the capped audit log collection (AUDIT_LOG_MB, default 64).
+// Once full, the oldest entries are overwritten.
+func AuditLogBytes() int64 {
+	return int64(envPositiveInt("AUDIT_LOG_MB", defaultAuditLogMB)) << 20
+}
+
+func auditExportURL() string {
+	return os.Getenv("AUDIT_EXPORT_URL")
+}
+
+func auditExportBatchSize() int {
+	return envPositiveInt("AUDIT_EXPORT_BATCH_SIZE", defaultAuditExportBatch)
+}
+
+func auditExportQueueSize() int {
+	return envPositiveInt("AUDIT_EXPORT_QUEUE_SIZE", defaultAuditExportQueue)
+}
+
+// RecordAudit logs an administrative action, stores it in the audit log and queues it for
+// export. clientIP is the request's, if any. Auditing never fails the action: a failed write is
+// logged and the entry is still exported.
+func RecordAudit(coll *mongo.Collection, action, actor, clientIP, message string) {
+	log.Printf("Audit: %s by %s", message, actor)
+	entry := models.AuditEntry{
+		ID:            primitive.NewObjectID(),
+		SchemaVersion: models.AuditSchemaVersion,
+		At:            models.Now(),
+		Action:        action,
+		Actor:         actor,
+		ClientIP:      clientIP,
+		Message:       message,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+	defer cancel()
+	if _, err := coll.InsertOne(ctx, entry); err != nil {
+		log.Printf("Warning: Could not store audit entry '%s': %v", action, err)
+	}
+	enqueueAuditExport(entry)
+}
+
+func enqueueAuditExport(entry models.AuditEntry) {
+	if auditExportURL() == "" {
+		return
+	}
+	auditExport.mu.Lock()
+	if over := len(auditExport.queue) + 1 - auditExportQueueSize(); over > 0 {
+		auditExport.queue = auditExport.queue[over:]
+		auditExport.status.Dropped += int64(over)
+	}
+	auditExport.queue = append(auditExport.queue, entry)
+	full := len(auditExport.queue) >= auditExportBatchSize()
+	auditExport.mu.Unlock()
+	if full {
+		select {
+		case auditExport.wake <- struct{}{}:
+		default: // A flush is already pending
+		}
+	}
+}
+
+// StartAuditExport delivers queued audit entries to AUDIT_EXPORT_URL as NDJSON batches, as soon
+// as a batch is full and at least every few seconds.
+func StartAuditExport() {
+	goWorker("audit export", func(ctx context.Context) {
+		ticker := time.NewTicker(auditExportFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-auditExport.wake:
+			case <-ctx.Done():
+				return // Undelivered entries remain in the audit log for replay
+			}
+			flushAuditExport(ctx)
+		}
+	})
+}
+
+// flushAuditExport sends the queue batch by batch until it is empty or a batch fails. A failed
+// batch stays at the head of the queue and is retried on the next flush.
+func flushAuditExport(ctx context.Context) {
+	for ctx.Err() == nil {
+		auditExport.mu.Lock()
+		n := min(len(auditExport.queue), auditExportBatchSize())
+		batch := append([]models.AuditEntry(nil), auditExport.queue[:n]...)
+		auditExport.mu.Unlock()
+		if n == 0 {
+			return
+		}
+
+		err := sendAuditBatch(ctx, batch)
+		auditExport.mu.Lock()
+		if err != nil {
+			auditExport.status.FailedBatches++
+			auditExport.status.LastError = err.Error()
+		} else {
+			// Some of the batch may have been dropped from a full queue meanwhile
+			delivered := make(map[primitive.ObjectID]bool, n)
+			for _, entry := range batch {
+				delivered[entry.ID] = true
+			}
+			i := 0
+			for i < len(auditExport.queue) && delivered[auditExport.queue[i].ID] {
+				i++
+			}
+			auditExport.queue = auditExport.queue[i:]
+			now := time.Now().UTC()
+			auditExport.status.Delivered += int64(n)
+			auditExport.status.LastDeliveredAt = &now
+			auditExport.status.LastError = ""
+		}
+		auditExport.mu.Unlock()
+		if err != nil {
+			log.Printf("Error exporting %d audit entries: %v", n, err)
+			return
+		}
+	}
+}
+
+// sendAuditBatch posts one batch, retrying with exponential backoff.
+func sendAuditBatch(ctx context.Context, batch []models.AuditEntry) error {
+	body, err := auditNDJSON(batch)
+	if err != nil {
+		return err
+	}
+	backoff := auditExportBackoff
+	for attempt := 1; ; attempt++ {
+		err = postAuditBatch(ctx, body)
+		if err == nil || attempt == auditExportAttempts {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// auditNDJSON encodes the entries one JSON object per line, masked per AUDIT_EXPORT_MASK.
+func auditNDJSON(batch []models.AuditEntry) ([]byte, error) {
+	masks := auditMasks()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range batch {
+		if err := enc.Encode(maskAuditEntry(entry, masks)); err != nil {
+			return nil, fmt.Errorf("encoding audit entry %s: %w", entry.ID.Hex(), err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// postAuditBatch sends an NDJSON body to the collector, with AUDIT_EXPORT_TOKEN as the
+// Authorization header when set (e.g. "Splunk <token>" for an HTTP Event Collector).
+func postAuditBatch(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auditExportURL(), bytes.NewReader(body))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if token := os.Getenv("AUDIT_EXPORT_TOKEN"); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	resp, err := auditExport.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // The URL may carry a credential; keep it out of logs and the status
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// auditMasks returns the fields listed in AUDIT_EXPORT_MASK (ip, email; default none).
+func auditMasks() map[string]bool {
+	masks := make(map[string]bool)
+	for _, name := range strings.Split(os.Getenv("AUDIT_EXPORT_MASK"), ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case AuditMaskIP, AuditMaskEmail:
+			masks[name] = true
+		case "":
+		default:
+			log.Printf("Warning: Ignoring unknown AUDIT_EXPORT_MASK field '%s'", LogValue(name))
+		}
+	}
+	return masks
+}
+
+// maskAuditEntry returns entry with the selected fields masked. Only the export is masked; the
+// stored audit log keeps the original values.
+func maskAuditEntry(entry models.AuditEntry, masks map[string]bool) models.AuditEntry {
+	if masks[AuditMaskIP] {
+		if net.ParseIP(entry.Actor) != nil {
+			entry.Actor = maskAuditValue(entry.Actor)
+		}
+		if entry.ClientIP != "" {
+			entry.ClientIP = maskAuditValue(entry.ClientIP)
+		}
+	}
+	if masks[AuditMaskEmail] {
+		entry.Message = auditEmailPattern.ReplaceAllStringFunc(entry.Message, maskAuditValue)
+	}
+	return entry
+}
+
+// maskAuditValue replaces value with an HMAC under AUDIT_EXPORT_MASK_SECRET, so entries of the
+// same actor can still be correlated in the SIEM. Without a secret the value is replaced by a
+// placeholder: an unkeyed hash of an IPv4 address is reversed by trying them all.
+func maskAuditValue(value string) string {
+	secret := os.Getenv("AUDIT_EXPORT_MASK_SECRET")
+	if secret == "" {
+		return "[masked]"
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(value))
+	return "masked:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// ReplayAudit sends the stored audit entries with from <= at < to to the collector, in the
+// order they were recorded, and returns how many were sent. Entries go out directly rather than
+// through the queue, so a large replay can't evict live entries. Entries the capped log has
+// already overwritten can't be replayed.
+func ReplayAudit(ctx context.Context, coll *mongo.Collection, from, to time.Time) (int, error) {
+	if auditExportURL() == "" {
+		return 0, ErrAuditExportNotConfigured
+	}
+	filter := bson.M{"at": bson.M{"$gte": from, "$lt": to}}
+	cursor, err := coll.Find(ctx, filter, options.Find().SetSort(bson.M{"$natural": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("finding audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	sent := 0
+	size := auditExportBatchSize()
+	batch := make([]models.AuditEntry, 0, size)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := sendAuditBatch(ctx, batch); err != nil {
+			return fmt.Errorf("after %d entries: %w", sent, err)
+		}
+		sent += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for cursor.Next(ctx) {
+		var entry models.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			log.Printf("Warning: Skipping audit entry %s that failed to decode: %v", rawDocumentID(cursor.Current), err)
+			continue
+		}
+		batch = append(batch, entry)
+		if len(batch) == size {
+			if err := flush(); err != nil {
+				return sent, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return sent, fmt.Errorf("iterating audit entries: %w", err)
+	}
+	return sent, flush()
+}
+
+// StartAuditReplay runs ReplayAudit in the background and returns the replay's initial status.
+// Only one replay runs at a time; its progress is reported by CurrentAuditExportStatus.
+func StartAuditReplay(coll *mongo.Collection, from, to time.Time) (AuditReplayStatus, error) {
+	if auditExportURL() == "" {
+		return AuditReplayStatus{}, ErrAuditExportNotConfigured
+	}
+	countCtx, cancel := context.WithTimeout(context.Background(), auditCountTimeout)
+	matched, err := coll.CountDocuments(countCtx, bson.M{"at": bson.M{"$gte": from, "$lt": to}})
+	cancel()
+	if err != nil {
+		return AuditReplayStatus{}, fmt.Errorf("counting audit entries: %w", err)
+	}
+
+	auditExport.mu.Lock()
+	defer auditExport.mu.Unlock()
+	if auditExport.replaying {
+		return AuditReplayStatus{}, ErrAuditReplayRunning
+	}
+	replay := &AuditReplayStatus{From: from, To: to, Matched: matched, StartedAt: time.Now().UTC()}
+	started := goWorker("audit replay", func(ctx context.Context) {
+		sent, err := ReplayAudit(ctx, coll, from, to)
+		if err != nil {
+			log.Printf("Error replaying audit entries: %v", err)
+		}
+		auditExport.mu.Lock()
+		defer auditExport.mu.Unlock()
+		now := time.Now().UTC()
+		replay.Sent, replay.FinishedAt = sent, &now
+		if err != nil {
+			replay.Error = err.Error()
+		}
+		auditExport.replaying = false
+	})
+	if !started {
+		return AuditReplayStatus{}, errors.New("background workers are shutting down")
+	}
+	auditExport.replaying = true
+	auditExport.status.Replay = replay
+	return *replay, nil
+}
+
+// CurrentAuditExportStatus returns the export's state and delivery metrics for the admin API.
+func CurrentAuditExportStatus() AuditExportStatus {
+	auditExport.mu.Lock()
+	defer auditExport.mu.Unlock()
+	status := auditExport.status
+	status.Configured = auditExportURL() != ""
+	status.Queued = len(auditExport.queue)
+	status.QueueCapacity = auditExportQueueSize()
+	if len(auditExport.queue) > 0 {
+		status.LagSeconds = time.Since(auditExport.queue[0].At).Seconds()
+	}
+	status.Masked = make([]string, 0, 2)
+	for name := range auditMasks() {
+		status.Masked = append(status.Masked, name)
+	}
+	sort.Strings(status.Masked)
+	if auditExport.status.Replay != nil {
+		replay := *auditExport.status.Replay
+		status.Replay = &replay
+	}
+	return status
+}
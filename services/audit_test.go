@@ -0,0 +1,450 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// auditCollector is a SIEM collector answering with the given statuses in turn (200 once they run
+// out), and keeping the entries of every request it received.
+type auditCollector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	batches  [][]models.AuditEntry
+	times    []time.Time
+	onPost   func() // Called while a request is in flight
+}
+
+func newAuditCollector(t *testing.T, statuses ...int) *auditCollector {
+	t.Helper()
+	collector := &auditCollector{statuses: statuses}
+	collector.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ct := req.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type %q, want application/x-ndjson", ct)
+		}
+		var batch []models.AuditEntry
+		scanner := bufio.NewScanner(req.Body)
+		for scanner.Scan() {
+			var entry models.AuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Errorf("line %q is not an audit entry: %v", scanner.Text(), err)
+			}
+			batch = append(batch, entry)
+		}
+		collector.mu.Lock()
+		collector.batches = append(collector.batches, batch)
+		collector.times = append(collector.times, time.Now())
+		status := http.StatusOK
+		if len(collector.statuses) > 0 {
+			status, collector.statuses = collector.statuses[0], collector.statuses[1:]
+		}
+		onPost := collector.onPost
+		collector.mu.Unlock()
+		if onPost != nil {
+			onPost()
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(collector.Close)
+	t.Setenv("AUDIT_EXPORT_URL", collector.URL)
+	return collector
+}
+
+// requests returns the entries and arrival time of each request.
+func (c *auditCollector) requests() ([][]models.AuditEntry, []time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]models.AuditEntry(nil), c.batches...), append([]time.Time(nil), c.times...)
+}
+
+// received returns the IDs of each request's entries.
+func (c *auditCollector) received() [][]primitive.ObjectID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([][]primitive.ObjectID, len(c.batches))
+	for i, batch := range c.batches {
+		ids[i] = auditIDs(batch)
+	}
+	return ids
+}
+
+// resetAuditExport empties the export queue and its counters for one test, with retries a
+// thousand times faster.
+func resetAuditExport(t *testing.T) {
+	t.Helper()
+	backoff := auditExportBackoff
+	auditExportBackoff = time.Millisecond
+	reset := func() {
+		auditExport.mu.Lock()
+		auditExport.queue, auditExport.status = nil, AuditExportStatus{}
+		auditExport.mu.Unlock()
+		select {
+		case <-auditExport.wake:
+		default:
+		}
+	}
+	reset()
+	t.Cleanup(func() {
+		reset()
+		auditExportBackoff = backoff
+	})
+}
+
+func testAuditEntries(n int) []models.AuditEntry {
+	entries := make([]models.AuditEntry, n)
+	for i := range entries {
+		entries[i] = models.AuditEntry{
+			ID:            primitive.NewObjectID(),
+			SchemaVersion: models.AuditSchemaVersion,
+			At:            syncEpoch.Add(time.Duration(i) * time.Minute),
+			Action:        "brand.delete",
+			Actor:         "203.0.113.7",
+			Message:       "Brand deleted",
+		}
+	}
+	return entries
+}
+
+func auditIDs(entries []models.AuditEntry) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+func queuedAuditIDs() []primitive.ObjectID {
+	auditExport.mu.Lock()
+	defer auditExport.mu.Unlock()
+	return auditIDs(auditExport.queue)
+}
+
+// Entries are only queued with a collector configured, and a full queue evicts its oldest
+// entries, counting them as dropped.
+func TestEnqueueAuditExportEvicts(t *testing.T) {
+	resetAuditExport(t)
+	entries := testAuditEntries(5)
+	t.Setenv("AUDIT_EXPORT_URL", "")
+	enqueueAuditExport(entries[0])
+	if queued := queuedAuditIDs(); len(queued) != 0 {
+		t.Fatalf("queued %d entries without a collector", len(queued))
+	}
+
+	t.Setenv("AUDIT_EXPORT_URL", "http://collector.invalid")
+	t.Setenv("AUDIT_EXPORT_QUEUE_SIZE", "3")
+	for _, entry := range entries {
+		enqueueAuditExport(entry)
+	}
+	if queued, want := queuedAuditIDs(), auditIDs(entries[2:]); !reflect.DeepEqual(queued, want) {
+		t.Errorf("queue %v, want the newest three %v", queued, want)
+	}
+	if status := CurrentAuditExportStatus(); status.Dropped != 2 || status.Queued != 3 || status.QueueCapacity != 3 {
+		t.Errorf("dropped %d, queued %d of %d; want 2, 3 of 3", status.Dropped, status.Queued, status.QueueCapacity)
+	}
+}
+
+// A flush sends the queue in NDJSON batches of AUDIT_EXPORT_BATCH_SIZE, oldest first.
+func TestFlushAuditExportBatches(t *testing.T) {
+	resetAuditExport(t)
+	collector := newAuditCollector(t)
+	t.Setenv("AUDIT_EXPORT_BATCH_SIZE", "2")
+	entries := testAuditEntries(5)
+	for _, entry := range entries {
+		enqueueAuditExport(entry)
+	}
+	flushAuditExport(context.Background())
+
+	want := [][]primitive.ObjectID{auditIDs(entries[:2]), auditIDs(entries[2:4]), auditIDs(entries[4:])}
+	if got := collector.received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("batches %v, want %v", got, want)
+	}
+	batches, _ := collector.requests()
+	if got := batches[0][0]; !got.At.Equal(entries[0].At) || got.Action != entries[0].Action || got.Message != entries[0].Message {
+		t.Errorf("exported %+v, want %+v", got, entries[0])
+	}
+	status := CurrentAuditExportStatus()
+	if status.Delivered != 5 || status.Queued != 0 || status.LastDeliveredAt == nil || status.FailedBatches != 0 {
+		t.Errorf("status %+v, want 5 delivered and nothing queued", status)
+	}
+}
+
+// Entries evicted while their batch was in flight are not removed twice: the entries queued
+// behind them are delivered next instead of being taken for the rest of the batch.
+func TestFlushAuditExportAfterEviction(t *testing.T) {
+	resetAuditExport(t)
+	collector := newAuditCollector(t)
+	t.Setenv("AUDIT_EXPORT_QUEUE_SIZE", "3")
+	t.Setenv("AUDIT_EXPORT_BATCH_SIZE", "3")
+	entries := testAuditEntries(5)
+	for _, entry := range entries[:3] {
+		enqueueAuditExport(entry)
+	}
+	var once sync.Once
+	collector.onPost = func() {
+		once.Do(func() {
+			enqueueAuditExport(entries[3]) // Evicts entries[0]
+			enqueueAuditExport(entries[4]) // Evicts entries[1]
+		})
+	}
+	flushAuditExport(context.Background())
+
+	want := [][]primitive.ObjectID{auditIDs(entries[:3]), auditIDs(entries[3:])}
+	if got := collector.received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("batches %v, want %v", got, want)
+	}
+	if status := CurrentAuditExportStatus(); status.Dropped != 2 || status.Delivered != 5 || status.Queued != 0 {
+		t.Errorf("dropped %d, delivered %d, queued %d; want 2, 5, 0", status.Dropped, status.Delivered, status.Queued)
+	}
+}
+
+// A batch the collector keeps rejecting is tried auditExportAttempts times, then stays at the
+// head of the queue for the next flush.
+func TestFlushAuditExportKeepsFailedBatch(t *testing.T) {
+	resetAuditExport(t)
+	failures := make([]int, auditExportAttempts)
+	for i := range failures {
+		failures[i] = http.StatusServiceUnavailable
+	}
+	collector := newAuditCollector(t, failures...)
+	entries := testAuditEntries(3)
+	for _, entry := range entries {
+		enqueueAuditExport(entry)
+	}
+
+	flushAuditExport(context.Background())
+	if got := len(collector.received()); got != auditExportAttempts {
+		t.Errorf("%d attempts, want %d", got, auditExportAttempts)
+	}
+	status := CurrentAuditExportStatus()
+	if status.FailedBatches != 1 || status.Delivered != 0 || !strings.Contains(status.LastError, "503") {
+		t.Errorf("status %+v, want one failed batch and the 503", status)
+	}
+	if queued := queuedAuditIDs(); !reflect.DeepEqual(queued, auditIDs(entries)) {
+		t.Errorf("queue %v after the failure, want every entry", queued)
+	}
+
+	flushAuditExport(context.Background())
+	status = CurrentAuditExportStatus()
+	if status.Delivered != 3 || status.Queued != 0 || status.LastError != "" {
+		t.Errorf("status %+v after the collector recovered, want all delivered", status)
+	}
+}
+
+// Failed attempts are retried after a doubling backoff, and not at all once the context ends.
+func TestSendAuditBatchRetries(t *testing.T) {
+	resetAuditExport(t)
+	auditExportBackoff = 20 * time.Millisecond
+	collector := newAuditCollector(t, http.StatusInternalServerError, http.StatusBadGateway)
+	if err := sendAuditBatch(context.Background(), testAuditEntries(1)); err != nil {
+		t.Fatalf("third attempt failed: %v", err)
+	}
+	_, times := collector.requests()
+	if len(times) != 3 {
+		t.Fatalf("%d attempts, want 3", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap, min := times[i].Sub(times[i-1]), auditExportBackoff<<(i-1); gap < min {
+			t.Errorf("attempt %d came %v after the previous one, want at least %v", i+1, gap, min)
+		}
+	}
+
+	collector = newAuditCollector(t, http.StatusInternalServerError)
+	ctx, cancel := context.WithCancel(context.Background())
+	collector.onPost = cancel
+	if err := sendAuditBatch(ctx, testAuditEntries(1)); err == nil {
+		t.Error("succeeded after the context ended")
+	}
+	if got := len(collector.received()); got != 1 {
+		t.Errorf("%d attempts after the context ended, want 1", got)
+	}
+}
+
+func TestAuditMasks(t *testing.T) {
+	t.Setenv("AUDIT_EXPORT_MASK", " IP, email,phone,")
+	if got, want := auditMasks(), map[string]bool{AuditMaskIP: true, AuditMaskEmail: true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("masks %v, want %v", got, want)
+	}
+}
+
+// Masking replaces client IPs, IP actors and email addresses with a placeholder, or with a keyed hash that
+// is the same for the same value when AUDIT_EXPORT_MASK_SECRET is set.
+func TestMaskAuditEntry(t *testing.T) {
+	hashed := regexp.MustCompile(`^masked:[0-9a-f]{16}$`)
+	entry := models.AuditEntry{Actor: "203.0.113.7", ClientIP: "198.51.100.2", Message: "Portal token issued to ops@example.com and a.b+c@sub.example.org"}
+	tests := []struct {
+		name        string
+		masks       map[string]bool
+		secret      string
+		actor       string
+		wantActor   string // Exact, or "hash"
+		wantMessage string
+	}{
+		{"nothing masked", nil, "", entry.Actor, entry.Actor, entry.Message},
+		{"ip without secret", map[string]bool{AuditMaskIP: true}, "", entry.Actor, "[masked]", entry.Message},
+		{"ip with secret", map[string]bool{AuditMaskIP: true}, "s3cret", entry.Actor, "hash", entry.Message},
+		{"ipv6", map[string]bool{AuditMaskIP: true}, "", "2001:db8::1", "[masked]", entry.Message},
+		{"actor that isn't an ip", map[string]bool{AuditMaskIP: true}, "", "SIGHUP", "SIGHUP", entry.Message},
+		{"api key actor", map[string]bool{AuditMaskIP: true}, "", "apikey:bootstrap (ADMIN_API_KEY)", "apikey:bootstrap (ADMIN_API_KEY)", entry.Message},
+		{"email without secret", map[string]bool{AuditMaskEmail: true}, "", entry.Actor, entry.Actor,
+			"Portal token issued to [masked] and [masked]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AUDIT_EXPORT_MASK_SECRET", tt.secret)
+			in := entry
+			in.Actor = tt.actor
+			got := maskAuditEntry(in, tt.masks)
+			if tt.wantActor == "hash" {
+				if !hashed.MatchString(got.Actor) {
+					t.Errorf("actor %q, want a keyed hash", got.Actor)
+				}
+			} else if got.Actor != tt.wantActor {
+				t.Errorf("actor %q, want %q", got.Actor, tt.wantActor)
+			}
+			if got.Message != tt.wantMessage {
+				t.Errorf("message %q, want %q", got.Message, tt.wantMessage)
+			}
+			wantIP := in.ClientIP
+			if tt.masks[AuditMaskIP] {
+				wantIP = "[masked]"
+				if tt.secret != "" {
+					wantIP = maskAuditValue(in.ClientIP)
+				}
+			}
+			if got.ClientIP != wantIP {
+				t.Errorf("client IP %q, want %q", got.ClientIP, wantIP)
+			}
+		})
+	}
+
+	t.Run("keyed hashes correlate", func(t *testing.T) {
+		t.Setenv("AUDIT_EXPORT_MASK_SECRET", "s3cret")
+		first, again, other := maskAuditValue("203.0.113.7"), maskAuditValue("203.0.113.7"), maskAuditValue("203.0.113.8")
+		if first != again || first == other {
+			t.Errorf("hashes %q, %q, %q: want the same value to hash alike and another not", first, again, other)
+		}
+		t.Setenv("AUDIT_EXPORT_MASK_SECRET", "other")
+		if maskAuditValue("203.0.113.7") == first {
+			t.Error("hash doesn't depend on the secret")
+		}
+	})
+}
+
+// The collector gets masked entries; what is queued (and stored) keeps the original values.
+func TestAuditExportMasksNDJSON(t *testing.T) {
+	resetAuditExport(t)
+	collector := newAuditCollector(t)
+	t.Setenv("AUDIT_EXPORT_MASK", "ip,email")
+	t.Setenv("AUDIT_EXPORT_MASK_SECRET", "")
+	entry := testAuditEntries(1)[0]
+	entry.Message = "Contact ops@example.com removed"
+	enqueueAuditExport(entry)
+	flushAuditExport(context.Background())
+
+	batches, _ := collector.requests()
+	if len(batches) != 1 || len(batches[0]) != 1 {
+		t.Fatalf("received %v, want one entry", collector.received())
+	}
+	got := batches[0][0]
+	if got.Actor != "[masked]" || got.Message != "Contact [masked] removed" || got.ID != entry.ID {
+		t.Errorf("exported %+v, want the IP and address masked", got)
+	}
+	if status := CurrentAuditExportStatus(); !reflect.DeepEqual(status.Masked, []string{AuditMaskEmail, AuditMaskIP}) {
+		t.Errorf("status lists masks %v", status.Masked)
+	}
+}
+
+func auditEntryDocs(t *testing.T, entries []models.AuditEntry) []bson.D {
+	t.Helper()
+	docs := make([]bson.D, len(entries))
+	for i, entry := range entries {
+		raw, err := bson.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bson.Unmarshal(raw, &docs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return docs
+}
+
+// A replay reads the range in recorded order and sends it in batches, stopping at the first batch
+// the collector rejects and reporting how many entries went out before it.
+func TestReplayAudit(t *testing.T) {
+	from, to := syncEpoch, syncEpoch.Add(time.Hour)
+	t.Setenv("AUDIT_EXPORT_URL", "")
+	if _, err := ReplayAudit(context.Background(), nil, from, to); !errors.Is(err, ErrAuditExportNotConfigured) {
+		t.Errorf("replay without a collector: %v", err)
+	}
+
+	entries := testAuditEntries(5)
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("all batches", func(mt *mtest.T) {
+		resetAuditExport(mt.T)
+		collector := newAuditCollector(mt.T)
+		mt.Setenv("AUDIT_EXPORT_BATCH_SIZE", "2")
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.audit_log", mtest.FirstBatch, auditEntryDocs(mt.T, entries)...))
+		sent, err := ReplayAudit(context.Background(), mt.Coll, from, to)
+		if err != nil || sent != 5 {
+			mt.Fatalf("sent %d, %v; want 5", sent, err)
+		}
+
+		cmd := mt.GetStartedEvent().Command
+		rng := cmd.Lookup("filter", "at").Document()
+		if gte, lt := rng.Lookup("$gte").Time(), rng.Lookup("$lt").Time(); !gte.Equal(from) || !lt.Equal(to) {
+			mt.Errorf("filter %s, want [%v, %v)", rng, from, to)
+		}
+		if natural, ok := cmd.Lookup("sort", "$natural").AsInt64OK(); !ok || natural != 1 {
+			mt.Errorf("sort %s, want the recorded order", cmd.Lookup("sort"))
+		}
+		want := [][]primitive.ObjectID{auditIDs(entries[:2]), auditIDs(entries[2:4]), auditIDs(entries[4:])}
+		if got := collector.received(); !reflect.DeepEqual(got, want) {
+			mt.Errorf("batches %v, want %v", got, want)
+		}
+		if queued := queuedAuditIDs(); len(queued) != 0 {
+			mt.Errorf("replay queued %d entries, want them sent directly", len(queued))
+		}
+	})
+
+	mt.Run("stops at the first failed batch", func(mt *mtest.T) {
+		resetAuditExport(mt.T)
+		failures := []int{http.StatusOK}
+		for range auditExportAttempts {
+			failures = append(failures, http.StatusInternalServerError)
+		}
+		collector := newAuditCollector(mt.T, failures...)
+		mt.Setenv("AUDIT_EXPORT_BATCH_SIZE", "2")
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.audit_log", mtest.FirstBatch, auditEntryDocs(mt.T, entries)...))
+		sent, err := ReplayAudit(context.Background(), mt.Coll, from, to)
+		if sent != 2 || err == nil || !strings.Contains(err.Error(), "after 2 entries") {
+			mt.Errorf("sent %d, %v; want 2 and the failure after them", sent, err)
+		}
+		received := collector.received()
+		if len(received) != 1+auditExportAttempts {
+			mt.Fatalf("%d requests, want the first batch and %d attempts at the second", len(received), auditExportAttempts)
+		}
+		for _, batch := range received[1:] {
+			if !reflect.DeepEqual(batch, auditIDs(entries[2:4])) {
+				mt.Errorf("retried %v, want the second batch only", batch)
+			}
+		}
+	})
+}
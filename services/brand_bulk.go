@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InsertBrands inserts brands with one unordered InsertMany, so one bad document doesn't stop
+// the others. failed maps the index of every brand that wasn't inserted to why:
+// apperrors.ErrAlreadyExists for a name taken by the unique index. err is set when the write
+// failed as a whole (e.g. the database is unreachable); it is then unknown which brands made it.
+func InsertBrands(ctx context.Context, coll *mongo.Collection, brands []models.Brand) (failed map[int]error, err error) {
+	docs := make([]interface{}, len(brands))
+	for i := range brands {
+		docs[i] = brands[i]
+	}
+	failed = map[int]error{}
+	_, err = coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return failed, nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+		return nil, apperrors.FromDB(err)
+	}
+	for _, we := range bulkErr.WriteErrors {
+		if we.Code == 11000 { // E11000 duplicate key
+			failed[we.Index] = apperrors.ErrAlreadyExists
+		} else {
+			failed[we.Index] = errors.New(we.Message)
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+// Sections a brand sheet can contain, in the order BRAND_EXPORT_SECTIONS lists them.
+const (
+	SheetSectionMetadata = "metadata"
+	SheetSectionSpecs    = "specs"
+	SheetSectionDetails  = "details"
+	SheetSectionKeywords = "keywords"
+)
+
+const defaultExportSections = "metadata,specs,details"
+
+// sheetHeadings are the section titles used in exported documents.
+var sheetHeadings = map[string]string{
+	SheetSectionMetadata: "Information",
+	SheetSectionSpecs:    "Specifications",
+	SheetSectionDetails:  "Details",
+	SheetSectionKeywords: "Keywords",
+}
+
+// ExportSections returns the sections exported brand sheets contain, in order
+// (BRAND_EXPORT_SECTIONS, default "metadata,specs,details"). Unknown names are skipped.
+func ExportSections() []string {
+	raw := os.Getenv("BRAND_EXPORT_SECTIONS")
+	if strings.TrimSpace(raw) == "" {
+		raw = defaultExportSections
+	}
+	var sections []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := sheetHeadings[name]; !ok {
+			log.Printf("Warning: Unknown BRAND_EXPORT_SECTIONS entry '%s' ignored", LogValue(name))
+			continue
+		}
+		sections = append(sections, name)
+	}
+	return sections
+}
+
+// SheetSection is one section of a brand sheet. Exactly one of Rows (a two-column field/value
+// table), Table (a table whose first row is the header) or Text is set.
+type SheetSection struct {
+	Heading string
+	Rows    [][2]string
+	Table   [][]string
+	Text    string
+}
+
+// BrandSheet is the format-independent content of an exported brand.
+type BrandSheet struct {
+	Title    string
+	Sections []SheetSection
+}
+
+// BuildBrandSheet lays out the given sections of a brand. Sections the brand has nothing for
+// (no specs, empty details, ...) are left out rather than exported empty.
+func BuildBrandSheet(brand *models.Brand, sections []string) BrandSheet {
+	sheet := BrandSheet{Title: brand.Name}
+	for _, name := range sections {
+		section := SheetSection{Heading: sheetHeadings[name]}
+		switch name {
+		case SheetSectionMetadata:
+			format := DetailsFormatOrDetect(brand.DetailsFormat, brand.Details)
+			section.Rows = [][2]string{{"ID", brand.ID.Hex()}, {"Details format", format}}
+			if !brand.CreatedAt.IsZero() {
+				section.Rows = append(section.Rows, [2]string{"Created", brand.CreatedAt.UTC().Format(time.RFC3339)})
+			}
+			if !brand.UpdatedAt.IsZero() {
+				section.Rows = append(section.Rows, [2]string{"Updated", brand.UpdatedAt.UTC().Format(time.RFC3339)})
+			}
+		case SheetSectionSpecs:
+			keys := make([]string, 0, len(brand.Specs))
+			for key := range brand.Specs {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				section.Rows = append(section.Rows, [2]string{key, specValue(brand.Specs[key])})
+			}
+		case SheetSectionDetails:
+			if strings.TrimSpace(brand.Details) == "" {
+				continue
+			}
+			if DetailsFormatOrDetect(brand.DetailsFormat, brand.Details) == models.DetailsFormatTSV {
+				for _, line := range strings.Split(strings.ReplaceAll(brand.Details, "\r\n", "\n"), "\n") {
+					if strings.TrimSpace(line) != "" {
+						section.Table = append(section.Table, strings.Split(line, "\t"))
+					}
+				}
+			} else {
+				section.Text = strings.TrimSpace(brand.Details)
+			}
+		case SheetSectionKeywords:
+			terms := make([]string, len(brand.Keywords))
+			for i, kw := range brand.Keywords {
+				terms[i] = kw.Term
+			}
+			section.Text = strings.Join(terms, ", ")
+		}
+		if len(section.Rows) == 0 && len(section.Table) == 0 && section.Text == "" {
+			continue
+		}
+		sheet.Sections = append(sheet.Sections, section)
+	}
+	return sheet
+}
+
+// specValue renders a spec value: scalars as-is, nested documents and arrays as JSON.
+func specValue(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return ""
+	case string, bool, int32, int64, float64:
+		return fmt.Sprint(v)
+	}
+	if encoded, err := json.Marshal(v); err == nil {
+		return string(encoded)
+	}
+	return fmt.Sprint(v)
+}
+
+// RenderSheetMarkdown renders a sheet as a Markdown document. Details already in Markdown are
+// embedded unchanged.
+func RenderSheetMarkdown(sheet BrandSheet) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n", sheet.Title)
+	for _, section := range sheet.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", section.Heading)
+		switch {
+		case len(section.Rows) > 0:
+			b.WriteString("| Field | Value |\n| --- | --- |\n")
+			for _, row := range section.Rows {
+				fmt.Fprintf(&b, "| %s | %s |\n", markdownCell(row[0]), markdownCell(row[1]))
+			}
+		case len(section.Table) > 0:
+			writeMarkdownTable(&b, section.Table)
+		default:
+			b.WriteString(section.Text)
+			b.WriteString("\n")
+		}
+	}
+	return b.Bytes()
+}
+
+func writeMarkdownTable(b *bytes.Buffer, table [][]string) {
+	columns := 0
+	for _, row := range table {
+		columns = max(columns, len(row))
+	}
+	for i, row := range table {
+		cells := make([]string, columns)
+		for j := range cells {
+			if j < len(row) {
+				cells[j] = markdownCell(row[j])
+			}
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+		if i == 0 {
+			fmt.Fprintf(b, "|%s\n", strings.Repeat(" --- |", columns))
+		}
+	}
+}
+
+// markdownCell keeps a value on one line and escapes the pipes that would split the cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "|", `\|`)
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "\n", " ")), " ")
+}
+
+// DOCX package parts. A WordprocessingML document only needs these three; headings are bold
+// runs rather than styles so no styles part is required.
+const (
+	docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`
+	docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/></Relationships>`
+	docxBorders = `<w:tblBorders><w:top w:val="single" w:sz="4"/><w:left w:val="single" w:sz="4"/><w:bottom w:val="single" w:sz="4"/><w:right w:val="single" w:sz="4"/><w:insideH w:val="single" w:sz="4"/><w:insideV w:val="single" w:sz="4"/></w:tblBorders>`
+)
+
+// RenderSheetDOCX renders a sheet as a Word document.
+func RenderSheetDOCX(sheet BrandSheet) ([]byte, error) {
+	var body bytes.Buffer
+	writeDocxParagraph(&body, sheet.Title, 36, true)
+	for _, section := range sheet.Sections {
+		writeDocxParagraph(&body, section.Heading, 28, true)
+		switch {
+		case len(section.Rows) > 0:
+			rows := make([][]string, len(section.Rows))
+			for i, row := range section.Rows {
+				rows[i] = []string{row[0], row[1]}
+			}
+			writeDocxTable(&body, rows, false)
+		case len(section.Table) > 0:
+			writeDocxTable(&body, section.Table, true)
+		default:
+			for _, line := range strings.Split(section.Text, "\n") {
+				writeDocxParagraph(&body, line, 0, false)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body.String() + `</w:body></w:document>`},
+	}
+	for _, part := range parts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeDocxParagraph writes one paragraph; size is in half-points, 0 for the default.
+func writeDocxParagraph(b *bytes.Buffer, text string, size int, bold bool) {
+	b.WriteString("<w:p><w:r>")
+	if size > 0 || bold {
+		b.WriteString("<w:rPr>")
+		if bold {
+			b.WriteString("<w:b/>")
+		}
+		if size > 0 {
+			fmt.Fprintf(b, `<w:sz w:val="%d"/>`, size)
+		}
+		b.WriteString("</w:rPr>")
+	}
+	b.WriteString(`<w:t xml:space="preserve">`)
+	_ = xml.EscapeText(b, []byte(text)) // Writing to a bytes.Buffer can't fail
+	b.WriteString("</w:t></w:r></w:p>")
+}
+
+func writeDocxTable(b *bytes.Buffer, rows [][]string, header bool) {
+	b.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="0" w:type="auto"/>` + docxBorders + `</w:tblPr><w:tblGrid>`)
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	b.WriteString(strings.Repeat("<w:gridCol/>", columns) + "</w:tblGrid>")
+	for i, row := range rows {
+		b.WriteString("<w:tr>")
+		for j := 0; j < columns; j++ {
+			cell := ""
+			if j < len(row) {
+				cell = row[j]
+			}
+			b.WriteString("<w:tc>")
+			writeDocxParagraph(b, cell, 0, header && i == 0)
+			b.WriteString("</w:tc>")
+		}
+		b.WriteString("</w:tr>")
+	}
+	b.WriteString("</w:tbl>")
+}
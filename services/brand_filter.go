@@ -0,0 +1,419 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Limits on filter expressions, so a single request can't build an arbitrarily expensive query.
+const (
+	MaxFilterLength      = 1000 // Characters
+	MaxFilterDepth       = 8    // Nested NOT/parentheses
+	MaxFilterComparisons = 20
+	maxFilterListValues  = 50
+)
+
+// MaxNameSearchLength caps the ?q= name search of the brand listing (characters).
+const MaxNameSearchLength = 100
+
+// NameContains is the condition matching names that contain term, ignoring case. term is
+// matched literally: regex metacharacters in it are escaped, so ".*" searches for ".*".
+func NameContains(term string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(term), "$options": "i"}
+}
+
+// NamePrefix is the condition matching names that start with prefix under the name collation,
+// which ignores case. It is a range rather than a regex, so with database.NameCollation the
+// collated name index bounds the scan to the matching names. U+FFFF sorts after every character
+// in ICU collations, which makes prefix+"\uffff" the upper bound.
+func NamePrefix(prefix string) bson.M {
+	return bson.M{"$gte": prefix, "$lt": prefix + "\uffff"}
+}
+
+// FilterError is a malformed or disallowed filter expression. Pos is the 1-based character
+// position the problem was found at.
+type FilterError struct {
+	Pos     int    `json:"position"`
+	Message string `json:"message"`
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("filter error at position %d: %s", e.Pos, e.Message)
+}
+
+// filterFieldKind decides which operators and values a field accepts.
+type filterFieldKind int
+
+const (
+	filterString filterFieldKind = iota
+	filterDate
+	filterLength
+)
+
+// filterFields is the whitelist of fields an expression may refer to, mapped to stored fields.
+// Nothing outside it reaches the query, and no Mongo operators are ever taken from the input.
+var filterFields = map[string]struct {
+	path string
+	kind filterFieldKind
+}{
+	"name":          {"name", filterString},
+	"details":       {"details", filterString},
+	"detailsFormat": {"detailsFormat", filterString},
+	"keyword":       {"keywords.term", filterString},
+	"createdAt":     {"createdAt", filterDate},
+	"updatedAt":     {"updatedAt", filterDate},
+	"len(details)":  {"details", filterLength},
+	"len(name)":     {"name", filterLength},
+}
+
+// filterOperators maps comparison operators to their Mongo equivalents; contains and in are
+// handled separately.
+var filterOperators = map[string]string{"=": "$eq", "!=": "$ne", "<": "$lt", "<=": "$lte", ">": "$gt", ">=": "$gte"}
+
+// CompileBrandFilter parses a filter expression and compiles it into a Mongo filter document.
+//
+//	expr       := term { OR term }
+//	term       := factor { AND factor }
+//	factor     := NOT factor | "(" expr ")" | comparison
+//	comparison := field op value | field contains "text" | field in [value, ...]
+//
+// AND binds tighter than OR; keywords are case-insensitive. Fields are name, details,
+// detailsFormat, keyword (matches any extracted keyword), createdAt, updatedAt, len(details)
+// and len(name). Strings are double-quoted with \" and \\ escapes; dates are YYYY-MM-DD or
+// RFC 3339 strings. contains is a case-insensitive substring match on string fields.
+//
+// For example: len(details) > 500 AND keyword in ["cotton", "linen"] AND updatedAt < "2024-01-01"
+func CompileBrandFilter(expr string) (bson.M, error) {
+	if len([]rune(expr)) > MaxFilterLength {
+		return nil, &FilterError{Pos: MaxFilterLength + 1, Message: fmt.Sprintf("expression is longer than %d characters", MaxFilterLength)}
+	}
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &FilterError{Pos: 1, Message: "expression is empty"}
+	}
+	filter, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &FilterError{Pos: tok.pos, Message: fmt.Sprintf("unexpected %s", tok)}
+	}
+	return filter, nil
+}
+
+type filterTokenKind int
+
+const (
+	tokEOF filterTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOperator
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string // Unquoted for strings
+	pos  int    // 1-based
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// keyword reports whether the token is the given (case-insensitive) keyword.
+func (t filterToken) keyword(word string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, word)
+}
+
+// lexFilter splits an expression into tokens. Positions count runes, not bytes.
+func lexFilter(expr string) ([]filterToken, error) {
+	runes := []rune(expr)
+	var tokens []filterToken
+	for i := 0; i < len(runes); {
+		r, pos := runes[i], i+1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '[' || r == ']' || r == ',':
+			kind := map[rune]filterTokenKind{'(': tokLParen, ')': tokRParen, '[': tokLBracket, ']': tokRBracket, ',': tokComma}[r]
+			tokens = append(tokens, filterToken{kind: kind, text: string(r), pos: pos})
+			i++
+		case r == '"':
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					if i+1 >= len(runes) || (runes[i+1] != '"' && runes[i+1] != '\\') {
+						return nil, &FilterError{Pos: i + 1, Message: `invalid escape, only \" and \\ are allowed`}
+					}
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, &FilterError{Pos: pos, Message: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, filterToken{kind: tokString, text: sb.String(), pos: pos})
+		case strings.ContainsRune("=!<>", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if _, ok := filterOperators[op]; !ok {
+				return nil, &FilterError{Pos: pos, Message: fmt.Sprintf("unknown operator '%s'", op)}
+			}
+			tokens = append(tokens, filterToken{kind: tokOperator, text: op, pos: pos})
+			i += len(op)
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokNumber, text: string(runes[start:i]), pos: pos})
+		case unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			// len(field) is a single field token, so it can't be confused with grouping parentheses
+			if word := string(runes[start:i]); word == "len" && i < len(runes) && runes[i] == '(' {
+				end := i + 1
+				for end < len(runes) && runes[end] != ')' {
+					end++
+				}
+				if end >= len(runes) {
+					return nil, &FilterError{Pos: pos, Message: "unterminated len("}
+				}
+				i = end + 1
+			}
+			tokens = append(tokens, filterToken{kind: tokIdent, text: string(runes[start:i]), pos: pos})
+		default:
+			return nil, &FilterError{Pos: pos, Message: fmt.Sprintf("unexpected character '%c'", r)}
+		}
+	}
+	return append(tokens, filterToken{kind: tokEOF, pos: len(runes) + 1}), nil
+}
+
+// filterParser is a recursive-descent parser over the token list.
+type filterParser struct {
+	tokens      []filterToken
+	next        int
+	comparisons int
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.next] }
+
+func (p *filterParser) advance() filterToken {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *filterParser) parseOr(depth int) (bson.M, error) {
+	first, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	clauses := []bson.M{first}
+	for p.peek().keyword("OR") {
+		p.advance()
+		next, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, next)
+	}
+	if len(clauses) == 1 {
+		return first, nil
+	}
+	return bson.M{"$or": clauses}, nil
+}
+
+func (p *filterParser) parseAnd(depth int) (bson.M, error) {
+	first, err := p.parseFactor(depth)
+	if err != nil {
+		return nil, err
+	}
+	clauses := []bson.M{first}
+	for p.peek().keyword("AND") {
+		p.advance()
+		next, err := p.parseFactor(depth)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, next)
+	}
+	if len(clauses) == 1 {
+		return first, nil
+	}
+	return bson.M{"$and": clauses}, nil
+}
+
+func (p *filterParser) parseFactor(depth int) (bson.M, error) {
+	tok := p.peek()
+	if depth >= MaxFilterDepth && (tok.keyword("NOT") || tok.kind == tokLParen) {
+		return nil, &FilterError{Pos: tok.pos, Message: fmt.Sprintf("expression is nested deeper than %d levels", MaxFilterDepth)}
+	}
+	switch {
+	case tok.keyword("NOT"):
+		p.advance()
+		inner, err := p.parseFactor(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		// $nor negates a whole sub-filter, which $not (field level only) can't
+		return bson.M{"$nor": []bson.M{inner}}, nil
+	case tok.kind == tokLParen:
+		p.advance()
+		inner, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != tokRParen {
+			return nil, &FilterError{Pos: closing.pos, Message: fmt.Sprintf("expected ')' but found %s", closing)}
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (bson.M, error) {
+	fieldTok := p.advance()
+	if fieldTok.kind != tokIdent || fieldTok.keyword("AND") || fieldTok.keyword("OR") || fieldTok.keyword("NOT") {
+		return nil, &FilterError{Pos: fieldTok.pos, Message: fmt.Sprintf("expected a field but found %s", fieldTok)}
+	}
+	field, ok := filterFields[fieldTok.text]
+	if !ok {
+		return nil, &FilterError{Pos: fieldTok.pos, Message: fmt.Sprintf("unknown field '%s', expected one of %s", fieldTok.text, filterFieldList())}
+	}
+	p.comparisons++
+	if p.comparisons > MaxFilterComparisons {
+		return nil, &FilterError{Pos: fieldTok.pos, Message: fmt.Sprintf("expression has more than %d comparisons", MaxFilterComparisons)}
+	}
+
+	opTok := p.advance()
+	switch {
+	case opTok.keyword("contains"):
+		if field.kind != filterString {
+			return nil, &FilterError{Pos: opTok.pos, Message: fmt.Sprintf("contains only applies to text fields, not '%s'", fieldTok.text)}
+		}
+		valueTok := p.advance()
+		if valueTok.kind != tokString {
+			return nil, &FilterError{Pos: valueTok.pos, Message: fmt.Sprintf("expected a string but found %s", valueTok)}
+		}
+		return bson.M{field.path: bson.M{"$regex": regexp.QuoteMeta(valueTok.text), "$options": "i"}}, nil
+	case opTok.keyword("in"):
+		values, err := p.parseList(fieldTok.text, field.kind)
+		if err != nil {
+			return nil, err
+		}
+		if field.kind == filterLength {
+			return lengthFilter(field.path, "$in", values), nil
+		}
+		return bson.M{field.path: bson.M{"$in": values}}, nil
+	case opTok.kind == tokOperator:
+		if field.kind == filterString && opTok.text != "=" && opTok.text != "!=" {
+			return nil, &FilterError{Pos: opTok.pos, Message: fmt.Sprintf("'%s' does not apply to text field '%s', use =, !=, contains or in", opTok.text, fieldTok.text)}
+		}
+		value, err := p.parseValue(fieldTok.text, field.kind)
+		if err != nil {
+			return nil, err
+		}
+		if field.kind == filterLength {
+			return lengthFilter(field.path, filterOperators[opTok.text], value), nil
+		}
+		return bson.M{field.path: bson.M{filterOperators[opTok.text]: value}}, nil
+	}
+	return nil, &FilterError{Pos: opTok.pos, Message: fmt.Sprintf("expected an operator (=, !=, <, <=, >, >=, contains, in) but found %s", opTok)}
+}
+
+// parseList parses [value, ...] for the in operator.
+func (p *filterParser) parseList(name string, kind filterFieldKind) ([]interface{}, error) {
+	open := p.advance()
+	if open.kind != tokLBracket {
+		return nil, &FilterError{Pos: open.pos, Message: fmt.Sprintf("expected '[' but found %s", open)}
+	}
+	values := []interface{}{}
+	for {
+		value, err := p.parseValue(name, kind)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if len(values) > maxFilterListValues {
+			return nil, &FilterError{Pos: open.pos, Message: fmt.Sprintf("list has more than %d values", maxFilterListValues)}
+		}
+		sep := p.advance()
+		if sep.kind == tokRBracket {
+			return values, nil
+		}
+		if sep.kind != tokComma {
+			return nil, &FilterError{Pos: sep.pos, Message: fmt.Sprintf("expected ',' or ']' but found %s", sep)}
+		}
+	}
+}
+
+// parseValue parses one literal and checks it suits the field.
+func (p *filterParser) parseValue(name string, kind filterFieldKind) (interface{}, error) {
+	tok := p.advance()
+	switch kind {
+	case filterLength:
+		if tok.kind == tokNumber {
+			if n, err := strconv.Atoi(tok.text); err == nil && n >= 0 {
+				return n, nil
+			}
+		}
+		return nil, &FilterError{Pos: tok.pos, Message: fmt.Sprintf("'%s' needs a non-negative whole number, found %s", name, tok)}
+	case filterDate:
+		if tok.kind == tokString {
+			if t, err := time.Parse(time.RFC3339, tok.text); err == nil {
+				return t, nil
+			}
+			if t, err := time.Parse("2006-01-02", tok.text); err == nil {
+				return t, nil
+			}
+		}
+		return nil, &FilterError{Pos: tok.pos, Message: fmt.Sprintf("'%s' needs a date string (YYYY-MM-DD or RFC 3339), found %s", name, tok)}
+	}
+	if tok.kind != tokString {
+		return nil, &FilterError{Pos: tok.pos, Message: fmt.Sprintf("'%s' needs a string value, found %s", name, tok)}
+	}
+	return tok.text, nil
+}
+
+// lengthFilter compares the character length of a string field; missing fields count as empty.
+func lengthFilter(path, op string, value interface{}) bson.M {
+	length := bson.M{"$strLenCP": bson.M{"$ifNull": bson.A{"$" + path, ""}}}
+	return bson.M{"$expr": bson.M{op: bson.A{length, value}}}
+}
+
+// filterFieldList returns the whitelisted field names for error messages, in a fixed order.
+func filterFieldList() string {
+	return "name, details, detailsFormat, keyword, createdAt, updatedAt, len(details), len(name)"
+}
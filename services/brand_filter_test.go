@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCompileBrandFilter(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want bson.M
+	}{
+		{`name = "Acme"`, bson.M{"name": bson.M{"$eq": "Acme"}}},
+		{`detailsFormat != "tsv"`, bson.M{"detailsFormat": bson.M{"$ne": "tsv"}}},
+		{`keyword in ["cotton", "linen"]`, bson.M{"keywords.term": bson.M{"$in": []interface{}{"cotton", "linen"}}}},
+		{`name contains "a.*b"`, bson.M{"name": bson.M{"$regex": `a\.\*b`, "$options": "i"}}},
+		{`details contains "say \"hi\" \\ bye"`, bson.M{"details": bson.M{"$regex": `say "hi" \\ bye`, "$options": "i"}}},
+		{`updatedAt < "2024-01-01"`, bson.M{"updatedAt": bson.M{"$lt": date}}},
+		{`createdAt >= "2024-01-01T00:00:00Z"`, bson.M{"createdAt": bson.M{"$gte": date}}},
+		{`len(details) > 500`, lengthFilter("details", "$gt", 500)},
+		{`len(name) in [1, 2]`, lengthFilter("name", "$in", []interface{}{1, 2})},
+		{`name = "a" AND name = "b" OR name = "c"`, bson.M{"$or": []bson.M{
+			{"$and": []bson.M{{"name": bson.M{"$eq": "a"}}, {"name": bson.M{"$eq": "b"}}}},
+			{"name": bson.M{"$eq": "c"}},
+		}}},
+		{`name = "a" and (name = "b" or name = "c")`, bson.M{"$and": []bson.M{
+			{"name": bson.M{"$eq": "a"}},
+			{"$or": []bson.M{{"name": bson.M{"$eq": "b"}}, {"name": bson.M{"$eq": "c"}}}},
+		}}},
+		{`NOT NOT name = "a"`, bson.M{"$nor": []bson.M{{"$nor": []bson.M{{"name": bson.M{"$eq": "a"}}}}}}},
+		{`((name = "a"))`, bson.M{"name": bson.M{"$eq": "a"}}},
+	}
+	for _, tt := range tests {
+		got, err := CompileBrandFilter(tt.expr)
+		if err != nil {
+			t.Errorf("CompileBrandFilter(%s): %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CompileBrandFilter(%s) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompileBrandFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		pos     int
+		message string
+	}{
+		{"", 1, "expression is empty"},
+		{"   ", 1, "expression is empty"},
+		{`name = "Acme`, 8, "unterminated string"},
+		{`name = "a\nb"`, 10, "invalid escape"},
+		{`name == "a"`, 6, "unknown operator '=='"},
+		{`name =! "a"`, 7, "unknown operator '!'"},
+		{`name ~ "a"`, 6, "unexpected character '~'"},
+		{`color = "red"`, 1, "unknown field 'color'"},
+		{`$where = "1"`, 1, "unexpected character '$'"},
+		{`name > "a"`, 6, "'>' does not apply to text field 'name'"},
+		{`createdAt contains "2024"`, 11, "contains only applies to text fields"},
+		{`createdAt = "yesterday"`, 13, "needs a date string"},
+		{`len(details) > -1`, 16, "non-negative whole number"},
+		{`len(details) > 1.5`, 16, "non-negative whole number"},
+		{`len(details > 5`, 1, "unterminated len("},
+		{`keyword in "cotton"`, 12, "expected '['"},
+		{`keyword in ["a" "b"]`, 17, "expected ',' or ']'"},
+		{`name = "a" AND`, 15, "expected a field but found end of expression"},
+		{`name = "a" OR AND name = "b"`, 15, "expected a field but found 'AND'"},
+		{`(name = "a"`, 12, "expected ')'"},
+		{`name = "a")`, 11, "unexpected ')'"},
+		{`name "a"`, 6, "expected an operator"},
+		{`name = "a" name = "b"`, 12, "unexpected 'name'"},
+	}
+	for _, tt := range tests {
+		_, err := CompileBrandFilter(tt.expr)
+		var filterErr *FilterError
+		if !errors.As(err, &filterErr) {
+			t.Errorf("CompileBrandFilter(%q) error = %v, want a *FilterError", tt.expr, err)
+			continue
+		}
+		if filterErr.Pos != tt.pos || !strings.Contains(filterErr.Message, tt.message) {
+			t.Errorf("CompileBrandFilter(%q) = %d %q, want %d %q", tt.expr, filterErr.Pos, filterErr.Message, tt.pos, tt.message)
+		}
+	}
+}
+
+// Positions count characters, so errors after multi-byte text still point at the right place.
+func TestCompileBrandFilterPositionsCountRunes(t *testing.T) {
+	_, err := CompileBrandFilter(`name = "Ünïcødé" ~`)
+	var filterErr *FilterError
+	if !errors.As(err, &filterErr) || filterErr.Pos != 18 {
+		t.Errorf("error = %v, want position 18", err)
+	}
+}
+
+func TestCompileBrandFilterLimits(t *testing.T) {
+	comparisons := strings.TrimSuffix(strings.Repeat(`name = "a" OR `, MaxFilterComparisons+1), " OR ")
+	tests := []struct {
+		name    string
+		expr    string
+		message string
+	}{
+		{"length", `name = "` + strings.Repeat("a", MaxFilterLength) + `"`, "longer than"},
+		{"depth", strings.Repeat("NOT ", MaxFilterDepth+1) + `name = "a"`, "nested deeper than"},
+		{"parentheses", strings.Repeat("(", MaxFilterDepth+1) + `name = "a"` + strings.Repeat(")", MaxFilterDepth+1), "nested deeper than"},
+		{"comparisons", comparisons, "more than 20 comparisons"},
+		{"list", `keyword in [` + strings.TrimSuffix(strings.Repeat(`"a", `, maxFilterListValues+1), ", ") + `]`, "more than 50 values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileBrandFilter(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("error = %v, want %q", err, tt.message)
+			}
+		})
+	}
+
+	// Exactly at the limits is fine
+	atLimit := []string{
+		strings.Repeat("NOT ", MaxFilterDepth) + `name = "a"`,
+		strings.TrimSuffix(strings.Repeat(`name = "a" OR `, MaxFilterComparisons), " OR "),
+	}
+	for _, expr := range atLimit {
+		if _, err := CompileBrandFilter(expr); err != nil {
+			t.Errorf("CompileBrandFilter at the limit: %v", err)
+		}
+	}
+}
+
+// The prefix condition is a plain range the collated name index can bound; metacharacters are
+// just characters in it.
+func TestNamePrefix(t *testing.T) {
+	for prefix, want := range map[string]bson.M{
+		"Ac":  {"$gte": "Ac", "$lt": "Ac\uffff"},
+		"a.*": {"$gte": "a.*", "$lt": "a.*\uffff"},
+		"Éc":  {"$gte": "Éc", "$lt": "Éc\uffff"},
+	} {
+		if got := NamePrefix(prefix); !reflect.DeepEqual(got, want) {
+			t.Errorf("NamePrefix(%q) = %v, want %v", prefix, got, want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxBrandDepth caps how many parent links are followed. Writes keep the hierarchy shallower
+// and acyclic, but concurrent writes or hand-edited documents could still produce a loop, so
+// every traversal stops here.
+const MaxBrandDepth = 16
+
+// BrandRef names a brand in a hierarchy response.
+type BrandRef struct {
+	ID   primitive.ObjectID `json:"id"`
+	Name string             `json:"name"`
+}
+
+// hierarchyNode is the part of a brand document the hierarchy functions read.
+type hierarchyNode struct {
+	ID     primitive.ObjectID  `bson:"_id"`
+	Name   string              `bson:"name"`
+	Parent *primitive.ObjectID `bson:"parentBrand"`
+}
+
+var hierarchyProjection = bson.M{"_id": 1, "name": 1, "parentBrand": 1}
+
+// ResolveParentBrand returns the ID of the brand called parentName after checking that making it
+// the parent of child creates no cycle and no chain longer than MaxBrandDepth. Problems are
+// returned as an *apperrors.ValidationError on the parentBrand field.
+func ResolveParentBrand(ctx context.Context, coll *mongo.Collection, child primitive.ObjectID, parentName string) (primitive.ObjectID, error) {
+	invalid := func(message string) error {
+		return &apperrors.ValidationError{Fields: map[string]string{"parentBrand": message}}
+	}
+	var parent hierarchyNode
+	err := coll.FindOne(ctx, bson.M{"name": parentName}, options.FindOne().SetProjection(hierarchyProjection)).Decode(&parent)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, invalid(fmt.Sprintf("no brand named '%s'", parentName))
+	}
+	if err != nil {
+		return primitive.NilObjectID, apperrors.FromDB(err)
+	}
+	if parent.ID == child {
+		return primitive.NilObjectID, invalid("a brand can't be its own parent")
+	}
+
+	ancestors, complete, err := brandAncestors(ctx, coll, parent)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == child {
+			return primitive.NilObjectID, invalid(fmt.Sprintf("'%s' is already a sub-brand of this brand", parentName))
+		}
+	}
+	// The child's own subtree sits below the new link too
+	below, err := subtreeDepth(ctx, coll, child)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if !complete || len(ancestors)+1+below > MaxBrandDepth {
+		return primitive.NilObjectID, invalid(fmt.Sprintf("the hierarchy would be deeper than %d levels", MaxBrandDepth))
+	}
+	return parent.ID, nil
+}
+
+// BrandAncestry returns the parents of brand, nearest first, up to the top-level brand. complete
+// is false when the walk stopped at MaxBrandDepth or a loop in stored data; a parent that no
+// longer exists simply ends the chain.
+func BrandAncestry(ctx context.Context, coll *mongo.Collection, brand *models.Brand) ([]BrandRef, bool, error) {
+	return brandAncestors(ctx, coll, hierarchyNode{ID: brand.ID, Name: brand.Name, Parent: brand.ParentBrand})
+}
+
+func brandAncestors(ctx context.Context, coll *mongo.Collection, start hierarchyNode) ([]BrandRef, bool, error) {
+	ancestors := make([]BrandRef, 0)
+	seen := map[primitive.ObjectID]bool{start.ID: true}
+	next := start.Parent
+	for next != nil {
+		if seen[*next] || len(ancestors) == MaxBrandDepth {
+			return ancestors, false, nil
+		}
+		var node hierarchyNode
+		err := coll.FindOne(ctx, bson.M{"_id": *next}, options.FindOne().SetProjection(hierarchyProjection)).Decode(&node)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break // Dangling reference to a deleted parent
+		}
+		if err != nil {
+			return nil, false, apperrors.FromDB(err)
+		}
+		seen[node.ID] = true
+		ancestors = append(ancestors, BrandRef{ID: node.ID, Name: node.Name})
+		next = node.Parent
+	}
+	return ancestors, true, nil
+}
+
+// BrandChildren returns the direct sub-brands of the brand with the given ID, sorted by name.
+func BrandChildren(ctx context.Context, coll *mongo.Collection, parent primitive.ObjectID) ([]BrandRef, error) {
+	cursor, err := coll.Find(ctx, bson.M{"parentBrand": parent}, options.Find().SetProjection(hierarchyProjection))
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	var nodes []hierarchyNode
+	if err := cursor.All(ctx, &nodes); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	children := make([]BrandRef, len(nodes))
+	for i, node := range nodes {
+		children[i] = BrandRef{ID: node.ID, Name: node.Name}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	return children, nil
+}
+
+// BrandDescendants returns every brand below the given one, deepest level first, so deleting
+// them in order never leaves a brand whose children are still there. Levels past MaxBrandDepth
+// are not followed.
+func BrandDescendants(ctx context.Context, coll *mongo.Collection, root primitive.ObjectID) ([]BrandRef, error) {
+	var levels [][]BrandRef
+	seen := map[primitive.ObjectID]bool{root: true}
+	frontier := []primitive.ObjectID{root}
+	for depth := 0; depth < MaxBrandDepth && len(frontier) > 0; depth++ {
+		cursor, err := coll.Find(ctx, bson.M{"parentBrand": bson.M{"$in": frontier}}, options.Find().SetProjection(hierarchyProjection))
+		if err != nil {
+			return nil, apperrors.FromDB(err)
+		}
+		var nodes []hierarchyNode
+		if err := cursor.All(ctx, &nodes); err != nil {
+			return nil, apperrors.FromDB(err)
+		}
+		var level []BrandRef
+		frontier = frontier[:0]
+		for _, node := range nodes {
+			if seen[node.ID] {
+				continue
+			}
+			seen[node.ID] = true
+			level = append(level, BrandRef{ID: node.ID, Name: node.Name})
+			frontier = append(frontier, node.ID)
+		}
+		if len(level) > 0 {
+			levels = append(levels, level)
+		}
+	}
+
+	descendants := make([]BrandRef, 0)
+	for i := len(levels) - 1; i >= 0; i-- {
+		descendants = append(descendants, levels[i]...)
+	}
+	return descendants, nil
+}
+
+// subtreeDepth returns how many levels of sub-brands hang below the given brand (0 for none),
+// counting at most MaxBrandDepth.
+func subtreeDepth(ctx context.Context, coll *mongo.Collection, root primitive.ObjectID) (int, error) {
+	depth := 0
+	seen := map[primitive.ObjectID]bool{root: true}
+	frontier := []primitive.ObjectID{root}
+	for depth < MaxBrandDepth {
+		cursor, err := coll.Find(ctx, bson.M{"parentBrand": bson.M{"$in": frontier}}, options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return 0, apperrors.FromDB(err)
+		}
+		var nodes []hierarchyNode
+		if err := cursor.All(ctx, &nodes); err != nil {
+			return 0, apperrors.FromDB(err)
+		}
+		frontier = frontier[:0]
+		for _, node := range nodes {
+			if !seen[node.ID] {
+				seen[node.ID] = true
+				frontier = append(frontier, node.ID)
+			}
+		}
+		if len(frontier) == 0 {
+			break
+		}
+		depth++
+	}
+	return depth, nil
+}
+
+// BrandParentNames maps the name of every brand matching filter that has a parent to the
+// parent's name. Parents that no longer exist are left out.
+func BrandParentNames(ctx context.Context, coll *mongo.Collection, filter bson.M) (map[string]string, error) {
+	query := bson.M{"parentBrand": bson.M{"$exists": true}}
+	if len(filter) > 0 {
+		query = bson.M{"$and": bson.A{filter, query}}
+	}
+	cursor, err := coll.Find(ctx, query, options.Find().SetProjection(hierarchyProjection))
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	var nodes []hierarchyNode
+	if err := cursor.All(ctx, &nodes); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+
+	parentIDs := make([]primitive.ObjectID, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Parent != nil {
+			parentIDs = append(parentIDs, *node.Parent)
+		}
+	}
+	names := make(map[primitive.ObjectID]string, len(parentIDs))
+	if len(parentIDs) > 0 {
+		cursor, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": parentIDs}}, options.Find().SetProjection(bson.M{"_id": 1, "name": 1}))
+		if err != nil {
+			return nil, apperrors.FromDB(err)
+		}
+		var parents []hierarchyNode
+		if err := cursor.All(ctx, &parents); err != nil {
+			return nil, apperrors.FromDB(err)
+		}
+		for _, parent := range parents {
+			names[parent.ID] = parent.Name
+		}
+	}
+
+	result := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		if node.Parent == nil {
+			continue
+		}
+		if name, ok := names[*node.Parent]; ok {
+			result[node.Name] = name
+		}
+	}
+	return result, nil
+}
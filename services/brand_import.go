@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultImportBatchSize is used when no (valid) batch size is configured.
+const DefaultImportBatchSize = 500
+
+// ImportBatchSize reads IMPORT_BATCH_SIZE from the environment, falling back to DefaultImportBatchSize.
+func ImportBatchSize() int {
+	if raw := os.Getenv("IMPORT_BATCH_SIZE"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Warning: Invalid IMPORT_BATCH_SIZE '%s', using default %d", LogValue(raw), DefaultImportBatchSize)
+	}
+	return DefaultImportBatchSize
+}
+
+// ImportRowError describes a single source row that could not be imported.
+type ImportRowError struct {
+	Row   int    `json:"row"` // CSV: 1-based line the record starts on (header is line 1); supplier feed: 1-based entry
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarises the outcome of a bulk import run.
+type ImportReport struct {
+	TotalRows     int              `json:"totalRows"`
+	Created       int64            `json:"created"`
+	Updated       int64            `json:"updated"`
+	Unchanged     int64            `json:"unchanged"`
+	Failed        int              `json:"failed"`
+	Batches       int              `json:"batches"`
+	DurationMs    int64            `json:"durationMs"`
+	RowsPerSecond float64          `json:"rowsPerSecond"`
+	Errors        []ImportRowError `json:"errors"`
+}
+
+// pendingRow keeps track of which source row produced a queued write model,
+// so per-index bulk write errors can be mapped back to the file.
+type pendingRow struct {
+	row  int
+	name string
+}
+
+// ImportBrandsCSV streams brand rows from a CSV source and upserts them into the
+// given collection using unordered BulkWrite batches.
+//
+// The first record must be a header containing at least the columns "name" and
+// "details" (case-insensitive, any order). Rows whose details exceed MAX_DETAILS_BYTES are
+// reported rather than written, like manual edits, and so are rows with a reserved name (see
+// IsReservedBrandName). Rows are never held in memory beyond the current batch, so arbitrarily
+// large files can be imported.
+func ImportBrandsCSV(ctx context.Context, coll *mongo.Collection, src io.Reader, batchSize int) (*ImportReport, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1 // Tolerate ragged rows; we only look at the known columns
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("import file is empty")
+		}
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	nameCol, detailsCol := -1, -1
+	for i, col := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff"))) {
+		case "name":
+			nameCol = i
+		case "details":
+			detailsCol = i
+		}
+	}
+	if nameCol < 0 || detailsCol < 0 {
+		return nil, errors.New("CSV header must contain 'name' and 'details' columns")
+	}
+
+	report := &ImportReport{Errors: []ImportRowError{}}
+	maxDetails := MaxDetailsBytes()
+	start := time.Now()
+
+	writes := make([]mongo.WriteModel, 0, batchSize)
+	rows := make([]pendingRow, 0, batchSize)
+
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		report.Batches++
+		if err := executeImportBatch(ctx, coll, writes, rows, report); err != nil {
+			return err
+		}
+		writes = writes[:0]
+		rows = rows[:0]
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A malformed line is reported but does not abort the import.
+			line := 0
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.StartLine
+			}
+			report.TotalRows++
+			report.Failed++
+			report.Errors = append(report.Errors, ImportRowError{Row: line, Error: err.Error()})
+			continue
+		}
+		report.TotalRows++
+		// Quoted fields may span lines and blank lines are skipped, so records and lines differ
+		rowNum, _ := reader.FieldPos(0)
+
+		name, details := "", ""
+		if nameCol < len(record) {
+			name = strings.TrimSpace(record[nameCol])
+		}
+		if detailsCol < len(record) {
+			details = record[detailsCol]
+		}
+		if name == "" {
+			report.Failed++
+			report.Errors = append(report.Errors, ImportRowError{Row: rowNum, Error: "name is required"})
+			continue
+		}
+		if IsReservedBrandName(name) {
+			report.Failed++
+			report.Errors = append(report.Errors, ImportRowError{Row: rowNum, Name: name, Error: "name is reserved"})
+			continue
+		}
+		if len(details) > maxDetails {
+			report.Failed++
+			report.Errors = append(report.Errors, ImportRowError{Row: rowNum, Name: name,
+				Error: fmt.Sprintf("details are %d bytes, exceeding the maximum of %d", len(details), maxDetails)})
+			continue
+		}
+
+		writes = append(writes, brandUpsertModel(name, details, models.Now()))
+		rows = append(rows, pendingRow{row: rowNum, name: name})
+
+		if len(writes) >= batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, err
+	}
+
+	elapsed := time.Since(start)
+	report.DurationMs = elapsed.Milliseconds()
+	if elapsed > 0 {
+		report.RowsPerSecond = float64(report.TotalRows) / elapsed.Seconds()
+	}
+
+	log.Printf("Import finished: %d rows (%d created, %d updated, %d failed) in %v (%.1f rows/s)",
+		report.TotalRows, report.Created, report.Updated, report.Failed, elapsed, report.RowsPerSecond)
+
+	return report, nil
+}
+
+// brandUpsertModel builds the same upsert document the PDF upload flow uses:
+// details/updatedAt are always set, name/createdAt only when inserting.
+func brandUpsertModel(name, details string, now time.Time) mongo.WriteModel {
+	return mongo.NewUpdateOneModel().
+		SetFilter(bson.M{"name": name}).
+		SetUpdate(bson.M{
+			"$set": bson.M{
+				"details":       details,
+				"detailsFormat": DetectDetailsFormat(details),
+				"keywords":      ExtractKeywords(details),
+				"sections":      SplitDetailsSections(details),
+				"updatedAt":     now,
+			},
+			"$setOnInsert": bson.M{
+				"name":      name,
+				"createdAt": now,
+			},
+		}).
+		SetUpsert(true)
+}
+
+// executeImportBatch runs one unordered BulkWrite and folds its result into the report.
+// Per-document write errors are mapped back to their source rows; any other error aborts the import.
+func executeImportBatch(ctx context.Context, coll *mongo.Collection, models []mongo.WriteModel, rows []pendingRow, report *ImportReport) error {
+	opts := options.BulkWrite().SetOrdered(false) // Keep going past individual failures
+
+	result, err := coll.BulkWrite(ctx, models, opts)
+	if result != nil {
+		report.Created += result.UpsertedCount
+		report.Updated += result.ModifiedCount
+		report.Unchanged += result.MatchedCount - result.ModifiedCount
+	}
+	if err == nil {
+		return nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+		log.Printf("Error executing import batch %d: %v", report.Batches, err)
+		return fmt.Errorf("bulk write failed: %w", err)
+	}
+
+	for _, we := range bulkErr.WriteErrors {
+		rowErr := ImportRowError{Error: we.Message}
+		if we.Index >= 0 && we.Index < len(rows) {
+			rowErr.Row = rows[we.Index].row
+			rowErr.Name = rows[we.Index].name
+		}
+		if we.Code == 11000 { // E11000 duplicate key
+			rowErr.Error = "duplicate brand name"
+		}
+		report.Failed++
+		report.Errors = append(report.Errors, rowErr)
+	}
+	return nil
+}
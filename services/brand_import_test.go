@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Rows are reported by the line they start on, however many lines earlier records span. Rows
+// without a name fail before any write, so no collection is needed.
+func TestImportBrandsCSVReportsLines(t *testing.T) {
+	csv := strings.Join([]string{
+		"name,details",             // 1
+		`,"spans`,                  // 2
+		`three`,                    // 3
+		`lines"`,                   // 4
+		"",                         // 5: blank lines are skipped
+		"  ,no name",               // 6
+		`,"bare " quote`,           // 7
+		`"",`,                      // 8
+		`,"a ""quoted"" word"`,     // 9
+		`,"ends on the last line"`, // 10
+	}, "\n")
+	report, err := ImportBrandsCSV(context.Background(), nil, strings.NewReader(csv), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	for _, rowErr := range report.Errors {
+		lines = append(lines, rowErr.Row)
+	}
+	if want := []int{2, 6, 7, 8, 9, 10}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("errors on lines %v, want %v", lines, want)
+	}
+	if report.TotalRows != 6 || report.Failed != 6 {
+		t.Errorf("%d rows, %d failed; want 6, 6", report.TotalRows, report.Failed)
+	}
+}
+
+// Rows over MAX_DETAILS_BYTES are reported by line and name instead of being written, so no
+// collection is needed either.
+func TestImportBrandsCSVRejectsLargeDetails(t *testing.T) {
+	t.Setenv("MAX_DETAILS_BYTES", "10")
+	csv := "name,details\nAcme,\"eleven\nbytes\"\nGlobex,Größe: elf\n"
+	report, err := ImportBrandsCSV(context.Background(), nil, strings.NewReader(csv), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ImportRowError{
+		{Row: 2, Name: "Acme", Error: "details are 12 bytes, exceeding the maximum of 10"},
+		{Row: 4, Name: "Globex", Error: "details are 12 bytes, exceeding the maximum of 10"},
+	}
+	if !reflect.DeepEqual(report.Errors, want) || report.Failed != 2 {
+		t.Errorf("errors = %+v, want %+v", report.Errors, want)
+	}
+}
+
+// Reserved names fail per row, so brandctl imports refuse them like the API does.
+func TestImportBrandsCSVRejectsReservedNames(t *testing.T) {
+	csv := "name,details\nsync,x\n\"by-name\",\"two\nlines\"\nupload,y\n"
+	report, err := ImportBrandsCSV(context.Background(), nil, strings.NewReader(csv), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ImportRowError{
+		{Row: 2, Name: "sync", Error: "name is reserved"},
+		{Row: 3, Name: "by-name", Error: "name is reserved"},
+		{Row: 5, Name: "upload", Error: "name is reserved"},
+	}
+	if !reflect.DeepEqual(report.Errors, want) || report.Failed != 3 {
+		t.Errorf("%d failed, errors = %+v; want 3, %+v", report.Failed, report.Errors, want)
+	}
+}
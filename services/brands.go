@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The functions in this file are the shared brand operations used by both the
+// HTTP handlers and the brandctl command, so the two can never disagree.
+// Driver errors with a domain meaning are returned as apperrors (e.g. a missing brand as
+// apperrors.ErrNotFound), so callers never test for mongo.ErrNoDocuments themselves.
+
+// Default cap on the size of a brand's details text (1 MiB), overridable via MAX_DETAILS_BYTES
+const defaultMaxDetailsBytes = 1 << 20
+
+// MaxDetailsBytes returns the configured maximum details size in bytes.
+func MaxDetailsBytes() int {
+	if raw := os.Getenv("MAX_DETAILS_BYTES"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Warning: Invalid MAX_DETAILS_BYTES '%s', using default %d", LogValue(raw), defaultMaxDetailsBytes)
+	}
+	return defaultMaxDetailsBytes
+}
+
+// SortLocales are the locales clients may request for alphabetical brand listings.
+var SortLocales = map[string]bool{
+	"en": true, "de": true, "fr": true, "es": true, "it": true, "pt": true, "nl": true,
+	"sv": true, "da": true, "nb": true, "fi": true, "pl": true, "cs": true, "tr": true,
+}
+
+// Fields brand name listings can be sorted by.
+const (
+	SortByName      = "name"
+	SortByCreatedAt = "createdAt"
+	SortByUpdatedAt = "updatedAt"
+)
+
+// BrandSortFields lists the accepted sort fields, default first.
+var BrandSortFields = []string{SortByName, SortByCreatedAt, SortByUpdatedAt}
+
+// BrandSort orders a brand name listing.
+type BrandSort struct {
+	Field      string // One of BrandSortFields
+	Descending bool
+}
+
+// brandListOptions sorts a listing by order. Names that only differ in case compare equal under
+// the collation, and timestamps can tie, so _id breaks the tie to keep the order stable. With the
+// default locale the name sort (either way round) is served by the collated name index, the
+// updatedAt sort by the sync index.
+func brandListOptions(locale string, order BrandSort) *options.FindOptions {
+	if locale == "" {
+		locale = database.CollationLocale()
+	}
+	sort := bson.D{{Key: order.Field, Value: 1}, {Key: "_id", Value: 1}}
+	if order.Field == SortByName {
+		sort = database.NameSort()
+	}
+	if order.Descending {
+		for i := range sort {
+			sort[i].Value = -1
+		}
+	}
+	opts := options.Find().SetSort(sort)
+	if order.Field == SortByName {
+		opts.SetCollation(database.NameCollation(locale))
+	}
+	return opts
+}
+
+// ListBrandNames returns the names of the brands matching filter (never nil) sorted
+// alphabetically in locale ("" = the configured default), together with the number of
+// documents that could not be decoded. Malformed documents are logged and skipped rather than
+// failing the whole listing.
+func ListBrandNames(ctx context.Context, coll *mongo.Collection, filter bson.M, locale string) ([]string, int, error) {
+	return ListBrandNamesSorted(ctx, coll, filter, locale, BrandSort{Field: SortByName})
+}
+
+// ListBrandNamesSorted is ListBrandNames in another order. locale only applies to name sorting.
+func ListBrandNamesSorted(ctx context.Context, coll *mongo.Collection, filter bson.M, locale string, order BrandSort) ([]string, int, error) {
+	// Find documents, projecting only the 'name' field (and '_id' so bad documents can be identified).
+	opts := brandListOptions(locale, order).SetProjection(bson.M{"name": 1, "_id": 1})
+	cursor, err := coll.Find(ctx, filter, opts) // Empty filter {} means find all
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding brands: %w", err)
+	}
+	defer cursor.Close(ctx) // Important to close the cursor
+
+	// Extract just the names into a string slice (empty, not nil, when there are none)
+	brandNames := make([]string, 0)
+	decodeErrors := 0
+	for cursor.Next(ctx) {
+		var res struct { // Temporary struct to decode only the name
+			Name string `bson:"name"`
+		}
+		if err := cursor.Decode(&res); err != nil {
+			decodeErrors++
+			log.Printf("Warning: Skipping brand document %s that failed to decode: %v", rawDocumentID(cursor.Current), err)
+			continue
+		}
+		brandNames = append(brandNames, res.Name)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, decodeErrors, fmt.Errorf("iterating brands: %w", err)
+	}
+	return brandNames, decodeErrors, nil
+}
+
+// SuggestBrandNames returns at most limit names starting with prefix (ignoring case), in the
+// listing's alphabetical order, for autocompletion. It is one query: the collated name index
+// serves both the prefix range and the order, so only matching keys are read and the scan stops
+// after limit of them.
+func SuggestBrandNames(ctx context.Context, coll *mongo.Collection, prefix string, limit int) ([]string, error) {
+	opts := brandListOptions("", BrandSort{Field: SortByName}).
+		SetProjection(bson.M{"_id": 0, "name": 1}).
+		SetLimit(int64(limit))
+	cursor, err := coll.Find(ctx, bson.M{"name": NamePrefix(prefix)}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding brand suggestions: %w", err)
+	}
+	var results []struct {
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("decoding brand suggestions: %w", err)
+	}
+	names := make([]string, len(results))
+	for i, res := range results {
+		names[i] = res.Name
+	}
+	return names, nil
+}
+
+// EachBrandSorted calls fn with the whole document of every brand matching filter, in the order
+// of ListBrandNamesSorted, one at a time as they are read from the cursor, so a caller that
+// streams them never holds the whole list. It stops at the first error fn returns; documents
+// that fail to decode are skipped and counted.
+func EachBrandSorted(ctx context.Context, coll *mongo.Collection, filter bson.M, locale string, order BrandSort, fn func(*models.Brand) error) (int, error) {
+	cursor, err := coll.Find(ctx, filter, brandListOptions(locale, order))
+	if err != nil {
+		return 0, fmt.Errorf("finding brands: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	decodeErrors := 0
+	for cursor.Next(ctx) {
+		var brand models.Brand
+		if err := cursor.Decode(&brand); err != nil {
+			decodeErrors++
+			log.Printf("Warning: Skipping brand document %s that failed to decode: %v", rawDocumentID(cursor.Current), err)
+			continue
+		}
+		if err := fn(&brand); err != nil {
+			return decodeErrors, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return decodeErrors, fmt.Errorf("iterating brands: %w", err)
+	}
+	return decodeErrors, nil
+}
+
+// DecodeFailure identifies a stored brand document that does not match models.Brand.
+type DecodeFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// FindUndecodableBrands scans the whole collection and reports every document that
+// cannot be decoded into models.Brand (e.g. legacy documents with details stored as an array).
+func FindUndecodableBrands(ctx context.Context, coll *mongo.Collection) ([]DecodeFailure, error) {
+	cursor, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("finding brands: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	failures := make([]DecodeFailure, 0)
+	for cursor.Next(ctx) {
+		var brand models.Brand
+		if err := cursor.Decode(&brand); err != nil {
+			failures = append(failures, DecodeFailure{ID: rawDocumentID(cursor.Current), Error: err.Error()})
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("iterating brands: %w", err)
+	}
+	return failures, nil
+}
+
+// rawDocumentID renders the _id of a raw document for logging, whatever its type.
+func rawDocumentID(doc bson.Raw) string {
+	idVal, err := doc.LookupErr("_id")
+	if err != nil {
+		return "<no _id>"
+	}
+	if oid, ok := idVal.ObjectIDOK(); ok {
+		return oid.Hex()
+	}
+	return idVal.String()
+}
+
+// GetBrandByName loads a single brand by its exact name.
+func GetBrandByName(ctx context.Context, coll *mongo.Collection, name string) (*models.Brand, error) {
+	var brand models.Brand
+	if err := coll.FindOne(ctx, bson.M{"name": name}).Decode(&brand); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	return &brand, nil
+}
+
+// ReprocessBrand recomputes the data derived from a brand's stored details
+// (the extracted keywords and the section index). The original PDFs are not kept, so text
+// extraction itself cannot be re-run; this refreshes everything computed from it.
+// An empty name reprocesses every brand. It returns the number of brands updated.
+func ReprocessBrand(ctx context.Context, coll *mongo.Collection, name string) (int, error) {
+	filter := bson.M{}
+	if name != "" {
+		filter = bson.M{"name": name}
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "details": 1})
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, fmt.Errorf("finding brands: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var brand models.Brand
+		if err := cursor.Decode(&brand); err != nil {
+			return updated, fmt.Errorf("decoding brand: %w", err)
+		}
+		update := bson.M{"$set": bson.M{"keywords": ExtractKeywords(brand.Details), "sections": SplitDetailsSections(brand.Details)}}
+		if _, err := coll.UpdateByID(ctx, brand.ID, update); err != nil {
+			return updated, fmt.Errorf("updating brand %s: %w", brand.ID.Hex(), err)
+		}
+		updated++
+	}
+	if err := cursor.Err(); err != nil {
+		return updated, err
+	}
+	if name != "" && updated == 0 {
+		return 0, apperrors.ErrNotFound
+	}
+	return updated, nil
+}
+
+// ExportBrandsCSV writes every brand as CSV in the same "name,details" layout
+// ImportBrandsCSV accepts, so an export can be re-imported unchanged.
+func ExportBrandsCSV(ctx context.Context, coll *mongo.Collection, dst io.Writer) (int, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0, "name": 1, "details": 1, "updatedAt": 1}).
+		SetSort(bson.M{"name": 1})
+	cursor, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return 0, fmt.Errorf("finding brands: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	writer := csv.NewWriter(dst)
+	if err := writer.Write([]string{"name", "details", "updatedAt"}); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for cursor.Next(ctx) {
+		var brand models.Brand
+		if err := cursor.Decode(&brand); err != nil {
+			return count, fmt.Errorf("decoding brand: %w", err)
+		}
+		if err := writer.Write([]string{brand.Name, brand.Details, brand.UpdatedAt.UTC().Format(time.RFC3339)}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := cursor.Err(); err != nil {
+		return count, err
+	}
+
+	writer.Flush()
+	return count, writer.Error()
+}
+
+// IndexInfo is a simplified view of a collection index.
+type IndexInfo struct {
+	Name   string `json:"name"`
+	Keys   string `json:"keys"`
+	Unique bool   `json:"unique"`
+}
+
+// ListIndexes reports the indexes currently defined on the collection.
+func ListIndexes(ctx context.Context, coll *mongo.Collection) ([]IndexInfo, error) {
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing indexes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var specs []bson.M
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, fmt.Errorf("decoding indexes: %w", err)
+	}
+
+	indexes := make([]IndexInfo, 0, len(specs))
+	for _, spec := range specs {
+		info := IndexInfo{Keys: fmt.Sprint(spec["key"])}
+		info.Name, _ = spec["name"].(string)
+		info.Unique, _ = spec["unique"].(bool)
+		indexes = append(indexes, info)
+	}
+	return indexes, nil
+}
+
+// timestampFields are the brand fields holding dates.
+var timestampFields = []string{"createdAt", "updatedAt"}
+
+// NormalizeTimestamps converts brand timestamps that were stored as strings (older writes and
+// manual imports) into BSON dates, which are always UTC. Mixed types break sorting and range
+// filters, because MongoDB orders every string before every date. Strings without an explicit
+// offset are read in legacyZone (an IANA name such as "Asia/Kolkata"; empty means UTC).
+// Values that can't be parsed are left unchanged. It returns the number of values converted.
+func NormalizeTimestamps(ctx context.Context, coll *mongo.Collection, legacyZone string) (int64, error) {
+	if legacyZone == "" {
+		legacyZone = "UTC"
+	}
+	if _, err := time.LoadLocation(legacyZone); err != nil {
+		return 0, fmt.Errorf("invalid time zone '%s': %w", legacyZone, err)
+	}
+
+	var modified int64
+	for _, field := range timestampFields {
+		filter := bson.M{field: bson.M{"$type": "string"}}
+		pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			field: bson.M{"$dateFromString": bson.M{
+				"dateString": "$" + field,
+				"timezone":   legacyZone,
+				"onError":    "$" + field,
+			}},
+		}}}}
+		result, err := coll.UpdateMany(ctx, filter, pipeline)
+		if err != nil {
+			return modified, fmt.Errorf("normalizing %s: %w", field, err)
+		}
+		modified += result.ModifiedCount
+	}
+	return modified, nil
+}
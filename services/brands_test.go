@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// An unknown legacy zone is rejected before any document is touched (the collection is nil here).
+func TestNormalizeTimestampsRejectsZone(t *testing.T) {
+	for _, zone := range []string{"Mars/Olympus_Mons", "+05:30", "IST5:30"} {
+		_, err := NormalizeTimestamps(context.Background(), nil, zone)
+		if err == nil || !strings.Contains(err.Error(), "invalid time zone") {
+			t.Errorf("NormalizeTimestamps(%q) = %v, want an invalid time zone error", zone, err)
+		}
+	}
+}
+
+// Listings sort by (name, _id) so collated ties keep their order, in the requested locale.
+func TestBrandListOptions(t *testing.T) {
+	for _, locale := range []string{"en", "de", "tr"} {
+		opts := brandListOptions(locale, BrandSort{Field: SortByName})
+		if !reflect.DeepEqual(opts.Sort, database.NameSort()) {
+			t.Errorf("%s: sort %v, want %v", locale, opts.Sort, database.NameSort())
+		}
+		if opts.Collation == nil || opts.Collation.Locale != locale {
+			t.Errorf("%s: collation %+v, want %s", locale, opts.Collation, locale)
+		}
+	}
+
+	// Descending reverses every key, so the index still serves it (walked backwards)
+	tests := []struct {
+		order         BrandSort
+		wantSort      bson.D
+		wantCollation bool
+	}{
+		{BrandSort{Field: SortByName, Descending: true}, bson.D{{Key: "name", Value: -1}, {Key: "_id", Value: -1}}, true},
+		{BrandSort{Field: SortByUpdatedAt}, bson.D{{Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}}, false},
+		{BrandSort{Field: SortByCreatedAt, Descending: true}, bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, false},
+	}
+	for _, tt := range tests {
+		opts := brandListOptions("en", tt.order)
+		if !reflect.DeepEqual(opts.Sort, tt.wantSort) {
+			t.Errorf("%+v: sort %v, want %v", tt.order, opts.Sort, tt.wantSort)
+		}
+		if (opts.Collation != nil) != tt.wantCollation {
+			t.Errorf("%+v: collation %+v, want collated %v", tt.order, opts.Collation, tt.wantCollation)
+		}
+	}
+}
+
+// A document whose name doesn't decode is skipped and counted; the names around it still come
+// back, in the order the server returned them.
+func TestListBrandNamesSkipsUndecodable(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("one bad document", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.brands", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Acme"}},
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: int32(42)}},
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Zeta"}},
+		))
+		names, decodeErrors, err := ListBrandNames(context.Background(), mt.Coll, bson.M{}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"Acme", "Zeta"}; !reflect.DeepEqual(names, want) || decodeErrors != 1 {
+			t.Errorf("got %q with %d decode errors, want %q with 1", names, decodeErrors, want)
+		}
+	})
+}
+
+// The listing's find command carries the requested locale's collation, so the server (and the
+// collated index) does the sorting.
+func TestListBrandNamesSendsCollation(t *testing.T) {
+	t.Setenv("BRAND_COLLATION_LOCALE", "fr")
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range []struct{ locale, want string }{{"", "fr"}, {"de", "de"}, {"sv", "sv"}} {
+		mt.Run("locale "+tt.want, func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.brands", mtest.FirstBatch))
+			if _, _, err := ListBrandNames(context.Background(), mt.Coll, bson.M{}, tt.locale); err != nil {
+				t.Fatal(err)
+			}
+			cmd := mt.GetStartedEvent().Command
+			collation, _ := cmd.Lookup("collation").DocumentOK()
+			if locale, _ := collation.Lookup("locale").StringValueOK(); locale != tt.want {
+				t.Errorf("collation %v, want locale %s", collation, tt.want)
+			}
+			if strength, _ := collation.Lookup("strength").AsInt64OK(); strength != 2 {
+				t.Errorf("collation %v, want strength 2", collation)
+			}
+			if sort := cmd.Lookup("sort").String(); sort != `{"name": {"$numberInt":"1"},"_id": {"$numberInt":"1"}}` {
+				t.Errorf("sort %s, want name then _id", sort)
+			}
+		})
+	}
+}
+
+// Accented names sort among their base letters rather than after "Z", except where the locale
+// treats them as letters of their own. This needs a real server: set MONGODB_TEST_URI to run it.
+func TestListBrandNamesAccentedOrder(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+	coll := client.Database("orderform_test").Collection("brands_collation_" + primitive.NewObjectID().Hex())
+	defer coll.Drop(ctx)
+	if _, err := coll.InsertMany(ctx, []interface{}{
+		bson.M{"name": "Zeta"}, bson.M{"name": "Éclair"}, bson.M{"name": "Ångström"}, bson.M{"name": "Apple"}, bson.M{"name": "acme"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{"en", []string{"acme", "Ångström", "Apple", "Éclair", "Zeta"}},
+		{"sv", []string{"acme", "Apple", "Éclair", "Zeta", "Ångström"}}, // Å is the 27th letter
+	}
+	for _, tt := range tests {
+		names, _, err := ListBrandNames(ctx, coll, bson.M{}, tt.locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.locale, names, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Surrogate keys ("Cache-Tag") responses are labeled with so a CDN can purge them selectively.
+const (
+	CacheTagBrandList = "brands" // Every brand listing
+	cacheTagBrand     = "brand-"
+)
+
+// cdnPurgeTimeout bounds one purge call; purges are best-effort and never delay a response.
+const cdnPurgeTimeout = 10 * time.Second
+
+var cdnClient = &http.Client{Timeout: cdnPurgeTimeout}
+
+// BrandCacheTag is the surrogate key of every cached response about the brand with the hex
+// ObjectID id.
+func BrandCacheTag(id string) string {
+	return cacheTagBrand + id
+}
+
+// CDNPurgeConfigured reports whether writes purge the CDN (CDN_PURGE_URL is set).
+func CDNPurgeConfigured() bool {
+	return os.Getenv("CDN_PURGE_URL") != ""
+}
+
+// PurgeCDN asks the CDN to drop the cached responses labeled with any of tags, in a background
+// worker. It POSTs {"tags": [...]} to CDN_PURGE_URL, with CDN_PURGE_TOKEN as a bearer token when
+// set. Failures are only logged: cached copies then expire on their own after their max-age.
+func PurgeCDN(tags ...string) {
+	endpoint := os.Getenv("CDN_PURGE_URL")
+	if endpoint == "" || len(tags) == 0 {
+		return
+	}
+	token := os.Getenv("CDN_PURGE_TOKEN")
+	goWorker("cdn-purge", func(ctx context.Context) {
+		if err := postCDNPurge(ctx, endpoint, token, tags); err != nil {
+			log.Printf("Warning: CDN purge of %v failed: %v", tags, err)
+		}
+	})
+}
+
+func postCDNPurge(ctx context.Context, endpoint, token string, tags []string) error {
+	body, err := json.Marshal(map[string][]string{"tags": tags})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid CDN_PURGE_URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := cdnClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // The URL may embed credentials; keep it out of logs
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("purge API returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrContactNotFound is returned when the brand exists but has no contact with the given ID.
+var ErrContactNotFound = errors.New("contact not found")
+
+// Contact changes are single-document aggregation-pipeline updates, so clearing the previous
+// preferred contact and writing the new one happen atomically: concurrent requests can never
+// leave a brand with two preferred contacts.
+//
+// Contact edits deliberately leave updatedAt alone; contacts are not part of the public brand
+// data, so sync clients have nothing to fetch.
+
+// contactsExpr is the brand's contacts array, empty when the field is missing.
+var contactsExpr = bson.M{"$ifNull": bson.A{"$contacts", bson.A{}}}
+
+// clearPreferredExpr is the contacts array with every contact un-preferred.
+var clearPreferredExpr = bson.M{"$map": bson.M{
+	"input": contactsExpr,
+	"as":    "c",
+	"in":    bson.M{"$mergeObjects": bson.A{"$$c", bson.M{"preferred": false}}},
+}}
+
+// literal stops user-supplied values that start with "$" being read as pipeline expressions.
+func literal(value interface{}) bson.M {
+	return bson.M{"$literal": value}
+}
+
+// newContact builds the stored contact from a payload.
+func newContact(id primitive.ObjectID, payload models.ContactPayload) models.Contact {
+	return models.Contact{
+		ID:        id,
+		Name:      payload.Name,
+		Role:      payload.Role,
+		Email:     payload.Email,
+		Phone:     payload.Phone,
+		Preferred: payload.Preferred,
+	}
+}
+
+// updateContacts applies a pipeline update and returns the brand's contacts afterwards.
+// No match is reported as apperrors.ErrNotFound (brand missing) or ErrContactNotFound.
+func updateContacts(ctx context.Context, coll *mongo.Collection, name string, contactID *primitive.ObjectID, pipeline mongo.Pipeline) ([]models.Contact, error) {
+	filter := bson.M{"name": name}
+	if contactID != nil {
+		filter["contacts._id"] = *contactID
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"contacts": 1})
+	var brand models.Brand
+	err := coll.FindOneAndUpdate(ctx, filter, pipeline, opts).Decode(&brand)
+	if err == mongo.ErrNoDocuments && contactID != nil {
+		// Tell "no such brand" apart from "no such contact"
+		if count, countErr := coll.CountDocuments(ctx, bson.M{"name": name}, options.Count().SetLimit(1)); countErr == nil && count > 0 {
+			return nil, ErrContactNotFound
+		}
+	}
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	if brand.Contacts == nil {
+		brand.Contacts = []models.Contact{}
+	}
+	return brand.Contacts, nil
+}
+
+// ListBrandContacts returns a brand's contacts (empty if it has none).
+func ListBrandContacts(ctx context.Context, coll *mongo.Collection, name string) ([]models.Contact, error) {
+	var brand models.Brand
+	opts := options.FindOne().SetProjection(bson.M{"contacts": 1})
+	if err := coll.FindOne(ctx, bson.M{"name": name}, opts).Decode(&brand); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	if brand.Contacts == nil {
+		brand.Contacts = []models.Contact{}
+	}
+	return brand.Contacts, nil
+}
+
+// AddBrandContact appends a contact with a generated ID. If it is preferred, any previously
+// preferred contact is un-preferred in the same update.
+func AddBrandContact(ctx context.Context, coll *mongo.Collection, name string, payload models.ContactPayload) (*models.Contact, []models.Contact, error) {
+	contact := newContact(primitive.NewObjectID(), payload)
+	existing := interface{}(contactsExpr)
+	if contact.Preferred {
+		existing = clearPreferredExpr
+	}
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"contacts": bson.M{"$concatArrays": bson.A{existing, bson.A{literal(contact)}}},
+	}}}}
+	contacts, err := updateContacts(ctx, coll, name, nil, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &contact, contacts, nil
+}
+
+// UpdateBrandContact replaces a contact (keeping its ID), un-preferring the others if it is now preferred.
+func UpdateBrandContact(ctx context.Context, coll *mongo.Collection, name string, contactID primitive.ObjectID, payload models.ContactPayload) (*models.Contact, []models.Contact, error) {
+	contact := newContact(contactID, payload)
+	input := interface{}(contactsExpr)
+	if contact.Preferred {
+		input = clearPreferredExpr // The edited contact is replaced below anyway
+	}
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"contacts": bson.M{"$map": bson.M{
+			"input": input,
+			"as":    "c",
+			"in":    bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$c._id", contactID}}, literal(contact), "$$c"}},
+		}},
+	}}}}
+	contacts, err := updateContacts(ctx, coll, name, &contactID, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &contact, contacts, nil
+}
+
+// RemoveBrandContact deletes a contact by ID and returns the remaining contacts.
+func RemoveBrandContact(ctx context.Context, coll *mongo.Collection, name string, contactID primitive.ObjectID) ([]models.Contact, error) {
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"contacts": bson.M{"$filter": bson.M{
+			"input": contactsExpr,
+			"as":    "c",
+			"cond":  bson.M{"$ne": bson.A{"$$c._id", contactID}},
+		}},
+	}}}}
+	return updateContacts(ctx, coll, name, &contactID, pipeline)
+}
+
+// PreferredContact returns the brand's preferred contact, or nil. Intended for outgoing
+// notifications (e.g. CC on order confirmations) once those exist.
+func PreferredContact(brand *models.Brand) *models.Contact {
+	for i := range brand.Contacts {
+		if brand.Contacts[i].Preferred {
+			return &brand.Contacts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,403 @@
+// Package deletion deletes a brand together with everything stored for it.
+//
+// A deletion is a sequence of idempotent steps recorded in a manifest (models.DeletionManifest)
+// that is written before anything is removed:
+//
+//  1. document and tombstone: the brand document is removed and its sync tombstone recorded, in
+//     one transaction when the deployment supports them (replica set or sharded cluster)
+//  2. logo files: GridFS can't take part in a transaction, so the variants go after the commit
+//  3. caches: the brand is dropped from the in-memory duplicate report
+//
+// If removing the document fails nothing has been deleted and the manifest is discarded. Recovery
+// discards it too when it finds the brand still there, in case the process stopped before the
+// document step finished or the discard failed: the client was never told the brand was deleted.
+// Once the document is gone the brand is never restored: a later step that fails is marked in the
+// manifest, and StartRecovery retries the remaining steps until the deletion is complete.
+package deletion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Recovery defaults; the interval is overridable via DELETION_RETRY_SECONDS.
+const (
+	defaultRetryInterval = 5 * time.Minute
+	recoveryGrace        = time.Minute // Younger incomplete manifests may still be running
+	recoveryTimeout      = 30 * time.Second
+	recoveryBatch        = 100
+)
+
+// transactionsUnsupported is set once the deployment rejected a transaction (standalone server),
+// so later deletions go straight to the sequential steps.
+var transactionsUnsupported atomic.Bool
+
+// errAbandoned is returned by a resumed run whose brand was never removed: the attempt that
+// recorded the manifest failed or stopped before its document step, so there is nothing to finish.
+var errAbandoned = errors.New("the brand was never removed")
+
+// effects are the side effects of the steps and of saving the manifest; tests replace them to
+// fail a single step.
+var effects = struct {
+	removeDocument  func(ctx context.Context, brands *mongo.Collection, m *models.DeletionManifest) (bool, error)
+	documentExists  func(ctx context.Context, brands *mongo.Collection, m *models.DeletionManifest) (bool, error)
+	recordTombstone func(ctx context.Context, tombstones *mongo.Collection, m *models.DeletionManifest) error
+	removeLogoFiles func(ctx context.Context, db *mongo.Database, fileIDs []primitive.ObjectID) error
+	forgetBrand     func(name string)
+	save            func(ctx context.Context, manifests *mongo.Collection, m *models.DeletionManifest)
+	discard         func(ctx context.Context, manifests *mongo.Collection, m *models.DeletionManifest)
+}{removeDocument, documentExists, recordTombstone, services.RemoveLogoFiles, services.ForgetDuplicateBrand, save, discard}
+
+// HasChildrenError is returned by Delete for a brand that other brands name as their parent.
+type HasChildrenError struct {
+	Children []services.BrandRef
+}
+
+func (e *HasChildrenError) Error() string {
+	names := make([]string, len(e.Children))
+	for i, child := range e.Children {
+		names[i] = child.Name
+	}
+	return fmt.Sprintf("brand has %d sub-brand(s): %s", len(names), strings.Join(names, ", "))
+}
+
+// Delete removes the brand called name and everything stored for it. It returns
+// apperrors.ErrNotFound if there is no such brand, a *HasChildrenError if it still has
+// sub-brands (see DeleteTree), and an error only if the brand was not removed. A returned
+// manifest with Complete false means the brand is gone but some cleanup failed; it is retried in
+// the background.
+func Delete(ctx context.Context, brands *mongo.Collection, name, requestedBy string) (*models.DeletionManifest, error) {
+	var brand struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Name     string             `bson:"name"`
+		Contacts []bson.Raw         `bson:"contacts"` // Only counted, so any shape decodes
+		Logo     *models.BrandLogo  `bson:"logo"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"_id": 1, "name": 1, "contacts": 1, "logo": 1})
+	if err := brands.FindOne(ctx, bson.M{"name": name}, opts).Decode(&brand); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	children, err := services.BrandChildren(ctx, brands, brand.ID)
+	if err != nil {
+		return nil, fmt.Errorf("checking sub-brands: %w", err)
+	}
+	if len(children) > 0 {
+		return nil, &HasChildrenError{Children: children}
+	}
+
+	now := models.Now()
+	manifest := &models.DeletionManifest{
+		ID:          primitive.NewObjectID(),
+		BrandID:     brand.ID,
+		BrandName:   brand.Name,
+		Contacts:    len(brand.Contacts),
+		StartedAt:   now,
+		UpdatedAt:   now,
+		RequestedBy: requestedBy,
+	}
+	steps := []string{models.DeletionStepDocument, models.DeletionStepTombstone, models.DeletionStepCaches}
+	if brand.Logo != nil {
+		for _, v := range brand.Logo.Variants {
+			manifest.LogoFiles = append(manifest.LogoFiles, v.FileID)
+		}
+		steps = []string{models.DeletionStepDocument, models.DeletionStepTombstone, models.DeletionStepLogoFiles, models.DeletionStepCaches}
+	}
+	for _, step := range steps {
+		manifest.Steps = append(manifest.Steps, models.DeletionStep{Name: step, Status: models.DeletionPending})
+	}
+
+	manifests := brands.Database().Collection(database.DeletionManifestCollection)
+	if _, err := manifests.InsertOne(ctx, manifest); err != nil {
+		return nil, fmt.Errorf("recording deletion manifest: %w", apperrors.FromDB(err))
+	}
+	if err := run(ctx, brands, manifests, manifest, false); err != nil {
+		if !stepDone(manifest, models.DeletionStepDocument) {
+			// Nothing was removed; drop the manifest so recovery doesn't delete the brand later
+			effects.discard(ctx, manifests, manifest)
+			return nil, err
+		}
+		log.Printf("Warning: Brand '%s' deleted but cleanup is incomplete (manifest %s), retrying in the background: %v",
+			services.LogValue(manifest.BrandName), manifest.ID.Hex(), err)
+	}
+	return manifest, nil
+}
+
+// DeleteTree deletes the brand called name after all of its sub-brands, deepest first, each
+// with its own manifest. It stops at the first brand that can't be removed, so a parent is never
+// deleted while a child is left; the manifests of the brands removed so far are returned with the
+// error.
+func DeleteTree(ctx context.Context, brands *mongo.Collection, name, requestedBy string) ([]*models.DeletionManifest, error) {
+	var root struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := brands.FindOne(ctx, bson.M{"name": name}, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&root); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	descendants, err := services.BrandDescendants(ctx, brands, root.ID)
+	if err != nil {
+		return nil, fmt.Errorf("listing sub-brands: %w", err)
+	}
+
+	manifests := make([]*models.DeletionManifest, 0, len(descendants)+1)
+	for _, brand := range append(descendants, services.BrandRef{ID: root.ID, Name: name}) {
+		manifest, err := Delete(ctx, brands, brand.Name, requestedBy)
+		if errors.Is(err, apperrors.ErrNotFound) && brand.ID != root.ID {
+			continue // Deleted concurrently
+		}
+		if err != nil {
+			return manifests, fmt.Errorf("deleting '%s': %w", brand.Name, err)
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// Resume retries the incomplete deletions last updated before olderThan, oldest first, and
+// returns how many it completed. Deletions whose brand was never removed are discarded.
+func Resume(ctx context.Context, brands *mongo.Collection, olderThan time.Time) (int, error) {
+	manifests := brands.Database().Collection(database.DeletionManifestCollection)
+	opts := options.Find().SetSort(bson.D{{Key: "startedAt", Value: 1}}).SetLimit(recoveryBatch)
+	cursor, err := manifests.Find(ctx, bson.M{"complete": false, "updatedAt": bson.M{"$lt": olderThan}}, opts)
+	if err != nil {
+		return 0, apperrors.FromDB(err)
+	}
+	var pending []models.DeletionManifest
+	if err := cursor.All(ctx, &pending); err != nil {
+		return 0, apperrors.FromDB(err)
+	}
+
+	completed := 0
+	for i := range pending {
+		manifest := &pending[i]
+		err := run(ctx, brands, manifests, manifest, true)
+		if errors.Is(err, errAbandoned) {
+			log.Printf("Deletion of brand '%s' (manifest %s) never removed the brand; discarding it",
+				services.LogValue(manifest.BrandName), manifest.ID.Hex())
+			effects.discard(ctx, manifests, manifest)
+			continue
+		}
+		if err != nil {
+			log.Printf("Warning: Deletion of brand '%s' (manifest %s) still incomplete: %v",
+				services.LogValue(manifest.BrandName), manifest.ID.Hex(), err)
+			continue
+		}
+		completed++
+	}
+	return completed, nil
+}
+
+// StartRecovery resumes incomplete deletions now and then every DELETION_RETRY_SECONDS
+// (default 300).
+func StartRecovery(brands *mongo.Collection) {
+	interval := defaultRetryInterval
+	if n, err := strconv.Atoi(os.Getenv("DELETION_RETRY_SECONDS")); err == nil && n > 0 {
+		interval = time.Duration(n) * time.Second
+	}
+	services.GoWorker("deletion-recovery", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			runCtx, cancel := context.WithTimeout(ctx, recoveryTimeout)
+			completed, err := Resume(runCtx, brands, time.Now().Add(-recoveryGrace))
+			cancel()
+			if err != nil {
+				log.Printf("Error looking for incomplete brand deletions: %v", err)
+			} else if completed > 0 {
+				log.Printf("Deletion recovery: completed %d brand deletion(s)", completed)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// List returns the most recent deletion manifests, newest first; with incompleteOnly only those
+// still waiting for cleanup.
+func List(ctx context.Context, brands *mongo.Collection, incompleteOnly bool, limit int) ([]models.DeletionManifest, error) {
+	filter := bson.M{}
+	if incompleteOnly {
+		filter["complete"] = false
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "startedAt", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := brands.Database().Collection(database.DeletionManifestCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	list := []models.DeletionManifest{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	return list, nil
+}
+
+// run executes the manifest's unfinished steps in order, saving it after each, and stops at the
+// first failure. When resuming, the document step is never run again: a brand document that is
+// already gone counts as removed (an earlier attempt got that far even if it couldn't record it),
+// and one that is still there returns errAbandoned.
+func run(ctx context.Context, brands, manifests *mongo.Collection, m *models.DeletionManifest, resuming bool) error {
+	db := brands.Database()
+	for i := range m.Steps {
+		step := &m.Steps[i]
+		if step.Status == models.DeletionDone {
+			continue
+		}
+		step.Attempts++
+
+		var err error
+		switch step.Name {
+		case models.DeletionStepDocument:
+			if resuming {
+				var exists bool
+				if exists, err = effects.documentExists(ctx, brands, m); err == nil && exists {
+					return errAbandoned
+				}
+				break
+			}
+			var inTransaction bool
+			inTransaction, err = effects.removeDocument(ctx, brands, m)
+			if err == nil && inTransaction {
+				m.Transactional = true
+				markDone(m, models.DeletionStepTombstone) // Recorded in the same transaction
+			}
+		case models.DeletionStepTombstone:
+			err = effects.recordTombstone(ctx, db.Collection(database.TombstoneCollection), m)
+		case models.DeletionStepLogoFiles:
+			err = effects.removeLogoFiles(ctx, db, m.LogoFiles)
+		case models.DeletionStepCaches:
+			effects.forgetBrand(m.BrandName)
+		default:
+			err = fmt.Errorf("unknown step %q", step.Name)
+		}
+
+		if err != nil {
+			step.Status, step.Error = models.DeletionFailed, err.Error()
+			effects.save(ctx, manifests, m)
+			return fmt.Errorf("%s: %w", step.Name, err)
+		}
+		step.Status, step.Error = models.DeletionDone, ""
+		effects.save(ctx, manifests, m)
+	}
+	m.Complete = true
+	effects.save(ctx, manifests, m)
+	return nil
+}
+
+// removeDocument removes the brand document, together with recording its tombstone when the
+// deployment supports transactions. It reports whether both happened atomically.
+func removeDocument(ctx context.Context, brands *mongo.Collection, m *models.DeletionManifest) (bool, error) {
+	tombstones := brands.Database().Collection(database.TombstoneCollection)
+	if !transactionsUnsupported.Load() {
+		session, err := brands.Database().Client().StartSession()
+		if err != nil {
+			return false, err
+		}
+		defer session.EndSession(ctx)
+		// Majority regardless of the request's write concern: a tombstone that could roll back
+		// would let sync clients keep a deleted brand
+		txnOpts := options.Transaction().SetWriteConcern(writeconcern.Majority())
+		_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			if err := deleteDocument(sc, brands, m); err != nil {
+				return nil, err
+			}
+			return nil, recordTombstone(sc, tombstones, m)
+		}, txnOpts)
+		if !transactionsRejected(err) {
+			return err == nil, err
+		}
+		transactionsUnsupported.Store(true)
+		log.Println("Deletions: the deployment doesn't support transactions, removing documents and tombstones in sequence")
+	}
+	return false, deleteDocument(ctx, brands, m)
+}
+
+// deleteDocument removes the brand by ID (a brand recreated under the same name is left alone).
+// A write concern failure counts as removed: the delete was applied, only not acknowledged.
+func deleteDocument(ctx context.Context, brands *mongo.Collection, m *models.DeletionManifest) error {
+	result, err := brands.DeleteOne(ctx, bson.M{"_id": m.BrandID})
+	var we mongo.WriteException
+	if errors.As(err, &we) && we.WriteConcernError != nil && len(we.WriteErrors) == 0 {
+		m.WriteConcernError = we.WriteConcernError.Message
+		return nil
+	}
+	if err != nil {
+		return apperrors.FromDB(err)
+	}
+	if result.DeletedCount == 0 {
+		return apperrors.ErrNotFound // Deleted concurrently; that deletion does the cleanup
+	}
+	return nil
+}
+
+// documentExists reports whether the manifest's brand document (by ID) is still stored.
+func documentExists(ctx context.Context, brands *mongo.Collection, m *models.DeletionManifest) (bool, error) {
+	count, err := brands.CountDocuments(ctx, bson.M{"_id": m.BrandID}, options.Count().SetLimit(1))
+	return count > 0, apperrors.FromDB(err)
+}
+
+// recordTombstone records the brand's sync tombstone, at most once per brand ID.
+func recordTombstone(ctx context.Context, tombstones *mongo.Collection, m *models.DeletionManifest) error {
+	update := bson.M{"$setOnInsert": bson.M{"brandId": m.BrandID, "name": m.BrandName, "deletedAt": models.Now()}}
+	_, err := tombstones.UpdateOne(ctx, bson.M{"brandId": m.BrandID}, update, options.Update().SetUpsert(true))
+	return apperrors.FromDB(err)
+}
+
+// transactionsRejected reports whether err says the server can't run transactions at all.
+func transactionsRejected(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 20 && strings.Contains(cmdErr.Message, "Transaction numbers") // IllegalOperation
+}
+
+func stepDone(m *models.DeletionManifest, name string) bool {
+	for _, step := range m.Steps {
+		if step.Name == name {
+			return step.Status == models.DeletionDone
+		}
+	}
+	return false
+}
+
+func markDone(m *models.DeletionManifest, name string) {
+	for i := range m.Steps {
+		if m.Steps[i].Name == name {
+			m.Steps[i].Status, m.Steps[i].Error = models.DeletionDone, ""
+		}
+	}
+}
+
+// save writes the manifest's progress. A failed save is only logged: the steps are idempotent, so
+// recovery repeating one that did complete is harmless.
+func save(ctx context.Context, manifests *mongo.Collection, m *models.DeletionManifest) {
+	m.UpdatedAt = models.Now()
+	if _, err := manifests.ReplaceOne(ctx, bson.M{"_id": m.ID}, m); err != nil {
+		log.Printf("Warning: Could not save deletion manifest %s: %v", m.ID.Hex(), err)
+	}
+}
+
+// discard removes the manifest of a deletion that removed nothing. A failed discard is only
+// logged: recovery discards the manifest once it finds the brand still there.
+func discard(ctx context.Context, manifests *mongo.Collection, m *models.DeletionManifest) {
+	if _, err := manifests.DeleteOne(ctx, bson.M{"_id": m.ID}); err != nil {
+		log.Printf("Warning: Could not discard deletion manifest %s: %v", m.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,254 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// faults replaces the deletion's side effects for one test: failing steps return their error,
+// every call is counted and the last saved manifest is kept. The brand document exists until
+// the document step removes it.
+type faults struct {
+	fail          map[string]error
+	transactional bool
+	brandExists   bool
+	calls         map[string]int
+	saved         models.DeletionManifest
+}
+
+func injectFaults(t *testing.T, fail map[string]error) *faults {
+	t.Helper()
+	f := &faults{fail: fail, brandExists: true, calls: map[string]int{}}
+	original := effects
+	t.Cleanup(func() { effects = original })
+
+	effects.removeDocument = func(context.Context, *mongo.Collection, *models.DeletionManifest) (bool, error) {
+		f.calls[models.DeletionStepDocument]++
+		if err := f.fail[models.DeletionStepDocument]; err != nil {
+			return false, err
+		}
+		f.brandExists = false
+		return f.transactional, nil
+	}
+	effects.documentExists = func(context.Context, *mongo.Collection, *models.DeletionManifest) (bool, error) {
+		return f.brandExists, nil
+	}
+	effects.recordTombstone = func(context.Context, *mongo.Collection, *models.DeletionManifest) error {
+		f.calls[models.DeletionStepTombstone]++
+		return f.fail[models.DeletionStepTombstone]
+	}
+	effects.removeLogoFiles = func(context.Context, *mongo.Database, []primitive.ObjectID) error {
+		f.calls[models.DeletionStepLogoFiles]++
+		return f.fail[models.DeletionStepLogoFiles]
+	}
+	effects.forgetBrand = func(string) {
+		f.calls[models.DeletionStepCaches]++
+	}
+	effects.save = func(_ context.Context, _ *mongo.Collection, m *models.DeletionManifest) {
+		f.saved = *m
+		f.saved.Steps = append([]models.DeletionStep(nil), m.Steps...)
+	}
+	return f
+}
+
+// testCollections returns collections of a client that is never connected; the faked effects
+// don't touch them.
+func testCollections(t *testing.T) (brands, manifests *mongo.Collection) {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+	db := client.Database("deletion_test")
+	return db.Collection("brands"), db.Collection("deletion_manifests")
+}
+
+func newManifest() *models.DeletionManifest {
+	m := &models.DeletionManifest{
+		ID:        primitive.NewObjectID(),
+		BrandID:   primitive.NewObjectID(),
+		BrandName: "Acme",
+		LogoFiles: []primitive.ObjectID{primitive.NewObjectID()},
+	}
+	for _, step := range []string{models.DeletionStepDocument, models.DeletionStepTombstone, models.DeletionStepLogoFiles, models.DeletionStepCaches} {
+		m.Steps = append(m.Steps, models.DeletionStep{Name: step, Status: models.DeletionPending})
+	}
+	return m
+}
+
+func statuses(m models.DeletionManifest) map[string]string {
+	out := map[string]string{}
+	for _, step := range m.Steps {
+		out[step.Name] = step.Status
+	}
+	return out
+}
+
+// A failure after the document step leaves the manifest for recovery, which finishes the
+// deletion without removing the document again.
+func TestRunFailureAtEachStep(t *testing.T) {
+	order := []string{models.DeletionStepDocument, models.DeletionStepTombstone, models.DeletionStepLogoFiles}
+	for failAt := 1; failAt < len(order); failAt++ { // A failed document step: TestResumeNeverRemovedBrand
+		failing := order[failAt]
+		t.Run(failing, func(t *testing.T) {
+			brands, manifests := testCollections(t)
+			fault := errors.New("injected fault")
+			f := injectFaults(t, map[string]error{failing: fault})
+			m := newManifest()
+
+			err := run(context.Background(), brands, manifests, m, false)
+			if !errors.Is(err, fault) || !strings.HasPrefix(err.Error(), failing+":") {
+				t.Fatalf("run error = %v, want the injected fault prefixed with %q", err, failing)
+			}
+			if f.saved.Complete {
+				t.Fatal("manifest saved as complete after a failed step")
+			}
+			got := statuses(f.saved)
+			for i, name := range order {
+				want := models.DeletionPending
+				switch {
+				case i < failAt:
+					want = models.DeletionDone
+				case i == failAt:
+					want = models.DeletionFailed
+				}
+				if got[name] != want {
+					t.Errorf("step %s = %q, want %q", name, got[name], want)
+				}
+			}
+			if got[models.DeletionStepCaches] != models.DeletionPending {
+				t.Errorf("caches step = %q, want pending", got[models.DeletionStepCaches])
+			}
+			for _, step := range f.saved.Steps {
+				if step.Name == failing && step.Error != fault.Error() {
+					t.Errorf("failed step error = %q, want %q", step.Error, fault.Error())
+				}
+			}
+
+			// Recovery picks the saved manifest up once the fault is gone
+			delete(f.fail, failing)
+			resumed := f.saved
+			if err := run(context.Background(), brands, manifests, &resumed, true); err != nil {
+				t.Fatalf("resumed run: %v", err)
+			}
+			if !f.saved.Complete {
+				t.Fatal("resumed manifest not complete")
+			}
+			for name, status := range statuses(f.saved) {
+				if status != models.DeletionDone {
+					t.Errorf("step %s = %q after resuming, want done", name, status)
+				}
+			}
+			for i, name := range order {
+				want := 1
+				if i == failAt {
+					want = 2
+				}
+				if f.calls[name] != want {
+					t.Errorf("%s ran %d time(s), want %d", name, f.calls[name], want)
+				}
+			}
+			if f.calls[models.DeletionStepCaches] != 1 {
+				t.Errorf("caches ran %d time(s), want 1", f.calls[models.DeletionStepCaches])
+			}
+		})
+	}
+}
+
+func TestRunTransactionalSkipsTombstone(t *testing.T) {
+	brands, manifests := testCollections(t)
+	f := injectFaults(t, nil)
+	f.transactional = true
+	m := newManifest()
+
+	if err := run(context.Background(), brands, manifests, m, false); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !m.Transactional || !m.Complete {
+		t.Fatalf("manifest transactional=%v complete=%v, want both", m.Transactional, m.Complete)
+	}
+	if f.calls[models.DeletionStepTombstone] != 0 {
+		t.Errorf("tombstone recorded again after the transaction")
+	}
+}
+
+// A brand deleted concurrently fails the first attempt, which then removed nothing.
+func TestRunDocumentAlreadyGone(t *testing.T) {
+	brands, manifests := testCollections(t)
+	f := injectFaults(t, map[string]error{models.DeletionStepDocument: apperrors.ErrNotFound})
+	m := newManifest()
+
+	if err := run(context.Background(), brands, manifests, m, false); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("run error = %v, want ErrNotFound", err)
+	}
+	if stepDone(m, models.DeletionStepDocument) || f.calls[models.DeletionStepTombstone] != 0 {
+		t.Error("later steps ran although the document wasn't removed")
+	}
+}
+
+// Recovery finds manifests whose document step never finished: the first attempt failed (and
+// couldn't discard the manifest) or the process stopped. It only finishes the deletion when the
+// brand is gone; a brand that is still there was never deleted and must stay.
+func TestResumeNeverRemovedBrand(t *testing.T) {
+	tests := []struct {
+		name         string
+		documentStep string // Status the first attempt left the step in
+		brandExists  bool
+		wantErr      error
+	}{
+		{"failed, brand still there", models.DeletionFailed, true, errAbandoned},
+		{"stopped before the step, brand still there", models.DeletionPending, true, errAbandoned},
+		{"removed but not recorded", models.DeletionPending, false, nil},
+		{"failed after the removal was applied", models.DeletionFailed, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brands, manifests := testCollections(t)
+			f := injectFaults(t, nil)
+			f.brandExists = tt.brandExists
+			m := newManifest()
+			m.Steps[0].Status = tt.documentStep
+
+			err := run(context.Background(), brands, manifests, m, true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resumed run error = %v, want %v", err, tt.wantErr)
+			}
+			if f.calls[models.DeletionStepDocument] != 0 {
+				t.Error("resumed run removed the document again")
+			}
+			if tt.wantErr != nil {
+				if len(f.calls) != 0 || f.saved.ID != (primitive.ObjectID{}) {
+					t.Errorf("abandoned run still ran steps %v or saved the manifest", f.calls)
+				}
+				return
+			}
+			if !f.saved.Complete || f.calls[models.DeletionStepTombstone] != 1 || f.calls[models.DeletionStepCaches] != 1 {
+				t.Errorf("resumed run complete=%v with calls %v, want every remaining step once", f.saved.Complete, f.calls)
+			}
+		})
+	}
+}
+
+func TestRunUnknownStep(t *testing.T) {
+	brands, manifests := testCollections(t)
+	f := injectFaults(t, nil)
+	m := newManifest()
+	m.Steps = append(m.Steps[:1], models.DeletionStep{Name: "bogus", Status: models.DeletionPending})
+
+	if err := run(context.Background(), brands, manifests, m, false); err == nil {
+		t.Fatal("run succeeded with an unknown step")
+	}
+	if got := statuses(f.saved)["bogus"]; got != models.DeletionFailed {
+		t.Errorf("unknown step = %q, want failed", got)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+// Markdown constructs looked for by DetectDetailsFormat. Headings and fenced code are rare in
+// prose and count as strong evidence; the rest only counts when several lines use them.
+var (
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s+\S`)
+	markdownFence   = regexp.MustCompile("^(```|~~~)")
+	markdownList    = regexp.MustCompile(`^\s*([-*+]|\d+\.)\s+\S`)
+	markdownTable   = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)+\|?\s*$`)
+	markdownInline  = regexp.MustCompile(`\*\*[^*]+\*\*|\[[^\]]+\]\([^)]+\)|` + "`[^`]+`")
+)
+
+// DetectDetailsFormat guesses how text is meant to be rendered:
+//
+//   - tsv: at least two non-empty lines, all with the same number (>= 2) of tab-separated columns
+//   - markdown: a heading, fenced code block or table separator, or at least two lines with
+//     list items, bold text, links or inline code
+//   - plain: anything else, including empty text
+func DetectDetailsFormat(text string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return models.DetailsFormatPlain
+	}
+
+	if len(lines) >= 2 {
+		columns := strings.Count(lines[0], "\t") + 1
+		tabular := columns >= 2
+		for _, line := range lines[1:] {
+			if !tabular {
+				break
+			}
+			tabular = strings.Count(line, "\t")+1 == columns
+		}
+		if tabular {
+			return models.DetailsFormatTSV
+		}
+	}
+
+	weak := 0
+	for _, line := range lines {
+		if markdownHeading.MatchString(line) || markdownFence.MatchString(line) || markdownTable.MatchString(line) {
+			return models.DetailsFormatMarkdown
+		}
+		if markdownList.MatchString(line) || markdownInline.MatchString(line) {
+			weak++
+		}
+	}
+	if weak >= 2 {
+		return models.DetailsFormatMarkdown
+	}
+	return models.DetailsFormatPlain
+}
+
+// DetailsFormatOrDetect returns format when set, otherwise the format detected from text.
+func DetailsFormatOrDetect(format, text string) string {
+	if format != "" {
+		return format
+	}
+	return DetectDetailsFormat(text)
+}
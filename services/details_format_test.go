@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+func TestDetectDetailsFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty", "", models.DetailsFormatPlain},
+		{"blank lines only", "\n  \n\t\n", models.DetailsFormatPlain},
+		{"prose", "Cordless drills and impact drivers.\nShips within two days.", models.DetailsFormatPlain},
+		{"table", "SKU\tName\tPrice\nD18\tDrill\t99\nI20\tDriver\t129", models.DetailsFormatTSV},
+		{"table with CRLF and blank lines", "SKU\tName\r\n\r\nD18\tDrill\r\n", models.DetailsFormatTSV},
+		{"single tab-separated line", "SKU\tName\tPrice", models.DetailsFormatPlain},
+		{"ragged columns", "SKU\tName\tPrice\nD18\tDrill", models.DetailsFormatPlain},
+		{"one column per line", "SKU\nD18\nI20", models.DetailsFormatPlain},
+		{"heading", "# Acme tools\nCordless drills.", models.DetailsFormatMarkdown},
+		{"hashtag is not a heading", "#drills are on sale\nSee the catalog.", models.DetailsFormatPlain},
+		{"fenced code", "Usage:\n```\ndrill --fast\n```", models.DetailsFormatMarkdown},
+		{"table separator", "| SKU | Name |\n| --- | --- |\n| D18 | Drill |", models.DetailsFormatMarkdown},
+		{"one list item", "Models:\n- D18 drill", models.DetailsFormatPlain},
+		{"list", "Models:\n- D18 drill\n- I20 driver", models.DetailsFormatMarkdown},
+		{"numbered list", "1. Unpack\n2. Charge", models.DetailsFormatMarkdown},
+		{"list item and bold text", "- D18 drill\nNow **20%** off", models.DetailsFormatMarkdown},
+		{"one link", "See [the catalog](https://example.com).", models.DetailsFormatPlain},
+		{"dash without a space", "-20% on all drills\n-10% on drivers", models.DetailsFormatPlain},
+		{"asterisks in prose", "Prices * excl. VAT\nDelivery * extra", models.DetailsFormatPlain},
+		{"tabs in a markdown list", "- SKU\tD18\n- SKU\tI20", models.DetailsFormatTSV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectDetailsFormat(tt.text); got != tt.want {
+				t.Errorf("DetectDetailsFormat(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetailsFormatOrDetect(t *testing.T) {
+	if got := DetailsFormatOrDetect(models.DetailsFormatPlain, "# Heading"); got != models.DetailsFormatPlain {
+		t.Errorf("explicit format: got %q, want %q", got, models.DetailsFormatPlain)
+	}
+	if got := DetailsFormatOrDetect("", "# Heading"); got != models.DetailsFormatMarkdown {
+		t.Errorf("detected format: got %q, want %q", got, models.DetailsFormatMarkdown)
+	}
+}
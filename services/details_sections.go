@@ -0,0 +1,165 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+// Limits of the heading heuristics. Extracted PDF text has no markup, so headings are
+// recognized by shape: short lines that are numbered, in capitals, or end with a colon.
+const (
+	maxHeadingLength   = 80 // Bytes; longer lines are prose
+	maxHeadingWords    = 10
+	maxSectionTitle    = 80
+	sectionIDHexLength = 12
+)
+
+// numberedHeading matches "3 Safety", "2.1. Charging" or "IV. Warranty": a section number
+// followed by a capitalized title.
+var numberedHeading = regexp.MustCompile(`^(\d+(\.\d+)*\.?|[IVX]+\.)\s+\p{Lu}`)
+
+// SplitDetailsSections splits details into sections at heading-like lines and returns them in
+// order; text before the first heading is an untitled section. The sections cover the details
+// without gaps, so concatenating them gives the details back. Details without headings are one
+// section, empty details none.
+//
+// A heading directly followed by another one (e.g. a table of contents, or a chapter and its
+// first subsection) doesn't get a section of its own: it is merged into the next one, which
+// keeps the first title.
+func SplitDetailsSections(details string) []models.DetailsSection {
+	if strings.TrimSpace(details) == "" {
+		return nil
+	}
+
+	type start struct {
+		offset int
+		title  string
+	}
+	starts := []start{{offset: 0}}
+	bodySeen := false // Whether the current section has text besides its heading
+	prevBlank := true
+	for offset := 0; offset < len(details); {
+		end := strings.IndexByte(details[offset:], '\n')
+		if end < 0 {
+			end = len(details)
+		} else {
+			end += offset + 1
+		}
+		line := details[offset:end]
+		if title, ok := sectionHeading(line, prevBlank); ok {
+			switch last := &starts[len(starts)-1]; {
+			case bodySeen:
+				starts = append(starts, start{offset: offset, title: title})
+				bodySeen = false
+			case last.title == "":
+				last.title = title // Only blank lines so far
+			}
+		} else if strings.TrimSpace(line) != "" {
+			bodySeen = true
+		}
+		prevBlank = strings.TrimSpace(line) == ""
+		offset = end
+	}
+
+	sections := make([]models.DetailsSection, len(starts))
+	seen := make(map[string]int, len(starts))
+	for i, s := range starts {
+		end := len(details)
+		if i+1 < len(starts) {
+			end = starts[i+1].offset
+		}
+		id := sectionID(details[s.offset:end])
+		if seen[id]++; seen[id] > 1 {
+			id += "-" + strconv.Itoa(seen[id]) // Identical sections, e.g. a repeated disclaimer
+		}
+		sections[i] = models.DetailsSection{ID: id, Title: s.title, Offset: s.offset, Length: end - s.offset}
+	}
+	return sections
+}
+
+// sectionID derives a section's ID from its text.
+func sectionID(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])[:sectionIDHexLength]
+}
+
+// sectionHeading reports whether line looks like a heading and returns its title. Numbered and
+// colon headings must follow a blank line (or start the text), so numbered steps and "Note:"
+// lines inside a paragraph don't split it.
+func sectionHeading(line string, prevBlank bool) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || len(trimmed) > maxHeadingLength || strings.ContainsRune(trimmed, '\t') {
+		return "", false // Tab-separated rows are table data
+	}
+	if markdownHeading.MatchString(trimmed) {
+		return sectionTitle(strings.TrimLeft(trimmed, "#")), true
+	}
+	if len(strings.Fields(trimmed)) > maxHeadingWords || strings.HasSuffix(trimmed, ".") {
+		return "", false
+	}
+	switch {
+	case numberedHeading.MatchString(trimmed) && prevBlank:
+		return sectionTitle(trimmed), true
+	case strings.HasSuffix(trimmed, ":") && prevBlank:
+		return sectionTitle(strings.TrimSuffix(trimmed, ":")), true
+	case isCapitalized(trimmed):
+		return sectionTitle(trimmed), true
+	}
+	return "", false
+}
+
+// isCapitalized reports whether s has at least three letters and none of them lowercase.
+func isCapitalized(s string) bool {
+	letters := 0
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters >= 3
+}
+
+// sectionTitle collapses whitespace in a heading and caps its length.
+func sectionTitle(heading string) string {
+	title := strings.Join(strings.Fields(heading), " ")
+	if len(title) > maxSectionTitle {
+		title = strings.ToValidUTF8(title[:maxSectionTitle], "")
+	}
+	return title
+}
+
+// DetailsSectionsOf returns the stored sections of a brand, or splits its details when they
+// were stored before sections existed or don't match the details.
+func DetailsSectionsOf(brand *models.Brand) []models.DetailsSection {
+	if len(brand.Sections) == 0 || !sectionsMatch(brand.Details, brand.Sections) {
+		return SplitDetailsSections(brand.Details)
+	}
+	return brand.Sections
+}
+
+// sectionsMatch reports whether sections cover details from the start to the end without gaps
+// or overlaps, and each one's ID is that of its own text. An index written for other details
+// (of the same length, say) or damaged by hand fails, so its offsets are never used to slice.
+func sectionsMatch(details string, sections []models.DetailsSection) bool {
+	end := 0
+	for _, section := range sections {
+		if section.Offset != end || section.Length <= 0 || section.Length > len(details)-end {
+			return false
+		}
+		end += section.Length
+		id := sectionID(details[section.Offset:end])
+		if section.ID != id && !strings.HasPrefix(section.ID, id+"-") { // "-2" etc. for repeated sections
+			return false
+		}
+	}
+	return end == len(details)
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+// The fixtures in testdata/sections are details as they arrive from PDF extraction and manual
+// entry: CRLF line ends, form feeds, tables of contents, tab-separated tables, markdown.
+func TestSplitDetailsSectionsFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		titles  []string
+	}{
+		{"pdf_manual.txt", []string{"", "CONTENTS", "1 Overview", "2 Safety", "3 Charging", "WARRANTY AND RETURNS"}},
+		{"crlf_colons.txt", []string{"", "Shipping", "Payment terms"}},
+		{"markdown.txt", []string{"Acme Tools", "Batteries", "Übersicht der Modelle"}},
+		{"table_only.txt", []string{""}},
+		{"roman_duplicates.txt", []string{"I. Terms", "II. Terms"}},
+		{"no_headings.txt", []string{""}},
+		{"leading_blank_heading.txt", []string{"SPECIFICATIONS"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("testdata", "sections", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			details := string(raw)
+			sections := SplitDetailsSections(details)
+			checkSectionsCover(t, details, sections)
+			var titles []string
+			for _, s := range sections {
+				titles = append(titles, s.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("titles = %q, want %q", titles, tt.titles)
+			}
+		})
+	}
+}
+
+// checkSectionsCover checks that sections cover details in order without gaps and have unique
+// IDs. Blank details have no sections.
+func checkSectionsCover(t *testing.T, details string, sections []models.DetailsSection) {
+	t.Helper()
+	if len(sections) == 0 {
+		if strings.TrimSpace(details) != "" {
+			t.Fatal("no sections for non-blank details")
+		}
+		return
+	}
+	var rebuilt strings.Builder
+	ids := map[string]bool{}
+	for i, s := range sections {
+		if s.Offset != rebuilt.Len() || s.Length <= 0 {
+			t.Fatalf("section %d at %d+%d, want it to start at %d", i, s.Offset, s.Length, rebuilt.Len())
+		}
+		rebuilt.WriteString(details[s.Offset : s.Offset+s.Length])
+		if ids[s.ID] {
+			t.Errorf("section %d repeats ID %s", i, s.ID)
+		}
+		ids[s.ID] = true
+	}
+	if rebuilt.String() != details {
+		t.Error("sections don't add up to the details")
+	}
+}
+
+func TestSplitDetailsSectionsEdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		details string
+		titles  []string
+	}{
+		{"empty", "", nil},
+		{"whitespace", " \n\t\r\n", nil},
+		{"no trailing newline", "Intro\n\nSHIPPING\nFrom Hamburg", []string{"", "SHIPPING"}},
+		{"heading only", "SHIPPING", []string{"SHIPPING"}},
+		{"steps inside a paragraph", "Assembly\n1 Insert the battery\n2 Press the trigger\n", []string{""}},
+		{"note inside a paragraph", "Ships in 3 days.\nNote:\nNot on Sundays.\n", []string{""}},
+		{"sentence in capitals", "INTRO\nText.\n\nALL PRICES EXCLUDE VAT.\n", []string{"INTRO"}},
+		{"too many words", "Intro\n\nTHIS HEADING HAS FAR TOO MANY WORDS TO BE A HEADING OF ANY KIND\n", []string{""}},
+		{"acronym too short", "Intro\n\nEU\nText\n", []string{""}},
+		{"tab row in capitals", "Intro\nSKU\tEAN\n", []string{""}},
+		{"chapter and first subsection", "Intro\n\n1 Batteries\n1.1 Charging\nText\n", []string{"", "1 Batteries"}},
+		{"spaced title", "Intro\n\n#   Spaced     out\n", []string{"", "Spaced out"}},
+		{"line too long for a heading", "Intro\n\n# " + strings.Repeat("Ü", 40) + "\nText\n", []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := SplitDetailsSections(tt.details)
+			checkSectionsCover(t, tt.details, sections)
+			var titles []string
+			for _, s := range sections {
+				titles = append(titles, s.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("titles = %q, want %q", titles, tt.titles)
+			}
+		})
+	}
+}
+
+// Section IDs derive from the section's text: editing one section keeps the others' IDs, and
+// identical sections get distinct ones.
+func TestSplitDetailsSectionsIDs(t *testing.T) {
+	before := SplitDetailsSections("INTRO\nHello.\n\nSHIPPING\nFrom Hamburg.\n")
+	after := SplitDetailsSections("INTRO\nHello.\n\nSHIPPING\nFrom Berlin.\n")
+	if before[0].ID != after[0].ID {
+		t.Errorf("unchanged section changed ID: %s -> %s", before[0].ID, after[0].ID)
+	}
+	if before[1].ID == after[1].ID {
+		t.Errorf("edited section kept ID %s", before[1].ID)
+	}
+
+	repeated := SplitDetailsSections("NOTE\nSame.\nNOTE\nSame.\n")
+	if len(repeated) != 2 || repeated[1].ID != repeated[0].ID+"-2" {
+		t.Errorf("repeated sections = %+v, want the second ID suffixed with -2", repeated)
+	}
+}
+
+func TestDetailsSectionsOf(t *testing.T) {
+	details := "INTRO\nHello.\n\nSHIPPING\nFrom Hamburg.\n"
+	stored := SplitDetailsSections(details)
+	stored[0].Title = "Stored" // Tells the stored index from a fresh split
+	tests := []struct {
+		name           string
+		brand          models.Brand
+		wantFirstTitle string
+	}{
+		{"stored sections", models.Brand{Details: details, Sections: stored}, "Stored"},
+		{"none stored", models.Brand{Details: details}, "INTRO"},
+		{"stale", models.Brand{Details: details + "More.\n", Sections: stored}, "INTRO"},
+	}
+	for _, tt := range tests {
+		if got := DetailsSectionsOf(&tt.brand); len(got) == 0 || got[0].Title != tt.wantFirstTitle {
+			t.Errorf("%s: got %+v, want first title %s", tt.name, got, tt.wantFirstTitle)
+		}
+	}
+
+	// Repeated sections keep their "-2" IDs.
+	raw, err := os.ReadFile(filepath.Join("testdata", "sections", "roman_duplicates.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repeated := SplitDetailsSections(string(raw))
+	if got := DetailsSectionsOf(&models.Brand{Details: string(raw), Sections: repeated}); !reflect.DeepEqual(got, repeated) {
+		t.Errorf("repeated sections: got %+v, want the stored %+v", got, repeated)
+	}
+}
+
+// The indexes in testdata/sections/corrupted_index.json don't match the details they are stored
+// with, though most end where the details do; the details are split again instead of slicing
+// them at the stored offsets.
+func TestDetailsSectionsOfCorruptedIndex(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "sections", "corrupted_index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixture struct {
+		Details string `json:"details"`
+		Indexes []struct {
+			Name     string                  `json:"name"`
+			Sections []models.DetailsSection `json:"sections"`
+		} `json:"indexes"`
+	}
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		t.Fatal(err)
+	}
+	want := SplitDetailsSections(fixture.Details)
+	for _, index := range fixture.Indexes {
+		t.Run(index.Name, func(t *testing.T) {
+			got := DetailsSectionsOf(&models.Brand{Details: fixture.Details, Sections: index.Sections})
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+			checkSectionsCover(t, fixture.Details, got)
+		})
+	}
+}
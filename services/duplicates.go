@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/featureflags"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Defaults for the near-duplicate scan, overridable via environment variables.
+const (
+	defaultDuplicateThreshold    = 0.85
+	defaultDuplicateScanInterval = 60 * time.Minute
+	duplicateScanTimeout         = 10 * time.Minute
+)
+
+// Reasons a pair of brands is reported as a possible duplicate.
+const (
+	DuplicateReasonName    = "name"
+	DuplicateReasonContent = "content"
+	DuplicateReasonBoth    = "name+content"
+)
+
+// DuplicatePair is a candidate pair of brands that may be the same brand entered twice.
+type DuplicatePair struct {
+	BrandA string  `json:"brandA"`
+	BrandB string  `json:"brandB"`
+	Score  float64 `json:"score"`  // Normalized-name similarity, 0..1; content matches keep theirs, so it can be low
+	Reason string  `json:"reason"` // name, content or name+content
+}
+
+// DuplicateReport is the cached result of the last scan.
+type DuplicateReport struct {
+	Pairs      []DuplicatePair `json:"pairs"`
+	BrandCount int             `json:"brandCount"`
+	Threshold  float64         `json:"threshold"`
+	ComputedAt *time.Time      `json:"computedAt"` // nil until the first scan has finished
+	DurationMs int64           `json:"durationMs"`
+	Running    bool            `json:"running"` // A scan is in progress right now
+	LastError  string          `json:"lastError,omitempty"`
+}
+
+// duplicateScanner holds the cached report; scans run in the background because they are O(n²).
+type duplicateScanner struct {
+	mu      sync.Mutex
+	report  DuplicateReport
+	running bool
+	deleted map[string]bool // Brands deleted while a scan is running, dropped from its result
+	scanned map[string]bool // Brands the cached report counted; only these are taken off its count
+}
+
+var duplicates = &duplicateScanner{report: DuplicateReport{Pairs: []DuplicatePair{}}}
+
+// DuplicateThreshold returns the minimum name similarity (DUPLICATE_NAME_THRESHOLD, default 0.85).
+func DuplicateThreshold() float64 {
+	if raw := os.Getenv("DUPLICATE_NAME_THRESHOLD"); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil && v > 0 && v <= 1 {
+			return v
+		}
+		log.Printf("Warning: Invalid DUPLICATE_NAME_THRESHOLD '%s', using default %.2f", LogValue(raw), defaultDuplicateThreshold)
+	}
+	return defaultDuplicateThreshold
+}
+
+// DuplicateScanInterval returns how often the background scan runs (DUPLICATE_SCAN_INTERVAL_MINUTES, default 60).
+func DuplicateScanInterval() time.Duration {
+	return time.Duration(envPositiveInt("DUPLICATE_SCAN_INTERVAL_MINUTES", int(defaultDuplicateScanInterval/time.Minute))) * time.Minute
+}
+
+// StartDuplicateScanner runs a scan immediately and then on every interval, for the life of the process.
+func StartDuplicateScanner(coll *mongo.Collection, interval time.Duration) {
+	goWorker("duplicate-scheduler", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if featureflags.Enabled(featureflags.DuplicateScan) {
+				TriggerDuplicateScan(coll)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// TriggerDuplicateScan starts a background scan unless one is already running or the service
+// is shutting down. It reports whether a new scan was started. A scan interrupted by shutdown is
+// discarded; the previous report stays cached.
+func TriggerDuplicateScan(coll *mongo.Collection) bool {
+	duplicates.mu.Lock()
+	if duplicates.running {
+		duplicates.mu.Unlock()
+		return false
+	}
+	duplicates.running = true
+	duplicates.mu.Unlock()
+
+	ok := goWorker("duplicate-scan", func(workerCtx context.Context) {
+		ctx, cancel := context.WithTimeout(workerCtx, duplicateScanTimeout)
+		defer cancel()
+
+		threshold := DuplicateThreshold()
+		started := time.Now()
+		pairs, scanned, err := findDuplicatePairs(ctx, coll, threshold)
+
+		duplicates.mu.Lock()
+		defer duplicates.mu.Unlock()
+		duplicates.running = false
+		if err != nil {
+			duplicates.deleted = nil
+			log.Printf("Error scanning for duplicate brands: %v", err)
+			duplicates.report.LastError = err.Error()
+			return
+		}
+		for name := range duplicates.deleted {
+			pairs = withoutBrand(pairs, name)
+			delete(scanned, name) // Brands created and deleted during the scan were never counted
+		}
+		duplicates.deleted = nil
+		duplicates.scanned = scanned
+		count := len(scanned)
+		finished := time.Now().UTC()
+		duplicates.report = DuplicateReport{
+			Pairs:      pairs,
+			BrandCount: count,
+			Threshold:  threshold,
+			ComputedAt: &finished,
+			DurationMs: finished.Sub(started).Milliseconds(),
+		}
+		log.Printf("Duplicate scan finished: %d candidate pairs among %d brands in %v", len(pairs), count, finished.Sub(started))
+	})
+	if !ok {
+		duplicates.mu.Lock()
+		duplicates.running = false
+		duplicates.mu.Unlock()
+	}
+	return ok
+}
+
+// GetDuplicateReport returns the most recent scan result.
+func GetDuplicateReport() DuplicateReport {
+	duplicates.mu.Lock()
+	defer duplicates.mu.Unlock()
+	report := duplicates.report
+	report.Running = duplicates.running
+	return report
+}
+
+// ForgetDuplicateBrand drops a deleted brand from the cached report right away, so it isn't
+// offered as a duplicate until the next scan. A scan in progress may still have read it; its
+// result is filtered when it finishes.
+func ForgetDuplicateBrand(name string) {
+	duplicates.mu.Lock()
+	defer duplicates.mu.Unlock()
+	if duplicates.running {
+		if duplicates.deleted == nil {
+			duplicates.deleted = make(map[string]bool)
+		}
+		duplicates.deleted[name] = true
+	}
+	if duplicates.scanned[name] {
+		delete(duplicates.scanned, name)
+		duplicates.report.Pairs = withoutBrand(duplicates.report.Pairs, name)
+		duplicates.report.BrandCount--
+	}
+}
+
+// withoutBrand returns the pairs that don't involve name.
+func withoutBrand(pairs []DuplicatePair, name string) []DuplicatePair {
+	kept := make([]DuplicatePair, 0, len(pairs))
+	for _, pair := range pairs {
+		if pair.BrandA != name && pair.BrandB != name {
+			kept = append(kept, pair)
+		}
+	}
+	return kept
+}
+
+// NormalizeBrandName lower-cases a name and strips everything but letters and digits,
+// so "ACME Corp." and "Acme-Corp" compare equal.
+func NormalizeBrandName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NameSimilarity returns the Levenshtein ratio (1 - distance/maxLen) of two normalized names.
+func NameSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+// levenshtein computes the edit distance using two rolling rows.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// duplicateCandidate is the per-brand data a scan needs; details are reduced to a hash immediately.
+type duplicateCandidate struct {
+	name        string
+	normalized  string
+	detailsHash string // Empty when the brand has no details (never a content match)
+}
+
+// findDuplicatePairs streams all brands and compares every pair. It also returns the names of
+// the brands it compared.
+func findDuplicatePairs(ctx context.Context, coll *mongo.Collection, threshold float64) ([]DuplicatePair, map[string]bool, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 0, "name": 1, "details": 1})
+	cursor, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var candidates []duplicateCandidate
+	for cursor.Next(ctx) {
+		var doc struct {
+			Name    string `bson:"name"`
+			Details string `bson:"details"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			continue // Malformed documents are reported by the decode-errors endpoint instead
+		}
+		cand := duplicateCandidate{name: doc.Name, normalized: NormalizeBrandName(doc.Name)}
+		if details := strings.TrimSpace(doc.Details); details != "" {
+			sum := sha256.Sum256([]byte(details))
+			cand.detailsHash = hex.EncodeToString(sum[:])
+		}
+		candidates = append(candidates, cand)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	pairs := []DuplicatePair{}
+	for i := 0; i < len(candidates); i++ {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		for j := i + 1; j < len(candidates); j++ {
+			a, b := candidates[i], candidates[j]
+			score := NameSimilarity(a.normalized, b.normalized)
+			nameMatch := score >= threshold
+			contentMatch := a.detailsHash != "" && a.detailsHash == b.detailsHash
+			if !nameMatch && !contentMatch {
+				continue
+			}
+			pair := DuplicatePair{BrandA: a.name, BrandB: b.name, Score: score, Reason: DuplicateReasonName}
+			if contentMatch {
+				pair.Reason = DuplicateReasonContent
+				if nameMatch {
+					pair.Reason = DuplicateReasonBoth
+				}
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+
+	// Most likely duplicates first
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		return pairs[i].BrandA < pairs[j].BrandA
+	})
+	names := make(map[string]bool, len(candidates))
+	for _, cand := range candidates {
+		names[cand.name] = true
+	}
+	return pairs, names, nil
+}
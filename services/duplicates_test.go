@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// Only brands the cached report counted are taken off its count, each once.
+func TestForgetDuplicateBrand(t *testing.T) {
+	saved := duplicates
+	t.Cleanup(func() { duplicates = saved })
+	computed := time.Now()
+	duplicates = &duplicateScanner{
+		report: DuplicateReport{
+			Pairs:      []DuplicatePair{{BrandA: "Acme", BrandB: "ACME Corp"}, {BrandA: "Bolt", BrandB: "Bolts"}},
+			BrandCount: 4,
+			ComputedAt: &computed,
+		},
+		scanned: map[string]bool{"Acme": true, "ACME Corp": true, "Bolt": true, "Bolts": true},
+	}
+
+	steps := []struct {
+		forget    string
+		wantCount int
+		wantPairs int
+	}{
+		{"Created after the scan", 4, 2},
+		{"Acme", 3, 1},
+		{"Acme", 3, 1}, // Deletion retried
+		{"Bolts", 2, 0},
+	}
+	for _, step := range steps {
+		ForgetDuplicateBrand(step.forget)
+		report := GetDuplicateReport()
+		if report.BrandCount != step.wantCount || len(report.Pairs) != step.wantPairs {
+			t.Errorf("after forgetting %q: %d brands, %d pairs; want %d, %d", step.forget, report.BrandCount, len(report.Pairs), step.wantCount, step.wantPairs)
+		}
+	}
+}
+
+// Before the first scan there is nothing to take off.
+func TestForgetDuplicateBrandBeforeScan(t *testing.T) {
+	saved := duplicates
+	t.Cleanup(func() { duplicates = saved })
+	duplicates = &duplicateScanner{report: DuplicateReport{Pairs: []DuplicatePair{}}}
+
+	ForgetDuplicateBrand("Acme")
+	if report := GetDuplicateReport(); report.BrandCount != 0 {
+		t.Errorf("brand count %d before any scan, want 0", report.BrandCount)
+	}
+}
+
+// While a scan runs, deleted brands are remembered so its result can be filtered.
+func TestForgetDuplicateBrandDuringScan(t *testing.T) {
+	saved := duplicates
+	t.Cleanup(func() { duplicates = saved })
+	duplicates = &duplicateScanner{report: DuplicateReport{Pairs: []DuplicatePair{}}, running: true}
+
+	ForgetDuplicateBrand("Acme")
+	ForgetDuplicateBrand("Bolt")
+	if !duplicates.deleted["Acme"] || !duplicates.deleted["Bolt"] || len(duplicates.deleted) != 2 {
+		t.Errorf("deleted during the scan = %v, want Acme and Bolt", duplicates.deleted)
+	}
+}
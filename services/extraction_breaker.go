@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/chaos"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+// ErrExtractionUnavailable is returned without running pdftotext while the circuit breaker is open.
+var ErrExtractionUnavailable = errors.New("pdf extraction temporarily unavailable")
+
+// Breaker defaults, overridable via PDF_BREAKER_THRESHOLD and PDF_BREAKER_COOLDOWN_SECONDS.
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+	quickRetryDelay         = 200 * time.Millisecond
+)
+
+// Breaker states as reported in /health.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// execError marks failures to run pdftotext at all (binary missing, fork/exec failing, e.g. out of
+// file descriptors, or timing out) as opposed to pdftotext rejecting the PDF. Only these count
+// against the breaker; a broken upload says nothing about the host.
+type execError struct {
+	err error
+}
+
+func (e *execError) Error() string { return e.err.Error() }
+func (e *execError) Unwrap() error { return e.err }
+
+func isExecFailure(err error) bool {
+	var ee *execError
+	return errors.As(err, &ee)
+}
+
+// BreakerStatus is the externally visible breaker state.
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Threshold           int        `json:"threshold"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAfterSeconds   int        `json:"retryAfterSeconds,omitempty"` // Until the next probe is allowed
+	Trips               int        `json:"trips"`                       // Times the breaker has opened since startup
+}
+
+// circuitBreaker trips after threshold consecutive exec failures, fails fast for the cooldown,
+// then lets a single probe through (half-open) to decide whether to close again.
+type circuitBreaker struct {
+	mu          sync.Mutex
+	state       string
+	consecutive int
+	openedAt    time.Time
+	probing     bool // A half-open probe is in flight
+	trips       int
+}
+
+var pdfBreaker = &circuitBreaker{state: BreakerClosed}
+
+func breakerThreshold() int {
+	return envPositiveInt("PDF_BREAKER_THRESHOLD", defaultBreakerThreshold)
+}
+
+func breakerCooldown() time.Duration {
+	return time.Duration(envPositiveInt("PDF_BREAKER_COOLDOWN_SECONDS", int(defaultBreakerCooldown/time.Second))) * time.Second
+}
+
+// allow reports whether a call may proceed, moving an expired open breaker to half-open.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < breakerCooldown() {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		log.Println("pdftotext circuit breaker half-open: letting a probe through")
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false // Only one probe at a time
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record updates the breaker with the outcome of a call that allow() let through.
+func (b *circuitBreaker) record(execFailed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !execFailed {
+		if b.state != BreakerClosed {
+			log.Println("pdftotext circuit breaker closed: extraction recovered")
+		}
+		b.state, b.consecutive = BreakerClosed, 0
+		return
+	}
+	b.consecutive++
+	if b.state == BreakerHalfOpen || b.consecutive >= breakerThreshold() {
+		if b.state != BreakerOpen {
+			b.trips++
+		}
+		b.state, b.openedAt = BreakerOpen, time.Now()
+		log.Printf("pdftotext circuit breaker open after %d consecutive exec failures; failing fast for %v", b.consecutive, breakerCooldown())
+	}
+}
+
+// release ends a call that allow() let through without an outcome, e.g. one stopped by the
+// caller's deadline: a probe slot is freed, the state is left alone.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// ExtractionBreakerStatus returns the current breaker state for health checks.
+func ExtractionBreakerStatus() BreakerStatus {
+	b := pdfBreaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := BreakerStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.consecutive,
+		Threshold:           breakerThreshold(),
+		Trips:               b.trips,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+		if remaining := breakerCooldown() - time.Since(b.openedAt); remaining > 0 {
+			status.RetryAfterSeconds = int(remaining.Round(time.Second) / time.Second)
+			if status.RetryAfterSeconds == 0 {
+				status.RetryAfterSeconds = 1
+			}
+		}
+	}
+	return status
+}
+
+// ExtractTextFromPDF extracts text with pdftotext behind the circuit breaker, or with the built-in
+// extractor where pdftotext isn't installed. While the breaker
+// is open it returns ErrExtractionUnavailable (and nil diagnostics) immediately. A single exec
+// failure that isn't a timeout is retried once after a short pause when the input can be rewound.
+//
+// ctx bounds the whole extraction, retry included; see runPDFToText for the requirements on the
+// pdftotext binary.
+func ExtractTextFromPDF(ctx context.Context, pdfStream io.Reader) (string, *models.ExtractionInfo, error) {
+	if err := chaos.ExtractionFault(); err != nil {
+		recordExtractionOutcome(true)
+		return "", nil, ErrExtractionUnavailable
+	}
+	if _, err := exec.LookPath(pdfEngine); err != nil {
+		// No pdftotext on this host: the built-in extractor runs no process, so the breaker
+		// (which guards against a failing binary) doesn't apply
+		text, info, err := runBuiltinPDFText(ctx, pdfStream)
+		recordExtractionOutcome(err != nil && !errors.Is(err, context.DeadlineExceeded))
+		return text, info, err
+	}
+	if !pdfBreaker.allow() {
+		return "", nil, ErrExtractionUnavailable
+	}
+
+	text, info, err := runPDFToText(ctx, pdfStream)
+	if isExecFailure(err) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, exec.ErrNotFound) {
+		if seeker, ok := pdfStream.(io.Seeker); ok {
+			if _, seekErr := seeker.Seek(0, io.SeekStart); seekErr == nil {
+				log.Printf("pdftotext failed to run (%v), retrying once", err)
+				time.Sleep(quickRetryDelay)
+				text, info, err = runPDFToText(ctx, pdfStream)
+			}
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		// The caller's budget ran out, which tells nothing about pdftotext
+		pdfBreaker.release()
+		return text, info, err
+	}
+
+	pdfBreaker.record(isExecFailure(err))
+	recordExtractionOutcome(err != nil)
+	return text, info, err
+}
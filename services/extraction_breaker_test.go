@@ -0,0 +1,88 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Breaker steps: "allow" and "deny" expect allow() to let a call through or not, "fail" and "ok"
+// record an exec failure or a success, "release" ends a call without an outcome and "wait" moves
+// the opening time back past the cooldown.
+func TestCircuitBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     string
+		wantState string
+		wantTrips int
+	}{
+		{"starts closed", "allow", BreakerClosed, 0},
+		{"failures below the threshold", "allow fail allow fail allow", BreakerClosed, 0},
+		{"success resets the count", "fail fail ok fail fail allow", BreakerClosed, 0},
+		{"opens at the threshold", "fail fail fail deny deny", BreakerOpen, 1},
+		{"half-open after the cooldown", "fail fail fail wait allow", BreakerHalfOpen, 1},
+		{"one probe at a time", "fail fail fail wait allow deny deny", BreakerHalfOpen, 1},
+		{"probe success closes", "fail fail fail wait allow ok allow allow", BreakerClosed, 1},
+		{"probe failure reopens", "fail fail fail wait allow fail deny", BreakerOpen, 2},
+		{"reopened breaker waits a full cooldown", "fail fail fail wait allow fail deny wait allow", BreakerHalfOpen, 2},
+		{"released probe frees the slot", "fail fail fail wait allow release allow", BreakerHalfOpen, 1},
+		{"failures while open don't count a trip", "fail fail fail fail fail deny", BreakerOpen, 1},
+	}
+	t.Setenv("PDF_BREAKER_THRESHOLD", "3")
+	t.Setenv("PDF_BREAKER_COOLDOWN_SECONDS", "30")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &circuitBreaker{state: BreakerClosed}
+			for i, step := range strings.Fields(tt.steps) {
+				switch step {
+				case "allow", "deny":
+					if got := b.allow(); got != (step == "allow") {
+						t.Fatalf("step %d: allow() = %v in state %s", i, got, b.state)
+					}
+				case "fail", "ok":
+					b.record(step == "fail")
+				case "release":
+					b.release()
+				case "wait":
+					b.openedAt = b.openedAt.Add(-breakerCooldown() - time.Second)
+				default:
+					t.Fatalf("unknown step %q", step)
+				}
+			}
+			if b.state != tt.wantState || b.trips != tt.wantTrips {
+				t.Errorf("ended %s after %d trips, want %s after %d", b.state, b.trips, tt.wantState, tt.wantTrips)
+			}
+		})
+	}
+}
+
+func TestExtractionBreakerStatus(t *testing.T) {
+	t.Setenv("PDF_BREAKER_THRESHOLD", "2")
+	t.Setenv("PDF_BREAKER_COOLDOWN_SECONDS", "30")
+	saved := pdfBreaker
+	t.Cleanup(func() { pdfBreaker = saved })
+
+	pdfBreaker = &circuitBreaker{state: BreakerClosed}
+	if status := ExtractionBreakerStatus(); status.State != BreakerClosed || status.OpenedAt != nil || status.RetryAfterSeconds != 0 || status.Threshold != 2 {
+		t.Errorf("closed status = %+v", status)
+	}
+
+	pdfBreaker.record(true)
+	pdfBreaker.record(true)
+	status := ExtractionBreakerStatus()
+	if status.State != BreakerOpen || status.ConsecutiveFailures != 2 || status.Trips != 1 || status.OpenedAt == nil {
+		t.Fatalf("open status = %+v", status)
+	}
+	if status.RetryAfterSeconds < 29 || status.RetryAfterSeconds > 30 {
+		t.Errorf("retryAfterSeconds = %d, want about the 30s cooldown", status.RetryAfterSeconds)
+	}
+
+	pdfBreaker.openedAt = time.Now().Add(-30*time.Second + 100*time.Millisecond)
+	if status := ExtractionBreakerStatus(); status.RetryAfterSeconds != 1 {
+		t.Errorf("retryAfterSeconds = %d just before the probe, want it rounded up to 1", status.RetryAfterSeconds)
+	}
+	pdfBreaker.openedAt = time.Now().Add(-time.Minute)
+	if status := ExtractionBreakerStatus(); status.RetryAfterSeconds != 0 {
+		t.Errorf("retryAfterSeconds = %d past the cooldown, want 0", status.RetryAfterSeconds)
+	}
+}
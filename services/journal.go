@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Defaults for the upload journal.
+const (
+	defaultUploadJournalMB = 16
+	journalWriteTimeout    = 5 * time.Second
+)
+
+// UploadJournalEnabled reports whether uploads are journaled (UPLOAD_JOURNAL, default true).
+func UploadJournalEnabled() bool {
+	return !strings.EqualFold(os.Getenv("UPLOAD_JOURNAL"), "false")
+}
+
+// UploadJournalBytes returns the size of the capped journal collection (UPLOAD_JOURNAL_MB, default 16).
+// Once full, the oldest entries are overwritten.
+func UploadJournalBytes() int64 {
+	return int64(envPositiveInt("UPLOAD_JOURNAL_MB", defaultUploadJournalMB)) << 20
+}
+
+// UploadJournal tracks one upload. The entry is inserted when the upload is received and written
+// once more when the response is sent, so an upload that never finishes stays visible at the
+// last stage that reached the database. A nil *UploadJournal does nothing.
+type UploadJournal struct {
+	coll  *mongo.Collection
+	entry models.UploadJournalEntry
+}
+
+// BeginUploadJournal inserts the "received" entry. Journaling never fails an upload: on error
+// (or when disabled) it logs and returns nil.
+func BeginUploadJournal(ctx context.Context, coll *mongo.Collection, requestID, brand, fileSHA256 string, size int64) *UploadJournal {
+	if !UploadJournalEnabled() {
+		return nil
+	}
+	j := &UploadJournal{coll: coll, entry: models.UploadJournalEntry{
+		ID:         primitive.NewObjectID(),
+		RequestID:  requestID,
+		Brand:      brand,
+		FileSHA256: fileSHA256,
+		Size:       size,
+		ReceivedAt: models.Now(),
+	}}
+	if _, err := coll.InsertOne(ctx, j.entry); err != nil {
+		log.Printf("Warning: Could not journal upload for brand '%s': %v", LogValue(brand), err)
+		return nil
+	}
+	return j
+}
+
+// Mark records that the upload reached stage (extracted or upserted). It only updates the
+// in-memory entry; Finish writes it.
+func (j *UploadJournal) Mark(stage string) {
+	if j == nil {
+		return
+	}
+	now := models.Now()
+	switch stage {
+	case models.UploadStageExtracted:
+		j.entry.ExtractedAt = now
+	case models.UploadStageUpserted:
+		j.entry.UpsertedAt = now
+	}
+}
+
+// Finish records the response status. It uses its own context so it still runs when the
+// request's context has expired, which is exactly the case worth journaling.
+func (j *UploadJournal) Finish(status int) {
+	if j == nil {
+		return
+	}
+	j.entry.RespondedAt = models.Now()
+	j.entry.Status = int32(status)
+	ctx, cancel := context.WithTimeout(context.Background(), journalWriteTimeout)
+	defer cancel()
+	// Only fixed-size fields change, as required for documents in a capped collection
+	update := bson.M{"$set": bson.M{
+		"extractedAt": j.entry.ExtractedAt,
+		"upsertedAt":  j.entry.UpsertedAt,
+		"respondedAt": j.entry.RespondedAt,
+		"status":      j.entry.Status,
+	}}
+	if _, err := j.coll.UpdateByID(ctx, j.entry.ID, update); err != nil {
+		log.Printf("Warning: Could not finish upload journal entry %s: %v", j.entry.ID.Hex(), err)
+	}
+}
+
+// RecentUploadJournal returns the newest journal entries after skipping skip of them, optionally
+// only those for brand.
+func RecentUploadJournal(ctx context.Context, coll *mongo.Collection, brand string, skip, limit int) ([]models.UploadJournalEntry, error) {
+	filter := bson.M{}
+	if brand != "" {
+		filter["brand"] = brand
+	}
+	// Capped collections keep insertion order, so reverse natural order is newest first
+	opts := options.Find().SetSort(bson.M{"$natural": -1}).SetSkip(int64(skip)).SetLimit(int64(limit))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]models.UploadJournalEntry, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Stage = entries[i].LastStage()
+	}
+	return entries, nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+// Defaults for keyword extraction; each can be overridden via environment variables.
+const (
+	defaultKeywordMinLength = 3
+	defaultKeywordTopN      = 25
+)
+
+// defaultStopwords is a small English stopword list covering the filler words
+// that dominate PDF-extracted brand details.
+var defaultStopwords = []string{
+	"a", "about", "above", "after", "again", "all", "also", "am", "an", "and", "any", "are", "as", "at",
+	"be", "because", "been", "before", "being", "below", "between", "both", "but", "by",
+	"can", "could", "did", "do", "does", "doing", "down", "during", "each", "few", "for", "from", "further",
+	"had", "has", "have", "having", "he", "her", "here", "hers", "him", "his", "how",
+	"i", "if", "in", "into", "is", "it", "its", "itself", "just", "may", "me", "more", "most", "must", "my",
+	"no", "nor", "not", "now", "of", "off", "on", "once", "only", "or", "other", "our", "ours", "out", "over", "own",
+	"per", "same", "she", "should", "so", "some", "such", "than", "that", "the", "their", "theirs", "them", "then",
+	"there", "these", "they", "this", "those", "through", "to", "too", "under", "until", "up", "upon", "us",
+	"very", "was", "we", "were", "what", "when", "where", "which", "while", "who", "whom", "why", "will", "with",
+	"would", "you", "your", "yours",
+}
+
+// KeywordConfig controls how keywords are extracted from brand details.
+type KeywordConfig struct {
+	MinLength int                 // Tokens shorter than this (in runes) are ignored
+	TopN      int                 // Maximum number of keywords stored per brand
+	Stopwords map[string]struct{} // Lower-cased words that are never keywords
+}
+
+var (
+	keywordConfig     KeywordConfig
+	keywordConfigOnce sync.Once
+)
+
+// GetKeywordConfig returns the keyword extraction settings, read once from the environment:
+//   - KEYWORD_MIN_LENGTH: minimum token length (default 3)
+//   - KEYWORD_TOP_N: keywords kept per brand (default 25)
+//   - KEYWORD_STOPWORDS: comma-separated list replacing the built-in stopwords
+func GetKeywordConfig() KeywordConfig {
+	keywordConfigOnce.Do(func() {
+		keywordConfig = KeywordConfig{
+			MinLength: envPositiveInt("KEYWORD_MIN_LENGTH", defaultKeywordMinLength),
+			TopN:      envPositiveInt("KEYWORD_TOP_N", defaultKeywordTopN),
+			Stopwords: make(map[string]struct{}),
+		}
+		words := defaultStopwords
+		if raw := os.Getenv("KEYWORD_STOPWORDS"); raw != "" {
+			words = strings.Split(raw, ",")
+		}
+		for _, w := range words {
+			if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+				keywordConfig.Stopwords[w] = struct{}{}
+			}
+		}
+	})
+	return keywordConfig
+}
+
+// envPositiveInt reads a positive integer from the environment, logging and
+// falling back to def when the variable is missing or invalid.
+func envPositiveInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: Invalid %s '%s', using default %d", key, LogValue(raw), def)
+		return def
+	}
+	return n
+}
+
+// KeywordTerms splits a lookup term into the keywords it would have been stored as: tokenized
+// and filtered like ExtractKeywords, in order. A phrase yields several terms; a term made only of
+// stopwords, numbers or short words yields none.
+func KeywordTerms(term string) []string {
+	cfg := GetKeywordConfig()
+	var terms []string
+	for _, token := range tokenize(term) {
+		if indexable(token, cfg) {
+			terms = append(terms, token)
+		}
+	}
+	return terms
+}
+
+// CountPhrase counts how often the phrase made of terms (as returned by KeywordTerms) occurs in
+// text: its words consecutive and in order once the text is tokenized and filtered the same way,
+// so stopwords, numbers and short words between them don't break a match.
+func CountPhrase(text string, terms []string) int {
+	if len(terms) == 0 {
+		return 0
+	}
+	words := KeywordTerms(text)
+	count := 0
+	for i := 0; i+len(terms) <= len(words); i++ {
+		match := true
+		for j, term := range terms {
+			if words[i+j] != term {
+				match = false
+				break
+			}
+		}
+		if match {
+			count++
+		}
+	}
+	return count
+}
+
+// tokenize splits text into lower-cased word tokens (letters and digits only).
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// isNumeric reports whether the token consists only of digits (prices, quantities, ...),
+// which are rarely useful as keywords on their own.
+func isNumeric(token string) bool {
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexable reports whether a token is kept as a keyword: long enough, not purely numeric and
+// not a stopword.
+func indexable(token string, cfg KeywordConfig) bool {
+	if len([]rune(token)) < cfg.MinLength || isNumeric(token) {
+		return false
+	}
+	_, stop := cfg.Stopwords[token]
+	return !stop
+}
+
+// ExtractKeywords tokenizes the details text, drops stopwords, short and purely numeric
+// tokens, and returns the most frequent terms (highest count first, ties alphabetical).
+func ExtractKeywords(text string) []models.Keyword {
+	cfg := GetKeywordConfig()
+
+	counts := make(map[string]int)
+	for _, token := range tokenize(text) {
+		if indexable(token, cfg) {
+			counts[token]++
+		}
+	}
+
+	keywords := make([]models.Keyword, 0, len(counts))
+	for term, count := range counts {
+		keywords = append(keywords, models.Keyword{Term: term, Count: count})
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Count != keywords[j].Count {
+			return keywords[i].Count > keywords[j].Count
+		}
+		return keywords[i].Term < keywords[j].Term
+	})
+
+	if len(keywords) > cfg.TopN {
+		keywords = keywords[:cfg.TopN]
+	}
+	return keywords
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Lookup terms are split and filtered exactly like stored details, so a term matches what
+// ExtractKeywords kept.
+func TestKeywordTerms(t *testing.T) {
+	tests := []struct {
+		term string
+		want []string
+	}{
+		{"cotton", []string{"cotton"}},
+		{"  Cotton ", []string{"cotton"}},
+		{"cotton!", []string{"cotton"}},
+		{"Organic Cotton", []string{"organic", "cotton"}},
+		{"organic-cotton", []string{"organic", "cotton"}},
+		{"bags of cotton and cotton", []string{"bags", "cotton", "cotton"}},
+		{"Übergröße", []string{"übergröße"}},
+		{"100 kg", nil},
+		{"of the", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := KeywordTerms(tt.term); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("KeywordTerms(%q) = %q, want %q", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestCountPhrase(t *testing.T) {
+	details := "Organic cotton shirts. Organic, 100% cotton bags; cotton organic socks and organic-cotton caps."
+	tests := []struct {
+		phrase string
+		want   int
+	}{
+		{"organic cotton", 3},
+		{"Organic-Cotton", 3},
+		{"cotton organic", 1},
+		{"organic cotton shirts", 1},
+		{"organic cotton socks", 0},
+		{"cotton caps", 1},
+		{"cotton", 4},
+		{"shirts organic", 1},
+		{"linen", 0},
+		{"the", 0},
+	}
+	for _, tt := range tests {
+		if got := CountPhrase(details, KeywordTerms(tt.phrase)); got != tt.want {
+			t.Errorf("CountPhrase(%q) = %d, want %d", tt.phrase, got, tt.want)
+		}
+	}
+}
+
+func TestKeywordTermsMatchExtraction(t *testing.T) {
+	details := "Organic COTTON shirts; organic-cotton bags (100% cotton)."
+	stored := map[string]bool{}
+	for _, keyword := range ExtractKeywords(details) {
+		stored[keyword.Term] = true
+	}
+	for _, term := range KeywordTerms(details) {
+		if !stored[term] {
+			t.Errorf("lookup term %q was not stored as a keyword", term)
+		}
+	}
+}
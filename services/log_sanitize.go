@@ -0,0 +1,38 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Default number of bytes of a user-supplied value that is written to the logs
+const defaultLogValueMaxLength = 128
+
+var (
+	logValueMaxLength     int
+	logValueMaxLengthOnce sync.Once
+)
+
+// LogValue prepares a user-supplied string (brand name, search term, file name, ...) for logging.
+// Values longer than LOG_VALUE_MAX_LENGTH bytes (default 128) are cut at a character boundary and
+// suffixed with an ellipsis and the original size, so a pasted 50 KB "name" can't flood the logs.
+// Brand details must never be logged at all, not even through this helper.
+func LogValue(s string) string {
+	logValueMaxLengthOnce.Do(func() {
+		// Parsed directly: envPositiveInt logs through LogValue, which would re-enter this Once
+		logValueMaxLength = defaultLogValueMaxLength
+		if n, err := strconv.Atoi(os.Getenv("LOG_VALUE_MAX_LENGTH")); err == nil && n > 0 {
+			logValueMaxLength = n
+		}
+	})
+	if len(s) <= logValueMaxLength {
+		return s
+	}
+	cut := logValueMaxLength
+	for cut > 0 && s[cut]&0xC0 == 0x80 { // Don't split a multi-byte UTF-8 sequence
+		cut--
+	}
+	return fmt.Sprintf("%s…(%d bytes)", s[:cut], len(s))
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LogoBucket is the GridFS bucket holding the encoded logo variants.
+const LogoBucket = "brand_logos"
+
+// LogoSizes are the boxes (in pixels) every uploaded logo is resized to fit, smallest first.
+var LogoSizes = []int{64, 256}
+
+// Limits for logo uploads.
+const (
+	defaultLogoMaxBytes = 2 << 20
+	maxLogoPixels       = 4096 * 4096 // Refuse to decode anything larger (decompression bombs)
+	logoJPEGQuality     = 85
+)
+
+// Supported logo content types. WebP would need a decoder outside the standard library.
+const (
+	LogoTypePNG  = "image/png"
+	LogoTypeJPEG = "image/jpeg"
+)
+
+// ErrInvalidLogo is returned for uploads that claim to be a supported image but don't decode.
+var ErrInvalidLogo = errors.New("invalid image")
+
+// LogoMaxBytes returns the size limit for logo uploads (LOGO_MAX_BYTES, default 2 MiB).
+func LogoMaxBytes() int64 {
+	return int64(envPositiveInt("LOGO_MAX_BYTES", defaultLogoMaxBytes))
+}
+
+// SniffLogoType returns the content type detected from the data's leading bytes, ignoring
+// whatever the client declared.
+func SniffLogoType(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// RenderedLogo is one encoded variant, ready to be stored.
+type RenderedLogo struct {
+	models.LogoVariant
+	Data []byte
+}
+
+// RenderLogoVariants decodes a PNG or JPEG logo and encodes one variant per LogoSizes entry, in
+// the source's format. Images smaller than a box are not enlarged. Data that doesn't decode
+// completely returns an error wrapping ErrInvalidLogo.
+func RenderLogoVariants(data []byte, contentType string) ([]RenderedLogo, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLogo, err)
+	}
+	if "image/"+format != contentType {
+		return nil, fmt.Errorf("%w: content is %s, not %s", ErrInvalidLogo, format, contentType)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxLogoPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels is out of range", ErrInvalidLogo, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLogo, err)
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height))
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	rendered := make([]RenderedLogo, 0, len(LogoSizes))
+	for _, size := range LogoSizes {
+		variant := fitLogo(src, size)
+		var buf bytes.Buffer
+		if contentType == LogoTypeJPEG {
+			err = jpeg.Encode(&buf, variant, &jpeg.Options{Quality: logoJPEGQuality})
+		} else {
+			err = png.Encode(&buf, variant)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encoding %dpx logo: %w", size, err)
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		rendered = append(rendered, RenderedLogo{
+			LogoVariant: models.LogoVariant{
+				Size:   size,
+				Width:  variant.Bounds().Dx(),
+				Height: variant.Bounds().Dy(),
+				Bytes:  int64(buf.Len()),
+				SHA256: hex.EncodeToString(sum[:]),
+			},
+			Data: buf.Bytes(),
+		})
+	}
+	return rendered, nil
+}
+
+// fitLogo scales src down to fit in a box x box square, keeping its aspect ratio. Each target
+// pixel averages the source pixels it covers (a box filter), which is all a downscale needs.
+func fitLogo(src *image.RGBA, box int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= box && h <= box {
+		return src
+	}
+	dw, dh := box, box
+	if w > h {
+		dh = max(1, (h*box+w/2)/w)
+	} else {
+		dw = max(1, (w*box+h/2)/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0, sy1 := y*h/dh, max(y*h/dh+1, (y+1)*h/dh)
+		for x := 0; x < dw; x++ {
+			sx0, sx1 := x*w/dw, max(x*w/dw+1, (x+1)*w/dw)
+			var sum [4]uint64
+			for sy := sy0; sy < sy1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					for i := 0; i < 4; i++ {
+						sum[i] += uint64(row[sx*4+i])
+					}
+				}
+			}
+			n := uint64((sy1 - sy0) * (sx1 - sx0))
+			off := y*dst.Stride + x*4
+			for i := 0; i < 4; i++ {
+				dst.Pix[off+i] = uint8((sum[i] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// logoBucket opens the GridFS bucket in db, applying ctx's deadline (GridFS in this driver
+// version takes deadlines rather than contexts).
+func logoBucket(ctx context.Context, db *mongo.Database) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(LogoBucket))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err := bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+// StoreBrandLogo uploads the rendered variants and points the brand at them in a single update,
+// so readers see either the old or the new set, never a mix. The previous variants are deleted
+// afterwards. found is false (and nothing is kept) when the brand doesn't exist.
+func StoreBrandLogo(ctx context.Context, coll *mongo.Collection, name, contentType string, rendered []RenderedLogo) (*models.BrandLogo, bool, error) {
+	bucket, err := logoBucket(ctx, coll.Database())
+	if err != nil {
+		return nil, false, fmt.Errorf("opening logo bucket: %w", err)
+	}
+
+	logo := &models.BrandLogo{ContentType: contentType, UpdatedAt: models.Now()}
+	for _, r := range rendered {
+		filename := fmt.Sprintf("%s-%d", name, r.Size)
+		meta := options.GridFSUpload().SetMetadata(bson.M{"brand": name, "size": r.Size, "contentType": contentType})
+		fileID, err := bucket.UploadFromStream(filename, bytes.NewReader(r.Data), meta)
+		if err != nil {
+			deleteLogoFiles(bucket, logo)
+			return nil, false, fmt.Errorf("storing %dpx logo: %w", r.Size, err)
+		}
+		variant := r.LogoVariant
+		variant.FileID = fileID
+		logo.Variants = append(logo.Variants, variant)
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetProjection(bson.M{"logo": 1}).
+		SetReturnDocument(options.Before)
+	update := bson.M{"$set": bson.M{"logo": logo, "updatedAt": logo.UpdatedAt}}
+	var previous models.Brand
+	if err := coll.FindOneAndUpdate(ctx, bson.M{"name": name}, update, opts).Decode(&previous); err != nil {
+		deleteLogoFiles(bucket, logo)
+		if err == mongo.ErrNoDocuments {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	deleteLogoFiles(bucket, previous.Logo)
+	return logo, true, nil
+}
+
+// FindBrandLogo returns the brand's logo, nil if it has none. found is false when the brand
+// doesn't exist.
+func FindBrandLogo(ctx context.Context, coll *mongo.Collection, name string) (*models.BrandLogo, bool, error) {
+	var brand models.Brand
+	opts := options.FindOne().SetProjection(bson.M{"logo": 1})
+	if err := coll.FindOne(ctx, bson.M{"name": name}, opts).Decode(&brand); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return brand.Logo, true, nil
+}
+
+// OpenLogoFile opens a stored variant for streaming. The caller must close it.
+func OpenLogoFile(ctx context.Context, db *mongo.Database, fileID primitive.ObjectID) (*gridfs.DownloadStream, error) {
+	bucket, err := logoBucket(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.OpenDownloadStream(fileID)
+}
+
+// RemoveLogoFiles removes stored logo variants by file ID, e.g. after their brand was deleted.
+// Files already gone are skipped, so it can be retried; every other failure is returned.
+func RemoveLogoFiles(ctx context.Context, db *mongo.Database, fileIDs []primitive.ObjectID) error {
+	bucket, err := logoBucket(ctx, db)
+	if err != nil {
+		return fmt.Errorf("opening logo bucket: %w", err)
+	}
+	var errs []error
+	for _, id := range fileIDs {
+		if err := bucket.Delete(id); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			errs = append(errs, fmt.Errorf("logo file %s: %w", id.Hex(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func deleteLogoFiles(bucket *gridfs.Bucket, logo *models.BrandLogo) {
+	if logo == nil {
+		return
+	}
+	for _, v := range logo.Variants {
+		if err := bucket.Delete(v.FileID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			log.Printf("Warning: Could not delete logo file %s: %v", v.FileID.Hex(), err)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxBrandPartitions caps the number of partitions a set can be split into.
+const MaxBrandPartitions = 64
+
+// BrandRangePage is one page of brand names from an _id range, in _id order.
+type BrandRangePage struct {
+	Names        []string
+	NextCursor   string // Opaque position after the last listed brand (see EncodeBrandCursor)
+	HasMore      bool
+	DecodeErrors int
+}
+
+// EncodeBrandCursor turns the _id of the last listed brand into the opaque token clients pass
+// back as ?cursor= to continue a listing.
+func EncodeBrandCursor(id primitive.ObjectID) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// DecodeBrandCursor returns the _id encoded in a token from EncodeBrandCursor.
+func DecodeBrandCursor(token string) (primitive.ObjectID, error) {
+	var id primitive.ObjectID
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return id, err
+	}
+	if len(raw) != len(id) {
+		return id, fmt.Errorf("cursor has %d bytes, want %d", len(raw), len(id))
+	}
+	copy(id[:], raw)
+	return id, nil
+}
+
+// BrandPartitions returns the stored partition set for count, computing and storing it first when
+// there is none yet or refresh is set. Sets are kept until refreshed, so every worker of a sync,
+// on any instance, resolves ?partition=KofN to the same ranges. Brands created after the set was
+// computed land in whichever range their _id falls in (new IDs sort last, so usually the last
+// one); a worker that already passed that point misses them until the next sync.
+func BrandPartitions(ctx context.Context, brands, sets *mongo.Collection, count int, refresh bool) (*models.BrandPartitionSet, error) {
+	var set models.BrandPartitionSet
+	if !refresh {
+		err := sets.FindOne(ctx, bson.M{"_id": count}).Decode(&set)
+		if err == nil {
+			fillPartitions(&set)
+			return &set, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperrors.FromDB(err)
+		}
+	}
+
+	boundaries, err := brandBoundaries(ctx, brands, count)
+	if err != nil {
+		return nil, err
+	}
+	set = models.BrandPartitionSet{Count: count, Boundaries: boundaries, ComputedAt: models.Now()}
+	if refresh {
+		_, err = sets.ReplaceOne(ctx, bson.M{"_id": count}, set, options.Replace().SetUpsert(true))
+	} else if _, err = sets.InsertOne(ctx, set); mongo.IsDuplicateKeyError(err) {
+		// Another worker computed the set at the same time; use the one that was stored
+		err = sets.FindOne(ctx, bson.M{"_id": count}).Decode(&set)
+	}
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	log.Printf("Brand partitions: computed %d ranges (refresh=%t)", count, refresh)
+	fillPartitions(&set)
+	return &set, nil
+}
+
+// brandBoundaries splits the current _ids into count evenly filled buckets and returns the count-1
+// IDs between them. With fewer brands than buckets the last boundary is repeated, leaving the
+// surplus partitions empty.
+func brandBoundaries(ctx context.Context, coll *mongo.Collection, count int) ([]primitive.ObjectID, error) {
+	pipeline := mongo.Pipeline{{{Key: "$bucketAuto", Value: bson.M{"groupBy": "$_id", "buckets": count}}}}
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("bucketing brands: %w", apperrors.FromDB(err))
+	}
+	var buckets []struct {
+		Range struct {
+			Min primitive.ObjectID `bson:"min"`
+		} `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, fmt.Errorf("bucketing brands: %w", apperrors.FromDB(err))
+	}
+
+	boundaries := make([]primitive.ObjectID, 0, count-1)
+	for i := 1; i < len(buckets); i++ {
+		boundaries = append(boundaries, buckets[i].Range.Min) // Equals the previous bucket's exclusive max
+	}
+	last := primitive.NilObjectID
+	if len(boundaries) > 0 {
+		last = boundaries[len(boundaries)-1]
+	}
+	for len(boundaries) < count-1 {
+		boundaries = append(boundaries, last)
+	}
+	return boundaries, nil
+}
+
+func fillPartitions(set *models.BrandPartitionSet) {
+	set.Partitions = make([]models.BrandPartition, set.Count)
+	for i := range set.Partitions {
+		set.Partitions[i] = set.Partition(i + 1)
+	}
+}
+
+// ListBrandNamesInRange lists up to limit brand names of partition p matching filter, in _id order,
+// starting after the brand with ID after (if set).
+func ListBrandNamesInRange(ctx context.Context, coll *mongo.Collection, filter bson.M, p models.BrandPartition, after *primitive.ObjectID, limit int) (*BrandRangePage, error) {
+	idRange := bson.M{}
+	if p.MinID != nil {
+		idRange["$gte"] = *p.MinID
+	}
+	if p.MaxID != nil {
+		idRange["$lt"] = *p.MaxID
+	}
+	if after != nil {
+		idRange["$gt"] = *after
+	}
+	query := bson.M{}
+	for key, value := range filter {
+		query[key] = value
+	}
+	if len(idRange) > 0 {
+		query["_id"] = idRange
+	}
+
+	// One extra document tells whether another page follows
+	opts := options.Find().
+		SetProjection(bson.M{"name": 1, "_id": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit) + 1)
+	cursor, err := coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding brands: %w", apperrors.FromDB(err))
+	}
+	defer cursor.Close(ctx)
+
+	page := &BrandRangePage{Names: make([]string, 0)}
+	listed := 0
+	for cursor.Next(ctx) {
+		if listed == limit {
+			page.HasMore = true
+			break
+		}
+		listed++
+		// Skipped documents still advance the cursor, so a bad one is never returned twice
+		if id, ok := cursor.Current.Lookup("_id").ObjectIDOK(); ok {
+			page.NextCursor = EncodeBrandCursor(id)
+		}
+		var res struct {
+			Name string `bson:"name"`
+		}
+		if err := cursor.Decode(&res); err != nil {
+			page.DecodeErrors++
+			log.Printf("Warning: Skipping brand document %s that failed to decode: %v", rawDocumentID(cursor.Current), err)
+			continue
+		}
+		page.Names = append(page.Names, res.Name)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("iterating brands: %w", apperrors.FromDB(err))
+	}
+	return page, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBrandCursorRoundTrip(t *testing.T) {
+	ids := []primitive.ObjectID{
+		primitive.NilObjectID,
+		primitive.NewObjectID(),
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		{0xfb, 0xef, 0xbe, 0xfb, 0xef, 0xbe, 0xfb, 0xef, 0xbe, 0xfb, 0xef, 0xbe}, // Encodes to - and _
+	}
+	for _, id := range ids {
+		token := EncodeBrandCursor(id)
+		if strings.ContainsAny(token, "+/=") {
+			t.Errorf("cursor %q for %s is not URL-safe", token, id.Hex())
+		}
+		if token == id.Hex() {
+			t.Errorf("cursor for %s is the plain hex ID", id.Hex())
+		}
+		got, err := DecodeBrandCursor(token)
+		if err != nil || got != id {
+			t.Errorf("DecodeBrandCursor(%q) = %s, %v; want %s", token, got.Hex(), err, id.Hex())
+		}
+	}
+}
+
+func TestDecodeBrandCursorRejects(t *testing.T) {
+	valid := EncodeBrandCursor(primitive.NewObjectID())
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"hex ID", primitive.NewObjectID().Hex()},
+		{"padded", valid + "="},
+		{"standard alphabet", strings.NewReplacer("-", "+", "_", "/").Replace(EncodeBrandCursor(primitive.ObjectID{0xfb, 0xef, 0xbe, 0xfb, 0xef, 0xbe, 0xfb, 0xef, 0xbe, 0xfb, 0xef, 0xbe}))},
+		{"too short", valid[:len(valid)-2]},
+		{"too long", valid + "AAAA"},
+		{"not base64", "!!!!!!!!!!!!!!!!"},
+		{"whitespace", " " + valid},
+	}
+	for _, tt := range tests {
+		if id, err := DecodeBrandCursor(tt.token); err == nil {
+			t.Errorf("%s: DecodeBrandCursor(%q) = %s, want an error", tt.name, tt.token, id.Hex())
+		}
+	}
+}
+
+func FuzzDecodeBrandCursor(f *testing.F) {
+	f.Add(EncodeBrandCursor(primitive.NewObjectID()))
+	f.Add("")
+	f.Add("%%%")
+	f.Fuzz(func(t *testing.T, token string) {
+		id, err := DecodeBrandCursor(token)
+		if err != nil {
+			return
+		}
+		// encoding/base64 skips line breaks; otherwise only canonical tokens may be accepted
+		if again := EncodeBrandCursor(id); again != strings.NewReplacer("\r", "", "\n", "").Replace(token) {
+			t.Errorf("DecodeBrandCursor accepted %q, which encodes back as %q", token, again)
+		}
+	})
+}
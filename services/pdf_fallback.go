@@ -0,0 +1,608 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/deadline"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+// builtinEngine identifies the pure-Go extractor in the stored diagnostics. It only runs where
+// pdftotext isn't installed (development machines, minimal containers).
+const builtinEngine = "builtin"
+
+// errPDFEncrypted is returned by the built-in extractor for password-protected PDFs.
+var errPDFEncrypted = errors.New("pdf is encrypted")
+
+// Kerning in a TJ array wider than this (in thousandths of an em) is taken as a word space.
+const tjSpaceThreshold = 200
+
+var (
+	pdfObjectStart = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	pdfReference   = regexp.MustCompile(`(\d+)\s+\d+\s+R\b`)
+	pdfEncryptRef  = regexp.MustCompile(`/Encrypt\s+(\d+\s+\d+\s+R|<<)`)
+)
+
+// pdfObject is one indirect object: its dictionary (or other value) and decoded stream, if any.
+type pdfObject struct {
+	dict   string
+	stream []byte
+}
+
+// runBuiltinPDFText extracts the text of a PDF without pdftotext. It reads the pages in page-tree
+// order and their content streams (uncompressed or FlateDecode, also inside object streams),
+// starting a line wherever the text moves to a new baseline and a paragraph wherever a line
+// starts at a different indent, e.g. a second column. Strings are decoded as WinAnsi, or UTF-16
+// with a byte order mark; fonts with other encodings come out garbled, so the layout and some
+// characters may differ from pdftotext. Like pdftotext, each page ends with a form feed.
+func runBuiltinPDFText(ctx context.Context, pdfStream io.Reader) (string, *models.ExtractionInfo, error) {
+	started := time.Now()
+	info := &models.ExtractionInfo{Engine: builtinEngine, Warnings: []string{}}
+
+	data, err := io.ReadAll(pdfStream)
+	if err != nil {
+		return "", info, fmt.Errorf("reading pdf: %w", err)
+	}
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\r "), []byte("%PDF-")) {
+		return "", info, errors.New("not a pdf file")
+	}
+	if pdfEncryptRef.Match(data) {
+		return "", info, errPDFEncrypted
+	}
+
+	objects := parsePDFObjects(data)
+	var raw strings.Builder
+	for _, page := range pdfPages(data, objects) {
+		if ctx.Err() != nil {
+			info.DurationMs = time.Since(started).Milliseconds()
+			return "", info, deadline.Exhausted(StageExtraction, 0)
+		}
+		var content bytes.Buffer
+		for _, ref := range pdfRefs(pdfDictValue(page.dict, "Contents")) {
+			if obj, ok := objects[ref]; ok {
+				content.Write(obj.stream)
+				content.WriteByte('\n')
+			}
+		}
+		raw.WriteString(pageContentText(content.Bytes()))
+		raw.WriteString(pageBreak)
+	}
+	info.DurationMs = time.Since(started).Milliseconds()
+
+	text := finishExtraction(raw.String(), info)
+	log.Printf("Built-in PDF extractor: %d bytes of text from %d page(s) in %dms.", len(text), info.PageCount, info.DurationMs)
+	return text, info, nil
+}
+
+// parsePDFObjects finds every indirect object in data, including those packed into object
+// streams, keyed by object number. A later definition of the same number (an incremental
+// update) replaces an earlier one.
+func parsePDFObjects(data []byte) map[int]*pdfObject {
+	objects := make(map[int]*pdfObject)
+	for _, loc := range pdfObjectStart.FindAllSubmatchIndex(data, -1) {
+		num, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		body := data[loc[1]:]
+		if end := bytes.Index(body, []byte("endobj")); end >= 0 {
+			body = body[:end]
+		}
+		obj := &pdfObject{dict: string(body)}
+		if start := bytes.Index(body, []byte("stream")); start >= 0 {
+			obj.dict = string(body[:start])
+			obj.stream = decodePDFStream(obj.dict, streamData(body[start+len("stream"):]))
+		}
+		objects[num] = obj
+	}
+
+	// Objects inside object streams: a header of "number offset" pairs, then the objects
+	var objStreams []*pdfObject
+	for _, obj := range objects {
+		if pdfDictValue(obj.dict, "Type") == "/ObjStm" && obj.stream != nil {
+			objStreams = append(objStreams, obj)
+		}
+	}
+	for _, stm := range objStreams {
+		first, _ := strconv.Atoi(pdfDictValue(stm.dict, "First"))
+		if first <= 0 || first > len(stm.stream) {
+			continue
+		}
+		header := strings.Fields(string(stm.stream[:first]))
+		for i := 0; i+1 < len(header); i += 2 {
+			num, err1 := strconv.Atoi(header[i])
+			offset, err2 := strconv.Atoi(header[i+1])
+			if err1 != nil || err2 != nil || first+offset > len(stm.stream) {
+				continue
+			}
+			end := len(stm.stream)
+			if i+3 < len(header) {
+				if next, err := strconv.Atoi(header[i+3]); err == nil && first+next <= end && next >= offset {
+					end = first + next
+				}
+			}
+			if _, defined := objects[num]; !defined {
+				objects[num] = &pdfObject{dict: string(stm.stream[first+offset : end])}
+			}
+		}
+	}
+	return objects
+}
+
+// streamData returns the bytes between the EOL after the stream keyword and endstream.
+func streamData(rest []byte) []byte {
+	rest = bytes.TrimPrefix(rest, []byte("\r"))
+	rest = bytes.TrimPrefix(rest, []byte("\n"))
+	if end := bytes.LastIndex(rest, []byte("endstream")); end >= 0 {
+		rest = rest[:end]
+	}
+	return bytes.TrimSuffix(bytes.TrimSuffix(rest, []byte("\n")), []byte("\r"))
+}
+
+// decodePDFStream applies the stream's filter. Only FlateDecode is supported; streams with
+// other filters (images, mostly) decode to nil.
+func decodePDFStream(dict string, data []byte) []byte {
+	switch filter := pdfDictValue(dict, "Filter"); filter {
+	case "":
+		return data
+	case "/FlateDecode", "[/FlateDecode]", "[ /FlateDecode ]":
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil
+		}
+		defer reader.Close()
+		decoded, _ := io.ReadAll(reader) // Keep what inflated before a corrupt tail
+		return decoded
+	}
+	return nil
+}
+
+// pdfDictValue returns the raw value of key in a dictionary: a name, number, reference, or
+// bracketed array. Nested dictionaries are not returned.
+func pdfDictValue(dict, key string) string {
+	idx := -1
+	for from := 0; ; {
+		i := strings.Index(dict[from:], "/"+key)
+		if i < 0 {
+			return ""
+		}
+		i += from
+		after := i + 1 + len(key)
+		if after >= len(dict) || !isPDFNameChar(dict[after]) {
+			idx = after
+			break
+		}
+		from = after
+	}
+	rest := strings.TrimLeft(dict[idx:], " \t\r\n")
+	switch {
+	case strings.HasPrefix(rest, "["):
+		if end := strings.IndexByte(rest, ']'); end >= 0 {
+			return rest[:end+1]
+		}
+		return ""
+	case strings.HasPrefix(rest, "/"):
+		end := 1
+		for end < len(rest) && isPDFNameChar(rest[end]) {
+			end++
+		}
+		return rest[:end]
+	}
+	if ref := pdfReference.FindStringIndex(rest); ref != nil && ref[0] == 0 {
+		return rest[:ref[1]]
+	}
+	end := 0
+	for end < len(rest) && (rest[end] == '-' || rest[end] == '.' || rest[end] >= '0' && rest[end] <= '9') {
+		end++
+	}
+	return rest[:end]
+}
+
+func isPDFNameChar(b byte) bool {
+	return !strings.ContainsRune(" \t\r\n/[]<>()%{}", rune(b))
+}
+
+// pdfRefs returns the object numbers referenced by a value ("5 0 R" or "[5 0 R 7 0 R]").
+func pdfRefs(value string) []int {
+	var refs []int
+	for _, match := range pdfReference.FindAllStringSubmatch(value, -1) {
+		if num, err := strconv.Atoi(match[1]); err == nil {
+			refs = append(refs, num)
+		}
+	}
+	return refs
+}
+
+// pdfPages returns the page objects in page-tree order, or every page object in object order
+// when the tree can't be followed (e.g. a damaged trailer).
+func pdfPages(data []byte, objects map[int]*pdfObject) []*pdfObject {
+	var pages []*pdfObject
+	visited := make(map[int]bool)
+	var walk func(num int)
+	walk = func(num int) {
+		obj, ok := objects[num]
+		if !ok || visited[num] {
+			return
+		}
+		visited[num] = true
+		switch pdfDictValue(obj.dict, "Type") {
+		case "/Pages":
+			for _, kid := range pdfRefs(pdfDictValue(obj.dict, "Kids")) {
+				walk(kid)
+			}
+		case "/Page":
+			pages = append(pages, obj)
+		}
+	}
+
+	root := 0
+	if trailer := bytes.LastIndex(data, []byte("trailer")); trailer >= 0 {
+		root = firstRef(pdfDictValue(string(data[trailer:]), "Root"))
+	}
+	if root == 0 {
+		for _, obj := range objects { // Cross-reference streams carry the trailer keys
+			if pdfDictValue(obj.dict, "Type") == "/XRef" {
+				root = firstRef(pdfDictValue(obj.dict, "Root"))
+			}
+		}
+	}
+	if catalog, ok := objects[root]; ok {
+		walk(firstRef(pdfDictValue(catalog.dict, "Pages")))
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	nums := make([]int, 0, len(objects))
+	for num, obj := range objects {
+		if pdfDictValue(obj.dict, "Type") == "/Page" {
+			nums = append(nums, num)
+		}
+	}
+	slices.Sort(nums)
+	for _, num := range nums {
+		pages = append(pages, objects[num])
+	}
+	return pages
+}
+
+func firstRef(value string) int {
+	if refs := pdfRefs(value); len(refs) > 0 {
+		return refs[0]
+	}
+	return 0
+}
+
+// pageContentText interprets the text operators of a page's content stream and returns its
+// lines, each ending with a newline, with a blank line between blocks.
+func pageContentText(content []byte) string {
+	var (
+		out          strings.Builder
+		line         strings.Builder
+		operands     []pdfToken
+		x, y         float64 // Start of the current text line
+		leading      float64
+		lineX, lineY float64 // Where the line being collected started
+		prevLineX    = math.NaN()
+		moved        = true
+		started      bool
+	)
+	flush := func() {
+		if strings.TrimSpace(line.String()) == "" {
+			line.Reset()
+			return
+		}
+		if !math.IsNaN(prevLineX) && math.Abs(lineX-prevLineX) > 1 {
+			out.WriteByte('\n') // A different indent: new block, e.g. the next column
+		}
+		out.WriteString(strings.TrimRight(line.String(), " "))
+		out.WriteByte('\n')
+		prevLineX = lineX
+		line.Reset()
+	}
+	show := func(text string) {
+		if moved {
+			switch {
+			case !started:
+			case math.Abs(y-lineY) > 1:
+				flush()
+			case line.Len() > 0 && !strings.HasSuffix(line.String(), " "):
+				line.WriteByte(' ') // Same baseline, further along
+			}
+			if line.Len() == 0 {
+				lineX, lineY = x, y
+			}
+			started, moved = true, false
+		}
+		line.WriteString(text)
+	}
+	number := func(i int) float64 {
+		if i < 0 || i >= len(operands) {
+			return 0
+		}
+		f, _ := strconv.ParseFloat(operands[i].value, 64)
+		return f
+	}
+
+	tokens := newPDFTokenizer(content)
+	for tok, ok := tokens.next(); ok; tok, ok = tokens.next() {
+		if tok.kind != pdfOperator {
+			operands = append(operands, tok)
+			continue
+		}
+		n := len(operands)
+		switch tok.value {
+		case "BT":
+			x, y, moved = 0, 0, true
+		case "TL":
+			leading = number(n - 1)
+		case "Td", "TD":
+			x, y, moved = x+number(n-2), y+number(n-1), true
+			if tok.value == "TD" {
+				leading = -number(n - 1)
+			}
+		case "Tm":
+			x, y, moved = number(n-2), number(n-1), true
+		case "T*":
+			y, moved = y-leading, true
+			if leading == 0 {
+				y--
+			}
+		case "Tj":
+			if n > 0 {
+				show(operands[n-1].text())
+			}
+		case "'", "\"":
+			y, moved = y-leading, true
+			if leading == 0 {
+				y--
+			}
+			if n > 0 {
+				show(operands[n-1].text())
+			}
+		case "TJ":
+			if n > 0 && operands[n-1].kind == pdfArray {
+				var text strings.Builder
+				for _, elem := range operands[n-1].elems {
+					if elem.kind == pdfString {
+						text.WriteString(elem.text())
+					} else if f, err := strconv.ParseFloat(elem.value, 64); err == nil && -f > tjSpaceThreshold {
+						text.WriteByte(' ')
+					}
+				}
+				show(text.String())
+			}
+		}
+		operands = operands[:0]
+	}
+	flush()
+	return out.String()
+}
+
+// Token kinds of a content stream.
+const (
+	pdfOperand = iota // Number, name, boolean, dictionary delimiter
+	pdfString
+	pdfArray
+	pdfOperator
+)
+
+type pdfToken struct {
+	kind  int
+	value string // Raw bytes of strings, the literal otherwise
+	elems []pdfToken
+}
+
+// text decodes a string token: UTF-16BE with a byte order mark, WinAnsi otherwise.
+func (t pdfToken) text() string {
+	if t.kind != pdfString {
+		return ""
+	}
+	raw := []byte(t.value)
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	var text strings.Builder
+	for _, b := range raw {
+		switch {
+		case b >= 0x80 && b < 0xA0:
+			if r := winAnsiHigh[b-0x80]; r != 0 {
+				text.WriteRune(r)
+			}
+		case b < 0x20 && b != '\t':
+		default:
+			text.WriteRune(rune(b))
+		}
+	}
+	return text.String()
+}
+
+// winAnsiHigh maps WinAnsiEncoding bytes 0x80-0x9F; the rest of the upper half is Latin-1.
+var winAnsiHigh = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// pdfTokenizer splits a content stream into operands and operators. Inline images are skipped.
+type pdfTokenizer struct {
+	data []byte
+	pos  int
+}
+
+func newPDFTokenizer(data []byte) *pdfTokenizer {
+	return &pdfTokenizer{data: data}
+}
+
+func (t *pdfTokenizer) next() (pdfToken, bool) {
+	d := t.data
+	for t.pos < len(d) {
+		c := d[t.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0:
+			t.pos++
+		case c == '%':
+			for t.pos < len(d) && d[t.pos] != '\n' && d[t.pos] != '\r' {
+				t.pos++
+			}
+		case c == '(':
+			return pdfToken{kind: pdfString, value: t.literalString()}, true
+		case c == '<' && t.pos+1 < len(d) && d[t.pos+1] == '<', c == '>' && t.pos+1 < len(d) && d[t.pos+1] == '>':
+			t.pos += 2
+			return pdfToken{kind: pdfOperand, value: string(d[t.pos-2 : t.pos])}, true
+		case c == '<':
+			return pdfToken{kind: pdfString, value: t.hexString()}, true
+		case c == '[':
+			t.pos++
+			array := pdfToken{kind: pdfArray}
+			for {
+				elem, ok := t.next()
+				if !ok || elem.kind == pdfOperator && elem.value == "]" {
+					return array, true
+				}
+				array.elems = append(array.elems, elem)
+			}
+		case c == ']':
+			t.pos++
+			return pdfToken{kind: pdfOperator, value: "]"}, true
+		case c == '/':
+			start := t.pos
+			t.pos++
+			for t.pos < len(d) && isPDFNameChar(d[t.pos]) {
+				t.pos++
+			}
+			return pdfToken{kind: pdfOperand, value: string(d[start:t.pos])}, true
+		default:
+			start := t.pos
+			for t.pos < len(d) && isPDFNameChar(d[t.pos]) {
+				t.pos++
+			}
+			if t.pos == start {
+				t.pos++ // Stray delimiter
+				continue
+			}
+			word := string(d[start:t.pos])
+			if c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9' || word == "true" || word == "false" || word == "null" {
+				return pdfToken{kind: pdfOperand, value: word}, true
+			}
+			if word == "BI" {
+				t.skipInlineImage()
+				continue
+			}
+			return pdfToken{kind: pdfOperator, value: word}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+// literalString reads a (...) string with its escapes and balanced parentheses.
+func (t *pdfTokenizer) literalString() string {
+	d := t.data
+	t.pos++ // (
+	var out []byte
+	depth := 1
+	for t.pos < len(d) {
+		c := d[t.pos]
+		t.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return string(out)
+			}
+		case '\\':
+			if t.pos >= len(d) {
+				return string(out)
+			}
+			e := d[t.pos]
+			t.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if t.pos < len(d) && d[t.pos] == '\n' {
+					t.pos++
+				}
+				continue // Line continuation
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && t.pos < len(d) && d[t.pos] >= '0' && d[t.pos] <= '7'; i++ {
+						value = value*8 + int(d[t.pos]-'0')
+						t.pos++
+					}
+					c = byte(value)
+				} else {
+					c = e // \( \) \\ and unknown escapes
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
+
+// hexString reads a <...> string.
+func (t *pdfTokenizer) hexString() string {
+	d := t.data
+	t.pos++ // <
+	var digits []byte
+	for t.pos < len(d) && d[t.pos] != '>' {
+		if c := d[t.pos]; strings.IndexByte("0123456789abcdefABCDEF", c) >= 0 {
+			digits = append(digits, c)
+		}
+		t.pos++
+	}
+	t.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return string(out)
+}
+
+// skipInlineImage moves past the binary data of an inline image (BI ... ID <data> EI).
+func (t *pdfTokenizer) skipInlineImage() {
+	if i := bytes.Index(t.data[t.pos:], []byte("ID")); i >= 0 {
+		t.pos += i + 2
+	}
+	for t.pos < len(t.data) {
+		i := bytes.Index(t.data[t.pos:], []byte("EI"))
+		if i < 0 {
+			t.pos = len(t.data)
+			return
+		}
+		t.pos += i + 2
+		if t.pos >= len(t.data) || !isPDFNameChar(t.data[t.pos]) {
+			return
+		}
+	}
+}
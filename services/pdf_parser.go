@@ -9,32 +9,50 @@ import (
 	"log"     // For logging errors or warnings
 	"os/exec" // For running external commands (pdftotext)
 	"strings" // For trimming whitespace from the result
+	"sync"    // For querying the extractor version only once
 	"time"    // For setting command timeout
+
+	"github.com/Gautam3767/Order_form_Details_Backend/deadline"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
 )
 
-// pdfTimeout defines how long we wait for the pdftotext command to run.
+// pdfTimeout defines how long we wait for the pdftotext command to run. A request's deadline
+// budget (see the deadline package) can cut it shorter.
 const pdfTimeout = 15 * time.Second
 
-// ExtractTextFromPDF uses the external 'pdftotext' command-line tool
+// StageExtraction names PDF extraction in deadline budget errors and stats.
+const StageExtraction = "extraction"
+
+// pdfEngine identifies the extractor in the stored diagnostics.
+const pdfEngine = "pdftotext"
+
+// pageBreak is the form feed pdftotext writes after every page.
+const pageBreak = "\f"
+
+// runPDFToText uses the external 'pdftotext' command-line tool
 // to extract text content from a given PDF data stream.
 //
 // IMPORTANT: Requires 'pdftotext' (part of the poppler-utils package)
-// to be installed and accessible in the system's PATH.
+// to be installed and accessible in the system's PATH; without it ExtractTextFromPDF falls
+// back to the less accurate built-in extractor (see runBuiltinPDFText).
 // - Ubuntu/Debian: sudo apt-get update && sudo apt-get install poppler-utils
 // - macOS (Homebrew): brew install poppler
 // - Windows: Requires installing poppler, potentially via scoop, chocolatey, or manual download.
 //
 // Args:
 //
+//	parent: Bounds the run together with pdfTimeout (e.g. the request's deadline budget).
 //	pdfStream: An io.Reader providing the raw PDF data.
 //
 // Returns:
 //
 //	string: The extracted text content.
-//	error: An error if pdftotext fails, isn't found, or times out.
-func ExtractTextFromPDF(pdfStream io.Reader) (string, error) {
+//	*models.ExtractionInfo: Diagnostics about the run (engine, duration, pages, warnings).
+//	error: An error if pdftotext fails, isn't found, or times out; a *deadline.ExhaustedError
+//	       when parent's deadline stopped it.
+func runPDFToText(parent context.Context, pdfStream io.Reader) (string, *models.ExtractionInfo, error) {
 	// Create a context with a timeout to prevent the command from running indefinitely.
-	ctx, cancel := context.WithTimeout(context.Background(), pdfTimeout)
+	ctx, cancel := context.WithTimeout(parent, pdfTimeout)
 	defer cancel() // Ensure context resources are released
 
 	// Prepare the command: pdftotext <input> <output>
@@ -54,37 +72,101 @@ func ExtractTextFromPDF(pdfStream io.Reader) (string, error) {
 
 	log.Println("Attempting to run pdftotext...") // Log attempt
 
-	// Execute the command.
+	// Execute the command, timing it for the diagnostics.
+	started := time.Now()
 	err := cmd.Run()
+	info := &models.ExtractionInfo{
+		Engine:     pdfEngine,
+		DurationMs: time.Since(started).Milliseconds(),
+		Warnings:   []string{},
+	}
 
-	// Check if the context timed out or was cancelled.
+	// Check if the context timed out or was cancelled. Running out of the caller's budget says
+	// nothing about pdftotext, so it isn't reported as an exec failure (see the circuit breaker).
+	if parent.Err() != nil {
+		log.Printf("pdftotext stopped after %dms: request deadline reached", info.DurationMs)
+		return "", info, deadline.Exhausted(StageExtraction, 0)
+	}
 	if ctx.Err() == context.DeadlineExceeded {
 		log.Printf("pdftotext command timed out after %v", pdfTimeout)
-		return "", fmt.Errorf("pdftotext command timed out after %v", pdfTimeout)
+		return "", info, &execError{fmt.Errorf("pdftotext command timed out after %v: %w", pdfTimeout, context.DeadlineExceeded)}
 	}
 
 	// Check for errors during command execution.
 	if err != nil {
 		stderrOutput := errbuf.String()
-		log.Printf("pdftotext execution failed. Stderr: %s", stderrOutput)
+		log.Printf("pdftotext execution failed. Stderr: %s", LogValue(stderrOutput))
 
 		// Check specifically if the error is because the command wasn't found.
 		if errors.Is(err, exec.ErrNotFound) {
-			return "", errors.New("pdftotext command not found: please ensure poppler-utils is installed and in the system PATH")
+			return "", info, &execError{fmt.Errorf("pdftotext command not found: please ensure poppler-utils is installed and in the system PATH: %w", err)}
+		}
+
+		// Anything other than a non-zero exit means the process never ran properly (e.g. fork/exec
+		// failing because the host is out of file descriptors)
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", info, &execError{fmt.Errorf("pdftotext could not be run: %w", err)}
 		}
 
 		// Return a generic error including the original error and stderr output.
-		return "", fmt.Errorf("pdftotext execution failed: %w, stderr: %s", err, stderrOutput)
+		return "", info, fmt.Errorf("pdftotext execution failed: %w, stderr: %s", err, stderrOutput)
+	}
+
+	// pdftotext reports recoverable problems (broken xref tables, missing fonts) on stderr while still succeeding.
+	for _, line := range strings.Split(strings.TrimSpace(errbuf.String()), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			info.Warnings = append(info.Warnings, line)
+		}
 	}
 
 	// If execution was successful, extract the text from the output buffer.
-	extractedText := strings.TrimSpace(outbuf.String())
-	log.Printf("pdftotext executed successfully. Extracted %d bytes of text.", len(extractedText))
+	extractedText := finishExtraction(outbuf.String(), info)
+	log.Printf("pdftotext executed successfully. Extracted %d bytes of text from %d page(s) in %dms.", len(extractedText), info.PageCount, info.DurationMs)
+	return extractedText, info, nil
+}
 
-	// Even if the command ran, it might not have output anything (e.g., image-only PDF).
+// finishExtraction turns an extractor's raw output (one form feed per page) into the stored
+// text, counting the pages into info and warning when there is no text at all.
+func finishExtraction(rawText string, info *models.ExtractionInfo) string {
+	info.PageCount = strings.Count(rawText, pageBreak) // One form feed per page
+	extractedText := strings.TrimSpace(rawText)
+	if info.PageCount == 0 && extractedText != "" {
+		info.PageCount = 1
+	}
+
+	// Even if the extractor ran, it might not have output anything (e.g., image-only PDF).
 	if extractedText == "" {
-		log.Println("Warning: pdftotext ran successfully but produced no text output. PDF might be image-based or empty.")
+		log.Printf("Warning: %s produced no text output. PDF might be image-based or empty.", info.Engine)
+		info.Warnings = append(info.Warnings, "no text extracted; the PDF may be image-based or empty")
 	}
+	return extractedText
+}
 
-	return extractedText, nil
+var (
+	extractorVersion     string
+	extractorVersionOnce sync.Once
+)
+
+// ExtractorVersion describes the PDF engine in use, e.g. "pdftotext 22.02.0", or notes that it
+// is missing and the built-in extractor runs instead. The binary is only queried once per process.
+func ExtractorVersion() string {
+	extractorVersionOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		// pdftotext prints its version banner to stderr
+		out, err := exec.CommandContext(ctx, "pdftotext", "-v").CombinedOutput()
+		if err != nil && len(out) == 0 {
+			extractorVersion = builtinEngine + " (" + pdfEngine + " not available)"
+			return
+		}
+		firstLine := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+		if fields := strings.Fields(firstLine); len(fields) >= 3 && fields[1] == "version" {
+			extractorVersion = pdfEngine + " " + fields[2]
+			return
+		}
+		extractorVersion = pdfEngine
+	})
+	return extractorVersion
 }
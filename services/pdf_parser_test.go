@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden text files in testdata/pdf")
+
+// TestExtractTextFromPDF runs the sample PDFs in testdata/pdf through both extractors and
+// compares the text with the golden <name>.txt next to each: the built-in extractor must read
+// these samples the same way pdftotext does. After an intended change in the output, regenerate
+// the goldens from pdftotext with: go test ./services -run TestExtractTextFromPDF -update
+//
+// The pdftotext cases are skipped where poppler-utils isn't installed; the built-in ones always run.
+func TestExtractTextFromPDF(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantPages   int
+		wantWarning string // Expected among the diagnostics' warnings
+		wantErr     bool   // No golden: extraction must fail
+	}{
+		{"text", 1, "", false},
+		{"multipage", 2, "", false},
+		{"multicolumn", 1, "", false},
+		{"unicode", 1, "", false},
+		{"scanned", 1, "no text extracted; the PDF may be image-based or empty", false},
+		{"protected", 0, "", true}, // Needs a user password
+	}
+	engines := []struct {
+		name string
+		run  func(context.Context, io.Reader) (string, *models.ExtractionInfo, error)
+	}{
+		{pdfEngine, runPDFToText},
+		{builtinEngine, runBuiltinPDFText},
+	}
+	_, lookErr := exec.LookPath(pdfEngine)
+	for _, engine := range engines {
+		for _, tt := range tests {
+			t.Run(engine.name+"/"+tt.name, func(t *testing.T) {
+				if engine.name == pdfEngine && lookErr != nil {
+					t.Skipf("%s not installed", pdfEngine)
+				}
+				checkExtraction(t, engine.run, tt.name, tt.wantPages, tt.wantWarning, tt.wantErr, *update && engine.name == pdfEngine)
+			})
+		}
+	}
+}
+
+// checkExtraction extracts testdata/pdf/<name>.pdf with run and checks the result, rewriting the
+// golden instead of comparing when update is set.
+func checkExtraction(t *testing.T, run func(context.Context, io.Reader) (string, *models.ExtractionInfo, error), name string, wantPages int, wantWarning string, wantErr, update bool) {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", "pdf", name+".pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	text, info, err := run(context.Background(), file)
+	if wantErr {
+		if err == nil {
+			t.Fatalf("extracted %q, want an error", text)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PageCount != wantPages {
+		t.Errorf("%d pages, want %d", info.PageCount, wantPages)
+	}
+	if wantWarning != "" && !slices.Contains(info.Warnings, wantWarning) {
+		t.Errorf("warnings %q lack %q", info.Warnings, wantWarning)
+	}
+
+	golden := filepath.Join("testdata", "pdf", name+".txt")
+	if update {
+		if err := os.WriteFile(golden, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != string(want) {
+		t.Errorf("text differs from %s:\ngot:  %q\nwant: %q", golden, text, want)
+	}
+}
+
+// Without pdftotext on the PATH, uploads are extracted by the built-in extractor rather than failing.
+func TestExtractTextFromPDFFallsBack(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	file, err := os.Open(filepath.Join("testdata", "pdf", "text.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	text, info, err := ExtractTextFromPDF(context.Background(), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Engine != builtinEngine || text == "" {
+		t.Errorf("engine %s extracted %q, want text from %s", info.Engine, text, builtinEngine)
+	}
+}
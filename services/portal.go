@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Portal defaults, overridable via the PORTAL_* settings.
+const (
+	defaultPortalRateLimit       = 60 // Requests per token per minute
+	defaultPortalRevocationCheck = 60 * time.Second
+	portalRefreshTimeout         = 10 * time.Second
+	portalTokenPrefix            = "pt1."
+)
+
+// Portal token errors.
+var (
+	ErrPortalDisabled     = errors.New("supplier portal is disabled (PORTAL_SIGNING_SECRET not set)")
+	ErrInvalidPortalToken = errors.New("invalid portal token")
+	ErrPortalTokenRevoked = errors.New("portal token revoked")
+)
+
+// PortalClaims are the signed contents of a portal token.
+type PortalClaims struct {
+	TokenID  primitive.ObjectID `json:"tid"`
+	BrandID  primitive.ObjectID `json:"bid"`
+	IssuedAt int64              `json:"iat"`
+}
+
+// portalRevocations caches the IDs of revoked tokens so verifying a token needs no database
+// round trip. It is refreshed every PORTAL_REVOKE_REFRESH_SECONDS and updated right away for
+// revocations made by this instance; other instances pick them up on their next refresh.
+var portalRevocations = struct {
+	mu      sync.RWMutex
+	loaded  bool
+	revoked map[primitive.ObjectID]bool
+}{revoked: make(map[primitive.ObjectID]bool)}
+
+// portalRates counts requests per token in fixed one-minute windows.
+var portalRates = struct {
+	mu      sync.Mutex
+	window  time.Time
+	counter map[primitive.ObjectID]int
+}{counter: make(map[primitive.ObjectID]int)}
+
+// PortalEnabled reports whether portal tokens can be issued and verified.
+func PortalEnabled() bool {
+	return os.Getenv("PORTAL_SIGNING_SECRET") != ""
+}
+
+// IssuePortalToken records a new token for brand and returns it signed. The token is not stored
+// and cannot be shown again; it stays valid until revoked.
+func IssuePortalToken(ctx context.Context, tokens *mongo.Collection, brand *models.Brand, label, createdBy string) (string, *models.PortalToken, error) {
+	if !PortalEnabled() {
+		return "", nil, ErrPortalDisabled
+	}
+	record := &models.PortalToken{
+		ID:        primitive.NewObjectID(),
+		BrandID:   brand.ID,
+		Label:     label,
+		CreatedAt: models.Now(),
+		CreatedBy: createdBy,
+	}
+	if _, err := tokens.InsertOne(ctx, record); err != nil {
+		return "", nil, apperrors.FromDB(err)
+	}
+	token, err := signPortalClaims(PortalClaims{TokenID: record.ID, BrandID: brand.ID, IssuedAt: record.CreatedAt.Unix()})
+	if err != nil {
+		return "", nil, err
+	}
+	return token, record, nil
+}
+
+// ListPortalTokens returns the tokens issued for a brand, newest first, revoked ones included.
+func ListPortalTokens(ctx context.Context, tokens *mongo.Collection, brandID primitive.ObjectID) ([]models.PortalToken, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := tokens.Find(ctx, bson.M{"brandId": brandID}, opts)
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	list := []models.PortalToken{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	return list, nil
+}
+
+// RevokePortalToken marks a brand's token as revoked. Revoking it again keeps the first
+// revocation time; a token of another brand is reported as not found.
+func RevokePortalToken(ctx context.Context, tokens *mongo.Collection, brandID, tokenID primitive.ObjectID) (*models.PortalToken, error) {
+	filter := bson.M{"_id": tokenID, "brandId": brandID}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"revokedAt": bson.M{"$ifNull": bson.A{"$revokedAt", models.Now()}},
+	}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var record models.PortalToken
+	if err := tokens.FindOneAndUpdate(ctx, filter, update, opts).Decode(&record); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	portalRevocations.mu.Lock()
+	portalRevocations.revoked[tokenID] = true
+	portalRevocations.mu.Unlock()
+	return &record, nil
+}
+
+// VerifyPortalToken checks a token's signature and that it hasn't been revoked. Only before the
+// revocation cache's first load does it query tokens.
+func VerifyPortalToken(ctx context.Context, tokens *mongo.Collection, token string) (PortalClaims, error) {
+	if !PortalEnabled() {
+		return PortalClaims{}, ErrPortalDisabled
+	}
+	claims, err := parsePortalToken(token)
+	if err != nil {
+		return PortalClaims{}, err
+	}
+
+	portalRevocations.mu.RLock()
+	loaded, revoked := portalRevocations.loaded, portalRevocations.revoked[claims.TokenID]
+	portalRevocations.mu.RUnlock()
+	if !loaded {
+		count, err := tokens.CountDocuments(ctx, bson.M{"_id": claims.TokenID, "revokedAt": bson.M{"$ne": nil}}, options.Count().SetLimit(1))
+		if err != nil {
+			return PortalClaims{}, apperrors.FromDB(err)
+		}
+		revoked = count > 0
+	}
+	if revoked {
+		return PortalClaims{}, ErrPortalTokenRevoked
+	}
+	return claims, nil
+}
+
+// AllowPortalRequest counts a request against the token's per-minute limit
+// (PORTAL_RATE_LIMIT_PER_MINUTE, default 60). When the limit is used up it returns false and how
+// long until the next window.
+func AllowPortalRequest(tokenID primitive.ObjectID) (bool, time.Duration) {
+	limit := envPositiveInt("PORTAL_RATE_LIMIT_PER_MINUTE", defaultPortalRateLimit)
+	now := time.Now()
+	window := now.Truncate(time.Minute)
+
+	portalRates.mu.Lock()
+	defer portalRates.mu.Unlock()
+	if !window.Equal(portalRates.window) {
+		portalRates.window = window
+		portalRates.counter = make(map[primitive.ObjectID]int) // Old windows are dropped wholesale
+	}
+	if portalRates.counter[tokenID] >= limit {
+		return false, window.Add(time.Minute).Sub(now)
+	}
+	portalRates.counter[tokenID]++
+	return true, 0
+}
+
+// StartPortalRevocationRefresh loads the revoked token IDs now and then every
+// PORTAL_REVOKE_REFRESH_SECONDS (default 60). It does nothing while the portal is disabled.
+func StartPortalRevocationRefresh(tokens *mongo.Collection) {
+	if !PortalEnabled() {
+		log.Println("Supplier portal disabled (PORTAL_SIGNING_SECRET not set)")
+		return
+	}
+	interval := time.Duration(envPositiveInt("PORTAL_REVOKE_REFRESH_SECONDS", int(defaultPortalRevocationCheck/time.Second))) * time.Second
+	goWorker("portal-revocations", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := refreshPortalRevocations(ctx, tokens); err != nil {
+				log.Printf("Error refreshing revoked portal tokens, keeping the cached list: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// refreshPortalRevocations replaces the cached revoked IDs with the stored ones.
+func refreshPortalRevocations(ctx context.Context, tokens *mongo.Collection) error {
+	ctx, cancel := context.WithTimeout(ctx, portalRefreshTimeout)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := tokens.Find(ctx, bson.M{"revokedAt": bson.M{"$ne": nil}}, opts)
+	if err != nil {
+		return err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+	revoked := make(map[primitive.ObjectID]bool, len(docs))
+	for _, doc := range docs {
+		revoked[doc.ID] = true
+	}
+
+	portalRevocations.mu.Lock()
+	defer portalRevocations.mu.Unlock()
+	for id := range portalRevocations.revoked {
+		revoked[id] = true // Revocations are permanent; keep local ones made during the query
+	}
+	portalRevocations.revoked = revoked
+	portalRevocations.loaded = true
+	return nil
+}
+
+// signPortalClaims encodes claims as "pt1.<claims>.<signature>", both parts base64url.
+func signPortalClaims(claims PortalClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return portalTokenPrefix + encoded + "." + base64.RawURLEncoding.EncodeToString(portalSignature(encoded)), nil
+}
+
+// parsePortalToken verifies the signature and decodes the claims.
+func parsePortalToken(token string) (PortalClaims, error) {
+	encoded, signature, ok := strings.Cut(strings.TrimPrefix(token, portalTokenPrefix), ".")
+	if !ok || !strings.HasPrefix(token, portalTokenPrefix) {
+		return PortalClaims{}, ErrInvalidPortalToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, portalSignature(encoded)) {
+		return PortalClaims{}, ErrInvalidPortalToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return PortalClaims{}, ErrInvalidPortalToken
+	}
+	var claims PortalClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.TokenID.IsZero() || claims.BrandID.IsZero() {
+		return PortalClaims{}, ErrInvalidPortalToken
+	}
+	return claims, nil
+}
+
+func portalSignature(encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("PORTAL_SIGNING_SECRET")))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testPortalClaims() PortalClaims {
+	return PortalClaims{TokenID: primitive.NewObjectID(), BrandID: primitive.NewObjectID(), IssuedAt: syncEpoch.Unix()}
+}
+
+func signTestPortalClaims(t *testing.T, claims PortalClaims) string {
+	t.Helper()
+	token, err := signPortalClaims(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestPortalTokenRoundTrip(t *testing.T) {
+	t.Setenv("PORTAL_SIGNING_SECRET", "test-secret")
+	claims := testPortalClaims()
+	token := signTestPortalClaims(t, claims)
+	if !strings.HasPrefix(token, portalTokenPrefix) || strings.ContainsAny(token, "+/= ") {
+		t.Errorf("token %q is not a URL-safe %s token", token, portalTokenPrefix)
+	}
+	got, err := parsePortalToken(token)
+	if err != nil || got != claims {
+		t.Errorf("parsePortalToken = %+v, %v; want %+v", got, err, claims)
+	}
+}
+
+func TestParsePortalTokenRejects(t *testing.T) {
+	t.Setenv("PORTAL_SIGNING_SECRET", "test-secret")
+	token := signTestPortalClaims(t, testPortalClaims())
+	encoded, signature, _ := strings.Cut(strings.TrimPrefix(token, portalTokenPrefix), ".")
+	other, _, _ := strings.Cut(strings.TrimPrefix(signTestPortalClaims(t, testPortalClaims()), portalTokenPrefix), ".")
+
+	// resign builds a correctly signed token around an arbitrary payload
+	resign := func(payload string) string {
+		encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+		return portalTokenPrefix + encoded + "." + base64.RawURLEncoding.EncodeToString(portalSignature(encoded))
+	}
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no prefix", encoded + "." + signature},
+		{"other version", "pt2." + encoded + "." + signature},
+		{"no signature", portalTokenPrefix + encoded},
+		{"empty signature", portalTokenPrefix + encoded + "."},
+		{"swapped claims", portalTokenPrefix + other + "." + signature},
+		{"altered signature", portalTokenPrefix + encoded + "." + strings.ToUpper(signature)},
+		{"signature not base64", portalTokenPrefix + encoded + ".!!!"},
+		{"padded signature", token + "="},
+		{"extra part", token + ".x"},
+		{"claims not JSON", resign("not json")},
+		{"claims without IDs", resign(`{"iat":1}`)},
+		{"claims with a bad ID", resign(`{"tid":"nope","bid":"nope","iat":1}`)},
+	}
+	for _, tt := range tests {
+		if claims, err := parsePortalToken(tt.token); !errors.Is(err, ErrInvalidPortalToken) {
+			t.Errorf("%s: parsePortalToken(%q) = %+v, %v; want ErrInvalidPortalToken", tt.name, tt.token, claims, err)
+		}
+	}
+
+	// A token signed with another secret is rejected once the secret is rotated
+	t.Setenv("PORTAL_SIGNING_SECRET", "rotated-secret")
+	if _, err := parsePortalToken(token); !errors.Is(err, ErrInvalidPortalToken) {
+		t.Errorf("token signed with the old secret: error = %v, want ErrInvalidPortalToken", err)
+	}
+}
+
+// With the revocation cache loaded VerifyPortalToken doesn't touch the database, so a nil
+// collection is enough.
+func TestVerifyPortalToken(t *testing.T) {
+	claims, revokedClaims := testPortalClaims(), testPortalClaims()
+	portalRevocations.mu.Lock()
+	savedLoaded, savedRevoked := portalRevocations.loaded, portalRevocations.revoked
+	portalRevocations.loaded = true
+	portalRevocations.revoked = map[primitive.ObjectID]bool{revokedClaims.TokenID: true}
+	portalRevocations.mu.Unlock()
+	t.Cleanup(func() {
+		portalRevocations.mu.Lock()
+		portalRevocations.loaded, portalRevocations.revoked = savedLoaded, savedRevoked
+		portalRevocations.mu.Unlock()
+	})
+
+	t.Setenv("PORTAL_SIGNING_SECRET", "test-secret")
+	token, revokedToken := signTestPortalClaims(t, claims), signTestPortalClaims(t, revokedClaims)
+	tests := []struct {
+		name    string
+		secret  string
+		token   string
+		wantErr error
+	}{
+		{"valid", "test-secret", token, nil},
+		{"revoked", "test-secret", revokedToken, ErrPortalTokenRevoked},
+		{"forged", "test-secret", token + "x", ErrInvalidPortalToken},
+		{"portal disabled", "", token, ErrPortalDisabled},
+	}
+	for _, tt := range tests {
+		t.Setenv("PORTAL_SIGNING_SECRET", tt.secret)
+		got, err := VerifyPortalToken(context.Background(), nil, tt.token)
+		if !errors.Is(err, tt.wantErr) || (err == nil && got != claims) {
+			t.Errorf("%s: VerifyPortalToken = %+v, %v; want error %v", tt.name, got, err, tt.wantErr)
+		}
+	}
+}
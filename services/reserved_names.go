@@ -0,0 +1,44 @@
+package services
+
+import "sort"
+
+// reservedBrandNames are the static path segments directly under /brands ("upload", "sync", ...).
+// A brand with such a name is shadowed by the static route for the same method and only reachable
+// through /brands/by-name/, so no brand may be created with one, whether through the API, a CSV
+// import or brandctl. The server adds every static segment it registers at startup; the segments
+// listed here also apply where no routes are registered, as in brandctl.
+var reservedBrandNames = map[string]bool{
+	"bulk":       true,
+	"by-name":    true,
+	"id":         true,
+	"import":     true,
+	"partitions": true,
+	"search":     true,
+	"suggest":    true,
+	"sync":       true,
+	"upload":     true,
+}
+
+// ReserveBrandNames adds names to the reserved brand names. Call it at startup only: the set is
+// read without locking afterwards.
+func ReserveBrandNames(names ...string) {
+	for _, name := range names {
+		reservedBrandNames[name] = true
+	}
+}
+
+// IsReservedBrandName reports whether name is reserved. Matching is exact, like Gin's routing:
+// "Upload" is not shadowed by "/upload".
+func IsReservedBrandName(name string) bool {
+	return reservedBrandNames[name]
+}
+
+// ReservedBrandNames returns the reserved brand names, sorted.
+func ReservedBrandNames() []string {
+	names := make([]string, 0, len(reservedBrandNames))
+	for name := range reservedBrandNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
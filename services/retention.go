@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Purge defaults, overridable via the RETENTION_* settings.
+const (
+	defaultRetentionInterval   = 24 * time.Hour
+	defaultRetentionBatchSize  = 1000
+	defaultRetentionBatchPause = 200 * time.Millisecond
+	retentionBatchTimeout      = 30 * time.Second
+)
+
+// retentionTarget is a collection whose documents can be purged by age.
+type retentionTarget struct {
+	collection string
+	setting    string // Days to keep; unset or 0 keeps everything
+	dateField  string
+	dayString  bool // dateField holds a YYYY-MM-DD string instead of a date
+}
+
+// retentionTargets lists the purgeable collections. Tombstones expire through their TTL index
+// and the upload journal is capped, so neither needs purging.
+var retentionTargets = []retentionTarget{
+	{collection: database.BrandViewsCollection, setting: "RETENTION_BRAND_VIEWS_DAYS", dateField: "day", dayString: true},
+}
+
+// RetentionPolicy is the configured retention of one collection.
+type RetentionPolicy struct {
+	Collection string `json:"collection"`
+	Days       int    `json:"days"`
+}
+
+// RetentionResult is what one run did (or, in dry-run mode, would do) to one collection.
+type RetentionResult struct {
+	Collection string `json:"collection"`
+	Cutoff     string `json:"cutoff"` // Documents dated before this are purged
+	Matched    int64  `json:"matched"`
+	Deleted    int64  `json:"deleted"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RetentionRun summarises one purge run.
+type RetentionRun struct {
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+	DryRun     bool              `json:"dryRun"`
+	Results    []RetentionResult `json:"results"`
+}
+
+// RetentionStatus is shown by the admin API.
+type RetentionStatus struct {
+	Enabled  bool              `json:"enabled"` // False when no retention is configured
+	DryRun   bool              `json:"dryRun"`
+	Policies []RetentionPolicy `json:"policies"`
+	LastRun  *RetentionRun     `json:"lastRun,omitempty"`
+}
+
+var retention = struct {
+	mu      sync.Mutex
+	lastRun *RetentionRun
+}{}
+
+// RetentionPolicies returns the collections with a retention configured.
+func RetentionPolicies() []RetentionPolicy {
+	policies := []RetentionPolicy{}
+	for _, target := range retentionTargets {
+		if days := envPositiveInt(target.setting, 0); days > 0 {
+			policies = append(policies, RetentionPolicy{Collection: target.collection, Days: days})
+		}
+	}
+	return policies
+}
+
+// RetentionDryRun reports whether purges only count what they would delete (RETENTION_DRY_RUN=true).
+func RetentionDryRun() bool {
+	return strings.EqualFold(os.Getenv("RETENTION_DRY_RUN"), "true")
+}
+
+// StartRetentionPurge purges expired documents now and then every RETENTION_INTERVAL_HOURS
+// (default 24). Nothing runs when no retention is configured.
+func StartRetentionPurge() {
+	policies := RetentionPolicies()
+	if len(policies) == 0 {
+		log.Println("Data retention purge disabled (no RETENTION_*_DAYS configured)")
+		return
+	}
+	interval := time.Duration(envPositiveInt("RETENTION_INTERVAL_HOURS", int(defaultRetentionInterval/time.Hour))) * time.Hour
+	goWorker("retention-purge", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			runRetentionPurge(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// runRetentionPurge applies every configured policy once and records the run.
+func runRetentionPurge(ctx context.Context) {
+	run := &RetentionRun{StartedAt: time.Now().UTC(), DryRun: RetentionDryRun(), Results: []RetentionResult{}}
+	for _, target := range retentionTargets {
+		days := envPositiveInt(target.setting, 0)
+		if days <= 0 {
+			continue
+		}
+		result := purgeCollection(ctx, target, days, run.DryRun)
+		if result.Error != "" {
+			log.Printf("Error purging '%s': %s", target.collection, result.Error)
+		}
+		log.Printf("Retention purge of '%s' (older than %s, dry run %t): %d matched, %d deleted",
+			target.collection, result.Cutoff, run.DryRun, result.Matched, result.Deleted)
+		run.Results = append(run.Results, result)
+	}
+	run.FinishedAt = time.Now().UTC()
+
+	retention.mu.Lock()
+	retention.lastRun = run
+	retention.mu.Unlock()
+}
+
+// purgeCollection deletes the target's documents older than days in batches, pausing between
+// batches so a large backlog doesn't saturate the database. A dry run only counts them.
+func purgeCollection(ctx context.Context, target retentionTarget, days int, dryRun bool) RetentionResult {
+	cutoff := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -days)
+	var filter bson.M
+	result := RetentionResult{Collection: target.collection}
+	if target.dayString {
+		result.Cutoff = cutoff.Format(ViewDayLayout)
+		filter = bson.M{target.dateField: bson.M{"$lt": result.Cutoff}}
+	} else {
+		result.Cutoff = cutoff.Format(time.RFC3339)
+		filter = bson.M{target.dateField: bson.M{"$lt": cutoff}}
+	}
+	coll := database.Collection(target.collection)
+
+	countCtx, cancel := context.WithTimeout(ctx, retentionBatchTimeout)
+	matched, err := coll.CountDocuments(countCtx, filter)
+	cancel()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Matched = matched
+	if dryRun || matched == 0 {
+		return result
+	}
+
+	batchSize := envPositiveInt("RETENTION_BATCH_SIZE", defaultRetentionBatchSize)
+	pause := time.Duration(envPositiveInt("RETENTION_BATCH_PAUSE_MS", int(defaultRetentionBatchPause/time.Millisecond))) * time.Millisecond
+	for {
+		deleted, err := deleteRetentionBatch(ctx, coll, filter, batchSize)
+		result.Deleted += deleted
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if deleted < int64(batchSize) {
+			return result
+		}
+		select {
+		case <-time.After(pause):
+		case <-ctx.Done():
+			result.Error = "interrupted by shutdown"
+			return result
+		}
+	}
+}
+
+// deleteRetentionBatch deletes up to batchSize matching documents by _id.
+func deleteRetentionBatch(ctx context.Context, coll *mongo.Collection, filter bson.M, batchSize int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, retentionBatchTimeout)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(int64(batchSize))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	var docs []struct {
+		ID interface{} `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	ids := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	res, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// CurrentRetentionStatus returns the configured policies and the last run for the admin API.
+func CurrentRetentionStatus() RetentionStatus {
+	policies := RetentionPolicies()
+	retention.mu.Lock()
+	defer retention.mu.Unlock()
+	return RetentionStatus{
+		Enabled:  len(policies) > 0,
+		DryRun:   RetentionDryRun(),
+		Policies: policies,
+		LastRun:  retention.lastRun,
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Limits of the details search.
+const (
+	MaxSearchQueryLength = 200   // Characters
+	MaxSearchOffset      = 10000 // Deeper pages make the server skip too many scored matches
+	searchSnippetRadius  = 80    // Bytes of context on each side of the first match
+)
+
+// BrandSearchHit is one brand whose details match a search, with MongoDB's text score (higher
+// is more relevant) and the details around the first match.
+type BrandSearchHit struct {
+	Name    string  `json:"name" bson:"name"`
+	Score   float64 `json:"score" bson:"score"`
+	Snippet string  `json:"snippet" bson:"-"`
+	Details string  `json:"-" bson:"details"`
+}
+
+// SearchBrandDetails runs a MongoDB text search (words, "quoted phrases", -excluded words) over
+// the details text index and returns one page of hits, most relevant first, with the total
+// number of matching brands. It needs the text index database.Connect ensures.
+func SearchBrandDetails(ctx context.Context, coll *mongo.Collection, query string, offset, limit int) ([]BrandSearchHit, int64, error) {
+	filter := bson.M{"$text": bson.M{"$search": query}}
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting search matches: %w", err)
+	}
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0, "name": 1, "details": 1, "score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "name", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("searching brand details: %w", err)
+	}
+	hits := make([]BrandSearchHit, 0, limit)
+	if err := cursor.All(ctx, &hits); err != nil {
+		return nil, 0, fmt.Errorf("decoding search results: %w", err)
+	}
+
+	pattern := searchSnippetPattern(query)
+	for i := range hits {
+		hits[i].Snippet = searchSnippet(hits[i].Details, pattern)
+	}
+	return hits, total, nil
+}
+
+// searchSnippetPattern matches the phrases and words of a $text query, ignoring case. Phrases
+// come first so a snippet centers on a whole phrase rather than one of its words; excluded
+// (-word) terms never match.
+func searchSnippetPattern(query string) *regexp.Regexp {
+	var alternatives []string
+	rest := query
+	for {
+		before, phrase, found := strings.Cut(rest, `"`)
+		if !found {
+			break
+		}
+		phrase, after, closed := strings.Cut(phrase, `"`)
+		if phrase = strings.TrimSpace(phrase); phrase != "" && !strings.HasSuffix(before, "-") {
+			alternatives = append(alternatives, regexp.QuoteMeta(phrase))
+		}
+		rest = before + " " + after
+		if !closed {
+			break
+		}
+	}
+	for _, word := range strings.Fields(rest) {
+		if !strings.HasPrefix(word, "-") {
+			alternatives = append(alternatives, regexp.QuoteMeta(word))
+		}
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(alternatives, "|"))
+}
+
+// searchSnippet returns about searchSnippetRadius bytes of details on each side of the first
+// match of pattern, cut at word boundaries where possible, with "…" where text was left out.
+// Without a literal match (the text index also matches stemmed forms) it returns the start.
+func searchSnippet(details string, pattern *regexp.Regexp) string {
+	start, end := 0, 0
+	if pattern != nil {
+		if loc := pattern.FindStringIndex(details); loc != nil {
+			start, end = loc[0], loc[1]
+		}
+	}
+	from := max(start-searchSnippetRadius, 0)
+	to := min(end+searchSnippetRadius, len(details))
+	for from > 0 && !utf8.RuneStart(details[from]) {
+		from++
+	}
+	for to < len(details) && !utf8.RuneStart(details[to]) {
+		to--
+	}
+	if from > 0 {
+		if space := strings.IndexAny(details[from:start], " \n\t"); space >= 0 {
+			from += space + 1
+		}
+	}
+	if to < len(details) {
+		if space := strings.LastIndexAny(details[end:to], " \n\t"); space >= 0 {
+			to = end + space
+		}
+	}
+
+	snippet := strings.Join(strings.Fields(details[from:to]), " ")
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(details) {
+		snippet += "…"
+	}
+	return snippet
+}
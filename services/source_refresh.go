@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Source refresh defaults, overridable via the SOURCE_REFRESH_* settings.
+const (
+	defaultSourceRefresh         = 24 * time.Hour
+	defaultSourceRefreshFailures = 3
+	sourceRefreshScan            = 5 * time.Minute
+	maxSourceErrorLength         = 500
+)
+
+// sourceRefreshInterval returns how often source is re-fetched: its own refreshHours, or
+// SOURCE_REFRESH_HOURS (default 24). source may be nil.
+func sourceRefreshInterval(source *models.BrandSource) time.Duration {
+	if source != nil && source.RefreshHours > 0 {
+		return time.Duration(source.RefreshHours) * time.Hour
+	}
+	return time.Duration(envPositiveInt("SOURCE_REFRESH_HOURS", int(defaultSourceRefresh/time.Hour))) * time.Hour
+}
+
+// SourceRefreshFailureThreshold returns after how many consecutive failed fetches a brand is
+// listed by ListSourceFailures (SOURCE_REFRESH_FAILURES, default 3).
+func SourceRefreshFailureThreshold() int {
+	return envPositiveInt("SOURCE_REFRESH_FAILURES", defaultSourceRefreshFailures)
+}
+
+// StartSourceRefresh queues the brands whose source is due for a refresh every few minutes.
+// Refreshes go through the supplier PDF queue and never take more than it has room for. Its
+// workers extract within the upload policy's concurrency limit, which uploads count against
+// too, and behind the extraction breaker. limits returns the download and details size limits
+// in effect.
+func StartSourceRefresh(coll *mongo.Collection, limits func() (maxBytes int64, maxDetails int)) {
+	goWorker("source-refresh", func(ctx context.Context) {
+		ticker := time.NewTicker(sourceRefreshScan)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				queueDueSources(ctx, coll, limits)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// queueDueSources queues due, unpaused sources, oldest first. A queued source's next refresh is
+// pushed back right away so the next scan doesn't queue it again; the fetch sets the real one.
+func queueDueSources(ctx context.Context, coll *mongo.Collection, limits func() (int64, int)) {
+	free := supplierPDFQueue - SupplierPDFQueueDepth()
+	if free <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, supplierPDFDBTimeout)
+	defer cancel()
+
+	now := models.Now()
+	filter := bson.M{"source.nextRefreshAt": bson.M{"$lte": now}, "source.paused": bson.M{"$ne": true}}
+	opts := options.Find().
+		SetProjection(bson.M{"name": 1, "source": 1}).
+		SetSort(bson.M{"source.nextRefreshAt": 1}).
+		SetLimit(int64(free))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Error finding brands due for a source refresh: %v", err)
+		return
+	}
+	var due []models.Brand
+	if err := cursor.All(ctx, &due); err != nil {
+		log.Printf("Error finding brands due for a source refresh: %v", err)
+		return
+	}
+
+	maxBytes, maxDetails := limits()
+	for _, brand := range due {
+		if brand.Source == nil || brand.Source.URL == "" {
+			continue
+		}
+		if !QueueSupplierPDF(coll, SupplierPDF{Brand: brand.Name, URL: brand.Source.URL, refresh: true}, maxBytes, maxDetails) {
+			return // Full or shutting down; the rest stay due
+		}
+		next := now.Add(sourceRefreshInterval(brand.Source))
+		if _, err := coll.UpdateByID(ctx, brand.ID, bson.M{"$set": bson.M{"source.nextRefreshAt": next}}); err != nil {
+			log.Printf("Warning: Could not reschedule the source refresh of brand '%s': %v", LogValue(brand.Name), err)
+		}
+	}
+	if len(due) > 0 {
+		log.Printf("Source refresh: %d brand(s) queued", len(due))
+	}
+}
+
+// loadBrandSource returns the stored source of a brand, or nil when it has none or can't be read.
+func loadBrandSource(coll *mongo.Collection, brandName string) *models.BrandSource {
+	ctx, cancel := context.WithTimeout(context.Background(), supplierPDFDBTimeout)
+	defer cancel()
+	var brand models.Brand
+	err := coll.FindOne(ctx, bson.M{"name": brandName}, options.FindOne().SetProjection(bson.M{"source": 1})).Decode(&brand)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		log.Printf("Warning: Could not read the source of brand '%s': %v", LogValue(brandName), err)
+	}
+	return brand.Source
+}
+
+// recordSourceFailure counts a failed fetch or extraction and schedules the next attempt a full
+// interval later, so a broken URL isn't retried every scan.
+func recordSourceFailure(coll *mongo.Collection, brandName string, source *models.BrandSource, cause error) {
+	message := cause.Error()
+	if len(message) > maxSourceErrorLength {
+		message = strings.ToValidUTF8(message[:maxSourceErrorLength], "")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), supplierPDFDBTimeout)
+	defer cancel()
+	update := bson.M{
+		"$set": bson.M{"source.lastError": message, "source.nextRefreshAt": models.Now().Add(sourceRefreshInterval(source))},
+		"$inc": bson.M{"source.consecutiveFailures": 1},
+	}
+	if _, err := coll.UpdateOne(ctx, bson.M{"name": brandName}, update); err != nil {
+		log.Printf("Warning: Could not record the failed source fetch of brand '%s': %v", LogValue(brandName), err)
+		return
+	}
+	if source != nil && source.ConsecutiveFailures+1 == SourceRefreshFailureThreshold() {
+		log.Printf("Warning: Source of brand '%s' failed %d times in a row", LogValue(brandName), source.ConsecutiveFailures+1)
+	}
+}
+
+// UpdateSourceRefresh pauses or resumes the refresh of a brand's source or changes its
+// interval, and returns the updated source. Resuming makes the source due at the next scan; a
+// new interval counts from the last successful fetch.
+func UpdateSourceRefresh(ctx context.Context, coll *mongo.Collection, brandName string, payload models.SourceRefreshPayload) (*models.BrandSource, error) {
+	var brand models.Brand
+	err := coll.FindOne(ctx, bson.M{"name": brandName}, options.FindOne().SetProjection(bson.M{"source": 1})).Decode(&brand)
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	if brand.Source == nil || brand.Source.URL == "" {
+		return nil, &apperrors.ValidationError{Fields: map[string]string{"source": "brand has no source URL; only brands with a supplier feed PDF are refreshed"}}
+	}
+
+	source := *brand.Source
+	set, unset := bson.M{}, bson.M{}
+	if payload.RefreshHours != nil {
+		source.RefreshHours = *payload.RefreshHours
+		if source.RefreshHours == 0 {
+			unset["source.refreshHours"] = ""
+		} else {
+			set["source.refreshHours"] = source.RefreshHours
+		}
+		from := models.Now()
+		if source.LastFetchedAt != nil {
+			from = *source.LastFetchedAt
+		}
+		set["source.nextRefreshAt"] = from.Add(sourceRefreshInterval(&source))
+	}
+	if payload.Paused != nil {
+		if source.Paused && !*payload.Paused {
+			set["source.nextRefreshAt"] = models.Now()
+		}
+		set["source.paused"] = *payload.Paused
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(update) == 0 {
+		return brand.Source, nil
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"source": 1})
+	if err := coll.FindOneAndUpdate(ctx, bson.M{"_id": brand.ID}, update, opts).Decode(&brand); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	return brand.Source, nil
+}
+
+// ListSourceFailures returns the brands whose source failed at least
+// SourceRefreshFailureThreshold times in a row, most failures first.
+func ListSourceFailures(ctx context.Context, coll *mongo.Collection) ([]models.SourceFailure, error) {
+	filter := bson.M{"source.consecutiveFailures": bson.M{"$gte": SourceRefreshFailureThreshold()}}
+	opts := options.Find().
+		SetProjection(bson.M{"name": 1, "source": 1}).
+		SetSort(bson.D{{Key: "source.consecutiveFailures", Value: -1}, {Key: "name", Value: 1}})
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	var brands []models.Brand
+	if err := cursor.All(ctx, &brands); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	failures := make([]models.SourceFailure, 0, len(brands))
+	for _, brand := range brands {
+		if brand.Source != nil {
+			failures = append(failures, models.SourceFailure{Name: brand.Name, Source: *brand.Source})
+		}
+	}
+	return failures, nil
+}
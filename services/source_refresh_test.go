@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/uploadpolicy"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// captureLog returns what the standard logger writes until the test ends.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+// updateOf returns the update document of the update command mtest saw next.
+func updateOf(mt *mtest.T) bson.Raw {
+	mt.Helper()
+	for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+		if event.CommandName == "update" {
+			return event.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+		}
+	}
+	mt.Fatal("no update was sent")
+	return nil
+}
+
+// closeTo reports whether got is within a few seconds of want, for timestamps taken by the code
+// under test.
+func closeTo(got, want time.Time) bool {
+	return got.Sub(want).Abs() < 5*time.Second
+}
+
+// A failed fetch counts towards the brand's consecutive failures, keeps the (cut) error and
+// postpones the next attempt by the source's interval; reaching the threshold is logged once.
+func TestRecordSourceFailure(t *testing.T) {
+	t.Setenv("SOURCE_REFRESH_FAILURES", "3")
+	tests := []struct {
+		name     string
+		source   *models.BrandSource
+		cause    string
+		interval time.Duration
+		wantWarn bool
+	}{
+		{"first failure", &models.BrandSource{URL: "https://s.example/a.pdf"}, "unexpected status 404 Not Found", 24 * time.Hour, false},
+		{"reaches the threshold", &models.BrandSource{URL: "https://s.example/a.pdf", ConsecutiveFailures: 2, RefreshHours: 6}, "timeout", 6 * time.Hour, true},
+		{"past the threshold", &models.BrandSource{URL: "https://s.example/a.pdf", ConsecutiveFailures: 3}, "timeout", 24 * time.Hour, false},
+		{"unknown source", nil, "not a PDF", 24 * time.Hour, false},
+		{"long error", &models.BrandSource{URL: "https://s.example/a.pdf"}, strings.Repeat("é", maxSourceErrorLength), 24 * time.Hour, false},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			logged := captureLog(mt.T)
+			mt.AddMockResponses(mtest.CreateSuccessResponse())
+			recordSourceFailure(mt.Coll, "Acme", tt.source, errors.New(tt.cause))
+
+			update := updateOf(mt)
+			if inc := update.Lookup("$inc", "source.consecutiveFailures").AsInt64(); inc != 1 {
+				mt.Errorf("consecutiveFailures incremented by %d, want 1", inc)
+			}
+			message := update.Lookup("$set", "source.lastError").StringValue()
+			if len(message) > maxSourceErrorLength || !utf8.ValidString(message) || !strings.HasPrefix(tt.cause, message) {
+				mt.Errorf("lastError %q, want the cause cut to %d bytes", message, maxSourceErrorLength)
+			}
+			if next := update.Lookup("$set", "source.nextRefreshAt").Time(); !closeTo(next, time.Now().Add(tt.interval)) {
+				mt.Errorf("next refresh at %v, want in %v", next, tt.interval)
+			}
+			if warned := strings.Contains(logged.String(), "failed 3 times in a row"); warned != tt.wantWarn {
+				mt.Errorf("threshold warning logged: %v, want %v (%s)", warned, tt.wantWarn, logged)
+			}
+		})
+	}
+}
+
+// Brands are listed as failing from the threshold on.
+func TestListSourceFailuresThreshold(t *testing.T) {
+	t.Setenv("SOURCE_REFRESH_FAILURES", "5")
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("filter", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch))
+		if _, err := ListSourceFailures(context.Background(), mt.Coll); err != nil {
+			mt.Fatal(err)
+		}
+		filter := mt.GetStartedEvent().Command.Lookup("filter")
+		if gte := filter.Document().Lookup("source.consecutiveFailures", "$gte").AsInt64(); gte != 5 {
+			mt.Errorf("filter %s, want at least 5 failures", filter)
+		}
+	})
+}
+
+func TestUpdateSourceRefresh(t *testing.T) {
+	t.Setenv("SOURCE_REFRESH_HOURS", "24")
+	fetched := models.Now().Add(-2 * time.Hour)
+	yes, no, twelve, zero := true, false, 12, 0
+	tests := []struct {
+		name       string
+		source     bson.D // Stored source, nil for none
+		payload    models.SourceRefreshPayload
+		wantSet    map[string]interface{} // Expected $set values; time.Time ones within a few seconds
+		wantUnset  []string
+		wantUpdate bool
+	}{
+		{"pause", bson.D{{Key: "url", Value: "https://s.example/a.pdf"}},
+			models.SourceRefreshPayload{Paused: &yes}, map[string]interface{}{"source.paused": true}, nil, true},
+		{"resume makes it due", bson.D{{Key: "url", Value: "https://s.example/a.pdf"}, {Key: "paused", Value: true}},
+			models.SourceRefreshPayload{Paused: &no}, map[string]interface{}{"source.paused": false, "source.nextRefreshAt": time.Now()}, nil, true},
+		{"resume while running keeps the schedule", bson.D{{Key: "url", Value: "https://s.example/a.pdf"}},
+			models.SourceRefreshPayload{Paused: &no}, map[string]interface{}{"source.paused": false}, nil, true},
+		{"interval counts from the last fetch", bson.D{{Key: "url", Value: "https://s.example/a.pdf"}, {Key: "lastFetchedAt", Value: fetched}},
+			models.SourceRefreshPayload{RefreshHours: &twelve},
+			map[string]interface{}{"source.refreshHours": int64(12), "source.nextRefreshAt": fetched.Add(12 * time.Hour)}, nil, true},
+		{"zero returns to the default", bson.D{{Key: "url", Value: "https://s.example/a.pdf"}, {Key: "refreshHours", Value: 6}, {Key: "lastFetchedAt", Value: fetched}},
+			models.SourceRefreshPayload{RefreshHours: &zero},
+			map[string]interface{}{"source.nextRefreshAt": fetched.Add(24 * time.Hour)}, []string{"source.refreshHours"}, true},
+		{"interval without a fetch counts from now", bson.D{{Key: "url", Value: "https://s.example/a.pdf"}},
+			models.SourceRefreshPayload{RefreshHours: &twelve},
+			map[string]interface{}{"source.refreshHours": int64(12), "source.nextRefreshAt": time.Now().Add(12 * time.Hour)}, nil, true},
+		{"nothing to change", bson.D{{Key: "url", Value: "https://s.example/a.pdf"}},
+			models.SourceRefreshPayload{}, nil, nil, false},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			brand := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "source", Value: tt.source}}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch, brand),
+				mtest.CreateSuccessResponse(bson.E{Key: "value", Value: brand}),
+			)
+			if _, err := UpdateSourceRefresh(context.Background(), mt.Coll, "Acme", tt.payload); err != nil {
+				mt.Fatal(err)
+			}
+			mt.GetStartedEvent() // The find
+			event := mt.GetStartedEvent()
+			if !tt.wantUpdate {
+				if event != nil {
+					mt.Errorf("sent %s, want no update", event.CommandName)
+				}
+				return
+			}
+			update := event.Command.Lookup("update").Document()
+			set, _ := update.Lookup("$set").DocumentOK()
+			if elements, _ := set.Elements(); len(elements) != len(tt.wantSet) {
+				mt.Errorf("$set %s, want %v", set, tt.wantSet)
+			}
+			for key, want := range tt.wantSet {
+				value := set.Lookup(key)
+				switch want := want.(type) {
+				case time.Time:
+					if got, ok := value.TimeOK(); !ok || !closeTo(got, want) {
+						mt.Errorf("%s = %s, want about %v", key, value, want)
+					}
+				case bool:
+					if got, ok := value.BooleanOK(); !ok || got != want {
+						mt.Errorf("%s = %s, want %v", key, value, want)
+					}
+				case int64:
+					if got, ok := value.AsInt64OK(); !ok || got != want {
+						mt.Errorf("%s = %s, want %v", key, value, want)
+					}
+				}
+			}
+			unset, _ := update.Lookup("$unset").DocumentOK()
+			for _, key := range tt.wantUnset {
+				if _, err := unset.LookupErr(key); err != nil {
+					mt.Errorf("$unset %s, want %s", unset, key)
+				}
+			}
+		})
+	}
+
+	mt.Run("no source", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch, bson.D{{Key: "_id", Value: primitive.NewObjectID()}}))
+		_, err := UpdateSourceRefresh(context.Background(), mt.Coll, "Acme", models.SourceRefreshPayload{Paused: &yes})
+		var validation *apperrors.ValidationError
+		if !errors.As(err, &validation) {
+			mt.Errorf("brand without a source: %v, want a validation error", err)
+		}
+	})
+}
+
+// serveSupplierPDF serves body as the supplier's PDF and returns its URL.
+func serveSupplierPDF(t *testing.T, body string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/catalog.pdf"
+}
+
+// holdExtractionSlots takes every extraction slot of an UPLOAD_CONCURRENCY=1 policy until the test
+// ends, as uploads in progress would.
+func holdExtractionSlots(t *testing.T) {
+	t.Helper()
+	t.Cleanup(uploadpolicy.ReloadEnv) // After UPLOAD_CONCURRENCY is restored
+	t.Setenv("UPLOAD_CONCURRENCY", "1")
+	uploadpolicy.ReloadEnv()
+	if !uploadpolicy.TryAcquireSlot() {
+		t.Fatal("extraction slot already taken")
+	}
+	t.Cleanup(uploadpolicy.ReleaseSlot)
+}
+
+// A refresh that downloads the file it extracted last time only records the check, without
+// waiting for an extraction slot. Other fetches extract, and wait for a slot while uploads hold
+// them all.
+func TestFetchSupplierPDFSlots(t *testing.T) {
+	const body = "%PDF-1.4 catalog"
+	sum := sha256.Sum256([]byte(body))
+	storedHash := hex.EncodeToString(sum[:])
+	tests := []struct {
+		name        string
+		refresh     bool
+		storedHash  string
+		wantErr     error
+		wantChecked bool // Only the check is recorded
+	}{
+		{"unchanged refresh", true, storedHash, nil, true},
+		{"changed refresh", true, "0000", context.DeadlineExceeded, false},
+		{"feed push of the same file", false, storedHash, context.DeadlineExceeded, false},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			holdExtractionSlots(mt.T)
+			url := serveSupplierPDF(mt.T, body)
+			source := bson.D{{Key: "url", Value: url}, {Key: "contentSha256", Value: tt.storedHash}, {Key: "consecutiveFailures", Value: 2}}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "orderform.brands", mtest.FirstBatch, bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "source", Value: source}}),
+				mtest.CreateSuccessResponse(),
+			)
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond) // Long enough to look for a free slot again
+			defer cancel()
+			job := supplierPDFJob{coll: mt.Coll, pdf: SupplierPDF{Brand: "Acme", URL: url, refresh: tt.refresh}, maxBytes: 1 << 20, maxDetails: 1 << 20}
+			err := fetchSupplierPDF(ctx, job)
+			if !errors.Is(err, tt.wantErr) {
+				mt.Fatalf("fetch: %v, want %v", err, tt.wantErr)
+			}
+
+			mt.GetStartedEvent() // Reading the source
+			event := mt.GetStartedEvent()
+			if !tt.wantChecked {
+				if event != nil {
+					mt.Errorf("sent %s while waiting for a slot, want nothing", event.CommandName)
+				}
+				return
+			}
+			update := event.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+			set := update.Lookup("$set").Document()
+			if _, err := set.LookupErr("details"); err == nil {
+				mt.Errorf("unchanged file rewrote the details: %s", set)
+			}
+			if failures := set.Lookup("source.consecutiveFailures").AsInt64(); failures != 0 {
+				mt.Errorf("consecutiveFailures %d after a good fetch, want 0", failures)
+			}
+			if fetchedAt := set.Lookup("source.lastFetchedAt").Time(); !closeTo(fetchedAt, time.Now()) {
+				mt.Errorf("lastFetchedAt %v, want now", fetchedAt)
+			}
+			if _, err := update.LookupErr("$unset", "source.lastError"); err != nil {
+				mt.Errorf("update %s keeps the last error", update)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+	"github.com/Gautam3767/Order_form_Details_Backend/uploadpolicy"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// supplierFeedMappingID identifies the mapping document within the settings collection.
+const supplierFeedMappingID = "supplier_feed_mapping"
+
+// Limits for fetching the PDFs referenced by feed entries.
+const (
+	supplierPDFWorkers   = 2
+	supplierPDFQueue     = 64
+	supplierPDFTimeout   = 60 * time.Second
+	supplierPDFDBTimeout = 10 * time.Second
+)
+
+// SupplierFeedMapping names the fields of a supplier feed entry. Dotted paths reach into nested
+// objects ("product.title"). Empty fields use the default mapping.
+type SupplierFeedMapping struct {
+	Name        string `bson:"name" json:"name"`
+	Description string `bson:"description" json:"description"`
+	Specs       string `bson:"specs" json:"specs"`
+	PDFURL      string `bson:"pdfUrl" json:"pdfUrl"`
+}
+
+// DefaultSupplierFeedMapping matches the supplier's documented schema.
+var DefaultSupplierFeedMapping = SupplierFeedMapping{Name: "name", Description: "description", Specs: "specs", PDFURL: "pdfUrl"}
+
+// withDefaults fills empty fields from DefaultSupplierFeedMapping.
+func (m SupplierFeedMapping) withDefaults() SupplierFeedMapping {
+	if m.Name == "" {
+		m.Name = DefaultSupplierFeedMapping.Name
+	}
+	if m.Description == "" {
+		m.Description = DefaultSupplierFeedMapping.Description
+	}
+	if m.Specs == "" {
+		m.Specs = DefaultSupplierFeedMapping.Specs
+	}
+	if m.PDFURL == "" {
+		m.PDFURL = DefaultSupplierFeedMapping.PDFURL
+	}
+	return m
+}
+
+// LoadSupplierFeedMapping reads the mapping document from the settings collection, falling
+// back to the default mapping when there is none.
+func LoadSupplierFeedMapping(ctx context.Context, settings *mongo.Collection) (SupplierFeedMapping, error) {
+	var mapping SupplierFeedMapping
+	err := settings.FindOne(ctx, bson.M{"_id": supplierFeedMappingID}).Decode(&mapping)
+	if err == mongo.ErrNoDocuments {
+		return DefaultSupplierFeedMapping, nil
+	}
+	if err != nil {
+		return SupplierFeedMapping{}, err
+	}
+	return mapping.withDefaults(), nil
+}
+
+// SaveSupplierFeedMapping stores the mapping document and returns it with defaults applied.
+func SaveSupplierFeedMapping(ctx context.Context, settings *mongo.Collection, mapping SupplierFeedMapping) (SupplierFeedMapping, error) {
+	mapping = mapping.withDefaults()
+	opts := options.Replace().SetUpsert(true)
+	if _, err := settings.ReplaceOne(ctx, bson.M{"_id": supplierFeedMappingID}, mapping, opts); err != nil {
+		return SupplierFeedMapping{}, err
+	}
+	return mapping, nil
+}
+
+// lookupPath returns the value at a dotted path in a decoded JSON object.
+func lookupPath(entry map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = entry
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// SupplierPDF is a PDF referenced by a feed entry, fetched after the feed has been applied.
+type SupplierPDF struct {
+	Brand string
+	URL   string
+
+	refresh bool // Scheduled refresh (see StartSourceRefresh): an unchanged file is not re-extracted
+}
+
+// ApplySupplierFeed upserts the feed entries in one unordered bulk write. The description
+// replaces a brand's details (when present, dropping the diagnostics of the PDF extraction they
+// may have come from) and specs are merged key by key into its existing
+// specs. Entries are reported by 1-based position in the feed; invalid entries are skipped and
+// reported, like bad CSV rows and reserved names. The returned PDFs should be passed to
+// QueueSupplierPDF.
+func ApplySupplierFeed(ctx context.Context, coll *mongo.Collection, entries []map[string]interface{}, mapping SupplierFeedMapping) (*ImportReport, []SupplierPDF, error) {
+	report := &ImportReport{TotalRows: len(entries), Errors: []ImportRowError{}}
+	start := time.Now()
+	fail := func(row int, name, message string) {
+		report.Failed++
+		report.Errors = append(report.Errors, ImportRowError{Row: row, Name: name, Error: message})
+	}
+
+	var writes []mongo.WriteModel
+	var rows []pendingRow
+	var pdfs []SupplierPDF
+	for i, entry := range entries {
+		row := i + 1
+		rawName, _ := lookupPath(entry, mapping.Name)
+		name, _ := rawName.(string)
+		name = strings.TrimSpace(name)
+		if name == "" {
+			fail(row, "", mapping.Name+" is required")
+			continue
+		}
+		if IsReservedBrandName(name) {
+			fail(row, name, "name is reserved")
+			continue
+		}
+
+		now := models.Now()
+		set := bson.M{"updatedAt": now}
+		update := bson.M{"$set": set, "$setOnInsert": bson.M{"name": name, "createdAt": now}}
+		if rawDescription, ok := lookupPath(entry, mapping.Description); ok && rawDescription != nil {
+			description, ok := rawDescription.(string)
+			if !ok {
+				fail(row, name, mapping.Description+" must be a string")
+				continue
+			}
+			set["details"] = description
+			set["detailsFormat"] = DetectDetailsFormat(description)
+			set["keywords"] = ExtractKeywords(description)
+			set["sections"] = SplitDetailsSections(description)
+			update["$unset"] = bson.M{"extraction": ""} // Details are no longer the output of a PDF extraction
+		}
+		if rawSpecs, ok := lookupPath(entry, mapping.Specs); ok && rawSpecs != nil {
+			specs, ok := rawSpecs.(map[string]interface{})
+			if !ok {
+				fail(row, name, mapping.Specs+" must be an object")
+				continue
+			}
+			badKey, valid := "", true
+			for key, value := range specs {
+				// Keys become field paths, so they can't be empty, contain dots or start with '$'
+				if key == "" || strings.Contains(key, ".") || strings.HasPrefix(key, "$") {
+					badKey, valid = key, false
+					break
+				}
+				set["specs."+key] = value
+			}
+			if !valid {
+				fail(row, name, fmt.Sprintf("invalid spec key '%s'", badKey))
+				continue
+			}
+		}
+		if rawURL, ok := lookupPath(entry, mapping.PDFURL); ok && rawURL != nil {
+			pdfURL, _ := rawURL.(string)
+			if parsed, err := url.Parse(pdfURL); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+				fail(row, name, mapping.PDFURL+" must be an http(s) URL")
+				continue
+			}
+			pdfs = append(pdfs, SupplierPDF{Brand: name, URL: pdfURL})
+			// The PDF is fetched right away, so the scheduled refresh is due a full interval later
+			set["source.url"] = pdfURL
+			set["source.nextRefreshAt"] = now.Add(sourceRefreshInterval(nil))
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(bson.M{"name": name}).SetUpdate(update).SetUpsert(true))
+		rows = append(rows, pendingRow{row: row, name: name})
+	}
+
+	if len(writes) > 0 {
+		report.Batches = 1
+		if err := executeImportBatch(ctx, coll, writes, rows, report); err != nil {
+			return report, nil, err
+		}
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+	log.Printf("Supplier feed applied: %d entries (%d created, %d updated, %d failed), %d PDF(s) to fetch",
+		report.TotalRows, report.Created, report.Updated, report.Failed, len(pdfs))
+	return report, pdfs, nil
+}
+
+// supplierPDFJob is a queued PDF fetch with the limits in effect when it was queued.
+type supplierPDFJob struct {
+	coll       *mongo.Collection
+	pdf        SupplierPDF
+	maxBytes   int64
+	maxDetails int
+}
+
+var (
+	supplierPDFOnce sync.Once
+	supplierPDFJobs chan supplierPDFJob
+	supplierClient  = &http.Client{Timeout: supplierPDFTimeout} // Default transport: honors HTTPS_PROXY/NO_PROXY
+)
+
+// QueueSupplierPDF schedules fetching pdf in the background: at most maxBytes are downloaded,
+// and the extracted text (cut to maxDetails bytes) replaces the brand's details like an
+// upload would. It returns false when the queue is full or the service is shutting down; the
+// entry's other data is kept. PDFs still queued at shutdown are dropped (and logged): the next
+// feed push queues them again.
+func QueueSupplierPDF(coll *mongo.Collection, pdf SupplierPDF, maxBytes int64, maxDetails int) bool {
+	if workersDraining() {
+		log.Printf("Warning: Shutting down, not fetching %s for brand '%s'", LogValue(pdf.URL), LogValue(pdf.Brand))
+		return false
+	}
+	supplierPDFOnce.Do(func() {
+		supplierPDFJobs = make(chan supplierPDFJob, supplierPDFQueue)
+		for i := 0; i < supplierPDFWorkers; i++ {
+			goWorker(fmt.Sprintf("supplier-pdf-%d", i+1), func(ctx context.Context) {
+				for {
+					select {
+					case job := <-supplierPDFJobs:
+						if err := fetchSupplierPDF(ctx, job); err != nil {
+							log.Printf("Error fetching supplier PDF for brand '%s' from %s: %v", LogValue(job.pdf.Brand), LogValue(job.pdf.URL), err)
+						}
+					case <-ctx.Done():
+						if dropped := len(supplierPDFJobs); dropped > 0 {
+							log.Printf("Warning: %d queued supplier PDF(s) dropped on shutdown", dropped)
+						}
+						return
+					}
+				}
+			})
+		}
+	})
+	select {
+	case supplierPDFJobs <- supplierPDFJob{coll: coll, pdf: pdf, maxBytes: maxBytes, maxDetails: maxDetails}:
+		return true
+	default:
+		log.Printf("Warning: Supplier PDF queue full, not fetching %s for brand '%s'", LogValue(pdf.URL), LogValue(pdf.Brand))
+		return false
+	}
+}
+
+// SupplierPDFQueueDepth returns the number of supplier PDFs waiting to be fetched.
+func SupplierPDFQueueDepth() int {
+	return len(supplierPDFJobs)
+}
+
+// fetchSupplierPDF downloads, extracts and stores one queued PDF, recording the outcome in the
+// brand's source. A scheduled refresh that finds the same file as last time only records the
+// check: details and updatedAt stay as they are. The extraction waits for one of the upload
+// policy's slots, so feed PDFs and refreshes never run more extractions than uploads may; it
+// gives up when ctx (the worker's) ends.
+func fetchSupplierPDF(ctx context.Context, job supplierPDFJob) error {
+	source := loadBrandSource(job.coll, job.pdf.Brand)
+	body, err := downloadSupplierPDF(job)
+	if err != nil {
+		recordSourceFailure(job.coll, job.pdf.Brand, source, err)
+		return err
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	now := models.Now()
+	checked := bson.M{
+		"source.lastFetchedAt":       now,
+		"source.nextRefreshAt":       now.Add(sourceRefreshInterval(source)),
+		"source.consecutiveFailures": 0,
+	}
+	if job.pdf.refresh && source != nil && source.ContentSHA256 == hash {
+		dbCtx, cancel := context.WithTimeout(context.Background(), supplierPDFDBTimeout)
+		defer cancel()
+		update := bson.M{"$set": checked, "$unset": bson.M{"source.lastError": ""}}
+		if _, err := job.coll.UpdateOne(dbCtx, bson.M{"name": job.pdf.Brand}, update); err != nil {
+			return err
+		}
+		log.Printf("Supplier PDF for brand '%s' unchanged", LogValue(job.pdf.Brand))
+		return nil
+	}
+
+	if err := uploadpolicy.AcquireSlot(ctx); err != nil {
+		return fmt.Errorf("waiting for an extraction slot: %w", err) // Shutting down; the next push or scan fetches it again
+	}
+	text, extraction, err := ExtractTextFromPDF(context.Background(), bytes.NewReader(body))
+	uploadpolicy.ReleaseSlot()
+	if err != nil {
+		recordSourceFailure(job.coll, job.pdf.Brand, source, err)
+		return err
+	}
+	if len(text) > job.maxDetails {
+		text = strings.ToValidUTF8(text[:job.maxDetails], "")
+		extraction.Truncated = true
+		extraction.Warnings = append(extraction.Warnings, fmt.Sprintf("text truncated to %d bytes", job.maxDetails))
+	}
+
+	set := bson.M{
+		"details":              text,
+		"detailsFormat":        DetectDetailsFormat(text),
+		"keywords":             ExtractKeywords(text),
+		"sections":             SplitDetailsSections(text),
+		"extraction":           extraction,
+		"updatedAt":            models.Now(),
+		"source.url":           job.pdf.URL,
+		"source.contentSha256": hash,
+		"source.lastChangedAt": now,
+	}
+	for key, value := range checked {
+		set[key] = value
+	}
+	dbCtx, cancel := context.WithTimeout(context.Background(), supplierPDFDBTimeout)
+	defer cancel()
+	update := bson.M{"$set": set, "$unset": bson.M{"source.lastError": ""}}
+	if _, err := job.coll.UpdateOne(dbCtx, bson.M{"name": job.pdf.Brand}, update); err != nil {
+		return err
+	}
+	log.Printf("Supplier PDF for brand '%s' applied (%d bytes of text)", LogValue(job.pdf.Brand), len(text))
+	return nil
+}
+
+// downloadSupplierPDF fetches the job's PDF within its size limit.
+func downloadSupplierPDF(job supplierPDFJob) ([]byte, error) {
+	resp, err := supplierClient.Get(job.pdf.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, job.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > job.maxBytes {
+		return nil, fmt.Errorf("PDF exceeds %d bytes", job.maxBytes)
+	}
+	if !bytes.HasPrefix(body, []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF")
+	}
+	return body, nil
+}
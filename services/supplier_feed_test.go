@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// feedUpdates returns the update of each upsert in the bulk write mtest saw, decoded.
+func feedUpdates(mt *mtest.T) []bson.M {
+	mt.Helper()
+	var updates []bson.M
+	for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+		if event.CommandName != "update" {
+			continue
+		}
+		values, _ := event.Command.Lookup("updates").Array().Values()
+		for _, value := range values {
+			var statement struct {
+				Q      bson.M `bson:"q"`
+				U      bson.M `bson:"u"`
+				Upsert bool   `bson:"upsert"`
+			}
+			if err := value.Unmarshal(&statement); err != nil {
+				mt.Fatal(err)
+			}
+			if !statement.Upsert {
+				mt.Errorf("update of %v is not an upsert", statement.Q)
+			}
+			updates = append(updates, statement.U)
+		}
+	}
+	return updates
+}
+
+func TestApplySupplierFeed(t *testing.T) {
+	t.Setenv("SOURCE_REFRESH_HOURS", "24")
+	nested := SupplierFeedMapping{Name: "product.title", Description: "product.body", Specs: "attributes", PDFURL: "links.catalog"}
+	tests := []struct {
+		name       string
+		mapping    SupplierFeedMapping
+		entries    []map[string]interface{}
+		response   bson.D // Of the bulk write, when there is one
+		wantReport ImportReport
+		wantPDFs   []SupplierPDF
+		wantSets   []bson.M   // Scalar $set values of each written entry; other fields may be set as well
+		wantNoSet  [][]string // $set fields each written entry must leave alone
+		wantUnset  []bool     // Whether each written entry drops the extraction diagnostics
+	}{
+		{
+			name:    "default mapping",
+			mapping: DefaultSupplierFeedMapping,
+			entries: []map[string]interface{}{
+				{"name": " Acme ", "description": "# Terms\nNet 30", "pdfUrl": "https://supplier.example/acme.pdf"},
+			},
+			response:   bson.D{{Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			wantReport: ImportReport{TotalRows: 1, Updated: 1, Batches: 1, Errors: []ImportRowError{}},
+			wantPDFs:   []SupplierPDF{{Brand: "Acme", URL: "https://supplier.example/acme.pdf"}},
+			wantSets: []bson.M{{
+				"details":       "# Terms\nNet 30",
+				"detailsFormat": "markdown",
+				"source.url":    "https://supplier.example/acme.pdf",
+			}},
+			wantNoSet: [][]string{{"extraction"}},
+			wantUnset: []bool{true},
+		},
+		{
+			name:    "nested fields",
+			mapping: nested,
+			entries: []map[string]interface{}{
+				{"product": map[string]interface{}{"title": "Globex", "body": "Terms"}, "links": map[string]interface{}{"catalog": "http://supplier.example/g.pdf"}},
+			},
+			response:   bson.D{{Key: "n", Value: 1}, {Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: "x"}}}}},
+			wantReport: ImportReport{TotalRows: 1, Created: 1, Batches: 1, Errors: []ImportRowError{}},
+			wantPDFs:   []SupplierPDF{{Brand: "Globex", URL: "http://supplier.example/g.pdf"}},
+			wantSets:   []bson.M{{"details": "Terms", "source.url": "http://supplier.example/g.pdf"}},
+			wantUnset:  []bool{true},
+		},
+		{
+			name:    "specs merge key by key and keep the details",
+			mapping: DefaultSupplierFeedMapping,
+			entries: []map[string]interface{}{
+				{"name": "Acme", "specs": map[string]interface{}{"color": "red", "weight": 1.5}},
+				{"name": "Initech", "description": nil, "specs": nil},
+			},
+			response:   bson.D{{Key: "n", Value: 2}, {Key: "nModified", Value: 1}},
+			wantReport: ImportReport{TotalRows: 2, Updated: 1, Unchanged: 1, Batches: 1, Errors: []ImportRowError{}},
+			wantSets:   []bson.M{{"specs.color": "red", "specs.weight": 1.5}, {}},
+			wantNoSet:  [][]string{{"details", "specs", "keywords", "sections", "source.url"}, {"details", "specs"}},
+			wantUnset:  []bool{false, false},
+		},
+		{
+			name:    "invalid entries are reported by position",
+			mapping: DefaultSupplierFeedMapping,
+			entries: []map[string]interface{}{
+				{"description": "no name"},
+				{"name": "sync"},
+				{"name": "Acme", "description": 42},
+				{"name": "Acme", "specs": []interface{}{"red"}},
+				{"name": "Acme", "specs": map[string]interface{}{"size.width": 3}},
+				{"name": "Acme", "specs": map[string]interface{}{"$where": 1}},
+				{"name": "Acme", "pdfUrl": "ftp://supplier.example/a.pdf"},
+				{"name": "Acme", "pdfUrl": "https://"},
+				{"name": "Valid"},
+			},
+			response: bson.D{{Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			wantReport: ImportReport{TotalRows: 9, Updated: 1, Failed: 8, Batches: 1, Errors: []ImportRowError{
+				{Row: 1, Name: "", Error: "name is required"},
+				{Row: 2, Name: "sync", Error: "name is reserved"},
+				{Row: 3, Name: "Acme", Error: "description must be a string"},
+				{Row: 4, Name: "Acme", Error: "specs must be an object"},
+				{Row: 5, Name: "Acme", Error: "invalid spec key 'size.width'"},
+				{Row: 6, Name: "Acme", Error: "invalid spec key '$where'"},
+				{Row: 7, Name: "Acme", Error: "pdfUrl must be an http(s) URL"},
+				{Row: 8, Name: "Acme", Error: "pdfUrl must be an http(s) URL"},
+			}},
+			wantSets:  []bson.M{{}},
+			wantUnset: []bool{false},
+		},
+		{
+			name:       "nothing valid writes nothing",
+			mapping:    DefaultSupplierFeedMapping,
+			entries:    []map[string]interface{}{{"name": ""}},
+			wantReport: ImportReport{TotalRows: 1, Failed: 1, Errors: []ImportRowError{{Row: 1, Error: "name is required"}}},
+		},
+		{
+			name:    "write errors name their entry",
+			mapping: DefaultSupplierFeedMapping,
+			entries: []map[string]interface{}{{"name": "bad"}, {"name": "Acme"}, {"name": "Acme "}},
+			response: bson.D{{Key: "n", Value: 1}, {Key: "nModified", Value: 1}, {Key: "writeErrors", Value: bson.A{
+				bson.D{{Key: "index", Value: 2}, {Key: "code", Value: 11000}, {Key: "errmsg", Value: "E11000 duplicate key"}},
+			}}},
+			wantReport: ImportReport{TotalRows: 3, Updated: 1, Unchanged: 0, Failed: 1, Batches: 1, Errors: []ImportRowError{
+				{Row: 3, Name: "Acme", Error: "duplicate brand name"},
+			}},
+			wantSets:  []bson.M{{}, {}, {}},
+			wantUnset: []bool{false, false, false},
+		},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			if tt.response != nil {
+				mt.AddMockResponses(mtest.CreateSuccessResponse(tt.response...))
+			}
+			report, pdfs, err := ApplySupplierFeed(context.Background(), mt.Coll, tt.entries, tt.mapping)
+			if err != nil {
+				mt.Fatal(err)
+			}
+			report.DurationMs, report.RowsPerSecond = 0, 0
+			if !reflect.DeepEqual(*report, tt.wantReport) {
+				mt.Errorf("report %+v, want %+v", *report, tt.wantReport)
+			}
+			if !reflect.DeepEqual(pdfs, tt.wantPDFs) {
+				mt.Errorf("PDFs %+v, want %+v", pdfs, tt.wantPDFs)
+			}
+
+			updates := feedUpdates(mt)
+			if len(updates) != len(tt.wantSets) {
+				mt.Fatalf("%d upserts, want %d", len(updates), len(tt.wantSets))
+			}
+			for i, update := range updates {
+				set, _ := update["$set"].(bson.M)
+				for key, want := range tt.wantSets[i] {
+					if got := set[key]; got != want {
+						mt.Errorf("entry %d sets %s = %#v, want %#v", i, key, got, want)
+					}
+				}
+				if i < len(tt.wantNoSet) {
+					for _, key := range tt.wantNoSet[i] {
+						if _, ok := set[key]; ok {
+							mt.Errorf("entry %d sets %s", i, key)
+						}
+					}
+				}
+				if _, ok := set["updatedAt"]; !ok {
+					mt.Errorf("entry %d doesn't set updatedAt", i)
+				}
+				if onInsert, _ := update["$setOnInsert"].(bson.M); onInsert["name"] == nil || onInsert["createdAt"] == nil {
+					mt.Errorf("entry %d inserts without name and createdAt: %v", i, update["$setOnInsert"])
+				}
+				unset, _ := update["$unset"].(bson.M)
+				if _, dropped := unset["extraction"]; dropped != tt.wantUnset[i] {
+					mt.Errorf("entry %d drops the extraction: %v, want %v", i, dropped, tt.wantUnset[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/database"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidSyncCursor is returned when a sync cursor can't be decoded.
+var ErrInvalidSyncCursor = errors.New("invalid sync cursor")
+
+// Change types reported by the sync endpoint.
+const (
+	SyncChangeUpsert = "upsert"
+	SyncChangeDelete = "delete"
+)
+
+// SyncChange is a single created/updated brand or a deletion tombstone.
+type SyncChange struct {
+	Type      string                 `json:"type"`
+	Brand     *models.Brand          `json:"brand,omitempty"`
+	Tombstone *models.BrandTombstone `json:"tombstone,omitempty"`
+}
+
+// SyncResult is one page of a differential sync.
+type SyncResult struct {
+	Changes    []SyncChange `json:"changes"`
+	NextCursor string       `json:"nextCursor"`
+	HasMore    bool         `json:"hasMore"` // Call again with NextCursor until false
+	Full       bool         `json:"full"`    // The client must replace its local data instead of applying a delta
+}
+
+// syncCursor is the decoded form of the opaque cursor token. Positions are (timestamp, ObjectID)
+// pairs so documents sharing a millisecond are still strictly ordered.
+type syncCursor struct {
+	TS    int64  `json:"t"`           // Unix milliseconds of the last delivered change
+	ID    string `json:"i,omitempty"` // Hex ObjectID tie-breaker of the last delivered change
+	Full  bool   `json:"f,omitempty"` // A full resync is in progress
+	Start int64  `json:"s,omitempty"` // When the full resync started (Unix ms)
+}
+
+func encodeSyncCursor(cur syncCursor) string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeSyncCursor(token string) (syncCursor, error) {
+	var cur syncCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cur, ErrInvalidSyncCursor
+	}
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return cur, ErrInvalidSyncCursor
+	}
+	if cur.ID != "" {
+		if _, err := primitive.ObjectIDFromHex(cur.ID); err != nil {
+			return cur, ErrInvalidSyncCursor
+		}
+	}
+	return cur, nil
+}
+
+// afterPosition builds a filter for documents strictly after (ts, id) in (field, _id) order.
+func afterPosition(field string, cur syncCursor) bson.M {
+	ts := time.UnixMilli(cur.TS)
+	id := primitive.NilObjectID
+	if cur.ID != "" {
+		id, _ = primitive.ObjectIDFromHex(cur.ID)
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{"$gt": ts}},
+		bson.M{field: ts, "_id": bson.M{"$gt": id}},
+	}}
+}
+
+// SyncBrands returns the brand changes after the given cursor (empty = from the beginning).
+//
+// Brands are ordered by (updatedAt, _id) and deletions by (deletedAt, _id) and the two streams are
+// merged, so pages are deterministic. When the cursor predates the tombstone retention window,
+// deletions may have been purged and the result switches to a full resync (Full=true) that pages
+// through every brand; once finished, the returned cursor continues from when that resync began.
+func SyncBrands(ctx context.Context, coll *mongo.Collection, token string, limit int) (*SyncResult, error) {
+	cur := syncCursor{}
+	if token != "" {
+		var err error
+		if cur, err = decodeSyncCursor(token); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if !cur.Full && (token == "" || time.UnixMilli(cur.TS).Before(now.Add(-database.TombstoneRetention()))) {
+		// Either a first sync or a cursor too old to honor: start a full pass
+		cur = syncCursor{Full: true, Start: now.UnixMilli()}
+	}
+
+	brands, err := findBrandsAfter(ctx, coll, cur, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	var tombstones []models.BrandTombstone
+	if !cur.Full {
+		// Deletions only matter for deltas; a full resync replaces the client's data anyway
+		tombstones, err = findTombstonesAfter(ctx, coll.Database().Collection(database.TombstoneCollection), cur, limit+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &SyncResult{Full: cur.Full}
+	var next syncCursor
+	result.Changes, next, result.HasMore = mergeSyncChanges(brands, tombstones, limit, cur)
+	if cur.Full && !result.HasMore {
+		// Full pass complete: continue with deltas from when it started (changes made during
+		// the pass may be delivered again, which is harmless for upserts)
+		next = syncCursor{TS: cur.Start}
+	}
+	result.NextCursor = encodeSyncCursor(next)
+	return result, nil
+}
+
+// mergeSyncChanges merges brands in (updatedAt, _id) order and tombstones in (deletedAt, _id)
+// order into at most limit changes. It returns them with the cursor after the last one (cur when
+// there are none) and whether any were left over.
+func mergeSyncChanges(brands []models.Brand, tombstones []models.BrandTombstone, limit int, cur syncCursor) ([]SyncChange, syncCursor, bool) {
+	changes := make([]SyncChange, 0, limit)
+	next := cur
+	bi, ti := 0, 0
+	for len(changes) < limit && (bi < len(brands) || ti < len(tombstones)) {
+		takeBrand := ti >= len(tombstones)
+		if bi < len(brands) && ti < len(tombstones) {
+			b, t := brands[bi], tombstones[ti]
+			takeBrand = b.UpdatedAt.Before(t.DeletedAt) ||
+				(b.UpdatedAt.Equal(t.DeletedAt) && b.ID.Hex() < t.ID.Hex())
+		}
+		if takeBrand {
+			brand := brands[bi]
+			bi++
+			changes = append(changes, SyncChange{Type: SyncChangeUpsert, Brand: &brand})
+			next.TS, next.ID = brand.UpdatedAt.UnixMilli(), brand.ID.Hex()
+		} else {
+			tombstone := tombstones[ti]
+			ti++
+			changes = append(changes, SyncChange{Type: SyncChangeDelete, Tombstone: &tombstone})
+			next.TS, next.ID = tombstone.DeletedAt.UnixMilli(), tombstone.ID.Hex()
+		}
+	}
+	return changes, next, bi < len(brands) || ti < len(tombstones)
+}
+
+func findBrandsAfter(ctx context.Context, coll *mongo.Collection, cur syncCursor, limit int) ([]models.Brand, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := coll.Find(ctx, afterPosition("updatedAt", cur), opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding changed brands: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	brands := make([]models.Brand, 0, limit)
+	if err := cursor.All(ctx, &brands); err != nil {
+		return nil, fmt.Errorf("decoding changed brands: %w", err)
+	}
+	return brands, nil
+}
+
+func findTombstonesAfter(ctx context.Context, coll *mongo.Collection, cur syncCursor, limit int) ([]models.BrandTombstone, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deletedAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := coll.Find(ctx, afterPosition("deletedAt", cur), opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding tombstones: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	tombstones := make([]models.BrandTombstone, 0, limit)
+	if err := cursor.All(ctx, &tombstones); err != nil {
+		return nil, fmt.Errorf("decoding tombstones: %w", err)
+	}
+	return tombstones, nil
+}
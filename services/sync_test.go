@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var syncEpoch = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func testID(n byte) primitive.ObjectID {
+	return primitive.ObjectID{0x65, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, n}
+}
+
+func testBrand(ms int64, id byte) models.Brand {
+	return models.Brand{ID: testID(id), Name: fmt.Sprintf("b%d", id), UpdatedAt: syncEpoch.Add(time.Duration(ms) * time.Millisecond)}
+}
+
+func testTombstone(ms int64, id byte) models.BrandTombstone {
+	return models.BrandTombstone{ID: testID(id), Name: fmt.Sprintf("t%d", id), DeletedAt: syncEpoch.Add(time.Duration(ms) * time.Millisecond)}
+}
+
+// changeNames lists the merged changes by the names of their brands and tombstones.
+func changeNames(changes []SyncChange) string {
+	names := make([]string, len(changes))
+	for i, change := range changes {
+		if change.Type == SyncChangeUpsert {
+			names[i] = change.Brand.Name
+		} else {
+			names[i] = change.Tombstone.Name
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestMergeSyncChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		brands      []models.Brand
+		tombstones  []models.BrandTombstone
+		limit       int
+		want        string
+		wantHasMore bool
+	}{
+		{"nothing", nil, nil, 10, "", false},
+		{"brands only", []models.Brand{testBrand(1, 1), testBrand(2, 2)}, nil, 10, "b1 b2", false},
+		{"tombstones only", nil, []models.BrandTombstone{testTombstone(1, 1)}, 10, "t1", false},
+		{"interleaved", []models.Brand{testBrand(1, 1), testBrand(3, 3)}, []models.BrandTombstone{testTombstone(2, 2), testTombstone(4, 4)}, 10, "b1 t2 b3 t4", false},
+		{"same millisecond, tombstone first by ID", []models.Brand{testBrand(5, 9)}, []models.BrandTombstone{testTombstone(5, 3)}, 10, "t3 b9", false},
+		{"same millisecond, brand first by ID", []models.Brand{testBrand(5, 2)}, []models.BrandTombstone{testTombstone(5, 3)}, 10, "b2 t3", false},
+		{"cut at the limit", []models.Brand{testBrand(1, 1), testBrand(3, 3)}, []models.BrandTombstone{testTombstone(2, 2)}, 2, "b1 t2", true},
+		{"limit reached exactly", []models.Brand{testBrand(1, 1)}, []models.BrandTombstone{testTombstone(2, 2)}, 2, "b1 t2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur := syncCursor{TS: 42, ID: testID(0).Hex()}
+			changes, next, hasMore := mergeSyncChanges(tt.brands, tt.tombstones, tt.limit, cur)
+			if got := changeNames(changes); got != tt.want || hasMore != tt.wantHasMore {
+				t.Fatalf("got %q, hasMore %v; want %q, %v", got, hasMore, tt.want, tt.wantHasMore)
+			}
+			if len(changes) == 0 {
+				if next != cur {
+					t.Errorf("cursor moved to %+v without changes", next)
+				}
+				return
+			}
+			last := changes[len(changes)-1]
+			var wantTS int64
+			var wantID string
+			if last.Brand != nil {
+				wantTS, wantID = last.Brand.UpdatedAt.UnixMilli(), last.Brand.ID.Hex()
+			} else {
+				wantTS, wantID = last.Tombstone.DeletedAt.UnixMilli(), last.Tombstone.ID.Hex()
+			}
+			if next.TS != wantTS || next.ID != wantID {
+				t.Errorf("cursor at %d/%s, want the last change %d/%s", next.TS, next.ID, wantTS, wantID)
+			}
+		})
+	}
+}
+
+// Paging through brands and tombstones the way SyncBrands does (each stream read after the
+// cursor, one more than the limit) delivers every change exactly once, in order, for any limit.
+func TestMergeSyncChangesPaging(t *testing.T) {
+	brands := []models.Brand{testBrand(1, 1), testBrand(1, 4), testBrand(2, 2), testBrand(5, 7), testBrand(5, 8), testBrand(9, 10)}
+	tombstones := []models.BrandTombstone{testTombstone(1, 3), testTombstone(2, 5), testTombstone(5, 6), testTombstone(7, 9), testTombstone(9, 11)}
+	all, _, _ := mergeSyncChanges(brands, tombstones, len(brands)+len(tombstones), syncCursor{})
+	want := changeNames(all)
+
+	for limit := 1; limit <= len(all)+1; limit++ {
+		var pages []string
+		cur := syncCursor{TS: syncEpoch.UnixMilli() - 1}
+		for page := 0; ; page++ {
+			if page > len(all) {
+				t.Fatalf("limit %d: paging doesn't end", limit)
+			}
+			changes, next, hasMore := mergeSyncChanges(brandsAfter(brands, cur, limit+1), tombstonesAfter(tombstones, cur, limit+1), limit, cur)
+			pages = append(pages, changeNames(changes))
+			cur = next
+			if !hasMore {
+				break
+			}
+		}
+		if got := strings.Join(pages, " "); strings.Join(strings.Fields(got), " ") != want {
+			t.Errorf("limit %d: pages deliver %q, want %q", limit, got, want)
+		}
+	}
+}
+
+// brandsAfter and tombstonesAfter stand in for the queries built by afterPosition.
+func brandsAfter(brands []models.Brand, cur syncCursor, limit int) []models.Brand {
+	var out []models.Brand
+	for _, b := range brands {
+		if positionAfter(b.UpdatedAt, b.ID, cur) {
+			out = append(out, b)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return lessPosition(out[i].UpdatedAt, out[i].ID, out[j].UpdatedAt, out[j].ID) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func tombstonesAfter(tombstones []models.BrandTombstone, cur syncCursor, limit int) []models.BrandTombstone {
+	var out []models.BrandTombstone
+	for _, ts := range tombstones {
+		if positionAfter(ts.DeletedAt, ts.ID, cur) {
+			out = append(out, ts)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return lessPosition(out[i].DeletedAt, out[i].ID, out[j].DeletedAt, out[j].ID) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func positionAfter(at time.Time, id primitive.ObjectID, cur syncCursor) bool {
+	curID := primitive.NilObjectID
+	if cur.ID != "" {
+		curID, _ = primitive.ObjectIDFromHex(cur.ID)
+	}
+	return lessPosition(time.UnixMilli(cur.TS), curID, at, id)
+}
+
+func lessPosition(at1 time.Time, id1 primitive.ObjectID, at2 time.Time, id2 primitive.ObjectID) bool {
+	return at1.Before(at2) || (at1.Equal(at2) && bytes.Compare(id1[:], id2[:]) < 0)
+}
+
+func TestSyncCursorRoundTrip(t *testing.T) {
+	cursors := []syncCursor{
+		{},
+		{TS: syncEpoch.UnixMilli(), ID: testID(7).Hex()},
+		{Full: true, Start: syncEpoch.UnixMilli()},
+		{TS: syncEpoch.UnixMilli(), ID: testID(1).Hex(), Full: true, Start: syncEpoch.UnixMilli() - 5},
+	}
+	for _, cur := range cursors {
+		got, err := decodeSyncCursor(encodeSyncCursor(cur))
+		if err != nil || got != cur {
+			t.Errorf("round trip of %+v = %+v, %v", cur, got, err)
+		}
+	}
+}
+
+func TestDecodeSyncCursorRejects(t *testing.T) {
+	for _, token := range []string{
+		"%%%",
+		encodeSyncCursorJSON(`not json`),
+		encodeSyncCursorJSON(`{"t":"yesterday"}`),
+		encodeSyncCursorJSON(`{"t":1,"i":"not-an-object-id"}`),
+	} {
+		if _, err := decodeSyncCursor(token); !errors.Is(err, ErrInvalidSyncCursor) {
+			t.Errorf("decodeSyncCursor(%q) error = %v, want ErrInvalidSyncCursor", token, err)
+		}
+	}
+}
+
+func encodeSyncCursorJSON(raw string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
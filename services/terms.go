@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/apperrors"
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SetBrandTerms replaces a brand's terms and returns them. The version is bumped (and updatedAt
+// set) only when the text or attachment actually changes; the comparison and the bump are one
+// pipeline update, so concurrent changes can't reuse a version. Every version is kept in history.
+func SetBrandTerms(ctx context.Context, coll, history *mongo.Collection, brandName string, payload models.BrandTermsPayload) (*models.BrandTerms, error) {
+	pipeline := termsUpdate(payload, models.Now())
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"terms": 1})
+	var brand models.Brand
+	if err := coll.FindOneAndUpdate(ctx, bson.M{"name": brandName}, pipeline, opts).Decode(&brand); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	if err := recordTermsVersion(ctx, history, brand.ID, brand.Terms); err != nil {
+		return nil, err
+	}
+	return brand.Terms, nil
+}
+
+// termsUpdate is the pipeline update setting the terms from payload at now: only if the text or
+// attachment differs from the stored ones are the terms replaced, with the next version, and the
+// brand's updatedAt set.
+func termsUpdate(payload models.BrandTermsPayload, now time.Time) mongo.Pipeline {
+	changed := bson.M{"$or": bson.A{
+		bson.M{"$ne": bson.A{"$terms.text", literal(payload.Text)}},
+		bson.M{"$ne": bson.A{"$terms.attachmentUrl", literal(payload.AttachmentURL)}},
+	}}
+	terms := bson.M{
+		"text":          literal(payload.Text),
+		"attachmentUrl": literal(payload.AttachmentURL),
+		"version":       bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$terms.version", 0}}, 1}},
+		"updatedAt":     now,
+	}
+	return mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"terms":     bson.M{"$cond": bson.A{changed, terms, "$terms"}},
+		"updatedAt": bson.M{"$cond": bson.A{changed, now, "$updatedAt"}},
+	}}}}
+}
+
+// recordTermsVersion adds terms to the history unless their version is already there; a recorded
+// version is never changed. Recording the current version on every call, changed or not, also
+// fills in a version whose recording failed after the bump once the same terms are sent again.
+func recordTermsVersion(ctx context.Context, history *mongo.Collection, brandID primitive.ObjectID, terms *models.BrandTerms) error {
+	version := models.BrandTermsVersion{
+		BrandID:       brandID,
+		Version:       terms.Version,
+		Text:          terms.Text,
+		AttachmentURL: terms.AttachmentURL,
+		PublishedAt:   terms.UpdatedAt,
+	}
+	filter := bson.M{"brandId": brandID, "version": terms.Version}
+	_, err := history.UpdateOne(ctx, filter, bson.M{"$setOnInsert": version}, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil // A concurrent call recorded it first
+	}
+	return apperrors.FromDB(err)
+}
+
+// ListTermsVersions returns every recorded version of a brand's terms, newest first.
+func ListTermsVersions(ctx context.Context, history *mongo.Collection, brandID primitive.ObjectID) ([]models.BrandTermsVersion, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cursor, err := history.Find(ctx, bson.M{"brandId": brandID}, opts)
+	if err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	versions := []models.BrandTermsVersion{}
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, apperrors.FromDB(err)
+	}
+	return versions, nil
+}
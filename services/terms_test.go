@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The terms, their version and the brand's updatedAt change together, under one condition that
+// compares both the text and the attachment with the payload.
+func TestTermsUpdate(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	payload := models.BrandTermsPayload{Text: "Net 30", AttachmentURL: "https://acme.example/terms.pdf"}
+	set := termsUpdate(payload, now)[0][0].Value.(bson.M)
+
+	changed := bson.M{"$or": bson.A{
+		bson.M{"$ne": bson.A{"$terms.text", bson.M{"$literal": payload.Text}}},
+		bson.M{"$ne": bson.A{"$terms.attachmentUrl", bson.M{"$literal": payload.AttachmentURL}}},
+	}}
+	terms := set["terms"].(bson.M)["$cond"].(bson.A)
+	updatedAt := set["updatedAt"].(bson.M)["$cond"].(bson.A)
+	if !reflect.DeepEqual(terms[0], changed) || !reflect.DeepEqual(updatedAt[0], changed) {
+		t.Errorf("conditions %v and %v, want %v", terms[0], updatedAt[0], changed)
+	}
+	if terms[2] != "$terms" || updatedAt[2] != "$updatedAt" {
+		t.Errorf("unchanged terms become %v and updatedAt %v, want both kept", terms[2], updatedAt[2])
+	}
+	next := terms[1].(bson.M)
+	wantVersion := bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$terms.version", 0}}, 1}}
+	if !reflect.DeepEqual(next["version"], wantVersion) || next["updatedAt"] != now || updatedAt[1] != now {
+		t.Errorf("changed terms %v with updatedAt %v, want the next version at %v", next, updatedAt[1], now)
+	}
+}
+
+// Every call records the returned version in the history, inserting only, so a recorded version
+// keeps the text it was published with.
+func TestSetBrandTermsRecordsVersion(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("record", func(mt *mtest.T) {
+		brandID := primitive.NewObjectID()
+		published := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+				{Key: "_id", Value: brandID},
+				{Key: "terms", Value: bson.D{{Key: "text", Value: "Net 30"}, {Key: "version", Value: 3}, {Key: "updatedAt", Value: published}}},
+			}}},
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		terms, err := SetBrandTerms(context.Background(), mt.Coll, mt.Coll, "Acme", models.BrandTermsPayload{Text: "Net 30"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if terms.Version != 3 {
+			t.Errorf("version %d, want 3", terms.Version)
+		}
+
+		mt.GetStartedEvent() // The findAndModify
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		filter := update.Lookup("q").Document()
+		if id, _ := filter.Lookup("brandId").ObjectIDOK(); id != brandID || filter.Lookup("version").AsInt64() != 3 {
+			t.Errorf("history filter %s, want brand %s version 3", filter, brandID.Hex())
+		}
+		if upsert, _ := update.Lookup("upsert").BooleanOK(); !upsert {
+			t.Errorf("history update %s doesn't upsert", update)
+		}
+		inserted, err := update.Lookup("u").Document().LookupErr("$setOnInsert")
+		if err != nil || inserted.Document().Lookup("text").StringValue() != "Net 30" {
+			t.Errorf("history update %s doesn't only insert the text", update)
+		}
+	})
+}
+
+// Against a real server: the version goes up only when the text or attachment changes, and every
+// version stays in the history. Set MONGODB_TEST_URI to run it.
+func TestSetBrandTermsVersions(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+	db := client.Database("orderform_test")
+	suffix := primitive.NewObjectID().Hex()
+	coll, history := db.Collection("brands_terms_"+suffix), db.Collection("brand_terms_"+suffix)
+	defer coll.Drop(ctx)
+	defer history.Drop(ctx)
+	if _, err := coll.InsertOne(ctx, bson.M{"name": "Acme"}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		payload     models.BrandTermsPayload
+		wantVersion int
+	}{
+		{models.BrandTermsPayload{Text: "Net 30"}, 1},
+		{models.BrandTermsPayload{Text: "Net 30"}, 1}, // Same again: no bump
+		{models.BrandTermsPayload{Text: "Net 60"}, 2},
+		{models.BrandTermsPayload{Text: "Net 60", AttachmentURL: "https://acme.example/terms.pdf"}, 3},
+		{models.BrandTermsPayload{Text: "Net 60", AttachmentURL: "https://acme.example/terms.pdf"}, 3},
+	}
+	for i, step := range steps {
+		terms, err := SetBrandTerms(ctx, coll, history, "Acme", step.payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if terms.Version != step.wantVersion {
+			t.Errorf("step %d: version %d, want %d", i, terms.Version, step.wantVersion)
+		}
+	}
+
+	var brand models.Brand
+	if err := coll.FindOne(ctx, bson.M{"name": "Acme"}).Decode(&brand); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := ListTermsVersions(ctx, history, brand.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, v := range versions {
+		texts = append(texts, v.Text+"|"+v.AttachmentURL)
+	}
+	want := []string{"Net 60|https://acme.example/terms.pdf", "Net 60|", "Net 30|"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("history %q, want %q", texts, want)
+	}
+}
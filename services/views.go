@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gautam3767/Order_form_Details_Backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ViewDayLayout is the format of the day keys in brand_views and in the views API.
+const ViewDayLayout = "2006-01-02"
+
+// Defaults for view counting.
+const (
+	defaultViewFlushInterval = 5 * time.Second
+	viewFlushTimeout         = 10 * time.Second
+)
+
+// viewKey identifies one counter document.
+type viewKey struct {
+	brand string
+	day   string
+}
+
+// viewCounter buffers view increments in memory and writes them in batches, so reading a
+// brand never costs a database write.
+type viewCounter struct {
+	mu      sync.Mutex
+	coll    *mongo.Collection
+	pending map[viewKey]int64
+}
+
+var views *viewCounter
+
+// ViewTrackingEnabled reports whether brand views are counted (BRAND_VIEW_TRACKING, default true).
+// Privacy-sensitive deployments can switch it off; already stored counters stay readable.
+func ViewTrackingEnabled() bool {
+	return !strings.EqualFold(os.Getenv("BRAND_VIEW_TRACKING"), "false")
+}
+
+// ViewFlushInterval returns how often buffered views are written (BRAND_VIEW_FLUSH_SECONDS, default 5).
+func ViewFlushInterval() time.Duration {
+	return time.Duration(envPositiveInt("BRAND_VIEW_FLUSH_SECONDS", int(defaultViewFlushInterval/time.Second))) * time.Second
+}
+
+// StartViewCounter begins buffering views and flushing them to coll on every interval.
+// It does nothing when view tracking is disabled.
+func StartViewCounter(coll *mongo.Collection, interval time.Duration) {
+	if !ViewTrackingEnabled() {
+		log.Println("Brand view tracking disabled (BRAND_VIEW_TRACKING=false)")
+		return
+	}
+	if interval <= 0 {
+		interval = defaultViewFlushInterval
+	}
+	vc := &viewCounter{
+		coll:    coll,
+		pending: make(map[viewKey]int64),
+	}
+	views = vc
+	// Runs until ShutdownWorkers, which waits for the final flush
+	goWorker("view-counter", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				vc.flush()
+			case <-ctx.Done():
+				vc.flush() // Final flush so buffered views aren't lost on shutdown
+				return
+			}
+		}
+	})
+}
+
+// RecordBrandView counts one view of a brand for today (UTC). Safe for concurrent use.
+func RecordBrandView(name string) {
+	if views == nil {
+		return
+	}
+	key := viewKey{brand: name, day: time.Now().UTC().Format(ViewDayLayout)}
+	views.mu.Lock()
+	views.pending[key]++
+	views.mu.Unlock()
+}
+
+// flush writes the pending increments as one unordered bulk upsert. On failure the counts are
+// put back so they are retried on the next flush.
+func (vc *viewCounter) flush() {
+	vc.mu.Lock()
+	batch := vc.pending
+	vc.pending = make(map[viewKey]int64)
+	vc.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(batch))
+	for key, count := range batch {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"brand": key.brand, "day": key.day}).
+			SetUpdate(bson.M{"$inc": bson.M{"count": count}}).
+			SetUpsert(true))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), viewFlushTimeout)
+	defer cancel()
+	if _, err := vc.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		log.Printf("Error flushing %d brand view counter(s), will retry: %v", len(batch), err)
+		vc.mu.Lock()
+		for key, count := range batch {
+			vc.pending[key] += count
+		}
+		vc.mu.Unlock()
+	}
+}
+
+// BrandViewSeries returns one entry per day from..to (inclusive, YYYY-MM-DD), with zero for
+// days without views. Views still buffered in memory are not included.
+func BrandViewSeries(ctx context.Context, coll *mongo.Collection, name string, from, to time.Time) ([]models.BrandViewDay, error) {
+	filter := bson.M{
+		"brand": name,
+		"day":   bson.M{"$gte": from.Format(ViewDayLayout), "$lte": to.Format(ViewDayLayout)},
+	}
+	cursor, err := coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 0, "day": 1, "count": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var stored []models.BrandViewDay
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(stored))
+	for _, day := range stored {
+		counts[day.Day] = day.Count
+	}
+
+	series := make([]models.BrandViewDay, 0)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(ViewDayLayout)
+		series = append(series, models.BrandViewDay{Day: key, Count: counts[key]})
+	}
+	return series, nil
+}
+
+// TopViewedBrands returns the limit most-viewed brands between from and to (inclusive).
+func TopViewedBrands(ctx context.Context, coll *mongo.Collection, from, to time.Time, limit int) ([]models.BrandViewTotal, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"day": bson.M{"$gte": from.Format(ViewDayLayout), "$lte": to.Format(ViewDayLayout)}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$brand", "count": bson.M{"$sum": "$count"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	top := make([]models.BrandViewTotal, 0, limit)
+	if err := cursor.All(ctx, &top); err != nil {
+		return nil, err
+	}
+	return top, nil
+}
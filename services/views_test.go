@@ -0,0 +1,78 @@
+package services
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Views recorded from many goroutines, some while a flush fails, are all written by the next
+// successful flush. Run with -race.
+func TestRecordBrandViewConcurrentFlush(t *testing.T) {
+	const goroutines, perGoroutine = 32, 200
+	brands := []string{"Acme", "Globex", "Initech"}
+	record := func() *sync.WaitGroup {
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < perGoroutine; i++ {
+					RecordBrandView(brands[(g+i)%len(brands)])
+				}
+			}(g)
+		}
+		return &wg
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("flush", func(mt *mtest.T) {
+		vc := &viewCounter{coll: mt.Coll, pending: make(map[viewKey]int64)}
+		views = vc
+		defer func() { views = nil }()
+
+		record().Wait()
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Name: "Unauthorized", Message: "not allowed"}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: len(brands)}),
+		)
+		second := record()
+		vc.flush() // Fails while the second round is recorded; its counts are put back
+		second.Wait()
+		vc.flush()
+
+		var written bson.Raw
+		updates := 0
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			if event.CommandName == "update" {
+				updates++
+				written = event.Command
+			}
+		}
+		if updates != 2 {
+			mt.Fatalf("%d flushes reached the database, want 2", updates)
+		}
+		totals := map[string]int64{}
+		values, _ := written.Lookup("updates").Array().Values()
+		for _, value := range values {
+			statement := value.Document()
+			brand := statement.Lookup("q", "brand").StringValue()
+			totals[brand] += statement.Lookup("u", "$inc", "count").AsInt64()
+		}
+		want := map[string]int64{}
+		for g := 0; g < goroutines; g++ {
+			for i := 0; i < perGoroutine; i++ {
+				want[brands[(g+i)%len(brands)]] += 2 // Once per round
+			}
+		}
+		if !reflect.DeepEqual(totals, want) {
+			mt.Errorf("flushed %v, want %v", totals, want)
+		}
+		if len(vc.pending) != 0 {
+			mt.Errorf("%d counters still pending after a successful flush", len(vc.pending))
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWorkerDrain is how long workers get to finish their current item on shutdown
+// (WORKER_DRAIN_SECONDS).
+const defaultWorkerDrain = 10 * time.Second
+
+// workerManager runs every background goroutine of the service under one shutdown context.
+// Long-running loops (view flusher, scanners, queue workers) and one-off tasks (a duplicate
+// scan) are both registered, so shutdown knows what is still running.
+type workerManager struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	draining bool
+	running  map[*workerUnit]bool
+}
+
+type workerUnit struct {
+	name string
+	done chan struct{}
+}
+
+var workers = func() *workerManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workerManager{ctx: ctx, cancel: cancel, running: make(map[*workerUnit]bool)}
+}()
+
+// WorkerDrainTimeout returns the shutdown drain window (WORKER_DRAIN_SECONDS, default 10).
+func WorkerDrainTimeout() time.Duration {
+	return time.Duration(envPositiveInt("WORKER_DRAIN_SECONDS", int(defaultWorkerDrain/time.Second))) * time.Second
+}
+
+// goWorker runs fn in the background. fn must return soon after ctx is cancelled, finishing (or
+// giving up) the item it is working on. Once draining has started no new work is accepted and
+// goWorker returns false without running fn.
+func goWorker(name string, fn func(ctx context.Context)) bool {
+	workers.mu.Lock()
+	if workers.draining {
+		workers.mu.Unlock()
+		log.Printf("Warning: Not starting '%s', background workers are shutting down", name)
+		return false
+	}
+	unit := &workerUnit{name: name, done: make(chan struct{})}
+	workers.running[unit] = true
+	workers.mu.Unlock()
+
+	go func() {
+		defer func() {
+			workers.mu.Lock()
+			delete(workers.running, unit)
+			workers.mu.Unlock()
+			close(unit.done)
+		}()
+		fn(workers.ctx)
+	}()
+	return true
+}
+
+// GoWorker runs fn as a background worker like goWorker, for packages that depend on services
+// (e.g. deletion recovery).
+func GoWorker(name string, fn func(ctx context.Context)) bool {
+	return goWorker(name, fn)
+}
+
+// workersDraining reports whether shutdown has started, for components that reject new work.
+func workersDraining() bool {
+	workers.mu.Lock()
+	defer workers.mu.Unlock()
+	return workers.draining
+}
+
+// ShutdownWorkers stops accepting background work, signals every worker to stop and waits up to
+// timeout for them. It logs which workers exited cleanly and which were cut off.
+func ShutdownWorkers(timeout time.Duration) {
+	workers.mu.Lock()
+	workers.draining = true
+	units := make([]*workerUnit, 0, len(workers.running))
+	for unit := range workers.running {
+		units = append(units, unit)
+	}
+	workers.mu.Unlock()
+
+	log.Printf("Draining %d background worker(s), waiting up to %v", len(units), timeout)
+	workers.cancel()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	var clean, cutOff []string
+	timedOut := false
+	for _, unit := range units {
+		if !timedOut {
+			select {
+			case <-unit.done:
+			case <-deadline.C:
+				timedOut = true // From here on only collect who is already done
+			}
+		}
+		select {
+		case <-unit.done:
+			clean = append(clean, unit.name)
+		default:
+			cutOff = append(cutOff, unit.name)
+		}
+	}
+	if len(cutOff) > 0 {
+		log.Printf("Warning: Background workers cut off after %v: %s (exited cleanly: %s)", timeout, strings.Join(cutOff, ", "), strings.Join(clean, ", "))
+		return
+	}
+	log.Printf("All background workers exited cleanly: %s", strings.Join(clean, ", "))
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Workers that stop on cancellation exit within the drain window; one that ignores it is cut
+// off and still running afterwards. No work is accepted once draining has started.
+func TestShutdownWorkers(t *testing.T) {
+	saved := workers
+	t.Cleanup(func() { workers = saved })
+	ctx, cancel := context.WithCancel(context.Background())
+	workers = &workerManager{ctx: ctx, cancel: cancel, running: make(map[*workerUnit]bool)}
+
+	release := make(chan struct{})
+	tests := []struct {
+		name        string
+		work        func(ctx context.Context)
+		wantRunning bool // Still running after the drain window
+	}{
+		{"loop", func(ctx context.Context) { <-ctx.Done() }, false},
+		{"finishes its item", func(ctx context.Context) { <-ctx.Done(); time.Sleep(10 * time.Millisecond) }, false},
+		{"ignores cancellation", func(context.Context) { <-release }, true},
+	}
+	for _, tt := range tests {
+		if !goWorker(tt.name, tt.work) {
+			t.Fatalf("goWorker(%s) refused before shutdown", tt.name)
+		}
+	}
+
+	ShutdownWorkers(200 * time.Millisecond)
+	running := map[string]bool{}
+	var cutOff []*workerUnit
+	workers.mu.Lock()
+	for unit := range workers.running {
+		running[unit.name] = true
+		cutOff = append(cutOff, unit)
+	}
+	workers.mu.Unlock()
+	defer func() {
+		// Let the cut-off worker finish before the manager is restored
+		close(release)
+		for _, unit := range cutOff {
+			<-unit.done
+		}
+	}()
+	for _, tt := range tests {
+		if running[tt.name] != tt.wantRunning {
+			t.Errorf("%s: running after shutdown = %v, want %v", tt.name, running[tt.name], tt.wantRunning)
+		}
+	}
+
+	if !workersDraining() {
+		t.Error("not draining after shutdown")
+	}
+	if goWorker("late", func(context.Context) { t.Error("late worker ran") }) {
+		t.Error("goWorker accepted work while draining")
+	}
+}
@@ -0,0 +1,52 @@
+package uploadpolicy
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// slotPoll is how often AcquireSlot looks for a free slot while all are taken.
+const slotPoll = 250 * time.Millisecond
+
+// active counts the PDF extractions in progress, of uploads and supplier PDFs alike: they compete
+// for the same CPU and extractor processes, so together they are limited by the policy's
+// concurrency.
+var active atomic.Int64
+
+// TryAcquireSlot reserves one of the policy's concurrent extraction slots if one is free, for
+// uploads, which are refused rather than kept waiting. Release it with ReleaseSlot when it
+// returns true.
+func TryAcquireSlot() bool {
+	if active.Add(1) > int64(Current().Concurrency) {
+		active.Add(-1)
+		return false
+	}
+	return true
+}
+
+// AcquireSlot waits for a free extraction slot, for background work that can wait its turn (the
+// supplier PDFs), or returns ctx's error when ctx ends first. Uploads don't queue behind it.
+// Release the slot with ReleaseSlot.
+func AcquireSlot(ctx context.Context) error {
+	if TryAcquireSlot() {
+		return nil
+	}
+	ticker := time.NewTicker(slotPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if TryAcquireSlot() {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ReleaseSlot frees a slot reserved by TryAcquireSlot or AcquireSlot.
+func ReleaseSlot() {
+	active.Add(-1)
+}
@@ -0,0 +1,52 @@
+package uploadpolicy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Slots are limited by the policy's concurrency. AcquireSlot waits for one to be released, or
+// gives up with the context.
+func TestSlots(t *testing.T) {
+	t.Cleanup(ReloadEnv) // After UPLOAD_CONCURRENCY is restored
+	t.Setenv("UPLOAD_CONCURRENCY", "2")
+	ReloadEnv()
+
+	if !TryAcquireSlot() || !TryAcquireSlot() {
+		t.Fatal("couldn't take both slots")
+	}
+	if TryAcquireSlot() {
+		t.Fatal("took a third slot")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := AcquireSlot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("AcquireSlot with every slot taken: %v, want the context's error", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- AcquireSlot(context.Background()) }()
+	select {
+	case err := <-acquired:
+		t.Fatalf("AcquireSlot returned %v before a slot was released", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	ReleaseSlot()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("AcquireSlot didn't take the released slot")
+	}
+	ReleaseSlot()
+	ReleaseSlot()
+	if !TryAcquireSlot() {
+		t.Error("slots not freed")
+	}
+	ReleaseSlot()
+}
@@ -0,0 +1,276 @@
+// Package uploadpolicy holds the settings that decide which uploads are accepted.
+//
+// The policy is one document in the settings collection, editable at runtime through the admin
+// API. Until that document exists, the environment variables (MAX_UPLOAD_BYTES,
+// UPLOAD_ALLOWED_TYPES, ...) provide the values. Like feature flags, the document is cached for
+// a short TTL so uploads don't read it from MongoDB every time.
+package uploadpolicy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// policyDocID identifies the policy document within the settings collection.
+const policyDocID = "upload_policy"
+
+// How long the policy is cached before it is re-read from MongoDB
+const cacheTTL = 30 * time.Second
+
+// Bootstrap defaults used when neither the document nor the environment sets a value.
+const (
+	defaultMaxBytes     = 32 << 20
+	defaultAllowedTypes = "application/pdf,application/octet-stream"
+	defaultConcurrency  = 4
+	maxConcurrency      = 64
+)
+
+// Sources reported for the current policy.
+const (
+	SourceEnv      = "env"
+	SourceDocument = "document"
+)
+
+// Policy is the set of upload acceptance rules.
+type Policy struct {
+	MaxBytes     int64      `bson:"maxBytes" json:"maxBytes"`         // Request body limit
+	AllowedTypes []string   `bson:"allowedTypes" json:"allowedTypes"` // Accepted media types of the uploaded file
+	MaxPages     int        `bson:"maxPages" json:"maxPages"`         // Maximum PDF pages, 0 for no limit
+	OCREnabled   bool       `bson:"ocrEnabled" json:"ocrEnabled"`     // OCR for image-only PDFs (no engine in this build)
+	Concurrency  int        `bson:"concurrency" json:"concurrency"`   // PDF extractions at the same time, uploads and supplier PDFs together
+	AllowEmpty   bool       `bson:"allowEmpty" json:"allowEmpty"`     // Accept PDFs without extractable text
+	Source       string     `bson:"-" json:"source"`                  // env or document
+	UpdatedAt    *time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+// Changes lists the fields that differ between p and next as "field: old -> new", for the audit log.
+func (p Policy) Changes(next Policy) []string {
+	var changes []string
+	add := func(field string, old, new interface{}) {
+		if fmt.Sprint(old) != fmt.Sprint(new) {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", field, old, new))
+		}
+	}
+	add("maxBytes", p.MaxBytes, next.MaxBytes)
+	add("allowedTypes", p.AllowedTypes, next.AllowedTypes)
+	add("maxPages", p.MaxPages, next.MaxPages)
+	add("ocrEnabled", p.OCREnabled, next.OCREnabled)
+	add("concurrency", p.Concurrency, next.Concurrency)
+	add("allowEmpty", p.AllowEmpty, next.AllowEmpty)
+	return changes
+}
+
+// AllowsType reports whether mediaType is one of the allowed types.
+func (p Policy) AllowsType(mediaType string) bool {
+	for _, allowed := range p.AllowedTypes {
+		if strings.EqualFold(allowed, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the values a PUT may set.
+func (p Policy) Validate() error {
+	var problems []string
+	if p.MaxBytes <= 0 {
+		problems = append(problems, "maxBytes must be positive")
+	}
+	if len(p.AllowedTypes) == 0 {
+		problems = append(problems, "allowedTypes must not be empty")
+	}
+	for _, t := range p.AllowedTypes {
+		if mediaType, _, err := mime.ParseMediaType(t); err != nil || mediaType != strings.ToLower(t) {
+			problems = append(problems, fmt.Sprintf("allowedTypes: '%s' is not a media type", t))
+		}
+	}
+	if p.MaxPages < 0 {
+		problems = append(problems, "maxPages must not be negative")
+	}
+	if p.OCREnabled {
+		problems = append(problems, "ocrEnabled: no OCR engine is available in this build")
+	}
+	if p.Concurrency < 1 || p.Concurrency > maxConcurrency {
+		problems = append(problems, fmt.Sprintf("concurrency must be between 1 and %d", maxConcurrency))
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidationError lists everything wrong with a submitted policy.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid upload policy: " + strings.Join(e.Problems, "; ")
+}
+
+type state struct {
+	mu       sync.Mutex
+	ready    bool // env has been read
+	coll     *mongo.Collection
+	env      Policy
+	current  Policy
+	loadedAt time.Time
+}
+
+var policy = &state{}
+
+// Init reads the bootstrap values from the environment and remembers the collection holding the
+// policy document. Before Init (or with a nil collection) only the environment applies.
+func Init(coll *mongo.Collection) {
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	policy.coll = coll
+	policy.ready = true
+	policy.env = fromEnv()
+	policy.current = policy.env
+	policy.loadedAt = time.Time{}
+}
+
+// ReloadEnv re-reads the bootstrap values from the environment, e.g. after a configuration
+// reload. A stored policy document still takes precedence.
+func ReloadEnv() {
+	env := fromEnv()
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	policy.ready, policy.env = true, env
+	if policy.current.Source != SourceDocument {
+		policy.current = env
+	}
+	policy.loadedAt = time.Time{} // Look for a policy document again on next use
+}
+
+// fromEnv builds the bootstrap policy from the environment.
+func fromEnv() Policy {
+	p := Policy{
+		MaxBytes:     defaultMaxBytes,
+		AllowedTypes: strings.Split(defaultAllowedTypes, ","),
+		Concurrency:  defaultConcurrency,
+		AllowEmpty:   true,
+		Source:       SourceEnv,
+	}
+	if raw := os.Getenv("MAX_UPLOAD_BYTES"); raw != "" {
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil && v > 0 {
+			p.MaxBytes = v
+		} else {
+			log.Printf("Warning: Invalid MAX_UPLOAD_BYTES '%s', using default %d", raw, p.MaxBytes)
+		}
+	}
+	if raw := os.Getenv("UPLOAD_ALLOWED_TYPES"); raw != "" {
+		var types []string
+		for _, t := range strings.Split(raw, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				types = append(types, t)
+			}
+		}
+		if len(types) > 0 {
+			p.AllowedTypes = types
+		}
+	}
+	if raw := os.Getenv("UPLOAD_MAX_PAGES"); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v >= 0 {
+			p.MaxPages = v
+		} else {
+			log.Printf("Warning: Invalid UPLOAD_MAX_PAGES '%s', not limiting pages", raw)
+		}
+	}
+	if raw := os.Getenv("UPLOAD_CONCURRENCY"); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v >= 1 && v <= maxConcurrency {
+			p.Concurrency = v
+		} else {
+			log.Printf("Warning: Invalid UPLOAD_CONCURRENCY '%s', using default %d", raw, p.Concurrency)
+		}
+	}
+	if strings.EqualFold(os.Getenv("UPLOAD_ALLOW_EMPTY"), "false") {
+		p.AllowEmpty = false
+	}
+	if strings.EqualFold(os.Getenv("UPLOAD_OCR_ENABLED"), "true") {
+		log.Println("Warning: UPLOAD_OCR_ENABLED is set, but no OCR engine is available in this build")
+	}
+	return p
+}
+
+// refreshLocked reloads the policy document when the cache has expired. Errors keep the previous policy.
+func (s *state) refreshLocked() {
+	if !s.ready {
+		// Used before Init: the environment is all there is
+		s.ready, s.env = true, fromEnv()
+		s.current = s.env
+	}
+	if s.coll == nil || time.Since(s.loadedAt) < cacheTTL {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var doc Policy
+	err := s.coll.FindOne(ctx, bson.M{"_id": policyDocID}).Decode(&doc)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		s.current = s.env
+	case err != nil:
+		log.Printf("Warning: Could not load upload policy, keeping cached policy: %v", err)
+	default:
+		doc.Source = SourceDocument
+		s.current = doc
+	}
+	s.loadedAt = time.Now() // Also on error, so an outage doesn't turn every upload into a DB call
+}
+
+// Current returns the effective policy.
+func Current() Policy {
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	policy.refreshLocked()
+	p := policy.current
+	p.AllowedTypes = append([]string(nil), p.AllowedTypes...) // Callers can't modify the cached slice
+	return p
+}
+
+// Set validates and stores p as the policy document, replacing the previous one, and refreshes
+// the cache immediately. It returns the policy that was in effect before.
+func Set(ctx context.Context, p Policy) (Policy, error) {
+	if err := p.Validate(); err != nil {
+		return Policy{}, err
+	}
+	previous := Current()
+
+	policy.mu.Lock()
+	coll := policy.coll
+	policy.mu.Unlock()
+	if coll == nil {
+		return Policy{}, fmt.Errorf("upload policy is not available (no database)")
+	}
+
+	for i, t := range p.AllowedTypes {
+		p.AllowedTypes[i] = strings.ToLower(t)
+	}
+	now := time.Now().UTC()
+	p.UpdatedAt = &now
+	opts := options.Replace().SetUpsert(true)
+	if _, err := coll.ReplaceOne(ctx, bson.M{"_id": policyDocID}, p, opts); err != nil {
+		return Policy{}, err
+	}
+
+	// Make the change visible on this instance right away; others pick it up within the TTL
+	policy.mu.Lock()
+	policy.loadedAt = time.Time{}
+	policy.refreshLocked()
+	policy.mu.Unlock()
+	return previous, nil
+}